@@ -172,8 +172,10 @@ type SubmissionStatusUpdate struct {
 	TestsCompleted int32                         `protobuf:"varint,3,opt,name=tests_completed,json=testsCompleted,proto3" json:"tests_completed,omitempty"`
 	TotalTests     int32                         `protobuf:"varint,4,opt,name=total_tests,json=totalTests,proto3" json:"total_tests,omitempty"`
 	MaxTimeSpentMs int64                         `protobuf:"varint,5,opt,name=max_time_spent_ms,json=maxTimeSpentMs,proto3" json:"max_time_spent_ms,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// output of the program on the failing test, only sent for wrong answers on samples
+	Output        string `protobuf:"bytes,7,opt,name=output,proto3" json:"output,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SubmissionStatusUpdate) Reset() {
@@ -248,17 +250,186 @@ func (x *SubmissionStatusUpdate) GetMaxTimeSpentMs() int64 {
 	return 0
 }
 
-type SubmissionRequest_TestCase struct {
+func (x *SubmissionStatusUpdate) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+type ProgramRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Input         string                 `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
-	Output        string                 `protobuf:"bytes,2,opt,name=output,proto3" json:"output,omitempty"`
+	ProgramId     string                 `protobuf:"bytes,1,opt,name=program_id,json=programId,proto3" json:"program_id,omitempty"`
+	Code          string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	TimeLimitMs   int64                  `protobuf:"varint,3,opt,name=time_limit_ms,json=timeLimitMs,proto3" json:"time_limit_ms,omitempty"`
+	MemoryLimitKb int64                  `protobuf:"varint,4,opt,name=memory_limit_kb,json=memoryLimitKb,proto3" json:"memory_limit_kb,omitempty"`
+	Runs          []*ProgramRequest_Run  `protobuf:"bytes,5,rep,name=runs,proto3" json:"runs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProgramRequest) Reset() {
+	*x = ProgramRequest{}
+	mi := &file_runner_submission_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProgramRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProgramRequest) ProtoMessage() {}
+
+func (x *ProgramRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_runner_submission_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProgramRequest.ProtoReflect.Descriptor instead.
+func (*ProgramRequest) Descriptor() ([]byte, []int) {
+	return file_runner_submission_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ProgramRequest) GetProgramId() string {
+	if x != nil {
+		return x.ProgramId
+	}
+	return ""
+}
+
+func (x *ProgramRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *ProgramRequest) GetTimeLimitMs() int64 {
+	if x != nil {
+		return x.TimeLimitMs
+	}
+	return 0
+}
+
+func (x *ProgramRequest) GetMemoryLimitKb() int64 {
+	if x != nil {
+		return x.MemoryLimitKb
+	}
+	return 0
+}
+
+func (x *ProgramRequest) GetRuns() []*ProgramRequest_Run {
+	if x != nil {
+		return x.Runs
+	}
+	return nil
+}
+
+type ProgramRunResult struct {
+	state         protoimpl.MessageState        `protogen:"open.v1"`
+	Index         int32                         `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Status        SubmissionStatusUpdate_Status `protobuf:"varint,2,opt,name=status,proto3,enum=gojudge.SubmissionStatusUpdate_Status" json:"status,omitempty"`
+	Stdout        string                        `protobuf:"bytes,3,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Stderr        string                        `protobuf:"bytes,4,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	TimeSpentMs   int64                         `protobuf:"varint,5,opt,name=time_spent_ms,json=timeSpentMs,proto3" json:"time_spent_ms,omitempty"`
+	MemoryKb      int64                         `protobuf:"varint,6,opt,name=memory_kb,json=memoryKb,proto3" json:"memory_kb,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProgramRunResult) Reset() {
+	*x = ProgramRunResult{}
+	mi := &file_runner_submission_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProgramRunResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProgramRunResult) ProtoMessage() {}
+
+func (x *ProgramRunResult) ProtoReflect() protoreflect.Message {
+	mi := &file_runner_submission_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProgramRunResult.ProtoReflect.Descriptor instead.
+func (*ProgramRunResult) Descriptor() ([]byte, []int) {
+	return file_runner_submission_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProgramRunResult) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ProgramRunResult) GetStatus() SubmissionStatusUpdate_Status {
+	if x != nil {
+		return x.Status
+	}
+	return SubmissionStatusUpdate_PENDING
+}
+
+func (x *ProgramRunResult) GetStdout() string {
+	if x != nil {
+		return x.Stdout
+	}
+	return ""
+}
+
+func (x *ProgramRunResult) GetStderr() string {
+	if x != nil {
+		return x.Stderr
+	}
+	return ""
+}
+
+func (x *ProgramRunResult) GetTimeSpentMs() int64 {
+	if x != nil {
+		return x.TimeSpentMs
+	}
+	return 0
+}
+
+func (x *ProgramRunResult) GetMemoryKb() int64 {
+	if x != nil {
+		return x.MemoryKb
+	}
+	return 0
+}
+
+type SubmissionRequest_TestCase struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Input  string                 `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	Output string                 `protobuf:"bytes,2,opt,name=output,proto3" json:"output,omitempty"`
+	// the output of a failing sample is sent back to be shown to the user
+	Sample        bool `protobuf:"varint,3,opt,name=sample,proto3" json:"sample,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SubmissionRequest_TestCase) Reset() {
 	*x = SubmissionRequest_TestCase{}
-	mi := &file_runner_submission_proto_msgTypes[2]
+	mi := &file_runner_submission_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -270,7 +441,7 @@ func (x *SubmissionRequest_TestCase) String() string {
 func (*SubmissionRequest_TestCase) ProtoMessage() {}
 
 func (x *SubmissionRequest_TestCase) ProtoReflect() protoreflect.Message {
-	mi := &file_runner_submission_proto_msgTypes[2]
+	mi := &file_runner_submission_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -300,21 +471,81 @@ func (x *SubmissionRequest_TestCase) GetOutput() string {
 	return ""
 }
 
+func (x *SubmissionRequest_TestCase) GetSample() bool {
+	if x != nil {
+		return x.Sample
+	}
+	return false
+}
+
+type ProgramRequest_Run struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Args          []string               `protobuf:"bytes,1,rep,name=args,proto3" json:"args,omitempty"`
+	Input         string                 `protobuf:"bytes,2,opt,name=input,proto3" json:"input,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProgramRequest_Run) Reset() {
+	*x = ProgramRequest_Run{}
+	mi := &file_runner_submission_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProgramRequest_Run) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProgramRequest_Run) ProtoMessage() {}
+
+func (x *ProgramRequest_Run) ProtoReflect() protoreflect.Message {
+	mi := &file_runner_submission_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProgramRequest_Run.ProtoReflect.Descriptor instead.
+func (*ProgramRequest_Run) Descriptor() ([]byte, []int) {
+	return file_runner_submission_proto_rawDescGZIP(), []int{2, 0}
+}
+
+func (x *ProgramRequest_Run) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *ProgramRequest_Run) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
 var File_runner_submission_proto protoreflect.FileDescriptor
 
 const file_runner_submission_proto_rawDesc = "" +
 	"\n" +
-	"\x17runner/submission.proto\x12\agojudge\"\x96\x02\n" +
+	"\x17runner/submission.proto\x12\agojudge\"\xae\x02\n" +
 	"\x11SubmissionRequest\x12#\n" +
 	"\rsubmission_id\x18\x01 \x01(\tR\fsubmissionId\x12\x12\n" +
 	"\x04code\x18\x02 \x01(\tR\x04code\x12\"\n" +
 	"\rtime_limit_ms\x18\x03 \x01(\x03R\vtimeLimitMs\x12&\n" +
 	"\x0fmemory_limit_kb\x18\x04 \x01(\x03R\rmemoryLimitKb\x12B\n" +
 	"\n" +
-	"test_cases\x18\x05 \x03(\v2#.gojudge.SubmissionRequest.TestCaseR\ttestCases\x1a8\n" +
+	"test_cases\x18\x05 \x03(\v2#.gojudge.SubmissionRequest.TestCaseR\ttestCases\x1aP\n" +
 	"\bTestCase\x12\x14\n" +
 	"\x05input\x18\x01 \x01(\tR\x05input\x12\x16\n" +
-	"\x06output\x18\x02 \x01(\tR\x06output\"\xd0\x03\n" +
+	"\x06output\x18\x02 \x01(\tR\x06output\x12\x16\n" +
+	"\x06sample\x18\x03 \x01(\bR\x06sample\"\xe8\x03\n" +
 	"\x16SubmissionStatusUpdate\x12#\n" +
 	"\rsubmission_id\x18\x01 \x01(\tR\fsubmissionId\x12>\n" +
 	"\x06status\x18\x02 \x01(\x0e2&.gojudge.SubmissionStatusUpdate.StatusR\x06status\x12%\n" +
@@ -322,7 +553,8 @@ const file_runner_submission_proto_rawDesc = "" +
 	"\x0ftests_completed\x18\x03 \x01(\x05R\x0etestsCompleted\x12\x1f\n" +
 	"\vtotal_tests\x18\x04 \x01(\x05R\n" +
 	"totalTests\x12)\n" +
-	"\x11max_time_spent_ms\x18\x05 \x01(\x03R\x0emaxTimeSpentMs\"\xb4\x01\n" +
+	"\x11max_time_spent_ms\x18\x05 \x01(\x03R\x0emaxTimeSpentMs\x12\x16\n" +
+	"\x06output\x18\a \x01(\tR\x06output\"\xb4\x01\n" +
 	"\x06Status\x12\v\n" +
 	"\aPENDING\x10\x00\x12\v\n" +
 	"\aRUNNING\x10\x01\x12\f\n" +
@@ -332,9 +564,28 @@ const file_runner_submission_proto_rawDesc = "" +
 	"\x15MEMORY_LIMIT_EXCEEDED\x10\x05\x12\x11\n" +
 	"\rRUNTIME_ERROR\x10\x06\x12\x15\n" +
 	"\x11COMPILATION_ERROR\x10\a\x12\x12\n" +
-	"\x0eINTERNAL_ERROR\x10\b2^\n" +
+	"\x0eINTERNAL_ERROR\x10\b\"\xf1\x01\n" +
+	"\x0eProgramRequest\x12\x1d\n" +
+	"\n" +
+	"program_id\x18\x01 \x01(\tR\tprogramId\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12\"\n" +
+	"\rtime_limit_ms\x18\x03 \x01(\x03R\vtimeLimitMs\x12&\n" +
+	"\x0fmemory_limit_kb\x18\x04 \x01(\x03R\rmemoryLimitKb\x12/\n" +
+	"\x04runs\x18\x05 \x03(\v2\x1b.gojudge.ProgramRequest.RunR\x04runs\x1a/\n" +
+	"\x03Run\x12\x12\n" +
+	"\x04args\x18\x01 \x03(\tR\x04args\x12\x14\n" +
+	"\x05input\x18\x02 \x01(\tR\x05input\"\xd9\x01\n" +
+	"\x10ProgramRunResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12>\n" +
+	"\x06status\x18\x02 \x01(\x0e2&.gojudge.SubmissionStatusUpdate.StatusR\x06status\x12\x16\n" +
+	"\x06stdout\x18\x03 \x01(\tR\x06stdout\x12\x16\n" +
+	"\x06stderr\x18\x04 \x01(\tR\x06stderr\x12\"\n" +
+	"\rtime_spent_ms\x18\x05 \x01(\x03R\vtimeSpentMs\x12\x1b\n" +
+	"\tmemory_kb\x18\x06 \x01(\x03R\bmemoryKb2\xa4\x01\n" +
 	"\x06Runner\x12T\n" +
-	"\x11ExecuteSubmission\x12\x1a.gojudge.SubmissionRequest\x1a\x1f.gojudge.SubmissionStatusUpdate\"\x000\x01B\x97\x01\n" +
+	"\x11ExecuteSubmission\x12\x1a.gojudge.SubmissionRequest\x1a\x1f.gojudge.SubmissionStatusUpdate\"\x000\x01\x12D\n" +
+	"\n" +
+	"RunProgram\x12\x17.gojudge.ProgramRequest\x1a\x19.gojudge.ProgramRunResult\"\x000\x01B\x97\x01\n" +
 	"\vcom.gojudgeB\x0fSubmissionProtoP\x01Z;github.com/computer-technology-team/go-judge/api/gen/runner\xa2\x02\x03GXX\xaa\x02\aGojudge\xca\x02\aGojudge\xe2\x02\x13Gojudge\\GPBMetadata\xea\x02\aGojudgeb\x06proto3"
 
 var (
@@ -350,23 +601,30 @@ func file_runner_submission_proto_rawDescGZIP() []byte {
 }
 
 var file_runner_submission_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_runner_submission_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_runner_submission_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_runner_submission_proto_goTypes = []any{
 	(SubmissionStatusUpdate_Status)(0), // 0: gojudge.SubmissionStatusUpdate.Status
 	(*SubmissionRequest)(nil),          // 1: gojudge.SubmissionRequest
 	(*SubmissionStatusUpdate)(nil),     // 2: gojudge.SubmissionStatusUpdate
-	(*SubmissionRequest_TestCase)(nil), // 3: gojudge.SubmissionRequest.TestCase
+	(*ProgramRequest)(nil),             // 3: gojudge.ProgramRequest
+	(*ProgramRunResult)(nil),           // 4: gojudge.ProgramRunResult
+	(*SubmissionRequest_TestCase)(nil), // 5: gojudge.SubmissionRequest.TestCase
+	(*ProgramRequest_Run)(nil),         // 6: gojudge.ProgramRequest.Run
 }
 var file_runner_submission_proto_depIdxs = []int32{
-	3, // 0: gojudge.SubmissionRequest.test_cases:type_name -> gojudge.SubmissionRequest.TestCase
+	5, // 0: gojudge.SubmissionRequest.test_cases:type_name -> gojudge.SubmissionRequest.TestCase
 	0, // 1: gojudge.SubmissionStatusUpdate.status:type_name -> gojudge.SubmissionStatusUpdate.Status
-	1, // 2: gojudge.Runner.ExecuteSubmission:input_type -> gojudge.SubmissionRequest
-	2, // 3: gojudge.Runner.ExecuteSubmission:output_type -> gojudge.SubmissionStatusUpdate
-	3, // [3:4] is the sub-list for method output_type
-	2, // [2:3] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	6, // 2: gojudge.ProgramRequest.runs:type_name -> gojudge.ProgramRequest.Run
+	0, // 3: gojudge.ProgramRunResult.status:type_name -> gojudge.SubmissionStatusUpdate.Status
+	1, // 4: gojudge.Runner.ExecuteSubmission:input_type -> gojudge.SubmissionRequest
+	3, // 5: gojudge.Runner.RunProgram:input_type -> gojudge.ProgramRequest
+	2, // 6: gojudge.Runner.ExecuteSubmission:output_type -> gojudge.SubmissionStatusUpdate
+	4, // 7: gojudge.Runner.RunProgram:output_type -> gojudge.ProgramRunResult
+	6, // [6:8] is the sub-list for method output_type
+	4, // [4:6] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_runner_submission_proto_init() }
@@ -380,7 +638,7 @@ func file_runner_submission_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_runner_submission_proto_rawDesc), len(file_runner_submission_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   3,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
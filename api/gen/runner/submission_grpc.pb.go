@@ -20,6 +20,7 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	Runner_ExecuteSubmission_FullMethodName = "/gojudge.Runner/ExecuteSubmission"
+	Runner_RunProgram_FullMethodName        = "/gojudge.Runner/RunProgram"
 )
 
 // RunnerClient is the client API for Runner service.
@@ -27,6 +28,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type RunnerClient interface {
 	ExecuteSubmission(ctx context.Context, in *SubmissionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubmissionStatusUpdate], error)
+	RunProgram(ctx context.Context, in *ProgramRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProgramRunResult], error)
 }
 
 type runnerClient struct {
@@ -56,11 +58,31 @@ func (c *runnerClient) ExecuteSubmission(ctx context.Context, in *SubmissionRequ
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Runner_ExecuteSubmissionClient = grpc.ServerStreamingClient[SubmissionStatusUpdate]
 
+func (c *runnerClient) RunProgram(ctx context.Context, in *ProgramRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProgramRunResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Runner_ServiceDesc.Streams[1], Runner_RunProgram_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ProgramRequest, ProgramRunResult]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Runner_RunProgramClient = grpc.ServerStreamingClient[ProgramRunResult]
+
 // RunnerServer is the server API for Runner service.
 // All implementations must embed UnimplementedRunnerServer
 // for forward compatibility.
 type RunnerServer interface {
 	ExecuteSubmission(*SubmissionRequest, grpc.ServerStreamingServer[SubmissionStatusUpdate]) error
+	RunProgram(*ProgramRequest, grpc.ServerStreamingServer[ProgramRunResult]) error
 	mustEmbedUnimplementedRunnerServer()
 }
 
@@ -74,6 +96,9 @@ type UnimplementedRunnerServer struct{}
 func (UnimplementedRunnerServer) ExecuteSubmission(*SubmissionRequest, grpc.ServerStreamingServer[SubmissionStatusUpdate]) error {
 	return status.Errorf(codes.Unimplemented, "method ExecuteSubmission not implemented")
 }
+func (UnimplementedRunnerServer) RunProgram(*ProgramRequest, grpc.ServerStreamingServer[ProgramRunResult]) error {
+	return status.Errorf(codes.Unimplemented, "method RunProgram not implemented")
+}
 func (UnimplementedRunnerServer) mustEmbedUnimplementedRunnerServer() {}
 func (UnimplementedRunnerServer) testEmbeddedByValue()                {}
 
@@ -106,6 +131,17 @@ func _Runner_ExecuteSubmission_Handler(srv interface{}, stream grpc.ServerStream
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Runner_ExecuteSubmissionServer = grpc.ServerStreamingServer[SubmissionStatusUpdate]
 
+func _Runner_RunProgram_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ProgramRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RunnerServer).RunProgram(m, &grpc.GenericServerStream[ProgramRequest, ProgramRunResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Runner_RunProgramServer = grpc.ServerStreamingServer[ProgramRunResult]
+
 // Runner_ServiceDesc is the grpc.ServiceDesc for Runner service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -119,6 +155,11 @@ var Runner_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Runner_ExecuteSubmission_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "RunProgram",
+			Handler:       _Runner_RunProgram_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "runner/submission.proto",
 }
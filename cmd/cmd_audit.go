@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+func NewAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Search and export the audit log",
+	}
+
+	cmd.AddCommand(NewAuditListCmd(), NewAuditExportCmd())
+
+	return cmd
+}
+
+func NewAuditListCmd() *cobra.Command {
+	var limit int32
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the newest audit events matching the filters",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			filter, err := auditFilter(cmd)
+			if err != nil {
+				return err
+			}
+
+			pool, err := connectDatabase(cmd)
+			if err != nil {
+				return err
+			}
+			defer pool.Close()
+
+			events, err := storage.New().ListAuditEvents(ctx, pool, filter.Params(0, limit))
+			if err != nil {
+				return fmt.Errorf("could not list audit events: %w", err)
+			}
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(writer, "ID\tTIME\tACTOR\tACTION\tTARGET\tBEFORE\tAFTER")
+			for _, event := range events {
+				fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s %s\t%s\t%s\n", event.ID,
+					event.CreatedAt.Time.Local().Format("2006-01-02 15:04:05"), event.ActorName, event.Action,
+					event.TargetType, event.TargetID, event.Before, event.After)
+			}
+			return writer.Flush()
+		},
+	}
+
+	addAuditFilterFlags(cmd)
+	cmd.Flags().Int32VarP(&limit, "limit", "n", 50, "how many events to list")
+
+	return cmd
+}
+
+func NewAuditExportCmd() *cobra.Command {
+	var format, output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export every audit event matching the filters as CSV or JSON lines",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			filter, err := auditFilter(cmd)
+			if err != nil {
+				return err
+			}
+
+			if format != audit.FormatCSV && format != audit.FormatJSON {
+				return fmt.Errorf("format must be %s or %s", audit.FormatCSV, audit.FormatJSON)
+			}
+
+			pool, err := connectDatabase(cmd)
+			if err != nil {
+				return err
+			}
+			defer pool.Close()
+
+			var w io.Writer = os.Stdout
+			if output != "" {
+				file, err := os.Create(output)
+				if err != nil {
+					return fmt.Errorf("could not create output file: %w", err)
+				}
+				defer file.Close()
+				w = file
+			}
+
+			return audit.Export(ctx, pool, storage.New(), filter, format, w)
+		},
+	}
+
+	addAuditFilterFlags(cmd)
+	cmd.Flags().StringVarP(&format, "format", "f", audit.FormatCSV, "csv or json, json writes one event per line")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "path of the file to write, defaults to stdout")
+
+	return cmd
+}
+
+// auditFilterFlags are the filter flags of the audit commands, named like the query of the audit log page
+var auditFilterFlags = []struct{ name, usage string }{
+	{"actor", "username of who acted, or cli"},
+	{"action", "action like problem.update, or a group like auth"},
+	{"target-type", "type of the target: problem, user, role, session or api_token"},
+	{"target-id", "id of the target, usernames for users"},
+	{"since", "first day to include, like 2006-01-02, or an RFC 3339 time"},
+	{"until", "last day to include, like 2006-01-02, or an RFC 3339 time to stop before"},
+}
+
+func addAuditFilterFlags(cmd *cobra.Command) {
+	for _, flag := range auditFilterFlags {
+		cmd.Flags().String(flag.name, "", flag.usage)
+	}
+}
+
+// auditFilter parses the filter flags like the audit log page parses its query
+func auditFilter(cmd *cobra.Command) (audit.Filter, error) {
+	values := url.Values{}
+	for _, flag := range auditFilterFlags {
+		value, err := cmd.Flags().GetString(flag.name)
+		if err != nil {
+			return audit.Filter{}, fmt.Errorf("could not get %s flag: %w", flag.name, err)
+		}
+		values.Set(strings.ReplaceAll(flag.name, "-", "_"), value)
+	}
+
+	return audit.ParseFilter(values)
+}
@@ -3,11 +3,15 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/spf13/cobra"
-	"golang.org/x/crypto/bcrypt"
 
 	"github.com/computer-technology-team/go-judge/config"
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	passwordPkg "github.com/computer-technology-team/go-judge/internal/auth/password"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
 	"github.com/computer-technology-team/go-judge/internal/storage"
 )
 
@@ -16,7 +20,7 @@ func NewCreateAdminCmd() *cobra.Command {
 
 	cmd := &cobra.Command{
 		Use:   "create-admin",
-		Short: "creates admin user, if user exists changes role to admin",
+		Short: "creates admin user, if user exists grants it the admin role",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 
@@ -41,16 +45,70 @@ func NewCreateAdminCmd() *cobra.Command {
 				return errors.New("username or password is empty")
 			}
 
-			passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			policy, err := passwordPkg.NewPolicy(cfg.Authentication.PasswordPolicy)
+			if err != nil {
+				return fmt.Errorf("could not create password policy: %w", err)
+			}
+			if err := policy.Check(password, username); err != nil {
+				return fmt.Errorf("password is not allowed: %w", err)
+			}
+
+			passwordHash, err := passwordPkg.Hash(password)
 			if err != nil {
 				return fmt.Errorf("could not hash the password: %w", err)
 			}
 
-			_, err = querier.CreateAdmin(ctx, pool, username, string(passwordHash))
+			tx, err := pool.Begin(ctx)
+			if err != nil {
+				return fmt.Errorf("could not begin transaction: %w", err)
+			}
+			defer func() {
+				if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+					slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+				}
+			}()
+
+			user, err := querier.GetUserByUsername(ctx, tx, username)
+			if errors.Is(err, pgx.ErrNoRows) {
+				user, err = querier.CreateUser(ctx, tx, username, passwordHash)
+				if err == nil {
+					err = querier.GrantUserRole(ctx, tx, user.ID, rbac.DefaultRole)
+				}
+			}
 			if err != nil {
 				return fmt.Errorf("could not create the admin in database: %w", err)
 			}
 
+			before, err := querier.GetUserRoles(ctx, tx, user.ID)
+			if err != nil {
+				return fmt.Errorf("could not get the roles of the admin: %w", err)
+			}
+
+			err = querier.GrantUserRole(ctx, tx, user.ID, rbac.AdminRole)
+			if err != nil {
+				return fmt.Errorf("could not grant the admin role: %w", err)
+			}
+
+			after, err := querier.GetUserRoles(ctx, tx, user.ID)
+			if err != nil {
+				return fmt.Errorf("could not get the roles of the admin: %w", err)
+			}
+
+			err = audit.Record(ctx, tx, querier, audit.Event{
+				Action:     audit.UserGrantRole,
+				TargetType: audit.TargetUser,
+				TargetID:   user.Username,
+				Before:     map[string][]string{"roles": before},
+				After:      map[string][]string{"roles": after},
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Commit(ctx); err != nil {
+				return fmt.Errorf("could not commit transaction: %w", err)
+			}
+
 			return nil
 		},
 	}
@@ -4,10 +4,13 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/spf13/cobra"
 
 	"github.com/computer-technology-team/go-judge/config"
 	authenticatorPkg "github.com/computer-technology-team/go-judge/internal/auth/authenticator"
+	"github.com/computer-technology-team/go-judge/internal/auth/session"
+	"github.com/computer-technology-team/go-judge/internal/storage"
 )
 
 func NewGenerateTokenCmd() *cobra.Command {
@@ -33,16 +36,26 @@ func NewGenerateTokenCmd() *cobra.Command {
 				return fmt.Errorf("could not create authenticator: %w", err)
 			}
 
-			if _, err := uuid.Parse(userID); err != nil {
+			id, err := uuid.Parse(userID)
+			if err != nil {
 				return fmt.Errorf("user id is not uuid: %w", err)
 			}
 
-			token, _, err := authenticator.GenerateToken(ctx, authenticatorPkg.Claims{UserID: userID})
+			pool, err := storage.NewPgxPool(ctx, cfg.Database)
+			if err != nil {
+				return fmt.Errorf("could not create database pool: %w", err)
+			}
+			defer pool.Close()
+
+			// the tokens belong to a session like the ones of a login, it is listed and can be revoked
+			sessions := session.NewManager(cfg.Authentication, authenticator, pool, storage.New())
+			tokens, err := sessions.Start(ctx, pgtype.UUID{Bytes: id, Valid: true}, "generate-token", "")
 			if err != nil {
 				return fmt.Errorf("failed to generate token: %w", err)
 			}
 
-			fmt.Printf("Generated token successfully:\n%s\n", token)
+			fmt.Printf("Generated token successfully:\n%s\n", tokens.AccessToken)
+			fmt.Printf("Refresh token, exchange it at /auth/refresh once the token expires:\n%s\n", tokens.RefreshToken)
 			return nil
 		},
 	}
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/computer-technology-team/go-judge/config"
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	runnerClient "github.com/computer-technology-team/go-judge/internal/clients/runner"
+	"github.com/computer-technology-team/go-judge/internal/problempackage"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/internal/testgen"
+	"github.com/computer-technology-team/go-judge/internal/verification"
+)
+
+func NewProblemCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "problem",
+		Short: "Manage problems",
+	}
+
+	cmd.AddCommand(NewProblemImportCmd(), NewProblemExportCmd(), NewProblemGenerateTestsCmd(), NewProblemVerifyCmd())
+
+	return cmd
+}
+
+func NewProblemImportCmd() *cobra.Command {
+	var filePath, author string
+	var problemID int32
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import a problem package, creates a new problem or updates an existing one",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			configPath, err := cmd.Flags().GetString(configFileFlag)
+			if err != nil {
+				return fmt.Errorf("could not get config path flag: %w", err)
+			}
+
+			cfg, err := config.Load(configPath)
+			if err != nil {
+				return fmt.Errorf("could not load config: %w", err)
+			}
+
+			if filePath == "" || author == "" {
+				return errors.New("file or author is empty")
+			}
+
+			file, err := os.Open(filePath)
+			if err != nil {
+				return fmt.Errorf("could not open package: %w", err)
+			}
+			defer file.Close()
+
+			info, err := file.Stat()
+			if err != nil {
+				return fmt.Errorf("could not stat package: %w", err)
+			}
+
+			pkg, err := problempackage.Read(file, info.Size())
+			if err != nil {
+				return fmt.Errorf("could not read package: %w", err)
+			}
+
+			pool, err := storage.NewPgxPool(ctx, cfg.Database)
+			if err != nil {
+				return fmt.Errorf("could not create database pool: %w", err)
+			}
+
+			querier := storage.New()
+
+			user, err := querier.GetUserByUsername(ctx, pool, author)
+			if err != nil {
+				return fmt.Errorf("could not get author: %w", err)
+			}
+
+			var target *int32
+			if cmd.Flags().Changed("problem-id") {
+				target = &problemID
+			}
+
+			problem, err := problempackage.NewStore(pool, querier).Import(ctx, pkg, user.ID, target)
+			if err != nil {
+				return fmt.Errorf("could not import package: %w", err)
+			}
+
+			fmt.Printf("Imported problem %d (%s) successfully\n", problem.ID, problem.Title)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&filePath, "file", "f", "", "path of the package zip to import")
+	cmd.Flags().StringVarP(&author, "author", "a", "", "username of the problem author")
+	cmd.Flags().Int32Var(&problemID, "problem-id", 0, "id of an existing problem to update instead of creating one")
+
+	return cmd
+}
+
+func NewProblemExportCmd() *cobra.Command {
+	var output string
+	var problemID int32
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a problem with its tests as a package",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			configPath, err := cmd.Flags().GetString(configFileFlag)
+			if err != nil {
+				return fmt.Errorf("could not get config path flag: %w", err)
+			}
+
+			cfg, err := config.Load(configPath)
+			if err != nil {
+				return fmt.Errorf("could not load config: %w", err)
+			}
+
+			if output == "" {
+				output = fmt.Sprintf("problem-%d.zip", problemID)
+			}
+
+			pool, err := storage.NewPgxPool(ctx, cfg.Database)
+			if err != nil {
+				return fmt.Errorf("could not create database pool: %w", err)
+			}
+
+			pkg, err := problempackage.NewStore(pool, storage.New()).Export(ctx, problemID)
+			if err != nil {
+				return fmt.Errorf("could not export problem: %w", err)
+			}
+
+			file, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("could not create output file: %w", err)
+			}
+			defer file.Close()
+
+			if err := pkg.Write(file); err != nil {
+				return fmt.Errorf("could not write package: %w", err)
+			}
+
+			fmt.Printf("Exported problem %d to %s\n", problemID, output)
+			return nil
+		},
+	}
+
+	cmd.Flags().Int32Var(&problemID, "problem-id", 0, "id of the problem to export")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "path of the zip to write, defaults to problem-<id>.zip")
+	_ = cmd.MarkFlagRequired("problem-id")
+
+	return cmd
+}
+
+func NewProblemGenerateTestsCmd() *cobra.Command {
+	var problemID int32
+
+	cmd := &cobra.Command{
+		Use:   "generate-tests",
+		Short: "Generate, validate and solve the generated tests of a problem using the runner",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			configPath, err := cmd.Flags().GetString(configFileFlag)
+			if err != nil {
+				return fmt.Errorf("could not get config path flag: %w", err)
+			}
+
+			cfg, err := config.Load(configPath)
+			if err != nil {
+				return fmt.Errorf("could not load config: %w", err)
+			}
+
+			pool, err := storage.NewPgxPool(ctx, cfg.Database)
+			if err != nil {
+				return fmt.Errorf("could not create database pool: %w", err)
+			}
+
+			client, err := runnerClient.NewClient(ctx, cfg.RunnerClient)
+			if err != nil {
+				return fmt.Errorf("could not create runner client: %w", err)
+			}
+			defer client.Close()
+
+			querier := storage.New()
+
+			err = audit.Record(ctx, pool, querier, audit.Event{
+				Action:     audit.ProblemGenerate,
+				TargetType: audit.TargetProblem,
+				TargetID:   strconv.Itoa(int(problemID)),
+			})
+			if err != nil {
+				return err
+			}
+
+			report, err := testgen.NewGenerator(pool, querier, client).Run(ctx, problemID)
+			fmt.Print(report.String())
+			if err != nil {
+				return fmt.Errorf("could not generate tests: %w", err)
+			}
+			if report.Failed() {
+				return errors.New("test generation failed")
+			}
+
+			fmt.Printf("Generated tests of problem %d successfully\n", problemID)
+			return nil
+		},
+	}
+
+	cmd.Flags().Int32Var(&problemID, "problem-id", 0, "id of the problem to generate tests for")
+	_ = cmd.MarkFlagRequired("problem-id")
+
+	return cmd
+}
+
+func NewProblemVerifyCmd() *cobra.Command {
+	var problemID int32
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Judge the solutions of a problem on its current tests and check their expected verdicts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			configPath, err := cmd.Flags().GetString(configFileFlag)
+			if err != nil {
+				return fmt.Errorf("could not get config path flag: %w", err)
+			}
+
+			cfg, err := config.Load(configPath)
+			if err != nil {
+				return fmt.Errorf("could not load config: %w", err)
+			}
+
+			pool, err := storage.NewPgxPool(ctx, cfg.Database)
+			if err != nil {
+				return fmt.Errorf("could not create database pool: %w", err)
+			}
+
+			client, err := runnerClient.NewClient(ctx, cfg.RunnerClient)
+			if err != nil {
+				return fmt.Errorf("could not create runner client: %w", err)
+			}
+			defer client.Close()
+
+			report, err := verification.NewVerifier(pool, storage.New(), client).Run(ctx, problemID)
+			if err != nil {
+				return fmt.Errorf("could not verify problem: %w", err)
+			}
+
+			fmt.Print(report.String())
+			if report.Failed() {
+				return errors.New("verification failed")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Int32Var(&problemID, "problem-id", 0, "id of the problem to verify")
+	_ = cmd.MarkFlagRequired("problem-id")
+
+	return cmd
+}
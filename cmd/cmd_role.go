@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/computer-technology-team/go-judge/config"
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+func NewRoleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "role",
+		Short: "Manage roles of users and the permissions of roles",
+	}
+
+	cmd.AddCommand(NewRoleListCmd(), NewRoleGrantCmd(), NewRoleRevokeCmd(),
+		NewRoleGrantPermissionCmd(), NewRoleRevokePermissionCmd())
+
+	return cmd
+}
+
+func NewRoleListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List roles with their permissions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			pool, err := connectDatabase(cmd)
+			if err != nil {
+				return err
+			}
+			defer pool.Close()
+
+			querier := storage.New()
+
+			roles, err := querier.ListRoles(ctx, pool)
+			if err != nil {
+				return fmt.Errorf("could not list roles: %w", err)
+			}
+
+			rolePermissions, err := querier.ListRolePermissions(ctx, pool)
+			if err != nil {
+				return fmt.Errorf("could not list role permissions: %w", err)
+			}
+
+			for _, role := range roles {
+				permissions := lo.FilterMap(rolePermissions, func(rp storage.RolePermission, _ int) (string, bool) {
+					return rp.Permission, rp.Role == role.Name
+				})
+				fmt.Printf("%s: %s\n", role.Name, strings.Join(permissions, ", "))
+			}
+
+			return nil
+		},
+	}
+}
+
+func NewRoleGrantCmd() *cobra.Command {
+	var username, role string
+
+	cmd := &cobra.Command{
+		Use:   "grant",
+		Short: "Grant a role to a user",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			pool, err := connectDatabase(cmd)
+			if err != nil {
+				return err
+			}
+			defer pool.Close()
+
+			querier := storage.New()
+
+			user, err := querier.GetUserByUsername(ctx, pool, username)
+			if err != nil {
+				return fmt.Errorf("could not get user: %w", err)
+			}
+
+			err = changeUserRoles(ctx, pool, querier, audit.UserGrantRole, user, func(tx pgx.Tx) error {
+				err := querier.GrantUserRole(ctx, tx, user.ID, role)
+				if err != nil {
+					if storage.IsForeignKeyViolation(err) {
+						return fmt.Errorf("role %q does not exist", role)
+					}
+					return fmt.Errorf("could not grant role: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Granted %s to %s\n", role, username)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&username, "username", "u", "", "the username to grant the role to")
+	cmd.Flags().StringVarP(&role, "role", "r", "", "the role to grant")
+	_ = cmd.MarkFlagRequired("username")
+	_ = cmd.MarkFlagRequired("role")
+
+	return cmd
+}
+
+func NewRoleRevokeCmd() *cobra.Command {
+	var username, role string
+
+	cmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke a role from a user",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			pool, err := connectDatabase(cmd)
+			if err != nil {
+				return err
+			}
+			defer pool.Close()
+
+			querier := storage.New()
+
+			user, err := querier.GetUserByUsername(ctx, pool, username)
+			if err != nil {
+				return fmt.Errorf("could not get user: %w", err)
+			}
+
+			err = changeUserRoles(ctx, pool, querier, audit.UserRevokeRole, user, func(tx pgx.Tx) error {
+				revoked, err := querier.RevokeUserRole(ctx, tx, user.ID, role)
+				if err != nil {
+					return fmt.Errorf("could not revoke role: %w", err)
+				}
+				if revoked == 0 {
+					return fmt.Errorf("%s does not have the role %q", username, role)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Revoked %s from %s\n", role, username)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&username, "username", "u", "", "the username to revoke the role from")
+	cmd.Flags().StringVarP(&role, "role", "r", "", "the role to revoke")
+	_ = cmd.MarkFlagRequired("username")
+	_ = cmd.MarkFlagRequired("role")
+
+	return cmd
+}
+
+func NewRoleGrantPermissionCmd() *cobra.Command {
+	var role, permission string
+
+	cmd := &cobra.Command{
+		Use:   "grant-permission",
+		Short: "Grant a permission to every user with a role",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			pool, err := connectDatabase(cmd)
+			if err != nil {
+				return err
+			}
+			defer pool.Close()
+
+			querier := storage.New()
+
+			err = changeRolePermissions(ctx, pool, querier, role, func(tx pgx.Tx) error {
+				err := querier.GrantRolePermission(ctx, tx, role, permission)
+				if err != nil {
+					if storage.IsForeignKeyViolation(err) {
+						return fmt.Errorf("role %q or permission %q does not exist", role, permission)
+					}
+					return fmt.Errorf("could not grant permission: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Granted %s to role %s\n", permission, role)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&role, "role", "r", "", "the role to grant the permission to")
+	cmd.Flags().StringVarP(&permission, "permission", "p", "", "the permission to grant")
+	_ = cmd.MarkFlagRequired("role")
+	_ = cmd.MarkFlagRequired("permission")
+
+	return cmd
+}
+
+func NewRoleRevokePermissionCmd() *cobra.Command {
+	var role, permission string
+
+	cmd := &cobra.Command{
+		Use:   "revoke-permission",
+		Short: "Revoke a permission from a role",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			pool, err := connectDatabase(cmd)
+			if err != nil {
+				return err
+			}
+			defer pool.Close()
+
+			querier := storage.New()
+
+			err = changeRolePermissions(ctx, pool, querier, role, func(tx pgx.Tx) error {
+				revoked, err := querier.RevokeRolePermission(ctx, tx, role, permission)
+				if err != nil {
+					return fmt.Errorf("could not revoke permission: %w", err)
+				}
+				if revoked == 0 {
+					return errors.New("the role does not have the permission")
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Revoked %s from role %s\n", permission, role)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&role, "role", "r", "", "the role to revoke the permission from")
+	cmd.Flags().StringVarP(&permission, "permission", "p", "", "the permission to revoke")
+	_ = cmd.MarkFlagRequired("role")
+	_ = cmd.MarkFlagRequired("permission")
+
+	return cmd
+}
+
+// changeUserRoles runs change in a transaction and records the roles of user before and after it
+func changeUserRoles(ctx context.Context, pool *pgxpool.Pool, querier storage.Querier, action audit.Action,
+	user storage.User, change func(tx pgx.Tx) error) error {
+	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		before, err := querier.GetUserRoles(ctx, tx, user.ID)
+		if err != nil {
+			return fmt.Errorf("could not get user roles: %w", err)
+		}
+
+		if err := change(tx); err != nil {
+			return err
+		}
+
+		after, err := querier.GetUserRoles(ctx, tx, user.ID)
+		if err != nil {
+			return fmt.Errorf("could not get user roles: %w", err)
+		}
+
+		return audit.Record(ctx, tx, querier, audit.Event{
+			Action:     action,
+			TargetType: audit.TargetUser,
+			TargetID:   user.Username,
+			Before:     map[string][]string{"roles": before},
+			After:      map[string][]string{"roles": after},
+		})
+	})
+}
+
+// changeRolePermissions runs change in a transaction and records the permissions of role before and after it
+func changeRolePermissions(ctx context.Context, pool *pgxpool.Pool, querier storage.Querier, role string,
+	change func(tx pgx.Tx) error) error {
+	permissions := func(tx pgx.Tx) ([]string, error) {
+		rolePermissions, err := querier.ListRolePermissions(ctx, tx)
+		if err != nil {
+			return nil, fmt.Errorf("could not list role permissions: %w", err)
+		}
+		return lo.FilterMap(rolePermissions, func(rp storage.RolePermission, _ int) (string, bool) {
+			return rp.Permission, rp.Role == role
+		}), nil
+	}
+
+	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		before, err := permissions(tx)
+		if err != nil {
+			return err
+		}
+
+		if err := change(tx); err != nil {
+			return err
+		}
+
+		after, err := permissions(tx)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, tx, querier, audit.Event{
+			Action:     audit.RoleUpdate,
+			TargetType: audit.TargetRole,
+			TargetID:   role,
+			Before:     map[string][]string{"permissions": before},
+			After:      map[string][]string{"permissions": after},
+		})
+	})
+}
+
+// connectDatabase loads the config from the config flag and connects to its database
+func connectDatabase(cmd *cobra.Command) (*pgxpool.Pool, error) {
+	configPath, err := cmd.Flags().GetString(configFileFlag)
+	if err != nil {
+		return nil, fmt.Errorf("could not get config path flag: %w", err)
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not load config: %w", err)
+	}
+
+	pool, err := storage.NewPgxPool(cmd.Context(), cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("could not create database pool: %w", err)
+	}
+
+	return pool, nil
+}
@@ -1,13 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"log/slog"
 	"os"
 
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc/resolver"
+
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
 )
 
+// cliActorName is who the audit log records for changes made from the command line
+const cliActorName = "cli"
+
 const configFileFlag = "config"
 
 func NewRootCmd() *cobra.Command {
@@ -19,6 +25,8 @@ func NewRootCmd() *cobra.Command {
 				Level: slog.LevelInfo,
 			})
 			slog.SetDefault(slog.New(logHandler))
+
+			cmd.SetContext(context.WithValue(cmd.Context(), internalcontext.ActorNameContextKey, cliActorName))
 		},
 	}
 
@@ -39,7 +47,13 @@ func RegisterCommandRecursive(parent *cobra.Command) {
 
 	createAdminCmd := NewCreateAdminCmd()
 
-	parent.AddCommand(serveCmd, runnerCmd, migrateCmd, createAdminCmd, generateTokenCmd)
+	problemCmd := NewProblemCmd()
+
+	roleCmd := NewRoleCmd()
+
+	auditCmd := NewAuditCmd()
+
+	parent.AddCommand(serveCmd, runnerCmd, migrateCmd, createAdminCmd, generateTokenCmd, problemCmd, roleCmd, auditCmd)
 }
 
 func Execute() {
@@ -21,8 +21,14 @@ import (
 	"github.com/computer-technology-team/go-judge/internal/runner"
 )
 
+// maxMessageSize leaves room for requests and results that carry whole test files
+const maxMessageSize = 256 << 20
+
 func StartServer(ctx context.Context, cfg config.Config) error {
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.MaxSendMsgSize(maxMessageSize),
+	)
 
 	evaluator, err := runner.NewCodeEvaluator(ctx)
 	if err != nil {
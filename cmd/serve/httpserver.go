@@ -15,14 +15,22 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	runnerPb "github.com/computer-technology-team/go-judge/api/gen/runner"
 	"github.com/computer-technology-team/go-judge/config"
+	"github.com/computer-technology-team/go-judge/internal/audit"
 	"github.com/computer-technology-team/go-judge/internal/auth"
 	authenticatorPkg "github.com/computer-technology-team/go-judge/internal/auth/authenticator"
+	"github.com/computer-technology-team/go-judge/internal/auth/lockout"
+	"github.com/computer-technology-team/go-judge/internal/auth/password"
+	"github.com/computer-technology-team/go-judge/internal/auth/provider"
+	"github.com/computer-technology-team/go-judge/internal/auth/session"
 	runnerClient "github.com/computer-technology-team/go-judge/internal/clients/runner"
+	"github.com/computer-technology-team/go-judge/internal/groups"
 	"github.com/computer-technology-team/go-judge/internal/home"
 	"github.com/computer-technology-team/go-judge/internal/middleware"
 	"github.com/computer-technology-team/go-judge/internal/problems"
 	"github.com/computer-technology-team/go-judge/internal/profiles"
+	"github.com/computer-technology-team/go-judge/internal/roles"
 	"github.com/computer-technology-team/go-judge/internal/storage"
 	"github.com/computer-technology-team/go-judge/internal/submissions"
 	"github.com/computer-technology-team/go-judge/web/static"
@@ -57,7 +65,20 @@ func StartServer(ctx context.Context, cfg config.Config) error {
 		return fmt.Errorf("could not create home handler: %w", err)
 	}
 
-	authServicer, err := createAuthenticationServicer(authenticator, pool, querier)
+	providers, err := provider.NewProviders(ctx, cfg.Authentication.Providers)
+	if err != nil {
+		return fmt.Errorf("could not create login providers: %w", err)
+	}
+
+	sessions := session.NewManager(cfg.Authentication, authenticator, pool, querier)
+
+	passwords, err := password.NewPolicy(cfg.Authentication.PasswordPolicy)
+	if err != nil {
+		return fmt.Errorf("could not create password policy: %w", err)
+	}
+
+	authServicer, err := createAuthenticationServicer(authenticator, providers, sessions, passwords,
+		lockout.New(cfg.Authentication.Lockout, pool, querier), pool, querier)
 	if err != nil {
 		return fmt.Errorf("could not create authenticantion servicer: %w", err)
 	}
@@ -67,12 +88,27 @@ func StartServer(ctx context.Context, cfg config.Config) error {
 		return fmt.Errorf("could not create profiles servicer: %w", err)
 	}
 
+	rolesServicer, err := createRolesServicer(pool, querier)
+	if err != nil {
+		return fmt.Errorf("could not create roles servicer: %w", err)
+	}
+
+	auditServicer, err := createAuditServicer(pool, querier)
+	if err != nil {
+		return fmt.Errorf("could not create audit servicer: %w", err)
+	}
+
+	groupsHandler, err := createGroupsHandler(pool, querier)
+	if err != nil {
+		return fmt.Errorf("could not create groups handler: %w", err)
+	}
+
 	problemTemplates, err := templates.GetTemplates(templates.Problems)
 	if err != nil {
 		return fmt.Errorf("could not get submit problem templates: %w", err)
 	}
 
-	submissionsServicer, err := createSubmissionsServicer(broker, pool, querier)
+	submissionsServicer, err := createSubmissionsServicer(broker, pool, querier, runnerClient, cfg)
 	if err != nil {
 		return fmt.Errorf("could not create submission servicer: %w", err)
 	}
@@ -83,42 +119,63 @@ func StartServer(ctx context.Context, cfg config.Config) error {
 
 	}
 
+	realIP, err := middleware.NewRealIPMiddleware(cfg.JudgeServer.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("could not create real ip middleware: %w", err)
+	}
+
 	// Create a new router
 	router := chi.NewRouter()
 
 	// Middleware
 	router.Use(chiMiddleware.Logger)
 	router.Use(middleware.NewRecoveryHandler(sharedTemplates))
-	router.Use(chiMiddleware.RealIP)
+	router.Use(realIP)
 	router.Use(chiMiddleware.RequestID)
 	router.Use(chiMiddleware.Timeout(60 * time.Second))
-	router.Use(middleware.NewAuthMiddleWare(authenticator, pool, querier, sharedTemplates))
-
-	// CORS configuration
-	router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
+	router.Use(middleware.NewAuthMiddleWare(authenticator, sessions, pool, querier, sharedTemplates))
+	router.Use(middleware.NewCSRFMiddleware(sharedTemplates))
+
+	// CORS configuration, any site may call the API with bearer tokens but only listed origins send cookies
+	corsOptions := cors.Options{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", middleware.CSRFHeader},
+		ExposedHeaders: []string{"Link"},
+		MaxAge:         300,
+	}
+	if len(cfg.JudgeServer.AllowedOrigins) > 0 {
+		corsOptions.AllowedOrigins = cfg.JudgeServer.AllowedOrigins
+		corsOptions.AllowCredentials = true
+	}
+	router.Use(cors.Handler(corsOptions))
 
 	// API routes
 	router.Route("/", func(r chi.Router) {
 		// Auth routes
 		r.Route("/auth", auth.NewRoutes(authServicer, sharedTemplates))
+		r.Get("/.well-known/jwks.json", auth.NewJWKSHandler(authenticator))
 
 		// Problem routes
-		r.Route("/problems", problems.NewRoutes(problems.NewHandler(problemTemplates, pool, querier), sharedTemplates))
+		r.Route("/problems", problems.NewRoutes(problems.NewHandler(problemTemplates, pool, querier, runnerClient), sharedTemplates))
 
 		// Submission routes
 		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).
-			Route("/submissions", submissions.NewRoutes(submissionsServicer))
+			Route("/submissions", submissions.NewRoutes(submissionsServicer, sharedTemplates,
+				middleware.NewSubmissionRateLimitMiddleware(cfg.RateLimits, sharedTemplates)))
 
 		// Profile routes
 		r.Route("/profiles", profiles.NewRoutes(profilesServicer, sharedTemplates))
 
+		// Role routes
+		r.Route("/roles", roles.NewRoutes(rolesServicer, sharedTemplates))
+
+		// Audit log routes
+		r.Route("/audit", audit.NewRoutes(auditServicer, sharedTemplates))
+
+		// Group routes
+		r.Route("/groups", groups.NewRoutes(groupsHandler, sharedTemplates))
+
 		// Home routes
 		r.Route("/", home.NewRoutes(homeHandler))
 	})
@@ -188,20 +245,50 @@ func createProfilesServicer(pool *pgxpool.Pool, querier storage.Querier) (profil
 	return profiles.NewServicer(tmpls, pool, querier), nil
 }
 
-func createAuthenticationServicer(authenticator authenticatorPkg.Authenticator, pool *pgxpool.Pool, querier storage.Querier) (auth.Servicer, error) {
+func createRolesServicer(pool *pgxpool.Pool, querier storage.Querier) (roles.Servicer, error) {
+	tmpls, err := templates.GetTemplates(templates.Roles)
+	if err != nil {
+		return nil, fmt.Errorf("could not get role templates: %w", err)
+	}
+
+	return roles.NewServicer(tmpls, pool, querier), nil
+}
+
+func createAuditServicer(pool *pgxpool.Pool, querier storage.Querier) (audit.Servicer, error) {
+	tmpls, err := templates.GetTemplates(templates.Audit)
+	if err != nil {
+		return nil, fmt.Errorf("could not get audit templates: %w", err)
+	}
+
+	return audit.NewServicer(tmpls, pool, querier), nil
+}
+
+func createGroupsHandler(pool *pgxpool.Pool, querier storage.Querier) (groups.Handler, error) {
+	tmpls, err := templates.GetTemplates(templates.Groups)
+	if err != nil {
+		return nil, fmt.Errorf("could not get group templates: %w", err)
+	}
+
+	return groups.NewHandler(tmpls, pool, querier), nil
+}
+
+func createAuthenticationServicer(authenticator authenticatorPkg.Authenticator, providers []provider.Provider,
+	sessions *session.Manager, passwords *password.Policy, lockout *lockout.Lockout, pool *pgxpool.Pool,
+	querier storage.Querier) (auth.Servicer, error) {
 	tmpls, err := templates.GetTemplates(templates.Authentication)
 	if err != nil {
 		return nil, fmt.Errorf("could not get authentication templates: %w", err)
 	}
 
-	return auth.NewServicer(authenticator, tmpls, pool, querier), nil
+	return auth.NewServicer(authenticator, tmpls, pool, querier, providers, sessions, passwords, lockout), nil
 }
 
-func createSubmissionsServicer(broker submissions.Broker, pool *pgxpool.Pool, querier storage.Querier) (submissions.Servicer, error) {
+func createSubmissionsServicer(broker submissions.Broker, pool *pgxpool.Pool, querier storage.Querier,
+	runnerClient runnerPb.RunnerClient, cfg config.Config) (submissions.Servicer, error) {
 	tmpls, err := templates.GetTemplates(templates.Submissions)
 	if err != nil {
 		return nil, fmt.Errorf("could not get submissions templates: %w", err)
 	}
 
-	return submissions.NewServicer(broker, tmpls, querier, pool), nil
+	return submissions.NewServicer(broker, tmpls, querier, pool, runnerClient, cfg.Submissions, cfg.RateLimits), nil
 }
@@ -15,11 +15,18 @@ type Config struct {
 	Database       DatabaseConfig       `mapstructure:"database"`
 	Authentication AuthenticationConfig `mapstructure:"authentication"`
 	Broker         BrokerConfig         `mapstructure:"broker"`
+	RateLimits     RateLimitsConfig     `mapstructure:"rate_limits"`
+	Submissions    SubmissionsConfig    `mapstructure:"submissions"`
 }
 
 type ServerConfig struct {
 	Port int    `mapstructure:"port"`
 	Host string `mapstructure:"host"`
+	// AllowedOrigins are the other sites whose scripts may call the server with credentials
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	// TrustedProxies are the addresses or CIDR ranges of reverse proxies whose X-Forwarded-For and X-Real-IP
+	// headers are used as the client address
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type ClientConfig struct {
@@ -45,6 +52,26 @@ type BrokerConfig struct {
 	JobTimeout time.Duration `mapstructure:"job_timeout"`
 }
 
+type SubmissionsConfig struct {
+	// DuplicateWindow is how long an identical submission of the same user to the same problem is not judged again,
+	// zero disables the check
+	DuplicateWindow time.Duration `mapstructure:"duplicate_window"`
+}
+
+type RateLimitsConfig struct {
+	CustomRun RateLimitConfig `mapstructure:"custom_run"`
+	// Submission is per user, users with unlimited submissions are not limited and admins can override it for single users
+	Submission      RateLimitConfig `mapstructure:"submission"`
+	SubmissionPerIP RateLimitConfig `mapstructure:"submission_per_ip"`
+}
+
+// RateLimitConfig is a token bucket, PerMinute tokens are added each minute up to Burst.
+// A PerMinute of zero disables the limit.
+type RateLimitConfig struct {
+	PerMinute float64 `mapstructure:"per_minute"`
+	Burst     int     `mapstructure:"burst"`
+}
+
 // DSN returns a PostgreSQL connection string
 func (c *DatabaseConfig) DSN() string {
 	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
@@ -52,9 +79,61 @@ func (c *DatabaseConfig) DSN() string {
 }
 
 type AuthenticationConfig struct {
-	Keys        map[string]string `mapstructure:"keys"`
+	// Keys are base64 encoded HS256 secrets by key id
+	Keys map[string]string `mapstructure:"keys"`
+	// KeyFiles are PEM files of ES256 (P-256) or EdDSA (Ed25519) keys by key id. Private keys sign and verify,
+	// public keys of retired keys only verify. Their public keys are served at /.well-known/jwks.json.
+	KeyFiles    map[string]string `mapstructure:"key_files"`
 	ActiveKeyID string            `mapstructure:"active_key_id"`
-	TokenExpiry time.Duration     `mapstructure:"token_expiry"`
+	// TokenExpiry is the lifetime of access tokens, browsers refresh them transparently
+	TokenExpiry time.Duration `mapstructure:"token_expiry"`
+	// RefreshTokenExpiry is the lifetime of a session, users log in again after it
+	RefreshTokenExpiry time.Duration `mapstructure:"refresh_token_expiry"`
+	// SessionCacheTTL is how long a server trusts a checked session, sessions revoked on another server
+	// keep working there for at most this long
+	SessionCacheTTL time.Duration `mapstructure:"session_cache_ttl"`
+	// Providers are the external login providers by name, the name is part of their callback url
+	Providers      map[string]ProviderConfig `mapstructure:"providers"`
+	PasswordPolicy PasswordPolicyConfig      `mapstructure:"password_policy"`
+	Lockout        LockoutConfig             `mapstructure:"lockout"`
+}
+
+// PasswordPolicyConfig are the rules new passwords must follow, existing passwords keep working
+type PasswordPolicyConfig struct {
+	MinLength int `mapstructure:"min_length"`
+	// CheckBreached rejects passwords found in the bundled list of breached passwords
+	CheckBreached bool `mapstructure:"check_breached"`
+	// BreachedListFile adds the passwords of a file, one per line, to the bundled list
+	BreachedListFile string `mapstructure:"breached_list_file"`
+}
+
+// LockoutConfig locks logins after repeated failures. Once failures reach the threshold of the account or of the
+// client IP, logins wait BaseDelay, doubled with every further failure up to MaxDelay.
+type LockoutConfig struct {
+	AccountThreshold int           `mapstructure:"account_threshold"`
+	IPThreshold      int           `mapstructure:"ip_threshold"`
+	BaseDelay        time.Duration `mapstructure:"base_delay"`
+	MaxDelay         time.Duration `mapstructure:"max_delay"`
+	// Window is how long failures are counted after the last one
+	Window time.Duration `mapstructure:"window"`
+}
+
+// ProviderConfig is an external login provider, only OpenID Connect providers are supported
+type ProviderConfig struct {
+	// Type is the protocol of the provider, "oidc" by default
+	Type         string `mapstructure:"type"`
+	DisplayName  string `mapstructure:"display_name"`
+	IssuerURL    string `mapstructure:"issuer_url"`
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	// RedirectURL must be the absolute url of /auth/providers/<name>/callback registered at the provider
+	RedirectURL string `mapstructure:"redirect_url"`
+	// Scopes are requested in addition to openid, profile and email
+	Scopes []string `mapstructure:"scopes"`
+	// UsernameClaim is the claim new users take their username from, preferred_username by default
+	UsernameClaim string `mapstructure:"username_claim"`
+	// AllowSignup creates users for accounts that are not linked yet, otherwise users link accounts after logging in
+	AllowSignup bool `mapstructure:"allow_signup"`
 }
 
 func Load(configPath string) (*Config, error) {
@@ -70,6 +149,15 @@ func Load(configPath string) (*Config, error) {
 	v.SetDefault("broker.workers", 5)
 	v.SetDefault("broker.job_timeout", time.Minute*5)
 
+	v.SetDefault("submissions.duplicate_window", time.Minute)
+
+	v.SetDefault("rate_limits.custom_run.per_minute", 6)
+	v.SetDefault("rate_limits.custom_run.burst", 3)
+	v.SetDefault("rate_limits.submission.per_minute", 4)
+	v.SetDefault("rate_limits.submission.burst", 5)
+	v.SetDefault("rate_limits.submission_per_ip.per_minute", 30)
+	v.SetDefault("rate_limits.submission_per_ip.burst", 20)
+
 	// Database defaults
 	v.SetDefault("database.host", "localhost")
 	v.SetDefault("database.port", 5432)
@@ -84,12 +172,21 @@ func Load(configPath string) (*Config, error) {
 	v.SetDefault("database.conn_timeout", 5*time.Second)
 
 	// Authentication defaults
-	v.SetDefault("authentication.token_expiry", 24*time.Hour)
+	v.SetDefault("authentication.token_expiry", 15*time.Minute)
+	v.SetDefault("authentication.refresh_token_expiry", 7*24*time.Hour)
+	v.SetDefault("authentication.session_cache_ttl", 30*time.Second)
 	// Default key is base64 encoded "default-secret-key-change-me-in-production"
 	v.SetDefault("authentication.keys", map[string]string{
 		"default": "ZGVmYXVsdC1zZWNyZXQta2V5LWNoYW5nZS1tZS1pbi1wcm9kdWN0aW9u",
 	})
 	v.SetDefault("authentication.active_key_id", "default")
+	v.SetDefault("authentication.password_policy.min_length", 8)
+	v.SetDefault("authentication.password_policy.check_breached", true)
+	v.SetDefault("authentication.lockout.account_threshold", 5)
+	v.SetDefault("authentication.lockout.ip_threshold", 20)
+	v.SetDefault("authentication.lockout.base_delay", time.Minute)
+	v.SetDefault("authentication.lockout.max_delay", time.Hour)
+	v.SetDefault("authentication.lockout.window", 24*time.Hour)
 
 	v.SetConfigName("config")
 	v.SetConfigType("yaml")
@@ -0,0 +1,168 @@
+// Package audit records administrative and security-relevant actions in the append-only audit log
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+// Action names what was done, actions are grouped by their prefix up to the first dot
+type Action string
+
+const (
+	ProblemCreate     Action = "problem.create"
+	ProblemUpdate     Action = "problem.update"
+	ProblemPublish    Action = "problem.publish"
+	ProblemUnpublish  Action = "problem.unpublish"
+	ProblemImport     Action = "problem.import"
+	ProblemUploadTest Action = "problem.upload_tests"
+	ProblemGenerate   Action = "problem.generate_tests"
+	ProblemRollback   Action = "problem.rollback"
+
+	ProblemAddProgram          Action = "problem.add_program"
+	ProblemUpdateProgram       Action = "problem.update_program"
+	ProblemDeleteProgram       Action = "problem.delete_program"
+	ProblemAddGeneratedTests   Action = "problem.add_generated_tests"
+	ProblemDeleteGeneratedTest Action = "problem.delete_generated_test"
+	ProblemUploadAttachment    Action = "problem.upload_attachment"
+	ProblemDeleteAttachment    Action = "problem.delete_attachment"
+
+	UserSignup       Action = "user.signup"
+	UserGrantRole    Action = "user.grant_role"
+	UserRevokeRole   Action = "user.revoke_role"
+	UserSetRateLimit Action = "user.set_rate_limit"
+
+	RoleCreate Action = "role.create"
+	RoleUpdate Action = "role.update"
+
+	AuthLogin             Action = "auth.login"
+	AuthLoginFailed       Action = "auth.login_failed"
+	AuthPasswordChange    Action = "auth.password_change"
+	AuthPasswordResetLink Action = "auth.password_reset_link"
+	AuthPasswordReset     Action = "auth.password_reset"
+	AuthTwoFactorEnable   Action = "auth.two_factor_enable"
+	AuthTwoFactorDisable  Action = "auth.two_factor_disable"
+	AuthRecoveryCodes     Action = "auth.recovery_codes"
+	AuthAPITokenCreate    Action = "auth.api_token_create"
+	AuthAPITokenDelete    Action = "auth.api_token_delete"
+	AuthSessionRevoke     Action = "auth.session_revoke"
+	AuthProviderLink      Action = "auth.provider_link"
+	AuthProviderUnlink    Action = "auth.provider_unlink"
+)
+
+// Target types name the kind of object an action was done to
+const (
+	TargetProblem  = "problem"
+	TargetUser     = "user"
+	TargetRole     = "role"
+	TargetSession  = "session"
+	TargetAPIToken = "api_token"
+)
+
+// Actions are every action, for filters
+var Actions = []Action{
+	ProblemCreate, ProblemUpdate, ProblemPublish, ProblemUnpublish, ProblemImport, ProblemUploadTest,
+	ProblemGenerate, ProblemRollback, ProblemAddProgram, ProblemUpdateProgram, ProblemDeleteProgram,
+	ProblemAddGeneratedTests, ProblemDeleteGeneratedTest, ProblemUploadAttachment, ProblemDeleteAttachment,
+	UserSignup, UserGrantRole, UserRevokeRole, UserSetRateLimit,
+	RoleCreate, RoleUpdate,
+	AuthLogin, AuthLoginFailed, AuthPasswordChange, AuthPasswordResetLink, AuthPasswordReset, AuthTwoFactorEnable,
+	AuthTwoFactorDisable, AuthRecoveryCodes, AuthAPITokenCreate, AuthAPITokenDelete, AuthSessionRevoke,
+	AuthProviderLink, AuthProviderUnlink,
+}
+
+// TargetTypes are every target type, for filters
+var TargetTypes = []string{TargetProblem, TargetUser, TargetRole, TargetSession, TargetAPIToken}
+
+// Event is an action to record. Before and After are marshalled to JSON, when both are objects only the fields that
+// changed are kept.
+type Event struct {
+	Action     Action
+	TargetType string
+	TargetID   string
+	Before     any
+	After      any
+	// Actor is who acted when they are not the user of the context, like a user who is logging in
+	Actor *storage.User
+}
+
+// Record appends event to the audit log with the actor and the request ID of ctx. Without a user the actor is the
+// name in the context, like the command line.
+// Changes made in a transaction record their event in it, so a change is never stored without its event.
+func Record(ctx context.Context, db storage.DBTX, querier storage.Querier, event Event) error {
+	params := storage.CreateAuditEventParams{
+		ActorName:  internalcontext.GetActorNameFromContext(ctx),
+		Action:     string(event.Action),
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		RequestID:  chiMiddleware.GetReqID(ctx),
+	}
+
+	actor := event.Actor
+	if actor == nil {
+		actor, _ = internalcontext.GetUserFromContext(ctx)
+	}
+	if actor != nil {
+		params.ActorID = actor.ID
+		params.ActorName = actor.Username
+	}
+
+	var err error
+	params.Before, params.After, err = diff(event.Before, event.After)
+	if err != nil {
+		return fmt.Errorf("could not encode audit event: %w", err)
+	}
+
+	if err := querier.CreateAuditEvent(ctx, db, params); err != nil {
+		return fmt.Errorf("could not record audit event: %w", err)
+	}
+	return nil
+}
+
+// diff encodes before and after, dropping the fields that are equal in both when both are objects
+func diff(before, after any) ([]byte, []byte, error) {
+	beforeJSON, err := encode(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterJSON, err := encode(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var beforeFields, afterFields map[string]any
+	if json.Unmarshal(beforeJSON, &beforeFields) != nil || json.Unmarshal(afterJSON, &afterFields) != nil ||
+		beforeFields == nil || afterFields == nil {
+		return beforeJSON, afterJSON, nil
+	}
+
+	for field, value := range beforeFields {
+		if other, ok := afterFields[field]; ok && reflect.DeepEqual(value, other) {
+			delete(beforeFields, field)
+			delete(afterFields, field)
+		}
+	}
+
+	if beforeJSON, err = json.Marshal(beforeFields); err != nil {
+		return nil, nil, err
+	}
+	if afterJSON, err = json.Marshal(afterFields); err != nil {
+		return nil, nil, err
+	}
+	return beforeJSON, afterJSON, nil
+}
+
+// encode marshals value to JSON, nil stays nil so the column is NULL
+func encode(value any) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
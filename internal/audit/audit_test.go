@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+// fakeQuerier keeps audit events in memory
+type fakeQuerier struct {
+	storage.Querier
+	created []storage.CreateAuditEventParams
+	events  []storage.AuditEvent
+}
+
+func (q *fakeQuerier) CreateAuditEvent(_ context.Context, _ storage.DBTX, arg storage.CreateAuditEventParams) error {
+	q.created = append(q.created, arg)
+	return nil
+}
+
+func (q *fakeQuerier) ListAuditEvents(_ context.Context, _ storage.DBTX,
+	arg storage.ListAuditEventsParams) ([]storage.AuditEvent, error) {
+	var events []storage.AuditEvent
+	for _, event := range q.events {
+		if (!arg.BeforeID.Valid || event.ID < arg.BeforeID.Int64) && len(events) < int(arg.MaxEvents) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+type AuditTestSuite struct {
+	suite.Suite
+}
+
+func (s *AuditTestSuite) TestDiff() {
+	type limits struct {
+		Title       string `json:"title"`
+		TimeLimitMs int64  `json:"time_limit_ms"`
+	}
+
+	before, after, err := diff(limits{"A", 1000}, limits{"A", 2000})
+	require.NoError(s.T(), err)
+	assert.JSONEq(s.T(), `{"time_limit_ms": 1000}`, string(before))
+	assert.JSONEq(s.T(), `{"time_limit_ms": 2000}`, string(after))
+
+	before, after, err = diff(map[string]any{"draft": true}, map[string]any{"draft": false, "revision": 3})
+	require.NoError(s.T(), err)
+	assert.JSONEq(s.T(), `{"draft": true}`, string(before))
+	assert.JSONEq(s.T(), `{"draft": false, "revision": 3}`, string(after))
+
+	before, after, err = diff(nil, []string{"admin"})
+	require.NoError(s.T(), err)
+	assert.Nil(s.T(), before)
+	assert.JSONEq(s.T(), `["admin"]`, string(after))
+}
+
+func (s *AuditTestSuite) TestRecord() {
+	querier := &fakeQuerier{}
+	user := &storage.User{ID: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Username: "admin"}
+	ctx := context.WithValue(context.Background(), internalcontext.UserContextKey, user)
+	ctx = context.WithValue(ctx, chiMiddleware.RequestIDKey, "host/abc-000001")
+
+	err := Record(ctx, nil, querier, Event{Action: ProblemPublish, TargetType: TargetProblem, TargetID: "7"})
+	require.NoError(s.T(), err)
+
+	ctx = context.WithValue(context.Background(), internalcontext.ActorNameContextKey, "cli")
+	err = Record(ctx, nil, querier, Event{Action: UserGrantRole, TargetType: TargetUser, TargetID: "alice"})
+	require.NoError(s.T(), err)
+
+	require.Len(s.T(), querier.created, 2)
+	assert.Equal(s.T(), user.ID, querier.created[0].ActorID)
+	assert.Equal(s.T(), "admin", querier.created[0].ActorName)
+	assert.Equal(s.T(), "host/abc-000001", querier.created[0].RequestID)
+	assert.Nil(s.T(), querier.created[0].Before)
+	assert.False(s.T(), querier.created[1].ActorID.Valid)
+	assert.Equal(s.T(), "cli", querier.created[1].ActorName)
+}
+
+func (s *AuditTestSuite) TestExport() {
+	querier := &fakeQuerier{}
+	for id := int64(exportBatchSize + 5); id > 0; id-- {
+		querier.events = append(querier.events, storage.AuditEvent{
+			ID:         id,
+			CreatedAt:  pgtype.Timestamptz{Time: time.Unix(1_700_000_000+id, 0), Valid: true},
+			ActorName:  "admin",
+			Action:     string(ProblemUpdate),
+			TargetType: TargetProblem,
+			TargetID:   "7",
+			Before:     []byte(`{"title":"A"}`),
+			After:      []byte(`{"title":"B, \"quoted\""}`),
+		})
+	}
+
+	var out bytes.Buffer
+	require.NoError(s.T(), Export(context.Background(), nil, querier, Filter{}, FormatCSV, &out))
+	records, err := csv.NewReader(&out).ReadAll()
+	require.NoError(s.T(), err)
+	require.Len(s.T(), records, exportBatchSize+6, "every batch is exported after the header")
+	assert.Equal(s.T(), "id", records[0][0])
+	assert.Equal(s.T(), "1005", records[1][0])
+	assert.Equal(s.T(), `{"title":"B, \"quoted\""}`, records[1][8])
+
+	out.Reset()
+	require.NoError(s.T(), Export(context.Background(), nil, querier, Filter{}, FormatJSON, &out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(s.T(), lines, exportBatchSize+5)
+
+	var event ExportedEvent
+	require.NoError(s.T(), json.Unmarshal([]byte(lines[len(lines)-1]), &event))
+	assert.Equal(s.T(), int64(1), event.ID)
+	assert.JSONEq(s.T(), `{"title":"A"}`, string(event.Before))
+	assert.Empty(s.T(), event.ActorID)
+
+	assert.Error(s.T(), Export(context.Background(), nil, querier, Filter{}, "xml", &out))
+}
+
+func (s *AuditTestSuite) TestFilterParams() {
+	params := Filter{Action: "auth", Since: time.Unix(1_700_000_000, 0)}.Params(42, 100)
+	assert.Equal(s.T(), pgtype.Text{String: "auth", Valid: true}, params.Action)
+	assert.False(s.T(), params.Actor.Valid)
+	assert.True(s.T(), params.Since.Valid)
+	assert.False(s.T(), params.Until.Valid)
+	assert.Equal(s.T(), pgtype.Int8{Int64: 42, Valid: true}, params.BeforeID)
+	assert.False(s.T(), Filter{}.Params(0, 100).BeforeID.Valid)
+}
+
+func (s *AuditTestSuite) TestParseFilter() {
+	filter, err := ParseFilter(url.Values{
+		"actor": {"admin"},
+		"since": {"2025-03-01"},
+		"until": {"2025-03-02"},
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "admin", filter.Actor)
+	assert.Equal(s.T(), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), filter.Since)
+	assert.Equal(s.T(), time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), filter.Until, "until includes its whole day")
+
+	filter, err = ParseFilter(url.Values{"until": {"2025-03-02T10:00:00Z"}})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), time.Date(2025, 3, 2, 10, 0, 0, 0, time.UTC), filter.Until)
+	assert.True(s.T(), filter.Since.IsZero())
+
+	_, err = ParseFilter(url.Values{"since": {"yesterday"}})
+	assert.ErrorIs(s.T(), err, ErrInvalidFilter)
+}
+
+func TestAuditTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditTestSuite))
+}
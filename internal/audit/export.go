@@ -0,0 +1,187 @@
+package audit
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+// exportBatchSize is how many events are loaded at a time while exporting
+const exportBatchSize = 1000
+
+// Export formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Filter selects events, empty fields select everything. Action matches the action or, without a dot, its group.
+type Filter struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	Since      time.Time
+	Until      time.Time
+}
+
+// dateLayout is how dates are written in filters, a time can be given in RFC 3339 instead
+const dateLayout = "2006-01-02"
+
+// ErrInvalidFilter is returned for filters whose times can not be parsed
+var ErrInvalidFilter = errors.New("since and until must be dates like 2006-01-02 or RFC 3339 times")
+
+// ParseFilter reads a filter from the query of the audit log page, the command line passes its flags the same way.
+// A date in until includes the whole day.
+func ParseFilter(values url.Values) (Filter, error) {
+	filter := Filter{
+		Actor:      values.Get("actor"),
+		Action:     values.Get("action"),
+		TargetType: values.Get("target_type"),
+		TargetID:   values.Get("target_id"),
+	}
+
+	var err error
+	if filter.Since, err = parseTime(values.Get("since"), false); err != nil {
+		return Filter{}, err
+	}
+	if filter.Until, err = parseTime(values.Get("until"), true); err != nil {
+		return Filter{}, err
+	}
+	return filter, nil
+}
+
+func parseTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidFilter
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// Params returns the query parameters of the filter for up to limit events older than beforeID, any when it is 0
+func (f Filter) Params(beforeID int64, limit int32) storage.ListAuditEventsParams {
+	return storage.ListAuditEventsParams{
+		Actor:      text(f.Actor),
+		Action:     text(f.Action),
+		TargetType: text(f.TargetType),
+		TargetID:   text(f.TargetID),
+		Since:      pgtype.Timestamptz{Time: f.Since, Valid: !f.Since.IsZero()},
+		Until:      pgtype.Timestamptz{Time: f.Until, Valid: !f.Until.IsZero()},
+		BeforeID:   pgtype.Int8{Int64: beforeID, Valid: beforeID > 0},
+		MaxEvents:  limit,
+	}
+}
+
+func text(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+// ExportedEvent is an event as exported, before and after are embedded as JSON
+type ExportedEvent struct {
+	ID         int64           `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ActorID    string          `json:"actor_id,omitempty"`
+	ActorName  string          `json:"actor_name"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+}
+
+func exportEvent(event storage.AuditEvent) ExportedEvent {
+	exported := ExportedEvent{
+		ID:         event.ID,
+		CreatedAt:  event.CreatedAt.Time.UTC(),
+		ActorName:  event.ActorName,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Before:     event.Before,
+		After:      event.After,
+		RequestID:  event.RequestID,
+	}
+	if event.ActorID.Valid {
+		exported.ActorID = event.ActorID.String()
+	}
+	return exported
+}
+
+// Export writes every event of the filter to w, newest first, as CSV with a header or as JSON lines
+func Export(ctx context.Context, db storage.DBTX, querier storage.Querier, filter Filter, format string,
+	w io.Writer) error {
+	var write func(ExportedEvent) error
+	var flush func() error
+
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		err := writer.Write([]string{"id", "created_at", "actor_id", "actor_name", "action", "target_type",
+			"target_id", "before", "after", "request_id"})
+		if err != nil {
+			return fmt.Errorf("could not write csv header: %w", err)
+		}
+		write = func(event ExportedEvent) error {
+			return writer.Write([]string{strconv.FormatInt(event.ID, 10), event.CreatedAt.Format(time.RFC3339),
+				event.ActorID, event.ActorName, event.Action, event.TargetType, event.TargetID, string(event.Before),
+				string(event.After), event.RequestID})
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		write = func(event ExportedEvent) error { return encoder.Encode(event) }
+		flush = func() error { return nil }
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+
+	var beforeID int64
+	for {
+		events, err := querier.ListAuditEvents(ctx, db, filter.Params(beforeID, exportBatchSize))
+		if err != nil {
+			return fmt.Errorf("could not list audit events: %w", err)
+		}
+
+		for _, event := range events {
+			if err := write(exportEvent(event)); err != nil {
+				return fmt.Errorf("could not write audit event: %w", err)
+			}
+		}
+
+		if len(events) < exportBatchSize {
+			break
+		}
+		beforeID = events[len(events)-1].ID
+	}
+
+	if err := flush(); err != nil {
+		return fmt.Errorf("could not write audit events: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/computer-technology-team/go-judge/internal/middleware"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// Servicer defines the interface for audit log handlers
+type Servicer interface {
+	ListEvents(w http.ResponseWriter, r *http.Request)
+	ExportEvents(w http.ResponseWriter, r *http.Request)
+}
+
+// NewRoutes returns a function that registers routes with the given handler
+// This allows for dependency injection when setting up routes
+func NewRoutes(s Servicer, sharedTemplates *templates.Templates) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Use(middleware.NewRequirePermissionMiddleware(sharedTemplates, rbac.ViewAuditLog))
+		r.Get("/", s.ListEvents)
+		r.Get("/export", s.ExportEvents)
+	}
+}
@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// pageSize is how many events the audit log page shows at a time
+const pageSize = 50
+
+type auditLogPageData struct {
+	Events      []ExportedEvent
+	Filter      map[string]string
+	Actions     []Action
+	TargetTypes []string
+	// OlderURL pages to the events before the last one shown, empty when there are none
+	OlderURL  string
+	ExportURL string
+}
+
+// servicerImpl is the default implementation of the Servicer interface
+type servicerImpl struct {
+	pool *pgxpool.Pool
+
+	querier storage.Querier
+
+	templates *templates.Templates
+}
+
+// NewServicer creates a new instance of the default audit log servicer
+func NewServicer(templates *templates.Templates, pool *pgxpool.Pool, querier storage.Querier) Servicer {
+	return &servicerImpl{
+		pool:      pool,
+		querier:   querier,
+		templates: templates,
+	}
+}
+
+// ListEvents shows the events of the filter in the query, newest first
+func (s *servicerImpl) ListEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	filter, err := ParseFilter(query)
+	if err != nil {
+		templates.RenderError(ctx, w, err.Error(), http.StatusBadRequest, s.templates)
+		return
+	}
+
+	var beforeID int64
+	if value := query.Get("before_id"); value != "" {
+		beforeID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || beforeID <= 0 {
+			templates.RenderError(ctx, w, "invalid before_id", http.StatusBadRequest, s.templates)
+			return
+		}
+	}
+
+	// one more event than shown tells whether there are older ones
+	events, err := s.querier.ListAuditEvents(ctx, s.pool, filter.Params(beforeID, pageSize+1))
+	if err != nil {
+		slog.ErrorContext(ctx, "could not list audit events", "error", err)
+		templates.RenderError(ctx, w, "could not list audit events", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	data := auditLogPageData{
+		Filter:      map[string]string{},
+		Actions:     Actions,
+		TargetTypes: TargetTypes,
+	}
+	for _, key := range []string{"actor", "action", "target_type", "target_id", "since", "until"} {
+		data.Filter[key] = query.Get(key)
+	}
+
+	if len(events) > pageSize {
+		events = events[:pageSize]
+
+		older := r.URL.Query()
+		older.Set("before_id", strconv.FormatInt(events[len(events)-1].ID, 10))
+		data.OlderURL = "/audit?" + older.Encode()
+	}
+	for _, event := range events {
+		data.Events = append(data.Events, exportEvent(event))
+	}
+
+	export := r.URL.Query()
+	export.Del("before_id")
+	data.ExportURL = "/audit/export?" + export.Encode()
+
+	err = s.templates.Render(ctx, "auditlogpage", w, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render audit log", "error", err)
+		templates.RenderError(ctx, w, "could not render audit log", http.StatusInternalServerError, s.templates)
+		return
+	}
+}
+
+// ExportEvents downloads every event of the filter in the query as CSV, or as JSON lines with format=json
+func (s *servicerImpl) ExportEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := ParseFilter(r.URL.Query())
+	if err != nil {
+		templates.RenderError(ctx, w, err.Error(), http.StatusBadRequest, s.templates)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "", FormatCSV:
+		format = FormatCSV
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit-events.csv"`)
+	case FormatJSON:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit-events.jsonl"`)
+	default:
+		templates.RenderError(ctx, w, "format must be csv or json", http.StatusBadRequest, s.templates)
+		return
+	}
+
+	// the download has started, a failure can only cut it short
+	err = Export(ctx, s.pool, s.querier, filter, format, w)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not export audit events", "error", err)
+	}
+}
@@ -0,0 +1,113 @@
+// Package apitoken implements personal access tokens, which let scripts act as their user within the scopes of
+// the token. Tokens are sent as "Authorization: Bearer <token>" and only their hashes are stored.
+package apitoken
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/computer-technology-team/go-judge/internal/auth/randomtoken"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+)
+
+// Prefix starts every personal access token, it tells them apart from access tokens and makes leaked ones easy to find
+const Prefix = "gjp_"
+
+// Scope is a kind of request a token may make
+type Scope string
+
+const (
+	// ReadProblems allows listing and viewing problems and their attachments, not the pages of their editors
+	ReadProblems Scope = "read_problems"
+	// Submit allows submitting solutions and running code against custom input
+	Submit Scope = "submit"
+	// ReadSubmissions allows viewing the submissions of the user
+	ReadSubmissions Scope = "read_submissions"
+)
+
+// ScopeInfo describes a scope for the token form
+type ScopeInfo struct {
+	Scope       Scope
+	Description string
+}
+
+// Scopes are all scopes in the order they are offered
+var Scopes = []ScopeInfo{
+	{ReadProblems, "Read problems"},
+	{Submit, "Submit solutions and run code"},
+	{ReadSubmissions, "Read your submissions"},
+}
+
+// ValidScope reports whether scope names a scope
+func ValidScope(scope string) bool {
+	return slices.ContainsFunc(Scopes, func(info ScopeInfo) bool { return string(info.Scope) == scope })
+}
+
+// Generate returns a new token and the hash to store
+func Generate() (string, []byte, error) {
+	return randomtoken.New(Prefix)
+}
+
+// IsToken reports whether a bearer token is a personal access token
+func IsToken(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+// RequiredScope returns the scope a request made with a token needs, tokens may not make other requests
+func RequiredScope(method, path string) (Scope, bool) {
+	read := method == http.MethodGet || method == http.MethodHead
+
+	switch {
+	case read && isProblemView(path):
+		return ReadProblems, true
+	case method == http.MethodPost && (path == "/submissions" || path == "/submissions/" || isCustomRun(path)):
+		return Submit, true
+	case read && (path == "/submissions" || strings.HasPrefix(path, "/submissions/")):
+		return ReadSubmissions, true
+	default:
+		return "", false
+	}
+}
+
+// isProblemView matches /problems, /problems/{id} and /problems/{id}/attachments/{name}
+func isProblemView(path string) bool {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if parts[0] != "problems" {
+		return false
+	}
+
+	switch len(parts) {
+	case 1:
+		return true
+	case 2:
+		return isID(parts[1])
+	case 4:
+		return isID(parts[1]) && parts[2] == "attachments" && parts[3] != ""
+	default:
+		return false
+	}
+}
+
+// isID reports whether a path segment is a problem id
+func isID(segment string) bool {
+	return segment != "" && strings.Trim(segment, "0123456789") == ""
+}
+
+// isCustomRun matches /submissions/problem/{problem_id}/run
+func isCustomRun(path string) bool {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	return len(parts) == 4 && parts[0] == "submissions" && parts[1] == "problem" && parts[3] == "run"
+}
+
+// Permissions limits the permissions of the user to the ones the scopes need, so a token of an admin
+// is not an admin token
+func Permissions(scopes []string, permissions rbac.Permissions) rbac.Permissions {
+	if !slices.Contains(scopes, string(Submit)) {
+		return rbac.Permissions{}
+	}
+
+	return slices.DeleteFunc(slices.Clone(permissions), func(permission rbac.Permission) bool {
+		return permission != rbac.Submit && permission != rbac.UnlimitedSubmissions
+	})
+}
@@ -0,0 +1,81 @@
+package apitoken
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/computer-technology-team/go-judge/internal/auth/randomtoken"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+)
+
+type APITokenTestSuite struct {
+	suite.Suite
+}
+
+func (s *APITokenTestSuite) TestGenerate() {
+	token, hash, err := Generate()
+	require.NoError(s.T(), err)
+
+	assert.True(s.T(), IsToken(token))
+	assert.Equal(s.T(), randomtoken.Hash(token), hash)
+
+	other, _, err := Generate()
+	require.NoError(s.T(), err)
+	assert.NotEqual(s.T(), token, other)
+
+	assert.False(s.T(), IsToken("eyJhbGciOiJIUzI1NiJ9.e30.sig"))
+}
+
+func (s *APITokenTestSuite) TestRequiredScope() {
+	tests := []struct {
+		method, path string
+		scope        Scope
+		allowed      bool
+	}{
+		{http.MethodGet, "/problems", ReadProblems, true},
+		{http.MethodGet, "/problems/12", ReadProblems, true},
+		{http.MethodGet, "/problems/", ReadProblems, true},
+		{http.MethodGet, "/problems/12/attachments/figure.png", ReadProblems, true},
+		{http.MethodPost, "/problems/12", "", false},
+		{http.MethodGet, "/problems/12/tests.zip", "", false},
+		{http.MethodGet, "/problems/12/programs", "", false},
+		{http.MethodGet, "/problems/12/revisions", "", false},
+		{http.MethodGet, "/problems/12/revisions/diff", "", false},
+		{http.MethodGet, "/problems/12/export", "", false},
+		{http.MethodGet, "/problems/form/12", "", false},
+		{http.MethodGet, "/problems/my", "", false},
+		{http.MethodGet, "/problems/import", "", false},
+		{http.MethodGet, "/problems/12/attachments/", "", false},
+		{http.MethodGet, "/problemset", "", false},
+		{http.MethodPost, "/submissions/", Submit, true},
+		{http.MethodPost, "/submissions/problem/12/run", Submit, true},
+		{http.MethodGet, "/submissions", ReadSubmissions, true},
+		{http.MethodGet, "/submissions/0d4b5b3e-5f5c-4a49-9d47-0f7d1f1b2b11/source", ReadSubmissions, true},
+		{http.MethodPost, "/auth/tokens", "", false},
+		{http.MethodGet, "/auth/sessions", "", false},
+		{http.MethodGet, "/", "", false},
+	}
+
+	for _, test := range tests {
+		scope, allowed := RequiredScope(test.method, test.path)
+		assert.Equal(s.T(), test.allowed, allowed, "%s %s", test.method, test.path)
+		assert.Equal(s.T(), test.scope, scope, "%s %s", test.method, test.path)
+	}
+}
+
+func (s *APITokenTestSuite) TestPermissions() {
+	admin := rbac.Permissions{rbac.Submit, rbac.ManageProblems, rbac.ViewSubmissions, rbac.UnlimitedSubmissions}
+
+	assert.Equal(s.T(), rbac.Permissions{rbac.Submit, rbac.UnlimitedSubmissions},
+		Permissions([]string{string(Submit), string(ReadProblems)}, admin))
+	assert.Empty(s.T(), Permissions([]string{string(ReadProblems), string(ReadSubmissions)}, admin))
+	assert.Len(s.T(), admin, 4)
+}
+
+func TestAPITokenTestSuite(t *testing.T) {
+	suite.Run(t, new(APITokenTestSuite))
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	"github.com/computer-technology-team/go-judge/internal/auth/apitoken"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+const maxAPITokenNameLength = 64
+
+// apiTokenExpiries are the lifetimes in days offered for new tokens
+var apiTokenExpiries = []int{7, 30, 90, 365}
+
+type apiTokensPageData struct {
+	Tokens   []storage.PersonalAccessToken
+	Scopes   []apitoken.ScopeInfo
+	Expiries []int
+	// NewToken is the token just created, it is shown only this once
+	NewToken string
+}
+
+// ShowAPITokens lists the personal access tokens of the user with a form to create one
+func (s *DefaultServicer) ShowAPITokens(w http.ResponseWriter, r *http.Request) {
+	s.renderAPITokens(w, r, "")
+}
+
+// CreateAPIToken creates a personal access token and shows it once
+func (s *DefaultServicer) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	if err := r.ParseForm(); err != nil {
+		templates.RenderError(ctx, w, "invalid form", http.StatusBadRequest, s.templates)
+		return
+	}
+
+	name := strings.TrimSpace(r.PostForm.Get("name"))
+	if name == "" || utf8.RuneCountInString(name) > maxAPITokenNameLength {
+		templates.RenderError(ctx, w, "token name must be between 1 and 64 characters", http.StatusBadRequest,
+			s.templates)
+		return
+	}
+
+	scopes := r.PostForm["scopes"]
+	if len(scopes) == 0 || slices.ContainsFunc(scopes, func(scope string) bool { return !apitoken.ValidScope(scope) }) {
+		templates.RenderError(ctx, w, "choose at least one valid scope", http.StatusBadRequest, s.templates)
+		return
+	}
+
+	days, err := strconv.Atoi(r.PostForm.Get("expires_in_days"))
+	if err != nil || !slices.Contains(apiTokenExpiries, days) {
+		templates.RenderError(ctx, w, "invalid token expiry", http.StatusBadRequest, s.templates)
+		return
+	}
+
+	token, tokenHash, err := apitoken.Generate()
+	if err != nil {
+		slog.ErrorContext(ctx, "could not generate personal access token", "error", err)
+		templates.RenderError(ctx, w, "could not create token", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	created, err := s.querier.CreatePersonalAccessToken(ctx, s.pool, storage.CreatePersonalAccessTokenParams{
+		UserID:    user.ID,
+		Name:      name,
+		TokenHash: tokenHash,
+		Scopes:    scopes,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().AddDate(0, 0, days), Valid: true},
+	})
+	if err != nil {
+		if storage.IsUniqueViolation(err) {
+			templates.RenderError(ctx, w, "you already have a token with this name", http.StatusConflict, s.templates)
+			return
+		}
+		slog.ErrorContext(ctx, "could not create personal access token", "error", err)
+		templates.RenderError(ctx, w, "could not create token", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	s.recordEvent(ctx, audit.Event{
+		Action:     audit.AuthAPITokenCreate,
+		TargetType: audit.TargetAPIToken,
+		TargetID:   created.ID.String(),
+		After:      map[string]any{"name": name, "scopes": scopes, "expires_at": created.ExpiresAt},
+	})
+
+	s.renderAPITokens(w, r, token)
+}
+
+// DeleteAPIToken deletes a personal access token of the user, it stops working at once
+func (s *DefaultServicer) DeleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid token id", http.StatusBadRequest, s.templates)
+		return
+	}
+
+	deleted, err := s.querier.DeletePersonalAccessToken(ctx, s.pool, user.ID, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not delete personal access token", "error", err)
+		templates.RenderError(ctx, w, "could not delete token", http.StatusInternalServerError, s.templates)
+		return
+	}
+	if deleted == 0 {
+		templates.RenderError(ctx, w, "token not found", http.StatusNotFound, s.templates)
+		return
+	}
+
+	s.recordEvent(ctx, audit.Event{
+		Action:     audit.AuthAPITokenDelete,
+		TargetType: audit.TargetAPIToken,
+		TargetID:   id.String(),
+	})
+
+	http.Redirect(w, r, "/auth/tokens", http.StatusSeeOther)
+}
+
+func (s *DefaultServicer) renderAPITokens(w http.ResponseWriter, r *http.Request, newToken string) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	tokens, err := s.querier.ListUserPersonalAccessTokens(ctx, s.pool, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not list personal access tokens", "error", err)
+		templates.RenderError(ctx, w, "could not get tokens", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	err = s.templates.Render(ctx, "apitokens", w, apiTokensPageData{
+		Tokens:   tokens,
+		Scopes:   apitoken.Scopes,
+		Expiries: apiTokenExpiries,
+		NewToken: newToken,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render apitokens", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, s.templates)
+		return
+	}
+}
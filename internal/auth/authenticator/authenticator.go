@@ -5,6 +5,8 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	jwt "github.com/golang-jwt/jwt/v5"
@@ -16,16 +18,20 @@ import (
 
 type Claims struct {
 	UserID string `json:"user_id"`
+	// SessionID is the session the token was issued for, tokens stop working once it is revoked
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type Authenticator interface {
 	GenerateToken(context.Context, Claims) (string, *Claims, error)
 	VerifyDecodeToken(context.Context, string) (*Claims, error)
+	// JWKS returns the public keys tokens can be verified with, HS256 secrets are not included
+	JWKS() JSONWebKeySet
 }
 
 type AuthenticatorImpl struct {
-	keys                map[string][]byte
+	keys                map[string]signingKey
 	keyID               string
 	tokenExpireDuration time.Duration
 }
@@ -43,15 +49,15 @@ func (a *AuthenticatorImpl) GenerateToken(ctx context.Context, claims Claims) (s
 		ExpiresAt: jwt.NewNumericDate(now.Add(a.tokenExpireDuration)),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	token.Header["kid"] = a.keyID
-
 	key, ok := a.keys[a.keyID]
-	if !ok {
+	if !ok || key.private == nil {
 		return "", nil, errors.New("active signing key not found")
 	}
 
-	signedToken, err := token.SignedString(key)
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = a.keyID
+
+	signedToken, err := token.SignedString(key.private)
 	if err != nil {
 		return "", nil, err
 	}
@@ -63,8 +69,8 @@ func (a *AuthenticatorImpl) VerifyDecodeToken(ctx context.Context, tokenStr stri
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenStr, claims, a.GetKey, jwt.WithValidMethods([]string{
 		jwt.SigningMethodHS256.Name,
-		jwt.SigningMethodES384.Name,
-		jwt.SigningMethodES512.Name,
+		jwt.SigningMethodES256.Name,
+		jwt.SigningMethodEdDSA.Alg(),
 	}))
 	if err != nil {
 		return nil, err
@@ -77,11 +83,9 @@ func (a *AuthenticatorImpl) VerifyDecodeToken(ctx context.Context, tokenStr stri
 	return claims, nil
 }
 
+// GetKey returns the key of the token's kid, the token must use the algorithm of the key so a public key
+// can never be used as an HMAC secret
 func (a *AuthenticatorImpl) GetKey(token *jwt.Token) (any, error) {
-	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-	}
-
 	kidRaw, ok := token.Header["kid"]
 	if !ok {
 		return nil, errors.New("kid not found")
@@ -97,25 +101,52 @@ func (a *AuthenticatorImpl) GetKey(token *jwt.Token) (any, error) {
 		return nil, ErrSigningKeyNotFound
 	}
 
-	return key, nil
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.public, nil
+}
+
+// JWKS implements Authenticator.
+func (a *AuthenticatorImpl) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, keyID := range slices.Sorted(maps.Keys(a.keys)) {
+		if jwk, ok := a.keys[keyID].jwk(keyID); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
 }
 
 func NewAuthenticator(cfg config.AuthenticationConfig) (Authenticator, error) {
 	var errs error
-	keys := lo.MapValues(cfg.Keys, func(v string, k string) []byte {
+	keys := lo.MapValues(cfg.Keys, func(v string, k string) signingKey {
 		decoded, err := base64.StdEncoding.DecodeString(v)
 		if err != nil {
 			errs = errors.Join(errs, err)
-			return nil
+			return signingKey{}
 		}
 
-		return decoded
+		return newSecretKey(decoded)
 	})
 	if errs != nil {
 		return nil, fmt.Errorf("failed to decode keys for authenticator: %w", errs)
 	}
 
-	if _, ok := keys[cfg.ActiveKeyID]; !ok {
+	for keyID, path := range cfg.KeyFiles {
+		if _, ok := keys[keyID]; ok {
+			return nil, fmt.Errorf("key id %s is used by a secret and a key file", keyID)
+		}
+
+		key, err := loadKeyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key %s for authenticator: %w", keyID, err)
+		}
+		keys[keyID] = key
+	}
+
+	if key, ok := keys[cfg.ActiveKeyID]; !ok || key.private == nil {
 		return nil, ErrSigningKeyNotFound
 	}
 
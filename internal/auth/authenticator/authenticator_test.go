@@ -2,7 +2,15 @@ package authenticator
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -90,6 +98,18 @@ func (s *AuthenticatorTestSuite) TestGenerateToken() {
 	s.verifyStandardClaims(claims)
 }
 
+func (s *AuthenticatorTestSuite) TestSessionIDClaim() {
+	auth := s.createAuthenticator(s.testKeyID, time.Hour)
+
+	tokenString, _, err := auth.GenerateToken(s.ctx, Claims{UserID: "user123", SessionID: "session456"})
+	require.NoError(s.T(), err)
+
+	claims, err := auth.VerifyDecodeToken(s.ctx, tokenString)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "session456", claims.SessionID)
+	assert.NotEqual(s.T(), claims.SessionID, claims.ID)
+}
+
 func (s *AuthenticatorTestSuite) verifyStandardClaims(claims jwt.MapClaims) {
 	// Check issuer
 	issuerClaim, ok := claims["iss"]
@@ -322,6 +342,196 @@ func (s *AuthenticatorTestSuite) TestNewAuthenticatorErrors() {
 	}
 }
 
+// writeKeyFile stores a key as PEM, private keys as PKCS #8 and public keys as PKIX
+func (s *AuthenticatorTestSuite) writeKeyFile(key any) string {
+	var (
+		block pem.Block
+		err   error
+	)
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+		block.Type = "PUBLIC KEY"
+		block.Bytes, err = x509.MarshalPKIXPublicKey(key)
+	default:
+		block.Type = "PRIVATE KEY"
+		block.Bytes, err = x509.MarshalPKCS8PrivateKey(key)
+	}
+	require.NoError(s.T(), err)
+
+	path := filepath.Join(s.T().TempDir(), "key.pem")
+	require.NoError(s.T(), os.WriteFile(path, pem.EncodeToMemory(&block), 0o600))
+	return path
+}
+
+func (s *AuthenticatorTestSuite) TestAsymmetricKeys() {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(s.T(), err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(s.T(), err)
+
+	testCases := []struct {
+		name string
+		key  any
+		alg  string
+	}{
+		{name: "ES256", key: ecKey, alg: "ES256"},
+		{name: "EdDSA", key: edKey, alg: "EdDSA"},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			auth, err := NewAuthenticator(config.AuthenticationConfig{
+				KeyFiles:    map[string]string{"asymmetric": s.writeKeyFile(tc.key)},
+				ActiveKeyID: "asymmetric",
+				TokenExpiry: time.Hour,
+			})
+			require.NoError(s.T(), err)
+
+			tokenString, _, err := auth.GenerateToken(s.ctx, s.validClaims)
+			require.NoError(s.T(), err)
+
+			token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), tc.alg, token.Header["alg"])
+			assert.Equal(s.T(), "asymmetric", token.Header["kid"])
+
+			claims, err := auth.VerifyDecodeToken(s.ctx, tokenString)
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), s.validClaims.UserID, claims.UserID)
+		})
+	}
+}
+
+func (s *AuthenticatorTestSuite) TestRotationToKeyFile() {
+	oldAuth := s.createAuthenticator(s.testKeyID, time.Hour)
+	oldToken, _, err := oldAuth.GenerateToken(s.ctx, s.validClaims)
+	require.NoError(s.T(), err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(s.T(), err)
+	newAuth, err := NewAuthenticator(config.AuthenticationConfig{
+		Keys:        map[string]string{s.testKeyID: base64.StdEncoding.EncodeToString([]byte(s.testKey))},
+		KeyFiles:    map[string]string{"new": s.writeKeyFile(edKey)},
+		ActiveKeyID: "new",
+		TokenExpiry: time.Hour,
+	})
+	require.NoError(s.T(), err)
+
+	_, err = newAuth.VerifyDecodeToken(s.ctx, oldToken)
+	assert.NoError(s.T(), err, "tokens of the previous key should stay valid")
+
+	newToken, _, err := newAuth.GenerateToken(s.ctx, s.validClaims)
+	require.NoError(s.T(), err)
+	_, err = oldAuth.VerifyDecodeToken(s.ctx, newToken)
+	assert.ErrorIs(s.T(), err, ErrSigningKeyNotFound)
+}
+
+func (s *AuthenticatorTestSuite) TestRetiredPublicKey() {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(s.T(), err)
+
+	signer, err := NewAuthenticator(config.AuthenticationConfig{
+		KeyFiles:    map[string]string{"retired": s.writeKeyFile(ecKey)},
+		ActiveKeyID: "retired",
+		TokenExpiry: time.Hour,
+	})
+	require.NoError(s.T(), err)
+	tokenString, _, err := signer.GenerateToken(s.ctx, s.validClaims)
+	require.NoError(s.T(), err)
+
+	cfg := config.AuthenticationConfig{
+		Keys:        map[string]string{s.testKeyID: base64.StdEncoding.EncodeToString([]byte(s.testKey))},
+		KeyFiles:    map[string]string{"retired": s.writeKeyFile(&ecKey.PublicKey)},
+		ActiveKeyID: s.testKeyID,
+		TokenExpiry: time.Hour,
+	}
+	verifier, err := NewAuthenticator(cfg)
+	require.NoError(s.T(), err)
+
+	_, err = verifier.VerifyDecodeToken(s.ctx, tokenString)
+	assert.NoError(s.T(), err)
+
+	cfg.ActiveKeyID = "retired"
+	_, err = NewAuthenticator(cfg)
+	assert.ErrorIs(s.T(), err, ErrSigningKeyNotFound, "public keys can not sign")
+}
+
+func (s *AuthenticatorTestSuite) TestAlgorithmConfusion() {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(s.T(), err)
+	path := s.writeKeyFile(&ecKey.PublicKey)
+
+	auth, err := NewAuthenticator(config.AuthenticationConfig{
+		Keys:        map[string]string{s.testKeyID: base64.StdEncoding.EncodeToString([]byte(s.testKey))},
+		KeyFiles:    map[string]string{"public": path},
+		ActiveKeyID: s.testKeyID,
+		TokenExpiry: time.Hour,
+	})
+	require.NoError(s.T(), err)
+
+	// a forged token using the published public key as an HMAC secret
+	publicPEM, err := os.ReadFile(path)
+	require.NoError(s.T(), err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		UserID: s.validClaims.UserID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	forged.Header["kid"] = "public"
+	forgedString, err := forged.SignedString(publicPEM)
+	require.NoError(s.T(), err)
+
+	_, err = auth.VerifyDecodeToken(s.ctx, forgedString)
+	assert.Error(s.T(), err)
+}
+
+func (s *AuthenticatorTestSuite) TestJWKS() {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(s.T(), err)
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(s.T(), err)
+
+	auth, err := NewAuthenticator(config.AuthenticationConfig{
+		Keys: map[string]string{s.testKeyID: base64.StdEncoding.EncodeToString([]byte(s.testKey))},
+		KeyFiles: map[string]string{
+			"ec": s.writeKeyFile(ecKey),
+			"ed": s.writeKeyFile(edKey),
+		},
+		ActiveKeyID: "ed",
+		TokenExpiry: time.Hour,
+	})
+	require.NoError(s.T(), err)
+
+	set := auth.JWKS()
+
+	require.Len(s.T(), set.Keys, 2, "secrets should not be published")
+	assert.Equal(s.T(), JSONWebKey{
+		KeyType:   "EC",
+		Curve:     "P-256",
+		X:         base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))),
+		Y:         base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))),
+		KeyID:     "ec",
+		Use:       "sig",
+		Algorithm: "ES256",
+	}, set.Keys[0])
+
+	// a service holding only the published key verifies tokens
+	published := set.Keys[1]
+	assert.Equal(s.T(), "OKP", published.KeyType)
+	assert.Equal(s.T(), "ed", published.KeyID)
+	publicKey, err := base64.RawURLEncoding.DecodeString(published.X)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []byte(edPublic), publicKey)
+
+	tokenString, _, err := auth.GenerateToken(s.ctx, s.validClaims)
+	require.NoError(s.T(), err)
+	_, err = jwt.Parse(tokenString, func(*jwt.Token) (any, error) {
+		return ed25519.PublicKey(publicKey), nil
+	}, jwt.WithValidMethods([]string{published.Algorithm}))
+	assert.NoError(s.T(), err)
+}
+
 // Helper function to create a token with a wrong signing method
 func (s *AuthenticatorTestSuite) createTokenWithWrongMethod() string {
 	claims := jwt.MapClaims{
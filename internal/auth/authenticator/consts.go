@@ -5,6 +5,8 @@ import "errors"
 const (
 	issuer         = "gojudge"
 	TokenCookieKey = "token"
+	// RefreshTokenCookieKey is the cookie browsers keep the refresh token of their session in
+	RefreshTokenCookieKey = "refresh_token"
 )
 
 var ErrSigningKeyNotFound = errors.New("signing key not found")
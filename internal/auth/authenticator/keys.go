@@ -0,0 +1,117 @@
+package authenticator
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is a key tokens are verified with, and signed with when the private part is known
+type signingKey struct {
+	method jwt.SigningMethod
+	// private is nil for keys that only verify
+	private any
+	public  any
+}
+
+// JSONWebKey is the public part of a signing key as in RFC 7517
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y,omitempty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// JSONWebKeySet is the document served at /.well-known/jwks.json
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+func newSecretKey(secret []byte) signingKey {
+	return signingKey{method: jwt.SigningMethodHS256, private: secret, public: secret}
+}
+
+// loadKeyFile reads a PEM encoded private or public ES256 or EdDSA key
+func loadKeyFile(path string) (signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return signingKey{}, fmt.Errorf("could not read key file: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return signingKey{}, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return signingKey{}, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return signingKey{}, fmt.Errorf("could not parse key: %w", err)
+	}
+
+	switch key := parsed.(type) {
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return signingKey{}, errors.New("only P-256 ECDSA keys are supported")
+		}
+		return signingKey{method: jwt.SigningMethodES256, private: key, public: &key.PublicKey}, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return signingKey{}, errors.New("only P-256 ECDSA keys are supported")
+		}
+		return signingKey{method: jwt.SigningMethodES256, public: key}, nil
+	case ed25519.PrivateKey:
+		return signingKey{method: jwt.SigningMethodEdDSA, private: key, public: key.Public()}, nil
+	case ed25519.PublicKey:
+		return signingKey{method: jwt.SigningMethodEdDSA, public: key}, nil
+	default:
+		return signingKey{}, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
+
+// jwk returns the public key as a JSON web key, secrets are never published
+func (k signingKey) jwk(keyID string) (JSONWebKey, bool) {
+	jwk := JSONWebKey{KeyID: keyID, Use: "sig", Algorithm: k.method.Alg()}
+
+	switch public := k.public.(type) {
+	case *ecdsa.PublicKey:
+		ecdhKey, err := public.ECDH()
+		if err != nil {
+			return JSONWebKey{}, false
+		}
+		// the uncompressed point is 0x04 followed by the coordinates
+		point := ecdhKey.Bytes()[1:]
+		jwk.KeyType = "EC"
+		jwk.Curve = public.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[:len(point)/2])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[len(point)/2:])
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	default:
+		return JSONWebKey{}, false
+	}
+
+	return jwk, true
+}
@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/computer-technology-team/go-judge/internal/auth/authenticator"
+)
+
+// NewJWKSHandler serves the public keys tokens are signed with, so other services can verify tokens without a secret
+func NewJWKSHandler(authenticator authenticator.Authenticator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// rotated keys are published before they sign, caches only have to outlive the rotation
+		w.Header().Set("Cache-Control", "public, max-age=300")
+
+		if err := json.NewEncoder(w).Encode(authenticator.JWKS()); err != nil {
+			slog.ErrorContext(r.Context(), "could not write jwks", "error", err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package lockout slows down password guessing by locking the logins of an account or of a client IP after
+// repeated failures
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/computer-technology-team/go-judge/config"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+// Lockout counts failed logins in the database, so every server sees the same locks
+type Lockout struct {
+	cfg     config.LockoutConfig
+	pool    *pgxpool.Pool
+	querier storage.Querier
+}
+
+func New(cfg config.LockoutConfig, pool *pgxpool.Pool, querier storage.Querier) *Lockout {
+	return &Lockout{cfg: cfg, pool: pool, querier: querier}
+}
+
+// Wait returns how long logins to username from ip are locked, zero when they are not
+func (l *Lockout) Wait(ctx context.Context, username, ip string) (time.Duration, error) {
+	keys := []string{accountKey(username)}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+
+	lockedUntil, err := l.querier.GetLoginLockedUntil(ctx, l.pool, keys)
+	if err != nil {
+		return 0, fmt.Errorf("could not get login lock: %w", err)
+	}
+
+	return max(time.Until(lockedUntil.Time), 0), nil
+}
+
+// Fail records a failed login to username from ip, locking the account or the ip once they reach their threshold
+func (l *Lockout) Fail(ctx context.Context, username, ip string) error {
+	now := time.Now()
+	windowStart := pgtype.Timestamptz{Time: now.Add(-l.cfg.Window), Valid: true}
+
+	counters := map[string]int{accountKey(username): l.cfg.AccountThreshold}
+	if ip != "" {
+		counters[ipKey(ip)] = l.cfg.IPThreshold
+	}
+
+	for key, threshold := range counters {
+		failures, err := l.querier.RecordLoginFailure(ctx, l.pool, key, windowStart)
+		if err != nil {
+			return fmt.Errorf("could not record login failure: %w", err)
+		}
+
+		if wait := delay(int(failures), threshold, l.cfg.BaseDelay, l.cfg.MaxDelay); wait > 0 {
+			err := l.querier.LockLogin(ctx, l.pool, pgtype.Timestamptz{Time: now.Add(wait), Valid: true}, key)
+			if err != nil {
+				return fmt.Errorf("could not lock login: %w", err)
+			}
+		}
+	}
+
+	if err := l.querier.DeleteStaleLoginFailures(ctx, l.pool, windowStart); err != nil {
+		return fmt.Errorf("could not delete stale login failures: %w", err)
+	}
+
+	return nil
+}
+
+// Succeed forgets the failures of the account. Failures of the ip are kept, otherwise logging in to one account
+// would allow guessing the passwords of others.
+func (l *Lockout) Succeed(ctx context.Context, username string) error {
+	if err := l.querier.ClearLoginFailures(ctx, l.pool, accountKey(username)); err != nil {
+		return fmt.Errorf("could not clear login failures: %w", err)
+	}
+	return nil
+}
+
+// delay is how long failures lock logins, nothing below threshold and then base doubled with every further
+// failure up to maxDelay. A threshold of zero never locks.
+func delay(failures, threshold int, base, maxDelay time.Duration) time.Duration {
+	if threshold <= 0 || failures < threshold {
+		return 0
+	}
+
+	wait := base
+	for range failures - threshold {
+		if wait >= maxDelay {
+			break
+		}
+		wait *= 2
+	}
+	return min(wait, maxDelay)
+}
+
+// accountKey names the account by the username tried, so unknown usernames are locked like existing ones
+func accountKey(username string) string {
+	return "account:" + strings.ToLower(username)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,45 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type LockoutTestSuite struct {
+	suite.Suite
+}
+
+func (s *LockoutTestSuite) TestDelay() {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{8, 8 * time.Minute},
+		{11, time.Hour},
+		{1000, time.Hour},
+	}
+
+	for _, test := range tests {
+		assert.Equal(s.T(), test.want, delay(test.failures, 5, time.Minute, time.Hour), "%d failures", test.failures)
+	}
+}
+
+func (s *LockoutTestSuite) TestDisabled() {
+	assert.Zero(s.T(), delay(100, 0, time.Minute, time.Hour))
+}
+
+func (s *LockoutTestSuite) TestAccountKey() {
+	assert.Equal(s.T(), accountKey("Alice"), accountKey("alice"))
+	assert.NotEqual(s.T(), accountKey("alice"), ipKey("alice"))
+}
+
+func TestLockoutTestSuite(t *testing.T) {
+	suite.Run(t, new(LockoutTestSuite))
+}
@@ -0,0 +1,118 @@
+// Package password checks new passwords against the password policy and hashes them
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/computer-technology-team/go-judge/config"
+)
+
+// maxLength is the most bcrypt hashes, longer passwords would be cut silently
+const maxLength = 72
+
+// breached are common passwords from public breach compilations
+//
+//go:embed breached.txt
+var breached string
+
+var ErrBreached = errors.New("this password appears in lists of breached passwords, choose another one")
+
+// Policy are the rules new passwords must follow
+type Policy struct {
+	minLength int
+	breached  map[string]struct{}
+}
+
+// NewPolicy creates the policy of the config, loading the breached passwords when they are checked
+func NewPolicy(cfg config.PasswordPolicyConfig) (*Policy, error) {
+	policy := &Policy{minLength: cfg.MinLength}
+	if !cfg.CheckBreached {
+		return policy, nil
+	}
+
+	policy.breached = make(map[string]struct{})
+	if err := policy.addBreached(strings.NewReader(breached)); err != nil {
+		return nil, fmt.Errorf("could not read bundled breached passwords: %w", err)
+	}
+
+	if cfg.BreachedListFile != "" {
+		file, err := os.Open(cfg.BreachedListFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not open breached password list: %w", err)
+		}
+		defer file.Close()
+
+		if err := policy.addBreached(file); err != nil {
+			return nil, fmt.Errorf("could not read breached password list: %w", err)
+		}
+	}
+
+	return policy, nil
+}
+
+func (p *Policy) addBreached(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.breached[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// Check returns why password may not be used by the user, the error is meant to be shown to them
+func (p *Policy) Check(password, username string) error {
+	if utf8.RuneCountInString(password) < p.minLength {
+		return fmt.Errorf("password must be at least %d characters", p.minLength)
+	}
+	if len(password) > maxLength {
+		return fmt.Errorf("password can not be longer than %d bytes", maxLength)
+	}
+	if strings.EqualFold(password, username) {
+		return errors.New("password can not be the username")
+	}
+	if _, ok := p.breached[strings.ToLower(password)]; ok {
+		return ErrBreached
+	}
+	return nil
+}
+
+// Hash hashes a password for storage
+func Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("could not hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+var (
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
+// Matches reports whether password is the one of hash. Users without a password never match, their check takes
+// as long as any other so response times do not tell which usernames exist.
+func Matches(hash, password string) bool {
+	if hash == "" {
+		dummyHashOnce.Do(func() {
+			dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+		})
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,62 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/computer-technology-team/go-judge/config"
+)
+
+type PasswordTestSuite struct {
+	suite.Suite
+}
+
+func (s *PasswordTestSuite) TestCheck() {
+	policy, err := NewPolicy(config.PasswordPolicyConfig{MinLength: 10, CheckBreached: true})
+	require.NoError(s.T(), err)
+
+	assert.NoError(s.T(), policy.Check("correct horse battery", "alice"))
+	assert.ErrorContains(s.T(), policy.Check("short", "alice"), "at least 10")
+	assert.ErrorContains(s.T(), policy.Check(strings.Repeat("a", 73), "alice"), "longer than 72")
+	assert.ErrorContains(s.T(), policy.Check("AliceInChains", "aliceinchains"), "username")
+	assert.ErrorIs(s.T(), policy.Check("Password123", "alice"), ErrBreached)
+	assert.ErrorIs(s.T(), policy.Check("QWERTYUIOP", "alice"), ErrBreached)
+}
+
+func (s *PasswordTestSuite) TestBreachedListFile() {
+	path := filepath.Join(s.T().TempDir(), "breached.txt")
+	require.NoError(s.T(), os.WriteFile(path, []byte("# leaked\ncorrect horse battery\n"), 0o600))
+
+	policy, err := NewPolicy(config.PasswordPolicyConfig{MinLength: 8, CheckBreached: true, BreachedListFile: path})
+	require.NoError(s.T(), err)
+	assert.ErrorIs(s.T(), policy.Check("Correct Horse Battery", "alice"), ErrBreached)
+	assert.ErrorIs(s.T(), policy.Check("password1", "alice"), ErrBreached)
+
+	_, err = NewPolicy(config.PasswordPolicyConfig{CheckBreached: true, BreachedListFile: path + ".missing"})
+	assert.Error(s.T(), err)
+}
+
+func (s *PasswordTestSuite) TestBreachedCheckDisabled() {
+	policy, err := NewPolicy(config.PasswordPolicyConfig{MinLength: 8})
+	require.NoError(s.T(), err)
+	assert.NoError(s.T(), policy.Check("password1", "alice"))
+}
+
+func (s *PasswordTestSuite) TestMatches() {
+	hash, err := Hash("correct horse battery")
+	require.NoError(s.T(), err)
+
+	assert.True(s.T(), Matches(hash, "correct horse battery"))
+	assert.False(s.T(), Matches(hash, "wrong horse battery"))
+	assert.False(s.T(), Matches("", ""))
+}
+
+func TestPasswordTestSuite(t *testing.T) {
+	suite.Run(t, new(PasswordTestSuite))
+}
@@ -0,0 +1,375 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	passwordPkg "github.com/computer-technology-team/go-judge/internal/auth/password"
+	"github.com/computer-technology-team/go-judge/internal/auth/randomtoken"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/middleware"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// passwordResetExpiry is how long a reset link works
+const passwordResetExpiry = 24 * time.Hour
+
+type changePasswordPageData struct {
+	// HasPassword is false for users who only log in with providers, they set a password without a current one
+	HasPassword bool
+	Changed     bool
+}
+
+type passwordResetLinkPageData struct {
+	Username  string
+	Link      string
+	ExpiresAt time.Time
+}
+
+type resetPasswordPageData struct {
+	Username string
+	Token    string
+}
+
+// ShowChangePassword shows the form to change the password of the user
+func (s *DefaultServicer) ShowChangePassword(w http.ResponseWriter, r *http.Request) {
+	user, _ := internalcontext.GetUserFromContext(r.Context())
+	s.renderChangePassword(w, r, changePasswordPageData{HasPassword: user.PasswordHash != ""})
+}
+
+// ChangePassword changes the password of the user and logs out their other sessions
+func (s *DefaultServicer) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	if err := r.ParseForm(); err != nil {
+		templates.RenderError(ctx, w, "Invalid form data", http.StatusBadRequest, s.templates)
+		return
+	}
+
+	// the current password is checked like a login, so a stolen session can not guess it
+	if user.PasswordHash != "" {
+		ip := middleware.ClientIP(r)
+		wait, err := s.lockout.Wait(ctx, user.Username, ip)
+		if err != nil {
+			slog.ErrorContext(ctx, "could not check login lockout", "error", err)
+			templates.RenderError(ctx, w, "could not change password", http.StatusInternalServerError, s.templates)
+			return
+		}
+		if wait > 0 {
+			templates.RenderError(ctx, w, fmt.Sprintf("Too many failed attempts, try again in %s",
+				wait.Round(time.Second)), http.StatusTooManyRequests, s.templates)
+			return
+		}
+
+		if !passwordPkg.Matches(user.PasswordHash, r.PostForm.Get("current_password")) {
+			if err := s.lockout.Fail(ctx, user.Username, ip); err != nil {
+				slog.ErrorContext(ctx, "could not record login failure", "error", err)
+			}
+			s.recordLoginFailure(ctx, user.Username, ip, "password")
+			templates.RenderError(ctx, w, "Current password is incorrect", http.StatusUnauthorized, s.templates)
+			return
+		}
+	}
+
+	hash, ok := s.newPasswordHash(w, r, user.Username)
+	if !ok {
+		return
+	}
+
+	var keep pgtype.UUID
+	if current, ok := internalcontext.GetSessionFromContext(ctx); ok {
+		keep = current.ID
+	}
+
+	revoked, err := s.setPassword(ctx, user.ID, hash, keep, nil, audit.Event{
+		Action:     audit.AuthPasswordChange,
+		TargetType: audit.TargetUser,
+		TargetID:   user.Username,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not change password", "error", err)
+		templates.RenderError(ctx, w, "could not change password", http.StatusInternalServerError, s.templates)
+		return
+	}
+	s.sessions.Forget(revoked...)
+
+	s.renderChangePassword(w, r, changePasswordPageData{HasPassword: true, Changed: true})
+}
+
+// CreatePasswordReset issues a one-time link an admin passes on to a user who forgot their password
+func (s *DefaultServicer) CreatePasswordReset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	admin, _ := internalcontext.GetUserFromContext(ctx)
+
+	user, err := s.querier.GetUserByUsername(ctx, s.pool, chi.URLParam(r, "username"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "user not found", http.StatusNotFound, s.templates)
+			return
+		}
+		slog.ErrorContext(ctx, "could not get user", "error", err)
+		templates.RenderError(ctx, w, "could not get user", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	token, tokenHash, err := randomtoken.New("")
+	if err != nil {
+		slog.ErrorContext(ctx, "could not generate reset token", "error", err)
+		templates.RenderError(ctx, w, "could not create reset link", http.StatusInternalServerError, s.templates)
+		return
+	}
+	expiresAt := time.Now().Add(passwordResetExpiry)
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not begin transaction", "error", err)
+		templates.RenderError(ctx, w, "could not create reset link", http.StatusInternalServerError, s.templates)
+		return
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	// only the newest link of a user works
+	if err := s.querier.DeleteUserPasswordResets(ctx, tx, user.ID); err != nil {
+		slog.ErrorContext(ctx, "could not delete password resets", "error", err)
+		templates.RenderError(ctx, w, "could not create reset link", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	err = s.querier.CreatePasswordReset(ctx, tx, storage.CreatePasswordResetParams{
+		TokenHash: tokenHash,
+		UserID:    user.ID,
+		CreatedBy: admin.ID,
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not create password reset", "error", err)
+		templates.RenderError(ctx, w, "could not create reset link", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	err = audit.Record(ctx, tx, s.querier, audit.Event{
+		Action:     audit.AuthPasswordResetLink,
+		TargetType: audit.TargetUser,
+		TargetID:   user.Username,
+		After:      map[string]time.Time{"expires_at": expiresAt.UTC()},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err)
+		templates.RenderError(ctx, w, "could not create reset link", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "could not commit transaction", "error", err)
+		templates.RenderError(ctx, w, "could not create reset link", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	err = s.templates.Render(ctx, "passwordresetlink", w, passwordResetLinkPageData{
+		Username:  user.Username,
+		Link:      fmt.Sprintf("%s://%s/auth/reset/%s", scheme, r.Host, token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render passwordresetlink", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, s.templates)
+		return
+	}
+}
+
+// ShowPasswordReset shows the form of a reset link
+func (s *DefaultServicer) ShowPasswordReset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token := chi.URLParam(r, "token")
+
+	reset, ok := s.getPasswordReset(w, r, token)
+	if !ok {
+		return
+	}
+
+	// the token is in the url, it must not leak to other sites
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	err := s.templates.Render(ctx, "resetpassword", w, resetPasswordPageData{Username: reset.Username, Token: token})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render resetpassword", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, s.templates)
+		return
+	}
+}
+
+// ResetPassword sets the password of a reset link, uses up the link and logs out every session of the user
+func (s *DefaultServicer) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token := chi.URLParam(r, "token")
+
+	reset, ok := s.getPasswordReset(w, r, token)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		templates.RenderError(ctx, w, "Invalid form data", http.StatusBadRequest, s.templates)
+		return
+	}
+
+	hash, ok := s.newPasswordHash(w, r, reset.Username)
+	if !ok {
+		return
+	}
+
+	// whoever holds the link acts as the user, like after a login
+	revoked, err := s.setPassword(ctx, reset.PasswordReset.UserID, hash, pgtype.UUID{},
+		randomtoken.Hash(token), audit.Event{
+			Action:     audit.AuthPasswordReset,
+			TargetType: audit.TargetUser,
+			TargetID:   reset.Username,
+			Actor:      &storage.User{ID: reset.PasswordReset.UserID, Username: reset.Username},
+		})
+	if errors.Is(err, pgx.ErrNoRows) {
+		templates.RenderError(ctx, w, "this reset link is invalid, expired or was used", http.StatusNotFound,
+			s.templates)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "could not reset password", "error", err)
+		templates.RenderError(ctx, w, "could not reset password", http.StatusInternalServerError, s.templates)
+		return
+	}
+	s.sessions.Forget(revoked...)
+
+	if err := s.lockout.Succeed(ctx, reset.Username); err != nil {
+		slog.ErrorContext(ctx, "could not clear login failures", "error", err)
+	}
+
+	http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+}
+
+// newPasswordHash checks the new password of the form against the policy and hashes it, rendering an error otherwise
+func (s *DefaultServicer) newPasswordHash(w http.ResponseWriter, r *http.Request, username string) (string, bool) {
+	ctx := r.Context()
+	newPassword := r.PostForm.Get("new_password")
+
+	if newPassword != r.PostForm.Get("confirm_password") {
+		templates.RenderError(ctx, w, "Passwords do not match", http.StatusBadRequest, s.templates)
+		return "", false
+	}
+
+	if err := s.passwords.Check(newPassword, username); err != nil {
+		templates.RenderError(ctx, w, err.Error(), http.StatusBadRequest, s.templates)
+		return "", false
+	}
+
+	hash, err := passwordPkg.Hash(newPassword)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not hash password", "error", err)
+		templates.RenderError(ctx, w, "Error processing password", http.StatusInternalServerError, s.templates)
+		return "", false
+	}
+
+	return hash, true
+}
+
+// setPassword stores the password hash of the user, drops their reset links and revokes their sessions except keep.
+// With a reset token the token is used up first, pgx.ErrNoRows means it was not valid anymore. The event is recorded
+// in the same transaction with the number of revoked sessions.
+func (s *DefaultServicer) setPassword(ctx context.Context, userID pgtype.UUID, hash string, keep pgtype.UUID,
+	resetTokenHash []byte, event audit.Event) ([]pgtype.UUID, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	if resetTokenHash != nil {
+		used, err := s.querier.UsePasswordReset(ctx, tx, resetTokenHash)
+		if err != nil {
+			return nil, fmt.Errorf("could not use password reset: %w", err)
+		}
+		if used == 0 {
+			return nil, pgx.ErrNoRows
+		}
+	}
+
+	if err := s.querier.SetUserPassword(ctx, tx, hash, userID); err != nil {
+		return nil, fmt.Errorf("could not set password: %w", err)
+	}
+
+	if err := s.querier.DeleteUserPasswordResets(ctx, tx, userID); err != nil {
+		return nil, fmt.Errorf("could not delete password resets: %w", err)
+	}
+
+	var revoked []pgtype.UUID
+	if keep.Valid {
+		revoked, err = s.querier.RevokeOtherUserSessions(ctx, tx, userID, keep)
+	} else {
+		revoked, err = s.querier.RevokeUserSessions(ctx, tx, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not revoke sessions: %w", err)
+	}
+
+	event.After = map[string]int{"revoked_sessions": len(revoked)}
+	if err := audit.Record(ctx, tx, s.querier, event); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// getPasswordReset loads the unused and unexpired reset of token, rendering an error otherwise
+func (s *DefaultServicer) getPasswordReset(w http.ResponseWriter, r *http.Request,
+	token string) (storage.GetPasswordResetRow, bool) {
+	ctx := r.Context()
+
+	reset, err := s.querier.GetPasswordReset(ctx, s.pool, randomtoken.Hash(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "this reset link is invalid, expired or was used", http.StatusNotFound,
+				s.templates)
+			return storage.GetPasswordResetRow{}, false
+		}
+		slog.ErrorContext(ctx, "could not get password reset", "error", err)
+		templates.RenderError(ctx, w, "could not get reset link", http.StatusInternalServerError, s.templates)
+		return storage.GetPasswordResetRow{}, false
+	}
+
+	return reset, true
+}
+
+func (s *DefaultServicer) renderChangePassword(w http.ResponseWriter, r *http.Request, data changePasswordPageData) {
+	err := s.templates.Render(r.Context(), "changepassword", w, data)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "could not render changepassword", "error", err)
+		templates.RenderError(r.Context(), w, "could not render", http.StatusInternalServerError, s.templates)
+		return
+	}
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/samber/lo"
+	"golang.org/x/oauth2"
+
+	"github.com/computer-technology-team/go-judge/config"
+)
+
+const defaultUsernameClaim = "preferred_username"
+
+var errNonceMismatch = errors.New("id token nonce does not match")
+
+type oidcProvider struct {
+	name          string
+	displayName   string
+	allowSignup   bool
+	usernameClaim string
+	oauth2        oauth2.Config
+	verifier      *oidc.IDTokenVerifier
+}
+
+// NewOIDCProvider discovers an OpenID Connect provider from its issuer url.
+// Logins use the authorization code flow with PKCE and a nonce bound to the ID token.
+func NewOIDCProvider(ctx context.Context, name string, cfg config.ProviderConfig) (Provider, error) {
+	if cfg.IssuerURL == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("issuer_url, client_id and redirect_url are required")
+	}
+
+	discovered, err := oidc.NewProvider(ctx, cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not discover provider: %w", err)
+	}
+
+	return &oidcProvider{
+		name:          name,
+		displayName:   lo.CoalesceOrEmpty(cfg.DisplayName, name),
+		allowSignup:   cfg.AllowSignup,
+		usernameClaim: lo.CoalesceOrEmpty(cfg.UsernameClaim, defaultUsernameClaim),
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     discovered.Endpoint(),
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       lo.Uniq(append([]string{oidc.ScopeOpenID, "profile", "email"}, cfg.Scopes...)),
+		},
+		verifier: discovered.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}, nil
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) DisplayName() string {
+	return p.displayName
+}
+
+func (p *oidcProvider) AllowSignup() bool {
+	return p.allowSignup
+}
+
+func (p *oidcProvider) AuthCodeURL(state, nonce, verifier string) string {
+	return p.oauth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, nonce, verifier string) (Identity, error) {
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return Identity{}, fmt.Errorf("could not exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return Identity{}, errors.New("token response has no id token")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return Identity{}, fmt.Errorf("could not verify id token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return Identity{}, errNonceMismatch
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return Identity{}, fmt.Errorf("could not decode id token claims: %w", err)
+	}
+
+	identity := Identity{Subject: idToken.Subject}
+	identity.Email, _ = claims["email"].(string)
+	identity.Username, _ = claims[p.usernameClaim].(string)
+
+	return identity, nil
+}
@@ -0,0 +1,258 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/oauth2"
+
+	"github.com/computer-technology-team/go-judge/config"
+)
+
+const (
+	testClientID    = "go-judge"
+	testRedirectURL = "http://judge.test/auth/providers/university/callback"
+	testKeyID       = "mock-key"
+)
+
+type mockAuthorization struct {
+	challenge string
+	nonce     string
+}
+
+// mockOIDCServer is an OpenID Connect provider that authorizes every request as the same account
+type mockOIDCServer struct {
+	*httptest.Server
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+func newMockOIDCServer(t *testing.T) *mockOIDCServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockOIDCServer{key: key, codes: make(map[string]mockAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("GET /jwks", m.jwks)
+	mux.HandleFunc("GET /authorize", m.authorize)
+	mux.HandleFunc("POST /token", m.token)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+func (m *mockOIDCServer) discovery(w http.ResponseWriter, _ *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"issuer":                                m.URL,
+		"authorization_endpoint":                m.URL + "/authorize",
+		"token_endpoint":                        m.URL + "/token",
+		"jwks_uri":                              m.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (m *mockOIDCServer) jwks(w http.ResponseWriter, _ *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": testKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (m *mockOIDCServer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != testClientID {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	code := oauth2.GenerateVerifier()
+	m.mu.Lock()
+	m.codes[code] = mockAuthorization{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	m.mu.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	redirect.RawQuery = url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (m *mockOIDCServer) token(w http.ResponseWriter, r *http.Request) {
+	code := r.PostFormValue("code")
+	m.mu.Lock()
+	authorization, ok := m.codes[code]
+	delete(m.codes, code)
+	m.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(challenge[:]) != authorization.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                m.URL,
+		"sub":                "alice-subject",
+		"aud":                testClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Hour).Unix(),
+		"nonce":              authorization.nonce,
+		"email":              "alice@university.test",
+		"preferred_username": "alice",
+	})
+	idToken.Header["kid"] = testKeyID
+	signed, err := idToken.SignedString(m.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+type OIDCTestSuite struct {
+	suite.Suite
+	server   *mockOIDCServer
+	provider Provider
+	ctx      context.Context
+}
+
+func (s *OIDCTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.server = newMockOIDCServer(s.T())
+
+	providers, err := NewProviders(s.ctx, map[string]config.ProviderConfig{
+		"university": {
+			DisplayName: "University",
+			IssuerURL:   s.server.URL,
+			ClientID:    testClientID,
+			RedirectURL: testRedirectURL,
+		},
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), providers, 1)
+	s.provider = providers[0]
+}
+
+// authorize follows the login url like a browser and returns the code the provider redirects back with
+func (s *OIDCTestSuite) authorize(state, nonce, verifier string) string {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(s.provider.AuthCodeURL(state, nonce, verifier))
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), state, location.Query().Get("state"))
+
+	return location.Query().Get("code")
+}
+
+func (s *OIDCTestSuite) TestLogin() {
+	verifier := oauth2.GenerateVerifier()
+	code := s.authorize("state", "nonce", verifier)
+
+	identity, err := s.provider.Exchange(s.ctx, code, "nonce", verifier)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), Identity{
+		Subject:  "alice-subject",
+		Email:    "alice@university.test",
+		Username: "alice",
+	}, identity)
+	assert.Equal(s.T(), "university", s.provider.Name())
+	assert.Equal(s.T(), "University", s.provider.DisplayName())
+	assert.False(s.T(), s.provider.AllowSignup())
+}
+
+func (s *OIDCTestSuite) TestWrongVerifier() {
+	code := s.authorize("state", "nonce", oauth2.GenerateVerifier())
+
+	_, err := s.provider.Exchange(s.ctx, code, "nonce", oauth2.GenerateVerifier())
+
+	assert.Error(s.T(), err)
+}
+
+func (s *OIDCTestSuite) TestNonceMismatch() {
+	verifier := oauth2.GenerateVerifier()
+	code := s.authorize("state", "nonce", verifier)
+
+	_, err := s.provider.Exchange(s.ctx, code, "other-nonce", verifier)
+
+	assert.ErrorIs(s.T(), err, errNonceMismatch)
+}
+
+func (s *OIDCTestSuite) TestCodeIsSingleUse() {
+	verifier := oauth2.GenerateVerifier()
+	code := s.authorize("state", "nonce", verifier)
+
+	_, err := s.provider.Exchange(s.ctx, code, "nonce", verifier)
+	require.NoError(s.T(), err)
+
+	_, err = s.provider.Exchange(s.ctx, code, "nonce", verifier)
+	assert.Error(s.T(), err)
+}
+
+func (s *OIDCTestSuite) TestUsernameClaim() {
+	provider, err := NewOIDCProvider(s.ctx, "university", config.ProviderConfig{
+		IssuerURL:     s.server.URL,
+		ClientID:      testClientID,
+		RedirectURL:   testRedirectURL,
+		UsernameClaim: "email",
+	})
+	require.NoError(s.T(), err)
+
+	verifier := oauth2.GenerateVerifier()
+	code := s.authorize("state", "nonce", verifier)
+	identity, err := provider.Exchange(s.ctx, code, "nonce", verifier)
+
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "alice@university.test", identity.Username)
+	assert.Equal(s.T(), "university", provider.DisplayName())
+}
+
+func (s *OIDCTestSuite) TestUnknownType() {
+	_, err := NewProviders(s.ctx, map[string]config.ProviderConfig{"saml": {Type: "saml"}})
+
+	assert.ErrorIs(s.T(), err, ErrUnknownType)
+}
+
+func TestOIDCTestSuite(t *testing.T) {
+	suite.Run(t, new(OIDCTestSuite))
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/computer-technology-team/go-judge/config"
+)
+
+// ErrUnknownType is returned for providers configured with an unsupported protocol
+var ErrUnknownType = errors.New("unknown provider type")
+
+// Identity is an account at an external provider
+type Identity struct {
+	// Subject is the id of the account at the provider, it never changes
+	Subject string
+	Email   string
+	// Username is what the account is called at the provider, new users take it as their username
+	Username string
+}
+
+// Provider is an external login provider using the authorization code flow
+type Provider interface {
+	Name() string
+	DisplayName() string
+	// AllowSignup reports whether accounts that are not linked to a user create one
+	AllowSignup() bool
+	// AuthCodeURL returns the url of the login page of the provider, state, nonce and verifier must be kept for Exchange
+	AuthCodeURL(state, nonce, verifier string) string
+	// Exchange trades the code the provider redirected back with for the identity of the account
+	Exchange(ctx context.Context, code, nonce, verifier string) (Identity, error)
+}
+
+// NewProviders creates the configured providers sorted by name, OpenID Connect providers are discovered on creation
+func NewProviders(ctx context.Context, cfgs map[string]config.ProviderConfig) ([]Provider, error) {
+	providers := make([]Provider, 0, len(cfgs))
+	for name, cfg := range cfgs {
+		var (
+			provider Provider
+			err      error
+		)
+
+		switch strings.ToLower(cfg.Type) {
+		case "", "oidc":
+			provider, err = NewOIDCProvider(ctx, name, cfg)
+		default:
+			err = fmt.Errorf("%w: %s", ErrUnknownType, cfg.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not create provider %s: %w", name, err)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	slices.SortFunc(providers, func(a, b Provider) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return providers, nil
+}
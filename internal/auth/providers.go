@@ -0,0 +1,423 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/samber/lo"
+	"golang.org/x/oauth2"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	"github.com/computer-technology-team/go-judge/internal/auth/provider"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+const (
+	// providerLoginCookieKey keeps the state of a login at a provider until it redirects back
+	providerLoginCookieKey = "provider_login"
+	providerLoginPath      = "/auth/providers/"
+	providerLoginMaxAge    = 10 * 60
+
+	maxUsernameLength = 64
+	usernameAttempts  = 5
+)
+
+// pendingLogin is a login started at a provider, stored in a cookie only this server reads
+type pendingLogin struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	// LinkUserID is set when a logged in user links the account instead of logging in with it
+	LinkUserID string `json:"link_user_id,omitempty"`
+}
+
+type linkedAccountsPageData struct {
+	Accounts []linkedAccount
+}
+
+type linkedAccount struct {
+	Provider provider.Provider
+	// Identity is nil when no account of the provider is linked
+	Identity *storage.UserIdentity
+}
+
+// StartProviderLogin redirects to the login page of a provider, with ?link=true a logged in user links an account
+func (s *DefaultServicer) StartProviderLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	p, ok := s.getProvider(w, r)
+	if !ok {
+		return
+	}
+
+	pending := pendingLogin{
+		Provider: p.Name(),
+		// verifiers are 32 random bytes, as random as a state or a nonce has to be
+		State:    oauth2.GenerateVerifier(),
+		Nonce:    oauth2.GenerateVerifier(),
+		Verifier: oauth2.GenerateVerifier(),
+	}
+
+	if r.URL.Query().Get("link") == "true" {
+		user, ok := internalcontext.GetUserFromContext(ctx)
+		if !ok {
+			templates.RenderError(ctx, w, "log in to link an account", http.StatusUnauthorized, s.templates)
+			return
+		}
+		pending.LinkUserID = user.ID.String()
+	}
+
+	value, err := json.Marshal(pending)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not encode pending login", "error", err)
+		templates.RenderError(ctx, w, "could not start login", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     providerLoginCookieKey,
+		Value:    base64.RawURLEncoding.EncodeToString(value),
+		Path:     providerLoginPath,
+		MaxAge:   providerLoginMaxAge,
+		HttpOnly: true,
+		// the provider redirects back with a top level navigation, which lax cookies are sent with
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, p.AuthCodeURL(pending.State, pending.Nonce, pending.Verifier), http.StatusSeeOther)
+}
+
+// ProviderCallback finishes a login at a provider, logging in the linked user or linking the account
+func (s *DefaultServicer) ProviderCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	p, ok := s.getProvider(w, r)
+	if !ok {
+		return
+	}
+
+	pending, err := readPendingLogin(r)
+	// a pending login is used once
+	http.SetCookie(w, &http.Cookie{Name: providerLoginCookieKey, Path: providerLoginPath, MaxAge: -1, HttpOnly: true})
+	if err != nil || pending.Provider != p.Name() ||
+		subtle.ConstantTimeCompare([]byte(pending.State), []byte(r.URL.Query().Get("state"))) != 1 {
+		templates.RenderError(ctx, w, "the login expired or is invalid, please try again", http.StatusBadRequest, s.templates)
+		return
+	}
+
+	if providerError := r.URL.Query().Get("error"); providerError != "" {
+		templates.RenderError(ctx, w, fmt.Sprintf("%s did not complete the login: %s", p.DisplayName(), providerError),
+			http.StatusUnauthorized, s.templates)
+		return
+	}
+
+	identity, err := p.Exchange(ctx, r.URL.Query().Get("code"), pending.Nonce, pending.Verifier)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not exchange provider code", "error", err, "provider", p.Name())
+		templates.RenderError(ctx, w, fmt.Sprintf("could not verify the login at %s", p.DisplayName()),
+			http.StatusUnauthorized, s.templates)
+		return
+	}
+
+	if pending.LinkUserID != "" {
+		s.linkIdentity(w, r, p, identity, pending.LinkUserID)
+		return
+	}
+
+	s.loginIdentity(w, r, p, identity)
+}
+
+// ShowLinkedAccounts lists the providers with the accounts the user linked
+func (s *DefaultServicer) ShowLinkedAccounts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	identities, err := s.querier.ListUserIdentities(ctx, s.pool, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not list user identities", "error", err)
+		templates.RenderError(ctx, w, "could not get linked accounts", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	data := linkedAccountsPageData{}
+	for _, p := range s.providers {
+		account := linkedAccount{Provider: p}
+		if identity, ok := lo.Find(identities, func(i storage.UserIdentity) bool { return i.Provider == p.Name() }); ok {
+			account.Identity = &identity
+		}
+		data.Accounts = append(data.Accounts, account)
+	}
+
+	err = s.templates.Render(ctx, "linkedaccounts", w, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render linkedaccounts", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, s.templates)
+		return
+	}
+}
+
+// UnlinkProvider removes the account of a provider from the user, unless it is the only way to log in
+func (s *DefaultServicer) UnlinkProvider(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	p, ok := s.getProvider(w, r)
+	if !ok {
+		return
+	}
+
+	if !hasPassword(*user) {
+		identities, err := s.querier.ListUserIdentities(ctx, s.pool, user.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "could not list user identities", "error", err)
+			templates.RenderError(ctx, w, "could not unlink account", http.StatusInternalServerError, s.templates)
+			return
+		}
+		if len(identities) <= 1 {
+			templates.RenderError(ctx, w, "this account is the only way to log in, it can not be unlinked",
+				http.StatusBadRequest, s.templates)
+			return
+		}
+	}
+
+	if _, err := s.querier.DeleteUserIdentity(ctx, s.pool, user.ID, p.Name()); err != nil {
+		slog.ErrorContext(ctx, "could not delete user identity", "error", err, "provider", p.Name())
+		templates.RenderError(ctx, w, "could not unlink account", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	s.recordEvent(ctx, audit.Event{
+		Action:     audit.AuthProviderUnlink,
+		TargetType: audit.TargetUser,
+		TargetID:   user.Username,
+		Before:     map[string]string{"provider": p.Name()},
+	})
+
+	http.Redirect(w, r, "/auth/accounts", http.StatusSeeOther)
+}
+
+func (s *DefaultServicer) linkIdentity(w http.ResponseWriter, r *http.Request, p provider.Provider,
+	identity provider.Identity, linkUserID string) {
+
+	ctx := r.Context()
+
+	// the account is linked to whoever started linking, not to whoever is logged in now
+	user, ok := internalcontext.GetUserFromContext(ctx)
+	if !ok || user.ID.String() != linkUserID {
+		templates.RenderError(ctx, w, "log in again to link the account", http.StatusUnauthorized, s.templates)
+		return
+	}
+
+	err := s.createLinkedIdentity(ctx, *user, p, identity)
+	if err != nil {
+		if storage.IsUniqueViolation(err) {
+			templates.RenderError(ctx, w, fmt.Sprintf("the %s account is already linked to a user, or you linked another one",
+				p.DisplayName()), http.StatusConflict, s.templates)
+			return
+		}
+		slog.ErrorContext(ctx, "could not link account", "error", err, "provider", p.Name())
+		templates.RenderError(ctx, w, "could not link account", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	http.Redirect(w, r, "/auth/accounts", http.StatusSeeOther)
+}
+
+// createLinkedIdentity links the account of a provider to user, recording the link in the same transaction
+func (s *DefaultServicer) createLinkedIdentity(ctx context.Context, user storage.User, p provider.Provider,
+	identity provider.Identity) error {
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	err = s.querier.CreateUserIdentity(ctx, tx, storage.CreateUserIdentityParams{
+		Provider: p.Name(),
+		Subject:  identity.Subject,
+		UserID:   user.ID,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create user identity: %w", err)
+	}
+
+	err = audit.Record(ctx, tx, s.querier, audit.Event{
+		Action:     audit.AuthProviderLink,
+		TargetType: audit.TargetUser,
+		TargetID:   user.Username,
+		After:      map[string]string{"provider": p.Name(), "email": identity.Email},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *DefaultServicer) loginIdentity(w http.ResponseWriter, r *http.Request, p provider.Provider,
+	identity provider.Identity) {
+
+	ctx := r.Context()
+
+	user, err := s.querier.GetUserByIdentity(ctx, s.pool, p.Name(), identity.Subject)
+	switch {
+	case err == nil:
+		err = s.querier.RecordIdentityLogin(ctx, s.pool, storage.RecordIdentityLoginParams{
+			Provider: p.Name(),
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "could not record identity login", "error", err, "provider", p.Name())
+		}
+	case errors.Is(err, pgx.ErrNoRows):
+		if !p.AllowSignup() {
+			templates.RenderError(ctx, w, fmt.Sprintf(
+				"no user is linked to this %s account, log in and link it from your linked accounts", p.DisplayName()),
+				http.StatusForbidden, s.templates)
+			return
+		}
+
+		user, err = s.createIdentityUser(ctx, p, identity)
+		if err != nil {
+			slog.ErrorContext(ctx, "could not create user for identity", "error", err, "provider", p.Name())
+			templates.RenderError(ctx, w, "could not create user", http.StatusInternalServerError, s.templates)
+			return
+		}
+	default:
+		slog.ErrorContext(ctx, "could not get user by identity", "error", err, "provider", p.Name())
+		templates.RenderError(ctx, w, "could not log in", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	s.issueToken(w, r, user)
+}
+
+// createIdentityUser creates a user for an account of a provider, named after the account when the name is free.
+// The user has no password and logs in only through linked accounts.
+func (s *DefaultServicer) createIdentityUser(ctx context.Context, p provider.Provider,
+	identity provider.Identity) (storage.User, error) {
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return storage.User{}, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	username, err := s.freeUsername(ctx, tx, identity)
+	if err != nil {
+		return storage.User{}, err
+	}
+
+	user, err := s.insertUser(ctx, tx, username, "")
+	if err != nil {
+		return storage.User{}, err
+	}
+
+	err = s.querier.CreateUserIdentity(ctx, tx, storage.CreateUserIdentityParams{
+		Provider: p.Name(),
+		Subject:  identity.Subject,
+		UserID:   user.ID,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		return storage.User{}, fmt.Errorf("could not create user identity: %w", err)
+	}
+
+	return user, tx.Commit(ctx)
+}
+
+// freeUsername picks an unused username from the account name, adding a number when it is taken
+func (s *DefaultServicer) freeUsername(ctx context.Context, tx pgx.Tx, identity provider.Identity) (string, error) {
+	base := strings.TrimSpace(identity.Username)
+	if base == "" {
+		base, _, _ = strings.Cut(identity.Email, "@")
+	}
+	base = strings.Join(strings.Fields(base), "-")
+	if base == "" {
+		base = "user"
+	}
+	if len(base) > maxUsernameLength {
+		base = strings.ToValidUTF8(base[:maxUsernameLength], "")
+	}
+
+	username := base
+	for range usernameAttempts {
+		_, err := s.querier.GetUserByUsername(ctx, tx, username)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return username, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("could not check username: %w", err)
+		}
+
+		suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", fmt.Errorf("could not generate username suffix: %w", err)
+		}
+		username = fmt.Sprintf("%s-%04d", base, suffix.Int64())
+	}
+
+	return "", errors.New("could not find a free username")
+}
+
+func (s *DefaultServicer) getProvider(w http.ResponseWriter, r *http.Request) (provider.Provider, bool) {
+	name := chi.URLParam(r, "provider")
+	p, ok := lo.Find(s.providers, func(p provider.Provider) bool { return p.Name() == name })
+	if !ok {
+		templates.RenderError(r.Context(), w, "login provider not found", http.StatusNotFound, s.templates)
+		return nil, false
+	}
+	return p, true
+}
+
+func readPendingLogin(r *http.Request) (pendingLogin, error) {
+	cookie, err := r.Cookie(providerLoginCookieKey)
+	if err != nil {
+		return pendingLogin{}, err
+	}
+
+	value, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return pendingLogin{}, err
+	}
+
+	var pending pendingLogin
+	if err := json.Unmarshal(value, &pending); err != nil {
+		return pendingLogin{}, err
+	}
+	if pending.State == "" {
+		return pendingLogin{}, errors.New("pending login has no state")
+	}
+
+	return pending, nil
+}
+
+// hasPassword reports whether the user can log in with a password, users created by providers have none
+func hasPassword(user storage.User) bool {
+	return user.PasswordHash != ""
+}
@@ -0,0 +1,31 @@
+// Package randomtoken creates the tokens of reset links, refresh tokens, two-factor challenges and personal access
+// tokens. Only their hashes are stored. Unlike passwords the tokens are random and can not be guessed, so a plain
+// SHA-256 suffices and tokens are looked up by their hash.
+package randomtoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// length is the number of random bytes of a token
+const length = 32
+
+// New returns a new token starting with prefix and the hash to store
+func New(prefix string) (string, []byte, error) {
+	raw := make([]byte, length)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("could not generate token: %w", err)
+	}
+
+	token := prefix + base64.RawURLEncoding.EncodeToString(raw)
+	return token, Hash(token), nil
+}
+
+// Hash hashes a token for storage and lookup
+func Hash(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
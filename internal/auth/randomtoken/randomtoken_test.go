@@ -0,0 +1,41 @@
+package randomtoken
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type RandomTokenTestSuite struct {
+	suite.Suite
+}
+
+func (s *RandomTokenTestSuite) TestNew() {
+	token, hash, err := New("gjp_")
+	require.NoError(s.T(), err)
+
+	require.True(s.T(), strings.HasPrefix(token, "gjp_"))
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, "gjp_"))
+	require.NoError(s.T(), err, "tokens fit in URLs and cookies")
+	assert.Len(s.T(), raw, length)
+	assert.Equal(s.T(), Hash(token), hash)
+
+	other, otherHash, err := New("gjp_")
+	require.NoError(s.T(), err)
+	assert.NotEqual(s.T(), token, other)
+	assert.NotEqual(s.T(), hash, otherHash)
+}
+
+func (s *RandomTokenTestSuite) TestHash() {
+	assert.Equal(s.T(), Hash("token"), Hash("token"))
+	assert.NotEqual(s.T(), Hash("token"), Hash("Token"))
+	assert.Len(s.T(), Hash(""), 32)
+}
+
+func TestRandomTokenTestSuite(t *testing.T) {
+	suite.Run(t, new(RandomTokenTestSuite))
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/computer-technology-team/go-judge/internal/middleware"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
 	"github.com/computer-technology-team/go-judge/web/templates"
 )
 
@@ -16,5 +17,30 @@ func NewRoutes(s Servicer, sharedTemplates *templates.Templates) func(r chi.Rout
 		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Get("/logout", s.Logout)
 		r.Post("/login", s.Login)
 		r.Post("/signup", s.Signup)
+		r.Get("/providers/{provider}/login", s.StartProviderLogin)
+		r.Get("/providers/{provider}/callback", s.ProviderCallback)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Get("/accounts", s.ShowLinkedAccounts)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Post("/providers/{provider}/unlink", s.UnlinkProvider)
+		r.Post("/refresh", s.RefreshToken)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Get("/sessions", s.ShowSessions)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Post("/sessions/revoke-all", s.RevokeAllSessions)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Post("/sessions/{id}/revoke", s.RevokeSession)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Get("/tokens", s.ShowAPITokens)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Post("/tokens", s.CreateAPIToken)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Post("/tokens/{id}/delete", s.DeleteAPIToken)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Get("/password", s.ShowChangePassword)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Post("/password", s.ChangePassword)
+		r.With(middleware.NewRequirePermissionMiddleware(sharedTemplates, rbac.ManageUsers)).
+			Post("/password-resets/{username}", s.CreatePasswordReset)
+		r.Get("/reset/{token}", s.ShowPasswordReset)
+		r.Post("/reset/{token}", s.ResetPassword)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Get("/2fa", s.ShowTwoFactor)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Post("/2fa/setup", s.SetupTwoFactor)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Post("/2fa/enable", s.EnableTwoFactor)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).Post("/2fa/disable", s.DisableTwoFactor)
+		r.With(middleware.NewRequireAuthMiddleware(sharedTemplates)).
+			Post("/2fa/recovery-codes", s.RegenerateRecoveryCodes)
+		r.Get("/2fa/verify", s.ShowTwoFactorLogin)
+		r.Post("/2fa/verify", s.VerifyTwoFactorLogin)
 	}
 }
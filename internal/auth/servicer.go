@@ -1,14 +1,25 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"golang.org/x/crypto/bcrypt"
 
+	"github.com/computer-technology-team/go-judge/internal/audit"
 	"github.com/computer-technology-team/go-judge/internal/auth/authenticator"
+	"github.com/computer-technology-team/go-judge/internal/auth/lockout"
+	passwordPkg "github.com/computer-technology-team/go-judge/internal/auth/password"
+	"github.com/computer-technology-team/go-judge/internal/auth/provider"
+	"github.com/computer-technology-team/go-judge/internal/auth/session"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/middleware"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
 	"github.com/computer-technology-team/go-judge/internal/storage"
 	"github.com/computer-technology-team/go-judge/web/templates"
 )
@@ -19,6 +30,29 @@ type Servicer interface {
 	ShowSignupPage(w http.ResponseWriter, r *http.Request)
 	Signup(w http.ResponseWriter, r *http.Request)
 	Logout(w http.ResponseWriter, r *http.Request)
+	StartProviderLogin(w http.ResponseWriter, r *http.Request)
+	ProviderCallback(w http.ResponseWriter, r *http.Request)
+	ShowLinkedAccounts(w http.ResponseWriter, r *http.Request)
+	UnlinkProvider(w http.ResponseWriter, r *http.Request)
+	ShowSessions(w http.ResponseWriter, r *http.Request)
+	RevokeSession(w http.ResponseWriter, r *http.Request)
+	RevokeAllSessions(w http.ResponseWriter, r *http.Request)
+	RefreshToken(w http.ResponseWriter, r *http.Request)
+	ShowAPITokens(w http.ResponseWriter, r *http.Request)
+	CreateAPIToken(w http.ResponseWriter, r *http.Request)
+	DeleteAPIToken(w http.ResponseWriter, r *http.Request)
+	ShowChangePassword(w http.ResponseWriter, r *http.Request)
+	ChangePassword(w http.ResponseWriter, r *http.Request)
+	CreatePasswordReset(w http.ResponseWriter, r *http.Request)
+	ShowPasswordReset(w http.ResponseWriter, r *http.Request)
+	ResetPassword(w http.ResponseWriter, r *http.Request)
+	ShowTwoFactor(w http.ResponseWriter, r *http.Request)
+	SetupTwoFactor(w http.ResponseWriter, r *http.Request)
+	EnableTwoFactor(w http.ResponseWriter, r *http.Request)
+	DisableTwoFactor(w http.ResponseWriter, r *http.Request)
+	RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request)
+	ShowTwoFactorLogin(w http.ResponseWriter, r *http.Request)
+	VerifyTwoFactorLogin(w http.ResponseWriter, r *http.Request)
 }
 
 type DefaultServicer struct {
@@ -26,24 +60,40 @@ type DefaultServicer struct {
 	templates     *templates.Templates
 	pool          *pgxpool.Pool
 	querier       storage.Querier
+	providers     []provider.Provider
+	sessions      *session.Manager
+	passwords     *passwordPkg.Policy
+	lockout       *lockout.Lockout
 }
 
 func NewServicer(authenticator authenticator.Authenticator,
 	templates *templates.Templates,
 	pool *pgxpool.Pool,
 	querier storage.Querier,
+	providers []provider.Provider,
+	sessions *session.Manager,
+	passwords *passwordPkg.Policy,
+	lockout *lockout.Lockout,
 ) Servicer {
 	return &DefaultServicer{
 		templates:     templates,
 		authenticator: authenticator,
 		pool:          pool,
 		querier:       querier,
+		providers:     providers,
+		sessions:      sessions,
+		passwords:     passwords,
+		lockout:       lockout,
 	}
 }
 
+type loginPageData struct {
+	Providers []provider.Provider
+}
+
 // ShowLoginPage handles user login
 func (s *DefaultServicer) ShowLoginPage(w http.ResponseWriter, r *http.Request) {
-	err := s.templates.Render(r.Context(), "login", w, nil)
+	err := s.templates.Render(r.Context(), "login", w, loginPageData{Providers: s.providers})
 	if err != nil {
 		slog.Error("could not render login", "error", err)
 		templates.RenderError(r.Context(), w, "could not render", http.StatusInternalServerError, s.templates)
@@ -66,48 +116,122 @@ func (s *DefaultServicer) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(password) < 8 {
-		templates.RenderError(r.Context(), w, "password length can not be less than 8", http.StatusBadRequest, s.templates)
-		return
-	}
-
 	s.loginUser(w, r, username, password)
 }
 
+// loginUser logs in with a password. A wrong password and an unknown username fail alike, and repeated failures
+// lock the account and the client IP for a while.
 func (s *DefaultServicer) loginUser(w http.ResponseWriter, r *http.Request, username string, password string) {
-	user, err := s.querier.GetUserByUsername(r.Context(), s.pool, username)
+	ctx := r.Context()
+	ip := middleware.ClientIP(r)
+
+	wait, err := s.lockout.Wait(ctx, username, ip)
 	if err != nil {
-		templates.RenderError(r.Context(), w, "Username not found", http.StatusUnauthorized, s.templates)
+		slog.ErrorContext(ctx, "could not check login lockout", "error", err)
+		templates.RenderError(ctx, w, "could not log in", http.StatusInternalServerError, s.templates)
+		return
+	}
+	if wait > 0 {
+		templates.RenderError(ctx, w, fmt.Sprintf("Too many failed logins, try again in %s", wait.Round(time.Second)),
+			http.StatusTooManyRequests, s.templates)
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		templates.RenderError(r.Context(), w, "Invalid credentials", http.StatusUnauthorized, s.templates)
+	user, err := s.querier.GetUserByUsername(ctx, s.pool, username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "could not get user", "error", err)
+		templates.RenderError(ctx, w, "could not log in", http.StatusInternalServerError, s.templates)
 		return
 	}
 
-	claims := authenticator.Claims{
-		UserID: user.ID.String(),
+	// unknown users have no password hash, checking it takes as long as checking a real one
+	if !passwordPkg.Matches(user.PasswordHash, password) {
+		if err := s.lockout.Fail(ctx, username, ip); err != nil {
+			slog.ErrorContext(ctx, "could not record login failure", "error", err)
+		}
+		s.recordLoginFailure(ctx, username, ip, "password")
+		templates.RenderError(ctx, w, "Invalid username or password", http.StatusUnauthorized, s.templates)
+		return
 	}
 
-	tokenString, tokenClaims, err := s.authenticator.GenerateToken(r.Context(), claims)
+	s.issueToken(w, r, user)
+}
+
+// issueToken finishes the login of user. Users with two-factor authentication enter their code first, users whose
+// roles require it are sent to set it up.
+func (s *DefaultServicer) issueToken(w http.ResponseWriter, r *http.Request, user storage.User) {
+	ctx := r.Context()
+
+	userTOTP, err := s.querier.GetUserTOTP(ctx, s.pool, user.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "could not get totp", "error", err)
+		templates.RenderError(ctx, w, "could not log in", http.StatusInternalServerError, s.templates)
+		return
+	}
+	if err == nil && userTOTP.EnabledAt.Valid {
+		s.startTwoFactorLogin(w, r, user)
+		return
+	}
+
+	required, err := s.querier.IsTwoFactorRequired(ctx, s.pool, user.ID)
 	if err != nil {
-		templates.RenderError(r.Context(), w, "Error generating token", http.StatusInternalServerError, s.templates)
+		slog.ErrorContext(ctx, "could not check whether two-factor authentication is required", "error", err)
+		templates.RenderError(ctx, w, "could not log in", http.StatusInternalServerError, s.templates)
 		return
 	}
 
-	cookie := &http.Cookie{
-		Name:     authenticator.TokenCookieKey,
-		Value:    tokenString,
-		Expires:  tokenClaims.ExpiresAt.Time,
-		HttpOnly: true,
-		Path:     "/",
+	if required {
+		s.startSession(w, r, user, "/auth/2fa")
+		return
 	}
-	http.SetCookie(w, cookie)
+	s.startSession(w, r, user, "/")
+}
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+// startSession starts a session of user, sets its token cookies and redirects to next
+func (s *DefaultServicer) startSession(w http.ResponseWriter, r *http.Request, user storage.User, next string) {
+	ctx := r.Context()
 
-	w.WriteHeader(http.StatusOK)
+	// failures are only forgiven once the whole login succeeded, a known password does not reset guessing codes
+	if err := s.lockout.Succeed(ctx, user.Username); err != nil {
+		slog.ErrorContext(ctx, "could not clear login failures", "error", err)
+	}
+
+	tokens, err := s.sessions.Start(ctx, user.ID, r.UserAgent(), middleware.ClientIP(r))
+	if err != nil {
+		slog.ErrorContext(ctx, "could not start session", "error", err)
+		templates.RenderError(ctx, w, "Error generating token", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	s.recordEvent(ctx, audit.Event{
+		Action:     audit.AuthLogin,
+		TargetType: audit.TargetUser,
+		TargetID:   user.Username,
+		After:      map[string]string{"ip": middleware.ClientIP(r)},
+		Actor:      &user,
+	})
+
+	middleware.SetTokenCookies(w, tokens)
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// recordLoginFailure records a failed login as username from ip, the factor that failed is "password" or
+// "second_factor". Nobody is logged in yet, so the event has no actor.
+func (s *DefaultServicer) recordLoginFailure(ctx context.Context, username, ip, factor string) {
+	s.recordEvent(ctx, audit.Event{
+		Action:     audit.AuthLoginFailed,
+		TargetType: audit.TargetUser,
+		TargetID:   username,
+		After:      map[string]string{"ip": ip, "factor": factor},
+	})
+}
+
+// recordEvent records an event for a change made outside of a transaction. The change is already stored, so a
+// failure is only logged.
+func (s *DefaultServicer) recordEvent(ctx context.Context, event audit.Event) {
+	if err := audit.Record(ctx, s.pool, s.querier, event); err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err, "action", event.Action)
+	}
 }
 
 // ShowSignupPage handles user registration
@@ -136,14 +260,19 @@ func (s *DefaultServicer) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err := s.passwords.Check(password, username); err != nil {
+		templates.RenderError(r.Context(), w, err.Error(), http.StatusBadRequest, s.templates)
+		return
+	}
+
+	hashedPassword, err := passwordPkg.Hash(password)
 	if err != nil {
-		slog.ErrorContext(r.Context(), "could not process password", "error", err, "username", username, "password", password)
+		slog.ErrorContext(r.Context(), "could not process password", "error", err, "username", username)
 		templates.RenderError(r.Context(), w, "Error processing password", http.StatusInternalServerError, s.templates)
 		return
 	}
 
-	_, err = s.querier.CreateUser(r.Context(), s.pool, username, string(hashedPassword))
+	err = s.createUser(r.Context(), username, hashedPassword)
 	if err != nil {
 		slog.ErrorContext(r.Context(), "could not create user", "error", err, "username", username)
 		templates.RenderError(r.Context(), w, "Could not create user", http.StatusInternalServerError, s.templates)
@@ -153,16 +282,66 @@ func (s *DefaultServicer) Signup(w http.ResponseWriter, r *http.Request) {
 	s.loginUser(w, r, username, password)
 }
 
-// Logout handles user logout
-func (s *DefaultServicer) Logout(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Name:     "token",
-		Value:    "", // Clear the value
-		Path:     "/",
-		Expires:  time.Unix(0, 0), // Expire it
-		MaxAge:   -1,              // Delete immediately
-		HttpOnly: true,
+// createUser creates a user with the default role
+func (s *DefaultServicer) createUser(ctx context.Context, username, passwordHash string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	if _, err := s.insertUser(ctx, tx, username, passwordHash); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// insertUser inserts a user with the default role
+func (s *DefaultServicer) insertUser(ctx context.Context, tx pgx.Tx, username, passwordHash string) (storage.User, error) {
+	user, err := s.querier.CreateUser(ctx, tx, username, passwordHash)
+	if err != nil {
+		return storage.User{}, fmt.Errorf("could not insert user: %w", err)
+	}
+
+	err = s.querier.GrantUserRole(ctx, tx, user.ID, rbac.DefaultRole)
+	if err != nil {
+		return storage.User{}, fmt.Errorf("could not grant default role: %w", err)
+	}
+
+	err = audit.Record(ctx, tx, s.querier, audit.Event{
+		Action:     audit.UserSignup,
+		TargetType: audit.TargetUser,
+		TargetID:   user.Username,
+		After:      map[string][]string{"roles": {rbac.DefaultRole}},
+		Actor:      &user,
 	})
+	if err != nil {
+		return storage.User{}, err
+	}
+
+	return user, nil
+}
+
+// Logout handles user logout, the session of the token is revoked so a copy of the token stops working too
+func (s *DefaultServicer) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	if current, ok := internalcontext.GetSessionFromContext(ctx); ok {
+		if _, err := s.querier.RevokeSession(ctx, s.pool, user.ID, current.ID); err != nil {
+			slog.ErrorContext(ctx, "could not revoke session", "error", err)
+			templates.RenderError(ctx, w, "could not log out", http.StatusInternalServerError, s.templates)
+			return
+		}
+		s.sessions.Forget(current.ID)
+	}
+
+	middleware.ClearTokenCookies(w)
 
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 
@@ -0,0 +1,209 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/computer-technology-team/go-judge/config"
+	"github.com/computer-technology-team/go-judge/internal/auth/authenticator"
+	"github.com/computer-technology-team/go-judge/internal/auth/randomtoken"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+const (
+	// maxUserAgentLength caps what is stored of the user agent of a session
+	maxUserAgentLength = 512
+	// reuseGrace is how long a used refresh token still gets an access token, so concurrent requests of a browser
+	// that all found the access token expired do not look like a replay
+	reuseGrace = 10 * time.Second
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused means a refresh token was used twice, the session was revoked as the token was stolen
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// Tokens are a short-lived access token and the single-use refresh token that replaces it
+type Tokens struct {
+	AccessToken string
+	Claims      *authenticator.Claims
+	// RefreshToken is empty when a concurrent request already rotated the refresh token
+	RefreshToken string
+	// ExpiresAt is when the session ends, refresh tokens do not outlive it
+	ExpiresAt time.Time
+}
+
+// Manager starts sessions and rotates their refresh tokens, the refresh tokens of a session are its token family
+type Manager struct {
+	*Cache
+	authenticator      authenticator.Authenticator
+	pool               *pgxpool.Pool
+	querier            storage.Querier
+	refreshTokenExpiry time.Duration
+}
+
+func NewManager(cfg config.AuthenticationConfig, authenticator authenticator.Authenticator, pool *pgxpool.Pool,
+	querier storage.Querier) *Manager {
+	return &Manager{
+		Cache: NewCache(cfg.SessionCacheTTL, func(ctx context.Context, id pgtype.UUID) (storage.Session, error) {
+			return querier.TouchSession(ctx, pool, id)
+		}),
+		authenticator:      authenticator,
+		pool:               pool,
+		querier:            querier,
+		refreshTokenExpiry: cfg.RefreshTokenExpiry,
+	}
+}
+
+// Start records a new session of the user and issues its first tokens
+func (m *Manager) Start(ctx context.Context, userID pgtype.UUID, userAgent, ipAddress string) (Tokens, error) {
+	if err := m.querier.DeleteExpiredUserSessions(ctx, m.pool, userID); err != nil {
+		slog.ErrorContext(ctx, "could not delete expired sessions", "error", err)
+	}
+
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return Tokens{}, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	session := storage.Session{
+		ID:        pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		UserID:    userID,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(m.refreshTokenExpiry), Valid: true},
+	}
+	err = m.querier.CreateSession(ctx, tx, storage.CreateSessionParams{
+		ID:        session.ID,
+		UserID:    session.UserID,
+		UserAgent: userAgent,
+		IpAddress: ipAddress,
+		ExpiresAt: session.ExpiresAt,
+	})
+	if err != nil {
+		return Tokens{}, fmt.Errorf("could not create session: %w", err)
+	}
+
+	refreshToken, err := m.createRefreshToken(ctx, tx, session.ID)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Tokens{}, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return m.issue(ctx, session, refreshToken)
+}
+
+// Refresh uses up a refresh token and issues the next tokens of its session.
+// A refresh token used again after the grace period revokes the session, as either copy may be the stolen one.
+func (m *Manager) Refresh(ctx context.Context, refreshToken string) (Tokens, error) {
+	tokenHash := randomtoken.Hash(refreshToken)
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return Tokens{}, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	// the row stays locked until commit, concurrent refreshes with the same token wait and then see it used
+	row, err := m.querier.GetRefreshTokenForUpdate(ctx, tx, tokenHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Tokens{}, ErrInvalidRefreshToken
+		}
+		return Tokens{}, fmt.Errorf("could not get refresh token: %w", err)
+	}
+
+	session := row.Session
+	if session.RevokedAt.Valid || !time.Now().Before(session.ExpiresAt.Time) {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+
+	if row.RefreshToken.UsedAt.Valid {
+		if time.Since(row.RefreshToken.UsedAt.Time) < reuseGrace {
+			return m.issue(ctx, session, "")
+		}
+
+		if _, err := m.querier.RevokeSession(ctx, tx, session.UserID, session.ID); err != nil {
+			return Tokens{}, fmt.Errorf("could not revoke session: %w", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return Tokens{}, fmt.Errorf("could not commit transaction: %w", err)
+		}
+		m.Forget(session.ID)
+
+		slog.WarnContext(ctx, "refresh token reused, session revoked",
+			"session_id", session.ID.String(), "user_id", session.UserID.String())
+		return Tokens{}, ErrRefreshTokenReused
+	}
+
+	if err := m.querier.UseRefreshToken(ctx, tx, tokenHash); err != nil {
+		return Tokens{}, fmt.Errorf("could not use refresh token: %w", err)
+	}
+
+	next, err := m.createRefreshToken(ctx, tx, session.ID)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Tokens{}, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return m.issue(ctx, session, next)
+}
+
+// issue signs an access token for the session
+func (m *Manager) issue(ctx context.Context, session storage.Session, refreshToken string) (Tokens, error) {
+	accessToken, claims, err := m.authenticator.GenerateToken(ctx, authenticator.Claims{
+		UserID:    session.UserID.String(),
+		SessionID: session.ID.String(),
+	})
+	if err != nil {
+		return Tokens{}, fmt.Errorf("could not generate token: %w", err)
+	}
+
+	return Tokens{
+		AccessToken:  accessToken,
+		Claims:       claims,
+		RefreshToken: refreshToken,
+		ExpiresAt:    session.ExpiresAt.Time,
+	}, nil
+}
+
+// createRefreshToken stores the hash of a new refresh token of the session, the token itself is only returned
+func (m *Manager) createRefreshToken(ctx context.Context, db storage.DBTX, sessionID pgtype.UUID) (string, error) {
+	token, tokenHash, err := randomtoken.New("")
+	if err != nil {
+		return "", fmt.Errorf("could not generate refresh token: %w", err)
+	}
+
+	if err := m.querier.CreateRefreshToken(ctx, db, tokenHash, sessionID); err != nil {
+		return "", fmt.Errorf("could not create refresh token: %w", err)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,144 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+// maxEntries is how many sessions are cached before expired entries are dropped
+const maxEntries = 10000
+
+// LoadFunc fetches a session and marks it as seen, it returns pgx.ErrNoRows for unknown sessions
+type LoadFunc func(ctx context.Context, id pgtype.UUID) (storage.Session, error)
+
+// Cache remembers sessions for a short time so most requests do not query the database.
+// Sessions revoked through this cache stop working at once, revocations by other servers after at most the ttl.
+type Cache struct {
+	ttl  time.Duration
+	load LoadFunc
+
+	mu      sync.Mutex
+	entries map[pgtype.UUID]entry
+	now     func() time.Time
+}
+
+type entry struct {
+	session storage.Session
+	found   bool
+	loaded  time.Time
+}
+
+// NewCache creates a cache of the sessions load returns, entries are reloaded after ttl
+func NewCache(ttl time.Duration, load LoadFunc) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		load:    load,
+		entries: make(map[pgtype.UUID]entry),
+		now:     time.Now,
+	}
+}
+
+// Active returns the session with id when it exists, is not revoked and has not expired
+func (c *Cache) Active(ctx context.Context, id pgtype.UUID) (storage.Session, bool, error) {
+	now := c.now()
+
+	c.mu.Lock()
+	e, ok := c.entries[id]
+	c.mu.Unlock()
+
+	if !ok || now.Sub(e.loaded) >= c.ttl {
+		session, err := c.load(ctx, id)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return storage.Session{}, false, fmt.Errorf("could not load session: %w", err)
+		}
+
+		e = entry{session: session, found: err == nil, loaded: now}
+		c.mu.Lock()
+		if len(c.entries) >= maxEntries {
+			c.sweep(now)
+		}
+		c.entries[id] = e
+		c.mu.Unlock()
+	}
+
+	active := e.found && !e.session.RevokedAt.Valid && now.Before(e.session.ExpiresAt.Time)
+	return e.session, active, nil
+}
+
+// Forget drops sessions after they were revoked, so they are checked again on their next use
+func (c *Cache) Forget(ids ...pgtype.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, id := range ids {
+		delete(c.entries, id)
+	}
+}
+
+// sweep drops entries that would be reloaded anyway, c.mu must be held
+func (c *Cache) sweep(now time.Time) {
+	for id, e := range c.entries {
+		if now.Sub(e.loaded) >= c.ttl {
+			delete(c.entries, id)
+		}
+	}
+}
+
+var browsers = []struct{ token, name string }{
+	// Edge and Opera also claim to be Chrome, and Chrome claims to be Safari
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"curl/", "curl"},
+}
+
+var systems = []struct{ token, name string }{
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// Device describes a user agent as "<browser> on <system>" for session lists
+func Device(userAgent string) string {
+	browser, system := "", ""
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, s := range systems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	case userAgent != "":
+		return userAgent
+	default:
+		return "Unknown device"
+	}
+}
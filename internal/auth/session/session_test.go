@@ -0,0 +1,133 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+type SessionTestSuite struct {
+	suite.Suite
+	now      time.Time
+	sessions map[pgtype.UUID]storage.Session
+	loads    int
+	cache    *Cache
+	ctx      context.Context
+}
+
+func (s *SessionTestSuite) SetupTest() {
+	s.now = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.sessions = make(map[pgtype.UUID]storage.Session)
+	s.loads = 0
+	s.ctx = context.Background()
+	s.cache = NewCache(time.Minute, func(_ context.Context, id pgtype.UUID) (storage.Session, error) {
+		s.loads++
+		session, ok := s.sessions[id]
+		if !ok {
+			return storage.Session{}, pgx.ErrNoRows
+		}
+		return session, nil
+	})
+	s.cache.now = func() time.Time { return s.now }
+}
+
+func (s *SessionTestSuite) addSession(expiresIn time.Duration) pgtype.UUID {
+	id := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	s.sessions[id] = storage.Session{ID: id, ExpiresAt: pgtype.Timestamptz{Time: s.now.Add(expiresIn), Valid: true}}
+	return id
+}
+
+func (s *SessionTestSuite) active(id pgtype.UUID) bool {
+	_, active, err := s.cache.Active(s.ctx, id)
+	require.NoError(s.T(), err)
+	return active
+}
+
+func (s *SessionTestSuite) TestActiveSessionIsCached() {
+	id := s.addSession(time.Hour)
+
+	assert.True(s.T(), s.active(id))
+	assert.True(s.T(), s.active(id))
+	assert.Equal(s.T(), 1, s.loads)
+
+	s.now = s.now.Add(time.Minute)
+	assert.True(s.T(), s.active(id))
+	assert.Equal(s.T(), 2, s.loads)
+}
+
+func (s *SessionTestSuite) TestUnknownSession() {
+	assert.False(s.T(), s.active(pgtype.UUID{Bytes: uuid.New(), Valid: true}))
+}
+
+func (s *SessionTestSuite) TestExpiredSession() {
+	id := s.addSession(30 * time.Second)
+	assert.True(s.T(), s.active(id))
+
+	s.now = s.now.Add(30 * time.Second)
+	assert.False(s.T(), s.active(id), "sessions expire even while cached")
+}
+
+func (s *SessionTestSuite) TestRevocation() {
+	id := s.addSession(time.Hour)
+	assert.True(s.T(), s.active(id))
+
+	session := s.sessions[id]
+	session.RevokedAt = pgtype.Timestamptz{Time: s.now, Valid: true}
+	s.sessions[id] = session
+
+	assert.True(s.T(), s.active(id), "revocations by other servers apply after the ttl")
+	s.now = s.now.Add(time.Minute)
+	assert.False(s.T(), s.active(id))
+}
+
+func (s *SessionTestSuite) TestForget() {
+	id := s.addSession(time.Hour)
+	assert.True(s.T(), s.active(id))
+
+	delete(s.sessions, id)
+	s.cache.Forget(id)
+
+	assert.False(s.T(), s.active(id))
+}
+
+func (s *SessionTestSuite) TestLoadError() {
+	cache := NewCache(time.Minute, func(context.Context, pgtype.UUID) (storage.Session, error) {
+		return storage.Session{}, errors.New("connection refused")
+	})
+
+	_, active, err := cache.Active(s.ctx, s.addSession(time.Hour))
+
+	assert.Error(s.T(), err)
+	assert.False(s.T(), active)
+}
+
+func (s *SessionTestSuite) TestDevice() {
+	testCases := map[string]string{
+		"Mozilla/5.0 (X11; Linux x86_64; rv:135.0) Gecko/20100101 Firefox/135.0": "Firefox on Linux",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) " +
+			"Chrome/133.0.0.0 Safari/537.36 Edg/133.0.0.0": "Edge on Windows",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 18_3 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) " +
+			"Version/18.3 Mobile/15E148 Safari/604.1": "Safari on iOS",
+		"curl/8.5.0":    "curl",
+		"custom-client": "custom-client",
+		"":              "Unknown device",
+	}
+
+	for userAgent, device := range testCases {
+		assert.Equal(s.T(), device, Device(userAgent), userAgent)
+	}
+}
+
+func TestSessionTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionTestSuite))
+}
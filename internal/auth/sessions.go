@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	"github.com/computer-technology-team/go-judge/internal/auth/session"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/middleware"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+type sessionsPageData struct {
+	Sessions []sessionView
+}
+
+type sessionView struct {
+	storage.Session
+	Device  string
+	Current bool
+}
+
+// ShowSessions lists the active sessions of the user
+func (s *DefaultServicer) ShowSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+	current, _ := internalcontext.GetSessionFromContext(ctx)
+
+	sessions, err := s.querier.ListUserSessions(ctx, s.pool, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not list sessions", "error", err)
+		templates.RenderError(ctx, w, "could not get sessions", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	data := sessionsPageData{}
+	for _, userSession := range sessions {
+		data.Sessions = append(data.Sessions, sessionView{
+			Session: userSession,
+			Device:  session.Device(userSession.UserAgent),
+			Current: current != nil && current.ID == userSession.ID,
+		})
+	}
+
+	err = s.templates.Render(ctx, "sessions", w, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render sessions", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, s.templates)
+		return
+	}
+}
+
+// RevokeSession logs out one session of the user
+func (s *DefaultServicer) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid session id", http.StatusBadRequest, s.templates)
+		return
+	}
+	sessionID := pgtype.UUID{Bytes: id, Valid: true}
+
+	revoked, err := s.querier.RevokeSession(ctx, s.pool, user.ID, sessionID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not revoke session", "error", err)
+		templates.RenderError(ctx, w, "could not revoke session", http.StatusInternalServerError, s.templates)
+		return
+	}
+	if revoked == 0 {
+		templates.RenderError(ctx, w, "session not found", http.StatusNotFound, s.templates)
+		return
+	}
+	s.sessions.Forget(sessionID)
+
+	s.recordEvent(ctx, audit.Event{
+		Action:     audit.AuthSessionRevoke,
+		TargetType: audit.TargetSession,
+		TargetID:   id.String(),
+	})
+
+	if current, ok := internalcontext.GetSessionFromContext(ctx); ok && current.ID == sessionID {
+		middleware.ClearTokenCookies(w)
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/auth/sessions", http.StatusSeeOther)
+}
+
+// RevokeAllSessions logs the user out everywhere, including the current session
+func (s *DefaultServicer) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	ids, err := s.querier.RevokeUserSessions(ctx, s.pool, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not revoke sessions", "error", err)
+		templates.RenderError(ctx, w, "could not revoke sessions", http.StatusInternalServerError, s.templates)
+		return
+	}
+	s.sessions.Forget(ids...)
+
+	s.recordEvent(ctx, audit.Event{
+		Action:     audit.AuthSessionRevoke,
+		TargetType: audit.TargetUser,
+		TargetID:   user.Username,
+		After:      map[string]int{"revoked_sessions": len(ids)},
+	})
+
+	middleware.ClearTokenCookies(w)
+	http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	// RefreshToken is left out when the posted one was rotated moments ago, the client keeps using the new one
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// RefreshToken exchanges the posted refresh token for new tokens, for API clients that send the access token
+// in the Authorization header. Browsers are refreshed by the auth middleware.
+func (s *DefaultServicer) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	refreshToken := r.PostFormValue("refresh_token")
+	if refreshToken == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	tokens, err := s.sessions.Refresh(ctx, refreshToken)
+	if errors.Is(err, session.ErrInvalidRefreshToken) || errors.Is(err, session.ErrRefreshTokenReused) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "could not refresh token", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "server_error"})
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(time.Until(tokens.Claims.ExpiresAt.Time).Seconds()),
+		RefreshToken: tokens.RefreshToken,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not write tokens", "error", err)
+	}
+}
@@ -0,0 +1,553 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	"github.com/computer-technology-team/go-judge/internal/auth/randomtoken"
+	"github.com/computer-technology-team/go-judge/internal/auth/twofactor"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/middleware"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+const (
+	// twoFactorCookieKey keeps the challenge of a login waiting for its second factor
+	twoFactorCookieKey  = "two_factor_challenge"
+	twoFactorVerifyPath = "/auth/2fa/verify"
+	// twoFactorChallengeExpiry is how long a user has to enter their code after the password
+	twoFactorChallengeExpiry = 5 * time.Minute
+)
+
+type twoFactorPageData struct {
+	Enabled   bool
+	EnabledAt time.Time
+	// Required is set when a role of the user only applies with two-factor authentication
+	Required          bool
+	RecoveryCodesLeft int64
+	// Setup is set while the user is enabling two-factor authentication
+	Setup *twoFactorSetup
+	// RecoveryCodes are shown once, right after they were generated
+	RecoveryCodes []string
+}
+
+type twoFactorSetup struct {
+	Secret string
+	QRCode template.URL
+}
+
+// ShowTwoFactor shows whether the user has two-factor authentication and lets them set it up or turn it off
+func (s *DefaultServicer) ShowTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user, _ := internalcontext.GetUserFromContext(r.Context())
+	s.renderTwoFactor(w, r, user, nil)
+}
+
+// SetupTwoFactor creates a new secret for the user to scan, it is used once they enter a code of it
+func (s *DefaultServicer) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	secret, err := twofactor.NewSecret(user.Username)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not generate totp secret", "error", err)
+		templates.RenderError(ctx, w, "could not set up two-factor authentication", http.StatusInternalServerError,
+			s.templates)
+		return
+	}
+
+	// an enabled secret is kept, it has to be turned off first
+	if err := s.querier.StartTOTPSetup(ctx, s.pool, user.ID, secret); err != nil {
+		slog.ErrorContext(ctx, "could not store totp secret", "error", err)
+		templates.RenderError(ctx, w, "could not set up two-factor authentication", http.StatusInternalServerError,
+			s.templates)
+		return
+	}
+
+	s.renderTwoFactor(w, r, user, nil)
+}
+
+// EnableTwoFactor turns on the secret being set up once the user entered a code of it, gives them recovery codes
+// and logs out their other sessions
+func (s *DefaultServicer) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	userTOTP, err := s.querier.GetUserTOTP(ctx, s.pool, user.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "could not get totp", "error", err)
+		templates.RenderError(ctx, w, "could not enable two-factor authentication", http.StatusInternalServerError,
+			s.templates)
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) || userTOTP.EnabledAt.Valid {
+		templates.RenderError(ctx, w, "there is no two-factor setup to finish", http.StatusBadRequest, s.templates)
+		return
+	}
+
+	step, ok := twofactor.Validate(userTOTP.Secret, r.PostFormValue("code"), 0, time.Now())
+	if !ok {
+		templates.RenderError(ctx, w, "The code is incorrect, check that the clock of your device is right",
+			http.StatusBadRequest, s.templates)
+		return
+	}
+
+	codes, revoked, err := s.enableTOTP(ctx, *user, step)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not enable totp", "error", err)
+		templates.RenderError(ctx, w, "could not enable two-factor authentication", http.StatusInternalServerError,
+			s.templates)
+		return
+	}
+	s.sessions.Forget(revoked...)
+
+	s.renderTwoFactor(w, r, user, codes)
+}
+
+// DisableTwoFactor turns off two-factor authentication after checking a code of it
+func (s *DefaultServicer) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	if !s.checkSecondFactor(w, r, *user, r.PostFormValue("code")) {
+		return
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not begin transaction", "error", err)
+		templates.RenderError(ctx, w, "could not disable two-factor authentication", http.StatusInternalServerError,
+			s.templates)
+		return
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	if err := s.querier.DeleteUserTOTP(ctx, tx, user.ID); err != nil {
+		slog.ErrorContext(ctx, "could not delete totp", "error", err)
+		templates.RenderError(ctx, w, "could not disable two-factor authentication", http.StatusInternalServerError,
+			s.templates)
+		return
+	}
+
+	if err := s.querier.DeleteUserRecoveryCodes(ctx, tx, user.ID); err != nil {
+		slog.ErrorContext(ctx, "could not delete recovery codes", "error", err)
+		templates.RenderError(ctx, w, "could not disable two-factor authentication", http.StatusInternalServerError,
+			s.templates)
+		return
+	}
+
+	// roles requiring two-factor authentication stop applying, somebody has to be left to manage users
+	managers, err := s.querier.CountUsersWithPermission(ctx, tx, string(rbac.ManageUsers))
+	if err != nil {
+		slog.ErrorContext(ctx, "could not count user managers", "error", err)
+		templates.RenderError(ctx, w, "could not disable two-factor authentication", http.StatusInternalServerError,
+			s.templates)
+		return
+	}
+	if managers == 0 {
+		templates.RenderError(ctx, w, "nobody could manage users anymore, your roles require two-factor authentication",
+			http.StatusBadRequest, s.templates)
+		return
+	}
+
+	err = audit.Record(ctx, tx, s.querier, audit.Event{
+		Action:     audit.AuthTwoFactorDisable,
+		TargetType: audit.TargetUser,
+		TargetID:   user.Username,
+		Before:     map[string]bool{"two_factor": true},
+		After:      map[string]bool{"two_factor": false},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err)
+		templates.RenderError(ctx, w, "could not disable two-factor authentication", http.StatusInternalServerError,
+			s.templates)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "could not commit transaction", "error", err)
+		templates.RenderError(ctx, w, "could not disable two-factor authentication", http.StatusInternalServerError,
+			s.templates)
+		return
+	}
+
+	http.Redirect(w, r, "/auth/2fa", http.StatusSeeOther)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user after checking a code
+func (s *DefaultServicer) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	if !s.checkSecondFactor(w, r, *user, r.PostFormValue("code")) {
+		return
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not begin transaction", "error", err)
+		templates.RenderError(ctx, w, "could not create recovery codes", http.StatusInternalServerError, s.templates)
+		return
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	codes, err := s.replaceRecoveryCodes(ctx, tx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not replace recovery codes", "error", err)
+		templates.RenderError(ctx, w, "could not create recovery codes", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	err = audit.Record(ctx, tx, s.querier, audit.Event{
+		Action:     audit.AuthRecoveryCodes,
+		TargetType: audit.TargetUser,
+		TargetID:   user.Username,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err)
+		templates.RenderError(ctx, w, "could not create recovery codes", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "could not commit transaction", "error", err)
+		templates.RenderError(ctx, w, "could not create recovery codes", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	s.renderTwoFactor(w, r, user, codes)
+}
+
+// ShowTwoFactorLogin asks for the second factor of a login that passed the password
+func (s *DefaultServicer) ShowTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if _, ok := s.getTwoFactorChallenge(w, r); !ok {
+		return
+	}
+
+	err := s.templates.Render(ctx, "twofactorlogin", w, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render twofactorlogin", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, s.templates)
+		return
+	}
+}
+
+// VerifyTwoFactorLogin checks the TOTP or recovery code of a login and starts its session
+func (s *DefaultServicer) VerifyTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	challenge, ok := s.getTwoFactorChallenge(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := s.querier.GetUser(ctx, s.pool, challenge.UserID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get user", "error", err)
+		templates.RenderError(ctx, w, "could not log in", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	if !s.checkSecondFactor(w, r, user, r.PostFormValue("code")) {
+		return
+	}
+
+	if err := s.querier.DeleteTwoFactorChallenge(ctx, s.pool, challenge.TokenHash); err != nil {
+		slog.ErrorContext(ctx, "could not delete two-factor challenge", "error", err)
+	}
+	clearTwoFactorCookie(w)
+
+	s.startSession(w, r, user, "/")
+}
+
+// startTwoFactorLogin remembers a login that passed the password and asks for its second factor
+func (s *DefaultServicer) startTwoFactorLogin(w http.ResponseWriter, r *http.Request, user storage.User) {
+	ctx := r.Context()
+
+	if err := s.querier.DeleteExpiredTwoFactorChallenges(ctx, s.pool); err != nil {
+		slog.ErrorContext(ctx, "could not delete expired two-factor challenges", "error", err)
+	}
+
+	token, tokenHash, err := randomtoken.New("")
+	if err != nil {
+		slog.ErrorContext(ctx, "could not generate challenge token", "error", err)
+		templates.RenderError(ctx, w, "could not log in", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	err = s.querier.CreateTwoFactorChallenge(ctx, s.pool, storage.CreateTwoFactorChallengeParams{
+		TokenHash: tokenHash,
+		UserID:    user.ID,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(twoFactorChallengeExpiry), Valid: true},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not create two-factor challenge", "error", err)
+		templates.RenderError(ctx, w, "could not log in", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     twoFactorCookieKey,
+		Value:    token,
+		Path:     twoFactorVerifyPath,
+		MaxAge:   int(twoFactorChallengeExpiry.Seconds()),
+		HttpOnly: true,
+		// provider logins arrive here from a redirect of the provider, which lax cookies are sent with
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, twoFactorVerifyPath, http.StatusSeeOther)
+}
+
+// getTwoFactorChallenge loads the unexpired challenge of the cookie, rendering an error otherwise
+func (s *DefaultServicer) getTwoFactorChallenge(w http.ResponseWriter,
+	r *http.Request) (storage.TwoFactorChallenge, bool) {
+	ctx := r.Context()
+
+	cookie, err := r.Cookie(twoFactorCookieKey)
+	if err != nil {
+		templates.RenderError(ctx, w, "the login expired, please log in again", http.StatusBadRequest, s.templates)
+		return storage.TwoFactorChallenge{}, false
+	}
+
+	challenge, err := s.querier.GetTwoFactorChallenge(ctx, s.pool, randomtoken.Hash(cookie.Value))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			clearTwoFactorCookie(w)
+			templates.RenderError(ctx, w, "the login expired, please log in again", http.StatusBadRequest, s.templates)
+			return storage.TwoFactorChallenge{}, false
+		}
+		slog.ErrorContext(ctx, "could not get two-factor challenge", "error", err)
+		templates.RenderError(ctx, w, "could not log in", http.StatusInternalServerError, s.templates)
+		return storage.TwoFactorChallenge{}, false
+	}
+
+	return challenge, true
+}
+
+func clearTwoFactorCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{Name: twoFactorCookieKey, Path: twoFactorVerifyPath, MaxAge: -1, HttpOnly: true})
+}
+
+// checkSecondFactor uses up a TOTP or recovery code of the user, failing like a login so codes can not be guessed.
+// It renders the error and returns false when the code is not accepted.
+func (s *DefaultServicer) checkSecondFactor(w http.ResponseWriter, r *http.Request, user storage.User,
+	code string) bool {
+	ctx := r.Context()
+	ip := middleware.ClientIP(r)
+
+	wait, err := s.lockout.Wait(ctx, user.Username, ip)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not check login lockout", "error", err)
+		templates.RenderError(ctx, w, "could not check the code", http.StatusInternalServerError, s.templates)
+		return false
+	}
+	if wait > 0 {
+		templates.RenderError(ctx, w, fmt.Sprintf("Too many failed attempts, try again in %s", wait.Round(time.Second)),
+			http.StatusTooManyRequests, s.templates)
+		return false
+	}
+
+	ok, err := s.useSecondFactor(ctx, user.ID, code)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not check second factor", "error", err)
+		templates.RenderError(ctx, w, "could not check the code", http.StatusInternalServerError, s.templates)
+		return false
+	}
+	if !ok {
+		if err := s.lockout.Fail(ctx, user.Username, ip); err != nil {
+			slog.ErrorContext(ctx, "could not record login failure", "error", err)
+		}
+		s.recordLoginFailure(ctx, user.Username, ip, "second_factor")
+		templates.RenderError(ctx, w, "Invalid authentication code", http.StatusUnauthorized, s.templates)
+		return false
+	}
+
+	if err := s.lockout.Succeed(ctx, user.Username); err != nil {
+		slog.ErrorContext(ctx, "could not clear login failures", "error", err)
+	}
+
+	return true
+}
+
+// useSecondFactor uses up a recovery code or the time step of a TOTP code, either is accepted once
+func (s *DefaultServicer) useSecondFactor(ctx context.Context, userID pgtype.UUID, code string) (bool, error) {
+	if twofactor.IsRecoveryCode(code) {
+		used, err := s.querier.UseRecoveryCode(ctx, s.pool, userID, twofactor.HashRecoveryCode(code))
+		if err != nil {
+			return false, fmt.Errorf("could not use recovery code: %w", err)
+		}
+		return used == 1, nil
+	}
+
+	userTOTP, err := s.querier.GetUserTOTP(ctx, s.pool, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("could not get totp: %w", err)
+	}
+	if !userTOTP.EnabledAt.Valid {
+		return false, nil
+	}
+
+	step, ok := twofactor.Validate(userTOTP.Secret, code, userTOTP.LastUsedStep, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	// a concurrent request with the same code loses here
+	used, err := s.querier.UseTOTPStep(ctx, s.pool, step, userID)
+	if err != nil {
+		return false, fmt.Errorf("could not use totp step: %w", err)
+	}
+	return used == 1, nil
+}
+
+// enableTOTP turns on the secret being set up, creates recovery codes and revokes the other sessions of the user
+func (s *DefaultServicer) enableTOTP(ctx context.Context, user storage.User, step int64) ([]string, []pgtype.UUID,
+	error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	enabled, err := s.querier.EnableTOTP(ctx, tx, step, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not enable totp: %w", err)
+	}
+	if enabled == 0 {
+		return nil, nil, errors.New("totp was enabled concurrently")
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, tx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var revoked []pgtype.UUID
+	if current, ok := internalcontext.GetSessionFromContext(ctx); ok {
+		revoked, err = s.querier.RevokeOtherUserSessions(ctx, tx, user.ID, current.ID)
+	} else {
+		revoked, err = s.querier.RevokeUserSessions(ctx, tx, user.ID)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not revoke sessions: %w", err)
+	}
+
+	err = audit.Record(ctx, tx, s.querier, audit.Event{
+		Action:     audit.AuthTwoFactorEnable,
+		TargetType: audit.TargetUser,
+		TargetID:   user.Username,
+		Before:     map[string]any{"two_factor": false},
+		After:      map[string]any{"two_factor": true, "revoked_sessions": len(revoked)},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return codes, revoked, nil
+}
+
+// replaceRecoveryCodes stores the hashes of new recovery codes of the user instead of the old ones
+func (s *DefaultServicer) replaceRecoveryCodes(ctx context.Context, db storage.DBTX,
+	userID pgtype.UUID) ([]string, error) {
+	codes, err := twofactor.NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.querier.DeleteUserRecoveryCodes(ctx, db, userID); err != nil {
+		return nil, fmt.Errorf("could not delete recovery codes: %w", err)
+	}
+
+	for _, code := range codes {
+		if err := s.querier.CreateRecoveryCode(ctx, db, userID, twofactor.HashRecoveryCode(code)); err != nil {
+			return nil, fmt.Errorf("could not create recovery code: %w", err)
+		}
+	}
+
+	return codes, nil
+}
+
+// renderTwoFactor renders the two-factor page of the user, with recovery codes that were just generated
+func (s *DefaultServicer) renderTwoFactor(w http.ResponseWriter, r *http.Request, user *storage.User,
+	recoveryCodes []string) {
+	ctx := r.Context()
+	data := twoFactorPageData{RecoveryCodes: recoveryCodes}
+
+	userTOTP, err := s.querier.GetUserTOTP(ctx, s.pool, user.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "could not get totp", "error", err)
+		templates.RenderError(ctx, w, "could not get two-factor authentication", http.StatusInternalServerError,
+			s.templates)
+		return
+	}
+
+	switch {
+	case err != nil:
+	case userTOTP.EnabledAt.Valid:
+		data.Enabled = true
+		data.EnabledAt = userTOTP.EnabledAt.Time
+		data.RecoveryCodesLeft, err = s.querier.CountUnusedRecoveryCodes(ctx, s.pool, user.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "could not count recovery codes", "error", err)
+			templates.RenderError(ctx, w, "could not get two-factor authentication", http.StatusInternalServerError,
+				s.templates)
+			return
+		}
+	default:
+		qr, err := twofactor.QRCode(userTOTP.Secret, user.Username)
+		if err != nil {
+			slog.ErrorContext(ctx, "could not render qr code", "error", err)
+			templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, s.templates)
+			return
+		}
+		data.Setup = &twoFactorSetup{Secret: userTOTP.Secret, QRCode: qr}
+	}
+
+	data.Required, err = s.querier.IsTwoFactorRequired(ctx, s.pool, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not check whether two-factor authentication is required", "error", err)
+		templates.RenderError(ctx, w, "could not get two-factor authentication", http.StatusInternalServerError,
+			s.templates)
+		return
+	}
+
+	// secrets and recovery codes must not stay in caches
+	w.Header().Set("Cache-Control", "no-store")
+	err = s.templates.Render(ctx, "twofactor", w, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render twofactor", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, s.templates)
+		return
+	}
+}
@@ -0,0 +1,150 @@
+// Package twofactor implements TOTP second factors and the recovery codes that replace a lost authenticator
+package twofactor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image/png"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/pquerna/otp/totp"
+
+	"github.com/computer-technology-team/go-judge/internal/auth/randomtoken"
+)
+
+const (
+	// Issuer names the site in authenticator apps
+	Issuer = "Go Judge"
+	// period is the length in seconds of a TOTP time step
+	period = 30
+	// skew is how many steps a code may be off, for clocks that drift
+	skew = 1
+	// qrSize is the width and height of the QR code image
+	qrSize = 200
+
+	// RecoveryCodeCount is the number of recovery codes a user gets
+	RecoveryCodeCount = 10
+	// recoveryCodeAlphabet leaves out characters that are easily confused
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	recoveryCodeLength   = 10
+)
+
+var validateOpts = hotp.ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+
+// NewSecret returns a new random TOTP secret in base32
+func NewSecret(username string) (string, error) {
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: Issuer, AccountName: username})
+	if err != nil {
+		return "", fmt.Errorf("could not generate totp secret: %w", err)
+	}
+	return key.Secret(), nil
+}
+
+// KeyURL returns the otpauth url authenticator apps import the secret from
+func KeyURL(secret, username string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", Issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", "6")
+	query.Set("period", fmt.Sprint(period))
+
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + Issuer + ":" + username,
+		RawQuery: query.Encode(),
+	}).String()
+}
+
+// QRCode renders the otpauth url of the secret as a PNG data url to scan
+func QRCode(secret, username string) (template.URL, error) {
+	key, err := otp.NewKeyFromURL(KeyURL(secret, username))
+	if err != nil {
+		return "", fmt.Errorf("could not parse key url: %w", err)
+	}
+
+	img, err := key.Image(qrSize, qrSize)
+	if err != nil {
+		return "", fmt.Errorf("could not render qr code: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", fmt.Errorf("could not encode qr code: %w", err)
+	}
+
+	// the data url is built from our own image, it is safe to embed
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+// Validate checks a code against the secret at time now, returning the time step it belongs to.
+// Only steps after lastStep are accepted, so a code can not be used twice.
+func Validate(secret, code string, lastStep int64, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != validateOpts.Digits.Length() {
+		return 0, false
+	}
+
+	current := now.Unix() / period
+	for step := current - skew; step <= current+skew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		valid, err := hotp.ValidateCustom(code, uint64(step), secret, validateOpts)
+		if err == nil && valid {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// NewRecoveryCodes returns fresh recovery codes formatted as xxxxx-xxxxx
+func NewRecoveryCodes() ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	raw := make([]byte, recoveryCodeLength)
+	for i := range codes {
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("could not generate recovery code: %w", err)
+		}
+
+		var code strings.Builder
+		for j, b := range raw {
+			if j == recoveryCodeLength/2 {
+				code.WriteByte('-')
+			}
+			// 256 is not a multiple of the alphabet, the slight bias does not matter at this length
+			code.WriteByte(recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+		}
+		codes[i] = code.String()
+	}
+
+	return codes, nil
+}
+
+// IsRecoveryCode reports whether code looks like a recovery code rather than a TOTP code
+func IsRecoveryCode(code string) bool {
+	return len(normalizeRecoveryCode(code)) == recoveryCodeLength
+}
+
+// HashRecoveryCode hashes a recovery code for storage and lookup, ignoring case, spaces and dashes
+func HashRecoveryCode(code string) []byte {
+	return randomtoken.Hash(normalizeRecoveryCode(code))
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
@@ -0,0 +1,76 @@
+package twofactor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type TwoFactorTestSuite struct {
+	suite.Suite
+}
+
+func (s *TwoFactorTestSuite) TestValidate() {
+	secret, err := NewSecret("alice")
+	require.NoError(s.T(), err)
+
+	now := time.Unix(1_700_000_000, 0)
+	code, err := totp.GenerateCode(secret, now)
+	require.NoError(s.T(), err)
+
+	step, ok := Validate(secret, code, 0, now)
+	assert.True(s.T(), ok)
+	assert.Equal(s.T(), now.Unix()/period, step)
+
+	_, ok = Validate(secret, code[:3]+" "+code[3:], 0, now)
+	assert.True(s.T(), ok, "spaces are ignored")
+
+	_, ok = Validate(secret, code, 0, now.Add(period*time.Second))
+	assert.True(s.T(), ok, "the previous step is accepted")
+
+	_, ok = Validate(secret, code, 0, now.Add(3*period*time.Second))
+	assert.False(s.T(), ok, "old codes expire")
+
+	_, ok = Validate(secret, code, step, now)
+	assert.False(s.T(), ok, "a used code is rejected")
+
+	_, ok = Validate(secret, "12345", 0, now)
+	assert.False(s.T(), ok)
+}
+
+func (s *TwoFactorTestSuite) TestKeyURL() {
+	key, err := otp.NewKeyFromURL(KeyURL("JBSWY3DPEHPK3PXP", "alice"))
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), Issuer, key.Issuer())
+	assert.Equal(s.T(), "alice", key.AccountName())
+	assert.Equal(s.T(), "JBSWY3DPEHPK3PXP", key.Secret())
+
+	qr, err := QRCode("JBSWY3DPEHPK3PXP", "alice")
+	require.NoError(s.T(), err)
+	assert.True(s.T(), strings.HasPrefix(string(qr), "data:image/png;base64,"))
+}
+
+func (s *TwoFactorTestSuite) TestRecoveryCodes() {
+	codes, err := NewRecoveryCodes()
+	require.NoError(s.T(), err)
+	require.Len(s.T(), codes, RecoveryCodeCount)
+
+	for _, code := range codes {
+		assert.Regexp(s.T(), `^[a-z2-9]{5}-[a-z2-9]{5}$`, code)
+		assert.True(s.T(), IsRecoveryCode(code))
+		assert.Equal(s.T(), HashRecoveryCode(code), HashRecoveryCode(" "+strings.ToUpper(strings.ReplaceAll(code, "-", ""))))
+	}
+
+	assert.False(s.T(), IsRecoveryCode("123456"))
+}
+
+func TestTwoFactorTestSuite(t *testing.T) {
+	suite.Run(t, new(TwoFactorTestSuite))
+}
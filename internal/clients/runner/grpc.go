@@ -15,6 +15,9 @@ import (
 	"github.com/computer-technology-team/go-judge/config"
 )
 
+// maxMessageSize leaves room for requests and results that carry whole test files
+const maxMessageSize = 256 << 20
+
 type RunnerClient struct {
 	runnerPb.RunnerClient
 	conn *grpc.ClientConn
@@ -31,6 +34,10 @@ func NewClient(ctx context.Context, cfg config.ClientConfig) (*RunnerClient, err
 	opts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy":"round_robin"}`),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(maxMessageSize),
+			grpc.MaxCallSendMsgSize(maxMessageSize),
+		),
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:    10 * time.Second,
 			Timeout: 3 * time.Second,
@@ -3,15 +3,58 @@ package context
 import (
 	"context"
 
+	"github.com/computer-technology-team/go-judge/internal/rbac"
 	"github.com/computer-technology-team/go-judge/internal/storage"
 )
 
 // Key for user context
 type contextKey string
 
-const UserContextKey = contextKey("user")
+const (
+	UserContextKey        = contextKey("user")
+	PermissionsContextKey = contextKey("permissions")
+	SessionContextKey     = contextKey("session")
+	APITokenContextKey    = contextKey("api_token")
+	CSRFTokenContextKey   = contextKey("csrf_token")
+	ActorNameContextKey   = contextKey("actor_name")
+)
 
 func GetUserFromContext(ctx context.Context) (user *storage.User, ok bool) {
 	user, ok = ctx.Value(UserContextKey).(*storage.User)
 	return
 }
+
+// GetPermissionsFromContext returns the permissions of the authenticated user, none when there is no user
+func GetPermissionsFromContext(ctx context.Context) rbac.Permissions {
+	permissions, _ := ctx.Value(PermissionsContextKey).(rbac.Permissions)
+	return permissions
+}
+
+// HasPermission reports whether the authenticated user holds permission
+func HasPermission(ctx context.Context, permission rbac.Permission) bool {
+	return GetPermissionsFromContext(ctx).Has(permission)
+}
+
+// GetSessionFromContext returns the session of the token the user authenticated with
+func GetSessionFromContext(ctx context.Context) (session *storage.Session, ok bool) {
+	session, ok = ctx.Value(SessionContextKey).(*storage.Session)
+	return
+}
+
+// GetAPITokenFromContext returns the personal access token the request was made with
+func GetAPITokenFromContext(ctx context.Context) (token *storage.PersonalAccessToken, ok bool) {
+	token, ok = ctx.Value(APITokenContextKey).(*storage.PersonalAccessToken)
+	return
+}
+
+// GetCSRFTokenFromContext returns the CSRF token for the forms of the response
+func GetCSRFTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(CSRFTokenContextKey).(string)
+	return token
+}
+
+// GetActorNameFromContext names who acts when there is no user, like the command line
+func GetActorNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(ActorNameContextKey).(string)
+	return name
+}
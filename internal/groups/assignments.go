@@ -0,0 +1,414 @@
+package groups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/lo"
+
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// datetimeLayout is the format of datetime-local inputs, times are in the time zone of the server
+const datetimeLayout = "2006-01-02T15:04"
+
+const maxAssignmentProblems = 50
+
+type assignmentFormData struct {
+	Group storage.Group
+	// Assignment is nil for a new assignment
+	Assignment         *storage.Assignment
+	OpensAt            string
+	ClosesAt           string
+	LateUntil          string
+	LatePenaltyPercent int32
+	ProblemIDs         string
+}
+
+type assignmentPageData struct {
+	Group      storage.Group
+	Owner      bool
+	Assignment storage.Assignment
+	Status     AssignmentStatus
+	// Problems are hidden from members until the assignment opens
+	Problems []assignmentProblemView
+}
+
+type assignmentProblemView struct {
+	ID    int32
+	Title string
+	// Cell is the best score of the member, empty for owners
+	Cell Cell
+}
+
+type assignmentForm struct {
+	Title              string
+	OpensAt            time.Time
+	ClosesAt           time.Time
+	LateUntil          pgtype.Timestamptz
+	LatePenaltyPercent int32
+	ProblemIDs         []int32
+}
+
+// AssignmentForm shows the form to create an assignment or to edit one, the assignment id is "new" for creating
+func (h *DefaultHandler) AssignmentForm(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	group, ok := h.getOwnedGroup(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	data := assignmentFormData{
+		Group:    group,
+		OpensAt:  now.Format(datetimeLayout),
+		ClosesAt: now.Add(7 * 24 * time.Hour).Format(datetimeLayout),
+	}
+
+	if chi.URLParam(r, "assignment_id") != "new" {
+		assignment, ok := h.getAssignment(w, r, group)
+		if !ok {
+			return
+		}
+
+		problems, err := h.querier.ListGroupAssignmentProblems(ctx, h.pool, group.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "could not list assignment problems", "error", err, "group_id", group.ID)
+			templates.RenderError(ctx, w, "could not get assignment", http.StatusInternalServerError, h.templates)
+			return
+		}
+
+		data.Assignment = &assignment
+		data.OpensAt = assignment.OpensAt.Time.In(time.Local).Format(datetimeLayout)
+		data.ClosesAt = assignment.ClosesAt.Time.In(time.Local).Format(datetimeLayout)
+		if assignment.LateUntil.Valid {
+			data.LateUntil = assignment.LateUntil.Time.In(time.Local).Format(datetimeLayout)
+		}
+		data.LatePenaltyPercent = assignment.LatePenaltyPercent
+		data.ProblemIDs = strings.Join(lo.FilterMap(problems, func(p storage.ListGroupAssignmentProblemsRow, _ int) (string, bool) {
+			return strconv.Itoa(int(p.ProblemID)), p.AssignmentID == assignment.ID
+		}), ", ")
+	}
+
+	err := h.templates.Render(ctx, "assignmentformpage", w, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render assignmentformpage", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, h.templates)
+		return
+	}
+}
+
+// CreateAssignment adds an assignment to a group
+func (h *DefaultHandler) CreateAssignment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	group, ok := h.getOwnedGroup(w, r)
+	if !ok {
+		return
+	}
+
+	form, ok := h.parseAssignmentForm(w, r)
+	if !ok {
+		return
+	}
+
+	var assignment storage.Assignment
+	err := h.saveAssignment(ctx, form, func(tx pgx.Tx) (storage.Assignment, error) {
+		var err error
+		assignment, err = h.querier.CreateAssignment(ctx, tx, storage.CreateAssignmentParams{
+			GroupID:            group.ID,
+			Title:              form.Title,
+			OpensAt:            pgtype.Timestamptz{Time: form.OpensAt, Valid: true},
+			ClosesAt:           pgtype.Timestamptz{Time: form.ClosesAt, Valid: true},
+			LateUntil:          form.LateUntil,
+			LatePenaltyPercent: form.LatePenaltyPercent,
+		})
+		return assignment, err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not create assignment", "error", err, "group_id", group.ID)
+		templates.RenderError(ctx, w, "could not create assignment", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/groups/%d/assignments/%d", group.ID, assignment.ID), http.StatusSeeOther)
+}
+
+// UpdateAssignment changes the schedule, the penalty or the problems of an assignment
+func (h *DefaultHandler) UpdateAssignment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	group, ok := h.getOwnedGroup(w, r)
+	if !ok {
+		return
+	}
+
+	assignment, ok := h.getAssignment(w, r, group)
+	if !ok {
+		return
+	}
+
+	form, ok := h.parseAssignmentForm(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.saveAssignment(ctx, form, func(tx pgx.Tx) (storage.Assignment, error) {
+		return h.querier.UpdateAssignment(ctx, tx, storage.UpdateAssignmentParams{
+			GroupID:            group.ID,
+			ID:                 assignment.ID,
+			Title:              form.Title,
+			OpensAt:            pgtype.Timestamptz{Time: form.OpensAt, Valid: true},
+			ClosesAt:           pgtype.Timestamptz{Time: form.ClosesAt, Valid: true},
+			LateUntil:          form.LateUntil,
+			LatePenaltyPercent: form.LatePenaltyPercent,
+		})
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not update assignment", "error", err, "assignment_id", assignment.ID)
+		templates.RenderError(ctx, w, "could not update assignment", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/groups/%d/assignments/%d", group.ID, assignment.ID), http.StatusSeeOther)
+}
+
+// DeleteAssignment removes an assignment, the submissions to its problems stay
+func (h *DefaultHandler) DeleteAssignment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	group, ok := h.getOwnedGroup(w, r)
+	if !ok {
+		return
+	}
+
+	assignment, ok := h.getAssignment(w, r, group)
+	if !ok {
+		return
+	}
+
+	_, err := h.querier.DeleteAssignment(ctx, h.pool, group.ID, assignment.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not delete assignment", "error", err, "assignment_id", assignment.ID)
+		templates.RenderError(ctx, w, "could not delete assignment", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/groups/%d", group.ID), http.StatusSeeOther)
+}
+
+// ViewAssignment shows the problems of an assignment, members also see their best score on each
+func (h *DefaultHandler) ViewAssignment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	group, role, ok := h.getGroup(w, r)
+	if !ok {
+		return
+	}
+
+	assignment, ok := h.getAssignment(w, r, group)
+	if !ok {
+		return
+	}
+
+	data := assignmentPageData{
+		Group:      group,
+		Owner:      role == storage.GroupRoleOWNER,
+		Assignment: assignment,
+		Status:     Status(assignment, time.Now()),
+	}
+
+	if data.Owner || data.Status != StatusUpcoming {
+		problems, err := h.querier.ListGroupAssignmentProblems(ctx, h.pool, group.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "could not list assignment problems", "error", err, "group_id", group.ID)
+			templates.RenderError(ctx, w, "could not get assignment", http.StatusInternalServerError, h.templates)
+			return
+		}
+		problems = lo.Filter(problems, func(p storage.ListGroupAssignmentProblemsRow, _ int) bool {
+			return p.AssignmentID == assignment.ID
+		})
+
+		var submissions []storage.GetGroupAcceptedSubmissionsRow
+		if !data.Owner {
+			submissions, err = h.querier.GetGroupAcceptedSubmissions(ctx, h.pool, group.ID, user.ID)
+			if err != nil {
+				slog.ErrorContext(ctx, "could not get accepted submissions", "error", err, "group_id", group.ID)
+				templates.RenderError(ctx, w, "could not get assignment", http.StatusInternalServerError, h.templates)
+				return
+			}
+		}
+
+		gradebook := BuildGradebook([]storage.Assignment{assignment}, problems,
+			[]storage.ListGroupMembersRow{{ID: user.ID, Username: user.Username, Role: storage.GroupRoleMEMBER}},
+			submissions)
+		for i, column := range gradebook.Columns {
+			data.Problems = append(data.Problems, assignmentProblemView{
+				ID:    column.ProblemID,
+				Title: column.ProblemTitle,
+				Cell:  gradebook.Rows[0].Cells[i],
+			})
+		}
+	}
+
+	err := h.templates.Render(ctx, "assignmentpage", w, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render assignmentpage", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, h.templates)
+		return
+	}
+}
+
+// saveAssignment creates or updates an assignment with save and replaces its problems
+func (h *DefaultHandler) saveAssignment(ctx context.Context, form assignmentForm,
+	save func(tx pgx.Tx) (storage.Assignment, error)) error {
+
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	assignment, err := save(tx)
+	if err != nil {
+		return fmt.Errorf("could not save assignment: %w", err)
+	}
+
+	if err := h.querier.DeleteAssignmentProblems(ctx, tx, assignment.ID); err != nil {
+		return fmt.Errorf("could not delete assignment problems: %w", err)
+	}
+
+	for i, problemID := range form.ProblemIDs {
+		err = h.querier.AddAssignmentProblem(ctx, tx, storage.AddAssignmentProblemParams{
+			AssignmentID: assignment.ID,
+			ProblemID:    problemID,
+			Position:     int32(i + 1),
+		})
+		if err != nil {
+			return fmt.Errorf("could not add assignment problem: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// parseAssignmentForm reads and checks an assignment form, rendering the error when it is invalid.
+// Problems must be published so members can open them.
+func (h *DefaultHandler) parseAssignmentForm(w http.ResponseWriter, r *http.Request) (assignmentForm, bool) {
+	ctx := r.Context()
+
+	fail := func(message string) (assignmentForm, bool) {
+		templates.RenderError(ctx, w, message, http.StatusBadRequest, h.templates)
+		return assignmentForm{}, false
+	}
+
+	form := assignmentForm{Title: strings.TrimSpace(r.PostFormValue("title"))}
+	if form.Title == "" {
+		return fail("title is required")
+	}
+
+	var err error
+	form.OpensAt, err = time.ParseInLocation(datetimeLayout, r.PostFormValue("opens_at"), time.Local)
+	if err != nil {
+		return fail("opening time is invalid")
+	}
+	form.ClosesAt, err = time.ParseInLocation(datetimeLayout, r.PostFormValue("closes_at"), time.Local)
+	if err != nil {
+		return fail("deadline is invalid")
+	}
+	if !form.ClosesAt.After(form.OpensAt) {
+		return fail("the deadline must be after the opening time")
+	}
+
+	if value := r.PostFormValue("late_until"); value != "" {
+		lateUntil, err := time.ParseInLocation(datetimeLayout, value, time.Local)
+		if err != nil {
+			return fail("late submission end is invalid")
+		}
+		if !lateUntil.After(form.ClosesAt) {
+			return fail("late submissions must end after the deadline")
+		}
+		form.LateUntil = pgtype.Timestamptz{Time: lateUntil, Valid: true}
+	}
+
+	if value := r.PostFormValue("late_penalty_percent"); value != "" {
+		penalty, err := strconv.Atoi(value)
+		if err != nil || penalty < 0 || penalty > 100 {
+			return fail("late penalty must be a percentage")
+		}
+		form.LatePenaltyPercent = int32(penalty)
+	}
+
+	for _, field := range strings.FieldsFunc(r.PostFormValue("problems"), func(c rune) bool {
+		return c == ',' || c == ' ' || c == '\n' || c == '\r'
+	}) {
+		problemID, err := strconv.Atoi(field)
+		if err != nil {
+			return fail(fmt.Sprintf("%q is not a problem id", field))
+		}
+		if lo.Contains(form.ProblemIDs, int32(problemID)) {
+			continue
+		}
+
+		problem, err := h.querier.GetProblemByID(ctx, h.pool, int32(problemID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fail(fmt.Sprintf("problem %d not found", problemID))
+			}
+			slog.ErrorContext(ctx, "could not get problem", "error", err, "problem_id", problemID)
+			templates.RenderError(ctx, w, "could not get problem", http.StatusInternalServerError, h.templates)
+			return assignmentForm{}, false
+		}
+		if problem.Draft {
+			return fail(fmt.Sprintf("problem %d is not published", problemID))
+		}
+
+		form.ProblemIDs = append(form.ProblemIDs, problem.ID)
+	}
+	if len(form.ProblemIDs) == 0 || len(form.ProblemIDs) > maxAssignmentProblems {
+		return fail(fmt.Sprintf("an assignment has between 1 and %d problems", maxAssignmentProblems))
+	}
+
+	return form, true
+}
+
+// getAssignment loads the assignment in the URL, it must belong to group
+func (h *DefaultHandler) getAssignment(w http.ResponseWriter, r *http.Request, group storage.Group) (storage.Assignment, bool) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(chi.URLParam(r, "assignment_id"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid assignment id", http.StatusBadRequest, h.templates)
+		return storage.Assignment{}, false
+	}
+
+	assignment, err := h.querier.GetAssignment(ctx, h.pool, group.ID, int32(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "assignment not found", http.StatusNotFound, h.templates)
+			return storage.Assignment{}, false
+		}
+		slog.ErrorContext(ctx, "could not get assignment", "error", err, "assignment_id", id)
+		templates.RenderError(ctx, w, "could not get assignment", http.StatusInternalServerError, h.templates)
+		return storage.Assignment{}, false
+	}
+
+	return assignment, true
+}
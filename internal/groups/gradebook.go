@@ -0,0 +1,90 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+type gradebookPageData struct {
+	Group     storage.Group
+	Gradebook Gradebook
+}
+
+// ShowGradebook shows the best score of every member on every assignment problem
+func (h *DefaultHandler) ShowGradebook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	group, ok := h.getOwnedGroup(w, r)
+	if !ok {
+		return
+	}
+
+	gradebook, err := h.buildGradebook(r, group)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not build gradebook", "error", err, "group_id", group.ID)
+		templates.RenderError(ctx, w, "could not build gradebook", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	err = h.templates.Render(ctx, "gradebookpage", w, gradebookPageData{Group: group, Gradebook: gradebook})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render gradebookpage", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, h.templates)
+		return
+	}
+}
+
+// ExportGradebook downloads the gradebook as CSV
+func (h *DefaultHandler) ExportGradebook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	group, ok := h.getOwnedGroup(w, r)
+	if !ok {
+		return
+	}
+
+	gradebook, err := h.buildGradebook(r, group)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not build gradebook", "error", err, "group_id", group.ID)
+		templates.RenderError(ctx, w, "could not build gradebook", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="group-%d-gradebook.csv"`, group.ID))
+	if err := gradebook.WriteCSV(w); err != nil {
+		slog.ErrorContext(ctx, "could not write gradebook", "error", err, "group_id", group.ID)
+	}
+}
+
+func (h *DefaultHandler) buildGradebook(r *http.Request, group storage.Group) (Gradebook, error) {
+	ctx := r.Context()
+
+	assignments, err := h.querier.ListGroupAssignments(ctx, h.pool, group.ID)
+	if err != nil {
+		return Gradebook{}, fmt.Errorf("could not list assignments: %w", err)
+	}
+
+	problems, err := h.querier.ListGroupAssignmentProblems(ctx, h.pool, group.ID)
+	if err != nil {
+		return Gradebook{}, fmt.Errorf("could not list assignment problems: %w", err)
+	}
+
+	members, err := h.querier.ListGroupMembers(ctx, h.pool, group.ID)
+	if err != nil {
+		return Gradebook{}, fmt.Errorf("could not list members: %w", err)
+	}
+
+	submissions, err := h.querier.GetGroupAcceptedSubmissions(ctx, h.pool, group.ID, pgtype.UUID{})
+	if err != nil {
+		return Gradebook{}, fmt.Errorf("could not get accepted submissions: %w", err)
+	}
+
+	return BuildGradebook(assignments, problems, members, submissions), nil
+}
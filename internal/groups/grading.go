@@ -0,0 +1,195 @@
+package groups
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+// fullScore is what an accepted submission earns before the deadline
+const fullScore = 100
+
+// Score is the percentage an accepted submission earns on an assignment. After the deadline every started day
+// costs the late penalty, and submissions outside the assignment window do not count.
+func Score(assignment storage.Assignment, submittedAt time.Time) (int, bool) {
+	if submittedAt.Before(assignment.OpensAt.Time) {
+		return 0, false
+	}
+	if !submittedAt.After(assignment.ClosesAt.Time) {
+		return fullScore, true
+	}
+	if !assignment.LateUntil.Valid || submittedAt.After(assignment.LateUntil.Time) {
+		return 0, false
+	}
+
+	daysLate := int(math.Ceil(submittedAt.Sub(assignment.ClosesAt.Time).Hours() / 24))
+	return max(0, fullScore-daysLate*int(assignment.LatePenaltyPercent)), true
+}
+
+// AssignmentStatus is where an assignment is in its schedule
+type AssignmentStatus string
+
+const (
+	StatusUpcoming AssignmentStatus = "upcoming"
+	StatusOpen     AssignmentStatus = "open"
+	// StatusLate is after the deadline while late submissions are still accepted
+	StatusLate   AssignmentStatus = "late"
+	StatusClosed AssignmentStatus = "closed"
+)
+
+// Status returns the status of an assignment at now
+func Status(assignment storage.Assignment, now time.Time) AssignmentStatus {
+	switch {
+	case now.Before(assignment.OpensAt.Time):
+		return StatusUpcoming
+	case !now.After(assignment.ClosesAt.Time):
+		return StatusOpen
+	case assignment.LateUntil.Valid && !now.After(assignment.LateUntil.Time):
+		return StatusLate
+	default:
+		return StatusClosed
+	}
+}
+
+// Column is a problem of an assignment in the gradebook
+type Column struct {
+	Assignment   storage.Assignment
+	ProblemID    int32
+	ProblemTitle string
+}
+
+// Cell is the best score of a member on a problem of an assignment
+type Cell struct {
+	Solved bool
+	Score  int
+	// Late is set when the best score was submitted after the deadline
+	Late bool
+}
+
+// Row is the scores of a member, in the order of the columns
+type Row struct {
+	UserID   pgtype.UUID
+	Username string
+	Cells    []Cell
+	Total    int
+}
+
+// Gradebook is the best score of every member on every problem of the assignments of a group
+type Gradebook struct {
+	Columns []Column
+	Rows    []Row
+}
+
+// BuildGradebook scores the accepted submissions of the members, problems are listed in the order of the columns.
+// Owners of the group are not graded.
+func BuildGradebook(assignments []storage.Assignment, problems []storage.ListGroupAssignmentProblemsRow,
+	members []storage.ListGroupMembersRow, submissions []storage.GetGroupAcceptedSubmissionsRow) Gradebook {
+
+	assignmentByID := make(map[int32]storage.Assignment, len(assignments))
+	for _, assignment := range assignments {
+		assignmentByID[assignment.ID] = assignment
+	}
+
+	type columnKey struct{ assignmentID, problemID int32 }
+	var gradebook Gradebook
+	columnIndex := make(map[columnKey]int)
+	for _, problem := range problems {
+		assignment, ok := assignmentByID[problem.AssignmentID]
+		if !ok {
+			continue
+		}
+		columnIndex[columnKey{problem.AssignmentID, problem.ProblemID}] = len(gradebook.Columns)
+		gradebook.Columns = append(gradebook.Columns, Column{
+			Assignment:   assignment,
+			ProblemID:    problem.ProblemID,
+			ProblemTitle: problem.Title,
+		})
+	}
+
+	rowIndex := make(map[pgtype.UUID]int)
+	for _, member := range members {
+		if member.Role != storage.GroupRoleMEMBER {
+			continue
+		}
+		rowIndex[member.ID] = len(gradebook.Rows)
+		gradebook.Rows = append(gradebook.Rows, Row{
+			UserID:   member.ID,
+			Username: member.Username,
+			Cells:    make([]Cell, len(gradebook.Columns)),
+		})
+	}
+
+	for _, submission := range submissions {
+		row, ok := rowIndex[submission.UserID]
+		if !ok {
+			continue
+		}
+		column, ok := columnIndex[columnKey{submission.AssignmentID, submission.ProblemID}]
+		if !ok {
+			continue
+		}
+
+		score, ok := Score(gradebook.Columns[column].Assignment, submission.CreatedAt.Time)
+		cell := &gradebook.Rows[row].Cells[column]
+		if !ok || (cell.Solved && score <= cell.Score) {
+			continue
+		}
+		*cell = Cell{
+			Solved: true,
+			Score:  score,
+			Late:   submission.CreatedAt.Time.After(gradebook.Columns[column].Assignment.ClosesAt.Time),
+		}
+	}
+
+	for i := range gradebook.Rows {
+		for _, cell := range gradebook.Rows[i].Cells {
+			gradebook.Rows[i].Total += cell.Score
+		}
+	}
+
+	return gradebook
+}
+
+// spreadsheetSafe keeps spreadsheets from running user chosen text as a formula
+func spreadsheetSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// WriteCSV writes a row per member with a column per problem, named "<assignment> / <problem>", and the total
+func (g Gradebook) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"username"}
+	for _, column := range g.Columns {
+		header = append(header, spreadsheetSafe(fmt.Sprintf("%s / %s", column.Assignment.Title, column.ProblemTitle)))
+	}
+	header = append(header, "total")
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("could not write header: %w", err)
+	}
+
+	for _, row := range g.Rows {
+		record := []string{spreadsheetSafe(row.Username)}
+		for _, cell := range row.Cells {
+			record = append(record, strconv.Itoa(cell.Score))
+		}
+		record = append(record, strconv.Itoa(row.Total))
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("could not write row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
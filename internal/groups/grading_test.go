@@ -0,0 +1,123 @@
+package groups
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+var (
+	opensAt  = time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	closesAt = opensAt.Add(7 * 24 * time.Hour)
+
+	alice = pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	bob   = pgtype.UUID{Bytes: [16]byte{2}, Valid: true}
+	owner = pgtype.UUID{Bytes: [16]byte{3}, Valid: true}
+)
+
+type GradingTestSuite struct {
+	suite.Suite
+	assignment storage.Assignment
+}
+
+func (s *GradingTestSuite) SetupTest() {
+	s.assignment = storage.Assignment{
+		ID:                 1,
+		Title:              "Week 1",
+		OpensAt:            pgtype.Timestamptz{Time: opensAt, Valid: true},
+		ClosesAt:           pgtype.Timestamptz{Time: closesAt, Valid: true},
+		LateUntil:          pgtype.Timestamptz{Time: closesAt.Add(3 * 24 * time.Hour), Valid: true},
+		LatePenaltyPercent: 20,
+	}
+}
+
+func (s *GradingTestSuite) TestScore() {
+	for _, tc := range []struct {
+		name        string
+		submittedAt time.Time
+		score       int
+		counts      bool
+	}{
+		{"before opening", opensAt.Add(-time.Minute), 0, false},
+		{"on time", opensAt.Add(time.Hour), 100, true},
+		{"at the deadline", closesAt, 100, true},
+		{"a minute late", closesAt.Add(time.Minute), 80, true},
+		{"a day late", closesAt.Add(24 * time.Hour), 80, true},
+		{"two days late", closesAt.Add(25 * time.Hour), 60, true},
+		{"after late submissions close", closesAt.Add(73 * time.Hour), 0, false},
+	} {
+		score, counts := Score(s.assignment, tc.submittedAt)
+		assert.Equal(s.T(), tc.counts, counts, tc.name)
+		assert.Equal(s.T(), tc.score, score, tc.name)
+	}
+}
+
+func (s *GradingTestSuite) TestScoreWithoutLateSubmissions() {
+	s.assignment.LateUntil = pgtype.Timestamptz{}
+
+	_, counts := Score(s.assignment, closesAt.Add(time.Second))
+	assert.False(s.T(), counts)
+}
+
+func (s *GradingTestSuite) TestPenaltyDoesNotGoNegative() {
+	s.assignment.LatePenaltyPercent = 60
+
+	score, counts := Score(s.assignment, closesAt.Add(50*time.Hour))
+	assert.True(s.T(), counts)
+	assert.Equal(s.T(), 0, score)
+}
+
+func (s *GradingTestSuite) TestStatus() {
+	assert.Equal(s.T(), StatusUpcoming, Status(s.assignment, opensAt.Add(-time.Second)))
+	assert.Equal(s.T(), StatusOpen, Status(s.assignment, closesAt))
+	assert.Equal(s.T(), StatusLate, Status(s.assignment, closesAt.Add(time.Hour)))
+	assert.Equal(s.T(), StatusClosed, Status(s.assignment, closesAt.Add(100*time.Hour)))
+
+	s.assignment.LateUntil = pgtype.Timestamptz{}
+	assert.Equal(s.T(), StatusClosed, Status(s.assignment, closesAt.Add(time.Hour)))
+}
+
+func (s *GradingTestSuite) TestBuildGradebook() {
+	at := func(t time.Time) pgtype.Timestamptz { return pgtype.Timestamptz{Time: t, Valid: true} }
+
+	gradebook := BuildGradebook(
+		[]storage.Assignment{s.assignment},
+		[]storage.ListGroupAssignmentProblemsRow{
+			{AssignmentID: 1, ProblemID: 10, Title: "Sum"},
+			{AssignmentID: 1, ProblemID: 11, Title: "Max"},
+		},
+		[]storage.ListGroupMembersRow{
+			{ID: owner, Username: "teacher", Role: storage.GroupRoleOWNER},
+			{ID: alice, Username: "alice", Role: storage.GroupRoleMEMBER},
+			{ID: bob, Username: "=bob", Role: storage.GroupRoleMEMBER},
+		},
+		[]storage.GetGroupAcceptedSubmissionsRow{
+			{UserID: alice, AssignmentID: 1, ProblemID: 10, CreatedAt: at(closesAt.Add(time.Hour))},
+			{UserID: alice, AssignmentID: 1, ProblemID: 10, CreatedAt: at(closesAt.Add(-time.Hour))},
+			{UserID: alice, AssignmentID: 1, ProblemID: 11, CreatedAt: at(closesAt.Add(30 * time.Hour))},
+			{UserID: owner, AssignmentID: 1, ProblemID: 11, CreatedAt: at(opensAt)},
+		},
+	)
+
+	require.Len(s.T(), gradebook.Columns, 2)
+	require.Len(s.T(), gradebook.Rows, 2)
+
+	assert.Equal(s.T(), []Cell{{Solved: true, Score: 100}, {Solved: true, Score: 60, Late: true}}, gradebook.Rows[0].Cells)
+	assert.Equal(s.T(), 160, gradebook.Rows[0].Total)
+	assert.Equal(s.T(), []Cell{{}, {}}, gradebook.Rows[1].Cells)
+
+	var buf bytes.Buffer
+	require.NoError(s.T(), gradebook.WriteCSV(&buf))
+	assert.Equal(s.T(), "username,Week 1 / Sum,Week 1 / Max,total\nalice,100,60,160\n'=bob,0,0,0\n", buf.String())
+}
+
+func TestGradingTestSuite(t *testing.T) {
+	suite.Run(t, new(GradingTestSuite))
+}
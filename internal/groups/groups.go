@@ -0,0 +1,277 @@
+package groups
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+const maxGroupNameLength = 100
+
+type groupsPageData struct {
+	Groups []storage.ListUserGroupsRow
+	// Code prefills the join form, invite links point to /groups?code=<invite code>
+	Code string
+}
+
+type groupPageData struct {
+	Group       storage.Group
+	Owner       bool
+	Assignments []assignmentView
+	// Members are only listed to owners
+	Members []storage.ListGroupMembersRow
+}
+
+type assignmentView struct {
+	storage.Assignment
+	Status AssignmentStatus
+}
+
+// ListGroups lists the groups of the user with forms to join and create groups
+func (h *DefaultHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	groups, err := h.querier.ListUserGroups(ctx, h.pool, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not list groups", "error", err)
+		templates.RenderError(ctx, w, "could not list groups", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	err = h.templates.Render(ctx, "groupspage", w, groupsPageData{Groups: groups, Code: r.URL.Query().Get("code")})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render groupspage", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, h.templates)
+		return
+	}
+}
+
+// CreateGroup creates a group owned by the user
+func (h *DefaultHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	name := strings.TrimSpace(r.PostFormValue("name"))
+	if name == "" || len(name) > maxGroupNameLength {
+		templates.RenderError(ctx, w, fmt.Sprintf("group name is required and can be at most %d characters", maxGroupNameLength),
+			http.StatusBadRequest, h.templates)
+		return
+	}
+
+	inviteCode, err := newInviteCode()
+	if err != nil {
+		slog.ErrorContext(ctx, "could not generate invite code", "error", err)
+		templates.RenderError(ctx, w, "could not create group", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not begin transaction", "error", err)
+		templates.RenderError(ctx, w, "could not create group", http.StatusInternalServerError, h.templates)
+		return
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	group, err := h.querier.CreateGroup(ctx, tx, storage.CreateGroupParams{
+		Name:        name,
+		Description: strings.TrimSpace(r.PostFormValue("description")),
+		InviteCode:  inviteCode,
+		CreatedBy:   user.ID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not create group", "error", err)
+		templates.RenderError(ctx, w, "could not create group", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	err = h.querier.AddGroupMember(ctx, tx, storage.AddGroupMemberParams{
+		GroupID: group.ID,
+		UserID:  user.ID,
+		Role:    storage.GroupRoleOWNER,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not add group owner", "error", err)
+		templates.RenderError(ctx, w, "could not create group", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "could not commit transaction", "error", err)
+		templates.RenderError(ctx, w, "could not create group", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/groups/%d", group.ID), http.StatusSeeOther)
+}
+
+// JoinGroup adds the user to the group of an invite code
+func (h *DefaultHandler) JoinGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	code := strings.ToUpper(strings.TrimSpace(r.PostFormValue("code")))
+	group, err := h.querier.GetGroupByInviteCode(ctx, h.pool, code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "invalid invite code", http.StatusNotFound, h.templates)
+			return
+		}
+		slog.ErrorContext(ctx, "could not get group by invite code", "error", err)
+		templates.RenderError(ctx, w, "could not join group", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	// joining twice keeps the current role
+	err = h.querier.AddGroupMember(ctx, h.pool, storage.AddGroupMemberParams{
+		GroupID: group.ID,
+		UserID:  user.ID,
+		Role:    storage.GroupRoleMEMBER,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not add group member", "error", err)
+		templates.RenderError(ctx, w, "could not join group", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/groups/%d", group.ID), http.StatusSeeOther)
+}
+
+// ViewGroup shows the assignments of a group, owners also see the members and manage the group
+func (h *DefaultHandler) ViewGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	group, role, ok := h.getGroup(w, r)
+	if !ok {
+		return
+	}
+
+	assignments, err := h.querier.ListGroupAssignments(ctx, h.pool, group.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not list assignments", "error", err, "group_id", group.ID)
+		templates.RenderError(ctx, w, "could not get assignments", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	now := time.Now()
+	data := groupPageData{Group: group, Owner: role == storage.GroupRoleOWNER}
+	for _, assignment := range assignments {
+		data.Assignments = append(data.Assignments, assignmentView{Assignment: assignment, Status: Status(assignment, now)})
+	}
+
+	if data.Owner {
+		data.Members, err = h.querier.ListGroupMembers(ctx, h.pool, group.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "could not list members", "error", err, "group_id", group.ID)
+			templates.RenderError(ctx, w, "could not get members", http.StatusInternalServerError, h.templates)
+			return
+		}
+	}
+
+	err = h.templates.Render(ctx, "grouppage", w, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render grouppage", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, h.templates)
+		return
+	}
+}
+
+// ResetInviteCode replaces the invite code of a group, the old one stops working
+func (h *DefaultHandler) ResetInviteCode(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	group, ok := h.getOwnedGroup(w, r)
+	if !ok {
+		return
+	}
+
+	inviteCode, err := newInviteCode()
+	if err != nil {
+		slog.ErrorContext(ctx, "could not generate invite code", "error", err)
+		templates.RenderError(ctx, w, "could not reset invite code", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	err = h.querier.SetGroupInviteCode(ctx, h.pool, group.ID, inviteCode)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not set invite code", "error", err, "group_id", group.ID)
+		templates.RenderError(ctx, w, "could not reset invite code", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/groups/%d", group.ID), http.StatusSeeOther)
+}
+
+// getGroup loads the group in the URL with the role of the user in it, the groups of others look missing
+func (h *DefaultHandler) getGroup(w http.ResponseWriter, r *http.Request) (storage.Group, storage.GroupRole, bool) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid group id", http.StatusBadRequest, h.templates)
+		return storage.Group{}, "", false
+	}
+
+	role, err := h.querier.GetGroupMemberRole(ctx, h.pool, int32(id), user.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "group not found", http.StatusNotFound, h.templates)
+			return storage.Group{}, "", false
+		}
+		slog.ErrorContext(ctx, "could not get group member role", "error", err, "group_id", id)
+		templates.RenderError(ctx, w, "could not get group", http.StatusInternalServerError, h.templates)
+		return storage.Group{}, "", false
+	}
+
+	group, err := h.querier.GetGroup(ctx, h.pool, int32(id))
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get group", "error", err, "group_id", id)
+		templates.RenderError(ctx, w, "could not get group", http.StatusInternalServerError, h.templates)
+		return storage.Group{}, "", false
+	}
+
+	return group, role, true
+}
+
+// getOwnedGroup is getGroup for the actions only owners of the group can take
+func (h *DefaultHandler) getOwnedGroup(w http.ResponseWriter, r *http.Request) (storage.Group, bool) {
+	group, role, ok := h.getGroup(w, r)
+	if !ok {
+		return storage.Group{}, false
+	}
+
+	if role != storage.GroupRoleOWNER {
+		templates.RenderError(r.Context(), w, "only owners of the group can do this", http.StatusForbidden, h.templates)
+		return storage.Group{}, false
+	}
+
+	return group, true
+}
+
+// newInviteCode returns 16 random characters that are easy to type
+func newInviteCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not read random bytes: %w", err)
+	}
+	return base32.StdEncoding.EncodeToString(b), nil
+}
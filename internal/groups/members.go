@@ -0,0 +1,164 @@
+package groups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+var (
+	errMemberNotFound = errors.New("member not found")
+	errLastOwner      = errors.New("a group needs an owner, make another member an owner first")
+)
+
+// LeaveGroup removes the user from a group
+func (h *DefaultHandler) LeaveGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	group, _, ok := h.getGroup(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.changeMembers(ctx, group.ID, func(tx pgx.Tx) (int64, error) {
+		return h.querier.RemoveGroupMember(ctx, tx, group.ID, user.ID)
+	})
+	if !h.handleMembersError(w, r, err) {
+		return
+	}
+
+	http.Redirect(w, r, "/groups", http.StatusSeeOther)
+}
+
+// SetMemberRole makes a member an owner of the group or a plain member again
+func (h *DefaultHandler) SetMemberRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	group, ok := h.getOwnedGroup(w, r)
+	if !ok {
+		return
+	}
+
+	memberID, ok := h.parseMemberID(w, r)
+	if !ok {
+		return
+	}
+
+	role := storage.GroupRole(r.PostFormValue("role"))
+	if role != storage.GroupRoleOWNER && role != storage.GroupRoleMEMBER {
+		templates.RenderError(ctx, w, "role must be OWNER or MEMBER", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	err := h.changeMembers(ctx, group.ID, func(tx pgx.Tx) (int64, error) {
+		return h.querier.SetGroupMemberRole(ctx, tx, storage.SetGroupMemberRoleParams{
+			GroupID: group.ID,
+			UserID:  memberID,
+			Role:    role,
+		})
+	})
+	if !h.handleMembersError(w, r, err) {
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/groups/%d", group.ID), http.StatusSeeOther)
+}
+
+// RemoveMember removes a member from the group, their submissions stay
+func (h *DefaultHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	group, ok := h.getOwnedGroup(w, r)
+	if !ok {
+		return
+	}
+
+	memberID, ok := h.parseMemberID(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.changeMembers(ctx, group.ID, func(tx pgx.Tx) (int64, error) {
+		return h.querier.RemoveGroupMember(ctx, tx, group.ID, memberID)
+	})
+	if !h.handleMembersError(w, r, err) {
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/groups/%d", group.ID), http.StatusSeeOther)
+}
+
+// changeMembers applies change to the members of a group unless it leaves the group without an owner
+func (h *DefaultHandler) changeMembers(ctx context.Context, groupID int32, change func(tx pgx.Tx) (int64, error)) error {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	// concurrent changes could otherwise each remove a different owner
+	if err := h.querier.LockGroupMembers(ctx, tx, groupID); err != nil {
+		return fmt.Errorf("could not lock group members: %w", err)
+	}
+
+	changed, err := change(tx)
+	if err != nil {
+		return fmt.Errorf("could not change group members: %w", err)
+	}
+	if changed == 0 {
+		return errMemberNotFound
+	}
+
+	owners, err := h.querier.CountGroupOwners(ctx, tx, groupID)
+	if err != nil {
+		return fmt.Errorf("could not count group owners: %w", err)
+	}
+	if owners == 0 {
+		return errLastOwner
+	}
+
+	return tx.Commit(ctx)
+}
+
+// handleMembersError renders the error of changeMembers, it returns true when there is none
+func (h *DefaultHandler) handleMembersError(w http.ResponseWriter, r *http.Request, err error) bool {
+	ctx := r.Context()
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errMemberNotFound):
+		templates.RenderError(ctx, w, err.Error(), http.StatusNotFound, h.templates)
+	case errors.Is(err, errLastOwner):
+		templates.RenderError(ctx, w, err.Error(), http.StatusBadRequest, h.templates)
+	default:
+		slog.ErrorContext(ctx, "could not change group members", "error", err)
+		templates.RenderError(ctx, w, "could not change group members", http.StatusInternalServerError, h.templates)
+	}
+	return false
+}
+
+func (h *DefaultHandler) parseMemberID(w http.ResponseWriter, r *http.Request) (pgtype.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		templates.RenderError(r.Context(), w, "invalid user id", http.StatusBadRequest, h.templates)
+		return pgtype.UUID{}, false
+	}
+	return pgtype.UUID{Bytes: id, Valid: true}, true
+}
@@ -0,0 +1,75 @@
+package groups
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/computer-technology-team/go-judge/internal/middleware"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// Handler defines the interface for group handlers
+type Handler interface {
+	ListGroups(w http.ResponseWriter, r *http.Request)
+	CreateGroup(w http.ResponseWriter, r *http.Request)
+	JoinGroup(w http.ResponseWriter, r *http.Request)
+	ViewGroup(w http.ResponseWriter, r *http.Request)
+	ResetInviteCode(w http.ResponseWriter, r *http.Request)
+
+	LeaveGroup(w http.ResponseWriter, r *http.Request)
+	SetMemberRole(w http.ResponseWriter, r *http.Request)
+	RemoveMember(w http.ResponseWriter, r *http.Request)
+
+	AssignmentForm(w http.ResponseWriter, r *http.Request)
+	CreateAssignment(w http.ResponseWriter, r *http.Request)
+	ViewAssignment(w http.ResponseWriter, r *http.Request)
+	UpdateAssignment(w http.ResponseWriter, r *http.Request)
+	DeleteAssignment(w http.ResponseWriter, r *http.Request)
+
+	ShowGradebook(w http.ResponseWriter, r *http.Request)
+	ExportGradebook(w http.ResponseWriter, r *http.Request)
+}
+
+// NewRoutes returns a function that registers routes with the given handler
+// This allows for dependency injection when setting up routes
+func NewRoutes(h Handler, sharedTmpls *templates.Templates) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Use(middleware.NewRequireAuthMiddleware(sharedTmpls))
+		r.Get("/", h.ListGroups)
+		r.With(middleware.NewRequirePermissionMiddleware(sharedTmpls, rbac.CreateGroups)).
+			Post("/", h.CreateGroup)
+		r.Post("/join", h.JoinGroup)
+		r.Get("/{id}", h.ViewGroup)
+		r.Post("/{id}/invite-code", h.ResetInviteCode)
+		r.Post("/{id}/leave", h.LeaveGroup)
+		r.Post("/{id}/members/{user_id}/role", h.SetMemberRole)
+		r.Post("/{id}/members/{user_id}/remove", h.RemoveMember)
+		r.Post("/{id}/assignments", h.CreateAssignment)
+		r.Get("/{id}/assignments/form/{assignment_id}", h.AssignmentForm)
+		r.Get("/{id}/assignments/{assignment_id}", h.ViewAssignment)
+		r.Post("/{id}/assignments/{assignment_id}", h.UpdateAssignment)
+		r.Post("/{id}/assignments/{assignment_id}/delete", h.DeleteAssignment)
+		r.Get("/{id}/gradebook", h.ShowGradebook)
+		r.Get("/{id}/gradebook.csv", h.ExportGradebook)
+	}
+}
+
+// DefaultHandler is the default implementation of the Handler interface
+type DefaultHandler struct {
+	templates *templates.Templates
+	pool      *pgxpool.Pool
+	querier   storage.Querier
+}
+
+// NewHandler creates a new instance of the default group handler
+func NewHandler(templates *templates.Templates, pool *pgxpool.Pool, querier storage.Querier) Handler {
+	return &DefaultHandler{
+		templates: templates,
+		pool:      pool,
+		querier:   querier,
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/computer-technology-team/go-judge/internal/auth/apitoken"
+	"github.com/computer-technology-team/go-judge/internal/auth/randomtoken"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// serveAPIToken serves a request made with a personal access token as the user of the token, limited to the
+// requests and permissions the scopes of the token allow
+func serveAPIToken(next http.Handler, w http.ResponseWriter, r *http.Request, token string, pool *pgxpool.Pool,
+	querier storage.Querier, tmpl *templates.Templates) {
+	ctx := r.Context()
+
+	apiToken, err := querier.UsePersonalAccessToken(ctx, pool, randomtoken.Hash(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "invalid token", http.StatusUnauthorized, tmpl)
+			return
+		}
+		slog.ErrorContext(ctx, "could not get personal access token", "error", err)
+		templates.RenderError(ctx, w, "could not check token", http.StatusInternalServerError, tmpl)
+		return
+	}
+
+	scope, ok := apitoken.RequiredScope(r.Method, r.URL.Path)
+	if !ok || !slices.Contains(apiToken.Scopes, string(scope)) {
+		templates.RenderError(ctx, w, "the token does not allow this request", http.StatusForbidden, tmpl)
+		return
+	}
+
+	user, err := querier.GetUser(ctx, pool, apiToken.UserID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get user of personal access token", "error", err)
+		templates.RenderError(ctx, w, "could not get user", http.StatusInternalServerError, tmpl)
+		return
+	}
+
+	permissions, err := querier.GetUserPermissions(ctx, pool, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get user permissions from database", "error", err)
+		templates.RenderError(ctx, w, "could not get user permissions", http.StatusInternalServerError, tmpl)
+		return
+	}
+
+	ctx = context.WithValue(ctx, internalcontext.UserContextKey, &user)
+	ctx = context.WithValue(ctx, internalcontext.PermissionsContextKey,
+		apitoken.Permissions(apiToken.Scopes, rbac.FromNames(permissions)))
+	ctx = context.WithValue(ctx, internalcontext.APITokenContextKey, &apiToken)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
@@ -2,42 +2,85 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/computer-technology-team/go-judge/internal/auth/apitoken"
 	authenticatorPkg "github.com/computer-technology-team/go-judge/internal/auth/authenticator"
+	"github.com/computer-technology-team/go-judge/internal/auth/session"
 	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
 	"github.com/computer-technology-team/go-judge/internal/storage"
 	"github.com/computer-technology-team/go-judge/web/templates"
 )
 
-func NewAuthMiddleWare(authenticator authenticatorPkg.Authenticator, pool *pgxpool.Pool, querier storage.Querier, tmpl *templates.Templates) func(http.Handler) http.Handler {
+// NewAuthMiddleWare authenticates the access token or personal access token of the Authorization header, or the
+// token cookie. Browsers with an expired token cookie are refreshed with their refresh token cookie.
+// Tokens of revoked or expired sessions are cleared and the request continues without a user.
+func NewAuthMiddleWare(authenticator authenticatorPkg.Authenticator, sessions *session.Manager, pool *pgxpool.Pool,
+	querier storage.Querier, tmpl *templates.Templates) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
 
-			authToken, err := r.Cookie(authenticatorPkg.TokenCookieKey)
+			var claims *authenticatorPkg.Claims
+			var err error
+			if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+				if apitoken.IsToken(bearer) {
+					serveAPIToken(next, w, r, bearer, pool, querier, tmpl)
+					return
+				}
+
+				claims, err = authenticator.VerifyDecodeToken(ctx, bearer)
+				if err != nil {
+					templates.RenderError(ctx, w, "invalid token", http.StatusUnauthorized, nil)
+					return
+				}
+			} else {
+				claims, err = cookieClaims(w, r, authenticator, sessions)
+				if err != nil {
+					slog.ErrorContext(ctx, "could not refresh token", "error", err)
+					templates.RenderError(ctx, w, "could not refresh token", http.StatusInternalServerError, tmpl)
+					return
+				}
+				if claims == nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			userUUID, err := uuid.Parse(claims.UserID)
 			if err != nil {
-				next.ServeHTTP(w, r)
+				slog.ErrorContext(ctx, "invalid user id in valid token",
+					slog.String("claims.user_id", claims.UserID))
+				templates.RenderError(ctx, w, "invalid token payload", http.StatusInternalServerError, nil)
 				return
 			}
 
-			claims, err := authenticator.VerifyDecodeToken(r.Context(), authToken.Value)
+			// tokens issued before sessions were recorded have no session id and are not accepted anymore
+			sessionUUID, err := uuid.Parse(claims.SessionID)
 			if err != nil {
-				templates.RenderError(r.Context(), w, "invalid token", http.StatusUnauthorized, nil)
+				ClearTokenCookies(w)
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			userUUID, err := uuid.Parse(claims.UserID)
+			currentSession, active, err := sessions.Active(ctx, pgtype.UUID{Valid: true, Bytes: sessionUUID})
 			if err != nil {
-				slog.ErrorContext(ctx, "invalid user id in valid token",
-					slog.String("token", authToken.Value),
-					slog.String("claims.user_id", claims.UserID))
-				templates.RenderError(ctx, w, "invalid token payload", http.StatusInternalServerError, nil)
+				slog.ErrorContext(ctx, "could not check session", "error", err, "session_id", claims.SessionID)
+				templates.RenderError(ctx, w, "could not check session", http.StatusInternalServerError, tmpl)
+				return
+			}
+			if !active || currentSession.UserID.Bytes != userUUID {
+				ClearTokenCookies(w)
+				next.ServeHTTP(w, r)
 				return
 			}
 
@@ -46,14 +89,94 @@ func NewAuthMiddleWare(authenticator authenticatorPkg.Authenticator, pool *pgxpo
 				slog.ErrorContext(ctx, "could not get user from database",
 					slog.String("claims.user_id", claims.UserID), "userUUID", userUUID)
 				templates.RenderError(ctx, w, "invalid token payload", http.StatusInternalServerError, tmpl)
+				return
+			}
+
+			permissions, err := querier.GetUserPermissions(ctx, pool, user.ID)
+			if err != nil {
+				slog.ErrorContext(ctx, "could not get user permissions from database",
+					slog.String("claims.user_id", claims.UserID), "error", err)
+				templates.RenderError(ctx, w, "could not get user permissions", http.StatusInternalServerError, tmpl)
+				return
 			}
 
 			ctx = context.WithValue(r.Context(), internalcontext.UserContextKey, &user)
+			ctx = context.WithValue(ctx, internalcontext.PermissionsContextKey, rbac.FromNames(permissions))
+			ctx = context.WithValue(ctx, internalcontext.SessionContextKey, &currentSession)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// cookieClaims returns the claims of the token cookie, the tokens are refreshed when it expired.
+// Claims are nil when the browser has no usable tokens, its cookies are cleared then.
+func cookieClaims(w http.ResponseWriter, r *http.Request, authenticator authenticatorPkg.Authenticator,
+	sessions *session.Manager) (*authenticatorPkg.Claims, error) {
+	ctx := r.Context()
+
+	if token, err := r.Cookie(authenticatorPkg.TokenCookieKey); err == nil {
+		if claims, err := authenticator.VerifyDecodeToken(ctx, token.Value); err == nil {
+			return claims, nil
+		}
+	}
+
+	refreshToken, err := r.Cookie(authenticatorPkg.RefreshTokenCookieKey)
+	if err != nil {
+		if _, err := r.Cookie(authenticatorPkg.TokenCookieKey); err == nil {
+			ClearTokenCookies(w)
+		}
+		return nil, nil
+	}
+
+	tokens, err := sessions.Refresh(ctx, refreshToken.Value)
+	if errors.Is(err, session.ErrInvalidRefreshToken) || errors.Is(err, session.ErrRefreshTokenReused) {
+		ClearTokenCookies(w)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	SetTokenCookies(w, tokens)
+	return tokens.Claims, nil
+}
+
+// SetTokenCookies stores the tokens of a session in the browser, the refresh token cookie is kept when tokens
+// have no refresh token
+func SetTokenCookies(w http.ResponseWriter, tokens session.Tokens) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     authenticatorPkg.TokenCookieKey,
+		Value:    tokens.AccessToken,
+		Path:     "/",
+		Expires:  tokens.Claims.ExpiresAt.Time,
+		HttpOnly: true,
+	})
+
+	if tokens.RefreshToken != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     authenticatorPkg.RefreshTokenCookieKey,
+			Value:    tokens.RefreshToken,
+			Path:     "/",
+			Expires:  tokens.ExpiresAt,
+			HttpOnly: true,
+		})
+	}
+}
+
+// ClearTokenCookies makes the browser drop the token and refresh token cookies
+func ClearTokenCookies(w http.ResponseWriter) {
+	for _, name := range []string{authenticatorPkg.TokenCookieKey, authenticatorPkg.RefreshTokenCookieKey} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			Expires:  time.Unix(0, 0),
+			MaxAge:   -1,
+			HttpOnly: true,
+		})
+	}
+}
+
 func NewRequireAuthMiddleware(tmpl *templates.Templates) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -66,16 +189,16 @@ func NewRequireAuthMiddleware(tmpl *templates.Templates) func(http.Handler) http
 	}
 }
 
-func NewRequireSuperUserMiddleware(tmpl *templates.Templates) func(http.Handler) http.Handler {
+// NewRequirePermissionMiddleware allows only users holding permission through one of their roles
+func NewRequirePermissionMiddleware(tmpl *templates.Templates, permission rbac.Permission) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
-			user, ok := internalcontext.GetUserFromContext(r.Context())
-			if !ok {
+			if _, ok := internalcontext.GetUserFromContext(ctx); !ok {
 				renderUnAuthenticated(ctx, tmpl, w)
 				return
 			}
-			if !user.Superuser {
+			if !internalcontext.HasPermission(ctx, permission) {
 				renderUnAuthorized(ctx, tmpl, w)
 				return
 			}
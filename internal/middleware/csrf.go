@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+const (
+	// CSRFCookieKey holds the secret the CSRF tokens of a browser are checked against
+	CSRFCookieKey = "csrf_token"
+	// CSRFFieldName is the form field of the CSRF token, scripts send it in the CSRFHeader instead
+	CSRFFieldName = "csrf_token"
+	CSRFHeader    = "X-CSRF-Token"
+
+	csrfSecretLength = 32
+	// maxCSRFPeekSize caps how much of a multipart body is read looking for the token
+	maxCSRFPeekSize = 64 << 10
+)
+
+// csrfExemptPaths take no cookies, their callers send their credentials in the request
+var csrfExemptPaths = map[string]bool{
+	"/auth/refresh": true,
+}
+
+// NewCSRFMiddleware protects state-changing requests with double-submit tokens: every browser gets a random secret
+// in a cookie and its forms send a masked copy of it, which other sites can not read. The token for the forms of a
+// page is put in the context for templates.
+// Requests with a bearer token are exempt, browsers never send one on their own and cookies are ignored with it.
+func NewCSRFMiddleware(tmpl *templates.Templates) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			secret, ok := csrfSecret(r)
+			if !ok {
+				secret = make([]byte, csrfSecretLength)
+				if _, err := rand.Read(secret); err != nil {
+					slog.ErrorContext(ctx, "could not generate csrf secret", "error", err)
+					templates.RenderError(ctx, w, "could not create csrf token", http.StatusInternalServerError, tmpl)
+					return
+				}
+				http.SetCookie(w, &http.Cookie{
+					Name:     CSRFCookieKey,
+					Value:    base64.RawURLEncoding.EncodeToString(secret),
+					Path:     "/",
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+
+			if requiresCSRFToken(r) && !validCSRFToken(requestCSRFToken(r), secret) {
+				templates.RenderError(ctx, w, "the form expired, reload the page and try again", http.StatusForbidden,
+					tmpl)
+				return
+			}
+
+			token, err := maskCSRFToken(secret)
+			if err != nil {
+				slog.ErrorContext(ctx, "could not mask csrf token", "error", err)
+				templates.RenderError(ctx, w, "could not create csrf token", http.StatusInternalServerError, tmpl)
+				return
+			}
+
+			ctx = context.WithValue(ctx, internalcontext.CSRFTokenContextKey, token)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func requiresCSRFToken(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	return !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") && !csrfExemptPaths[r.URL.Path]
+}
+
+// csrfSecret returns the secret of the cookie, false when the browser has none yet
+func csrfSecret(r *http.Request) ([]byte, bool) {
+	cookie, err := r.Cookie(CSRFCookieKey)
+	if err != nil {
+		return nil, false
+	}
+
+	secret, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(secret) != csrfSecretLength {
+		return nil, false
+	}
+	return secret, true
+}
+
+// requestCSRFToken returns the token of the header or of the form
+func requestCSRFToken(r *http.Request) string {
+	if token := r.Header.Get(CSRFHeader); token != "" {
+		return token
+	}
+
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err == nil && mediaType == "multipart/form-data" {
+		return peekMultipartCSRFToken(r, params["boundary"])
+	}
+	return r.PostFormValue(CSRFFieldName)
+}
+
+// peekMultipartCSRFToken reads the token from the first part of a multipart body, forms put it first. The rest is
+// left to the handler, which parses uploads with its own size limits, and the peeked bytes are put back for it.
+func peekMultipartCSRFToken(r *http.Request, boundary string) string {
+	var peeked bytes.Buffer
+	body := r.Body
+	defer func() {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(&peeked, body), body}
+	}()
+
+	reader := multipart.NewReader(io.TeeReader(io.LimitReader(body, maxCSRFPeekSize), &peeked), boundary)
+	part, err := reader.NextPart()
+	if err != nil || part.FormName() != CSRFFieldName {
+		return ""
+	}
+
+	token, err := io.ReadAll(io.LimitReader(part, 256))
+	if err != nil {
+		return ""
+	}
+	return string(token)
+}
+
+// maskCSRFToken returns the secret XORed with a random pad, after the pad. Pages get a different token each time,
+// so compressed responses do not leak the secret.
+func maskCSRFToken(secret []byte) (string, error) {
+	token := make([]byte, 2*len(secret))
+	pad := token[:len(secret)]
+	if _, err := rand.Read(pad); err != nil {
+		return "", err
+	}
+	for i := range secret {
+		token[len(secret)+i] = pad[i] ^ secret[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// validCSRFToken reports whether the masked token is one of secret
+func validCSRFToken(token string, secret []byte) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != 2*len(secret) {
+		return false
+	}
+
+	unmasked := make([]byte, len(secret))
+	for i := range secret {
+		unmasked[i] = raw[i] ^ raw[len(secret)+i]
+	}
+	return subtle.ConstantTimeCompare(unmasked, secret) == 1
+}
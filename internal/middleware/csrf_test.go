@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+type CSRFTestSuite struct {
+	suite.Suite
+	handler http.Handler
+	// token and file are what the last request that reached the handler carried
+	token string
+	file  string
+}
+
+func (s *CSRFTestSuite) SetupTest() {
+	tmpl, err := templates.GetSharedTemplates()
+	require.NoError(s.T(), err)
+
+	s.token, s.file = "", ""
+	s.handler = NewCSRFMiddleware(tmpl)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.token = internalcontext.GetCSRFTokenFromContext(r.Context())
+		if err := r.ParseMultipartForm(1 << 20); err == nil {
+			s.file = r.PostForm.Get("file")
+		}
+	}))
+}
+
+// page gets a page as a new browser, returning its cookie and the token of its forms
+func (s *CSRFTestSuite) page() (*http.Cookie, string) {
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+
+	cookies := rec.Result().Cookies()
+	require.Len(s.T(), cookies, 1)
+	require.NotEmpty(s.T(), s.token)
+	return cookies[0], s.token
+}
+
+func (s *CSRFTestSuite) post(cookie *http.Cookie, contentType string, body string,
+	header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/problems", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	for key, values := range header {
+		req.Header.Set(key, values[0])
+	}
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *CSRFTestSuite) TestForm() {
+	cookie, token := s.page()
+	form := "application/x-www-form-urlencoded"
+
+	rec := s.post(cookie, form, url.Values{CSRFFieldName: {token}}.Encode(), nil)
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.NotEqual(s.T(), token, s.token, "every page gets a new token")
+
+	rec = s.post(cookie, form, "", http.Header{CSRFHeader: {token}})
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+
+	rec = s.post(cookie, form, "", nil)
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code)
+
+	other, _ := s.page()
+	rec = s.post(other, form, url.Values{CSRFFieldName: {token}}.Encode(), nil)
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code, "tokens only work with their own cookie")
+
+	rec = s.post(nil, form, url.Values{CSRFFieldName: {token}}.Encode(), nil)
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code)
+}
+
+func (s *CSRFTestSuite) TestMultipart() {
+	cookie, token := s.page()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	require.NoError(s.T(), writer.WriteField(CSRFFieldName, token))
+	require.NoError(s.T(), writer.WriteField("file", strings.Repeat("x", 10<<10)))
+	require.NoError(s.T(), writer.Close())
+
+	rec := s.post(cookie, writer.FormDataContentType(), body.String(), nil)
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Equal(s.T(), strings.Repeat("x", 10<<10), s.file, "the handler reads the whole body")
+
+	body.Reset()
+	writer = multipart.NewWriter(&body)
+	require.NoError(s.T(), writer.WriteField("file", "x"))
+	require.NoError(s.T(), writer.WriteField(CSRFFieldName, token))
+	require.NoError(s.T(), writer.Close())
+
+	rec = s.post(cookie, writer.FormDataContentType(), body.String(), nil)
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code, "the token must come first")
+}
+
+func (s *CSRFTestSuite) TestExempt() {
+	rec := s.post(nil, "application/json", "{}", http.Header{"Authorization": {"Bearer gjp_token"}})
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+
+	rec = s.post(nil, "application/json", "{}", http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}})
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code)
+}
+
+func TestCSRFTestSuite(t *testing.T) {
+	suite.Run(t, new(CSRFTestSuite))
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/computer-technology-team/go-judge/config"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/ratelimit"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// NewSubmissionRateLimitMiddleware limits requests per user and per client address.
+// Users with the unlimited submissions permission are not limited, users with an override set by an admin use it instead of the per user limit.
+func NewSubmissionRateLimitMiddleware(cfg config.RateLimitsConfig, tmpl *templates.Templates) func(http.Handler) http.Handler {
+	userLimiter := ratelimit.New(cfg.Submission)
+	ipLimiter := ratelimit.New(cfg.SubmissionPerIP)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			user, ok := internalcontext.GetUserFromContext(ctx)
+			if !ok {
+				renderUnAuthenticated(ctx, tmpl, w)
+				return
+			}
+			if internalcontext.HasPermission(ctx, rbac.UnlimitedSubmissions) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userLimit := cfg.Submission
+			if user.SubmissionRatePerMinute.Valid {
+				userLimit = config.RateLimitConfig{
+					PerMinute: user.SubmissionRatePerMinute.Float64,
+					Burst:     int(user.SubmissionRateBurst.Int32),
+				}
+			}
+
+			// a request is only counted when both limits allow it, users sharing an address do not
+			// use up its tokens with requests their own limit rejects
+			allowed, retryAfter, cancel := userLimiter.ReserveWith(user.ID.String(), userLimit)
+			if allowed {
+				allowed, retryAfter = ipLimiter.Allow(ClientIP(r))
+				if !allowed {
+					cancel()
+				}
+			}
+
+			if !allowed {
+				seconds := int(math.Ceil(retryAfter.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				templates.RenderError(ctx, w, fmt.Sprintf("You are submitting too fast, try again in %s.",
+					time.Duration(seconds)*time.Second), http.StatusTooManyRequests, tmpl)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// NewRealIPMiddleware replaces the RemoteAddr of requests coming from a trusted proxy with the client address the
+// proxy forwarded in X-Forwarded-For or X-Real-IP. Headers of any other request are ignored, so clients can not pick
+// the address that rate limits and login lockouts count.
+// Trusted proxies are addresses or CIDR ranges, none are trusted by default.
+func NewRealIPMiddleware(trustedProxies []string) (func(http.Handler) http.Handler, error) {
+	prefixes := make([]netip.Prefix, 0, len(trustedProxies))
+	for _, proxy := range trustedProxies {
+		prefix, err := parseProxy(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	trusted := func(addr netip.Addr) bool {
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, err := netip.ParseAddr(ClientIP(r))
+			if err == nil && trusted(peer.Unmap()) {
+				if client, ok := forwardedClient(r.Header, trusted); ok {
+					r.RemoteAddr = client.String()
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func parseProxy(proxy string) (netip.Prefix, error) {
+	if strings.Contains(proxy, "/") {
+		prefix, err := netip.ParsePrefix(proxy)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(proxy)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
+
+// forwardedClient finds the client in the headers set by trusted proxies. X-Forwarded-For is read from the right,
+// every proxy appends the address it got the request from, so the first untrusted one is the client. What is left
+// of it was sent by the client and can not be trusted.
+func forwardedClient(header http.Header, trusted func(netip.Addr) bool) (netip.Addr, bool) {
+	if forwarded := header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+
+		var client netip.Addr
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			client = addr.Unmap()
+			if !trusted(client) {
+				break
+			}
+		}
+		return client, client.IsValid()
+	}
+
+	if realIP := header.Get("X-Real-IP"); realIP != "" {
+		addr, err := netip.ParseAddr(strings.TrimSpace(realIP))
+		return addr.Unmap(), err == nil
+	}
+
+	return netip.Addr{}, false
+}
+
+// ClientIP is the address of the client without the port. It is only forwarded by a proxy when the proxy is trusted,
+// see NewRealIPMiddleware.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
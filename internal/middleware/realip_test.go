@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type RealIPTestSuite struct {
+	suite.Suite
+	handler http.Handler
+	// clientIP is what the last request that reached the handler came from
+	clientIP string
+}
+
+func (s *RealIPTestSuite) SetupTest() {
+	realIP, err := NewRealIPMiddleware([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(s.T(), err)
+
+	s.clientIP = ""
+	s.handler = realIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.clientIP = ClientIP(r)
+	}))
+}
+
+func (s *RealIPTestSuite) serve(remoteAddr string, header http.Header) string {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	s.handler.ServeHTTP(httptest.NewRecorder(), req)
+	return s.clientIP
+}
+
+func (s *RealIPTestSuite) TestClientIP() {
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		expected   string
+	}{
+		{"no proxy", "203.0.113.7:4000", nil, "203.0.113.7"},
+		{"untrusted peer", "203.0.113.7:4000", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:4000", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "198.51.100.1"},
+		{"trusted address", "192.168.1.1:4000", http.Header{"X-Real-IP": {"198.51.100.1"}}, "198.51.100.1"},
+		{"spoofed hops", "10.1.2.3:4000", http.Header{"X-Forwarded-For": {"1.1.1.1, 198.51.100.1, 10.0.0.5"}},
+			"198.51.100.1"},
+		{"several headers", "10.1.2.3:4000", http.Header{"X-Forwarded-For": {"1.1.1.1", "198.51.100.1"}},
+			"198.51.100.1"},
+		{"invalid header", "10.1.2.3:4000", http.Header{"X-Forwarded-For": {"unknown"}}, "10.1.2.3"},
+		{"no header", "10.1.2.3:4000", nil, "10.1.2.3"},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			assert.Equal(s.T(), tc.expected, s.serve(tc.remoteAddr, tc.header))
+		})
+	}
+}
+
+func (s *RealIPTestSuite) TestInvalidProxy() {
+	_, err := NewRealIPMiddleware([]string{"10.0.0.0/33"})
+	assert.Error(s.T(), err)
+
+	_, err = NewRealIPMiddleware([]string{"proxy.local"})
+	assert.Error(s.T(), err)
+}
+
+func TestRealIPTestSuite(t *testing.T) {
+	suite.Run(t, new(RealIPTestSuite))
+}
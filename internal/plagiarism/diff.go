@@ -0,0 +1,59 @@
+package plagiarism
+
+import (
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Line is one side of a row of a side-by-side diff, Number is zero where the side has no line
+type Line struct {
+	Number  int
+	Text    string
+	Matched bool
+}
+
+// Row is a row of a side-by-side diff
+type Row struct {
+	Left    Line
+	Right   Line
+	Changed bool
+}
+
+// SideBySide aligns the lines of two solutions, lines covered by shared fingerprints are marked as matched
+// since renamed copies rarely have equal lines
+func SideBySide(a, b string) []Row {
+	linesA := strings.Split(strings.TrimRight(a, "\n"), "\n")
+	linesB := strings.Split(strings.TrimRight(b, "\n"), "\n")
+	matchedA, matchedB := MatchedLines(Fingerprints(a), Fingerprints(b))
+
+	left := func(i int) Line {
+		return Line{Number: i + 1, Text: linesA[i], Matched: matchedA[i+1]}
+	}
+	right := func(j int) Line {
+		return Line{Number: j + 1, Text: linesB[j], Matched: matchedB[j+1]}
+	}
+
+	var rows []Row
+	for _, op := range difflib.NewMatcher(linesA, linesB).GetOpCodes() {
+		if op.Tag == 'e' {
+			for k := range op.I2 - op.I1 {
+				rows = append(rows, Row{Left: left(op.I1 + k), Right: right(op.J1 + k)})
+			}
+			continue
+		}
+
+		for k := range max(op.I2-op.I1, op.J2-op.J1) {
+			row := Row{Changed: true}
+			if op.I1+k < op.I2 {
+				row.Left = left(op.I1 + k)
+			}
+			if op.J1+k < op.J2 {
+				row.Right = right(op.J1 + k)
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
@@ -0,0 +1,209 @@
+package plagiarism
+
+import (
+	"cmp"
+	"go/scanner"
+	"go/token"
+	"hash/fnv"
+	"slices"
+)
+
+const (
+	// kgramSize is the number of tokens hashed together, shorter matches are noise
+	kgramSize = 5
+	// windowSize is the winnowing window, any match of windowSize+kgramSize-1 tokens is guaranteed to be found
+	windowSize = 4
+)
+
+// Fingerprint is a selected k-gram hash with the source lines it covers
+type Fingerprint struct {
+	Hash      uint64
+	StartLine int
+	EndLine   int
+}
+
+type normalizedToken struct {
+	tok  token.Token
+	line int
+}
+
+// Fingerprints tokenizes Go source and winnows the hashes of its k-grams, as done by MOSS.
+// Identifiers and literal values are replaced by their kind and comments are dropped, so renaming and reformatting
+// do not change the result. The package clause and imports are skipped since every solution shares them.
+// Source that does not scan is fingerprinted up to the errors.
+func Fingerprints(src string) []Fingerprint {
+	tokens := tokenize(src)
+	if len(tokens) < kgramSize {
+		return nil
+	}
+
+	hashes := make([]uint64, len(tokens)-kgramSize+1)
+	for i := range hashes {
+		h := fnv.New64a()
+		for _, t := range tokens[i : i+kgramSize] {
+			_, _ = h.Write([]byte{byte(t.tok)})
+		}
+		hashes[i] = h.Sum64()
+	}
+
+	fingerprint := func(i int) Fingerprint {
+		return Fingerprint{Hash: hashes[i], StartLine: tokens[i].line, EndLine: tokens[i+kgramSize-1].line}
+	}
+
+	if len(hashes) < windowSize {
+		return []Fingerprint{fingerprint(minIndex(hashes, 0, len(hashes)))}
+	}
+
+	var fingerprints []Fingerprint
+	selected := -1
+	for start := 0; start+windowSize <= len(hashes); start++ {
+		if i := minIndex(hashes, start, start+windowSize); i != selected {
+			selected = i
+			fingerprints = append(fingerprints, fingerprint(i))
+		}
+	}
+
+	return fingerprints
+}
+
+// minIndex returns the rightmost minimum of hashes[from:to]
+func minIndex(hashes []uint64, from, to int) int {
+	index := from
+	for i := from + 1; i < to; i++ {
+		if hashes[i] <= hashes[index] {
+			index = i
+		}
+	}
+	return index
+}
+
+func tokenize(src string) []normalizedToken {
+	fset := token.NewFileSet()
+	file := fset.AddFile("solution.go", -1, len(src))
+
+	var s scanner.Scanner
+	s.Init(file, []byte(src), nil, 0)
+
+	var tokens []normalizedToken
+	for {
+		pos, tok, _ := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON || tok == token.ILLEGAL {
+			continue
+		}
+		// only the kind is kept, the names of identifiers and the values of literals are dropped
+		tokens = append(tokens, normalizedToken{tok: tok, line: file.Line(pos)})
+	}
+
+	return skipHeader(tokens)
+}
+
+// skipHeader drops the package clause and the import declarations
+func skipHeader(tokens []normalizedToken) []normalizedToken {
+	i := 0
+	if i < len(tokens) && tokens[i].tok == token.PACKAGE {
+		i += 2
+	}
+	for i < len(tokens) && tokens[i].tok == token.IMPORT {
+		i++
+		if i < len(tokens) && tokens[i].tok == token.LPAREN {
+			for i < len(tokens) && tokens[i].tok != token.RPAREN {
+				i++
+			}
+			i++
+			continue
+		}
+		for i < len(tokens) && tokens[i].tok != token.STRING {
+			i++
+		}
+		i++
+	}
+
+	return tokens[min(i, len(tokens)):]
+}
+
+// Similarity is the share of the fingerprints of the smaller solution that also appear in the other one,
+// so padding a copied solution with extra code does not hide it
+func Similarity(a, b []Fingerprint) float64 {
+	score, _ := compare(hashSet(a), hashSet(b))
+	return score
+}
+
+// compare returns the similarity and the number of shared fingerprints
+func compare(a, b map[uint64]struct{}) (float64, int) {
+	smaller := min(len(a), len(b))
+	if smaller == 0 {
+		return 0, 0
+	}
+
+	shared := 0
+	for h := range a {
+		if _, ok := b[h]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(smaller), shared
+}
+
+func hashSet(fingerprints []Fingerprint) map[uint64]struct{} {
+	set := make(map[uint64]struct{}, len(fingerprints))
+	for _, f := range fingerprints {
+		set[f.Hash] = struct{}{}
+	}
+	return set
+}
+
+// Pair is a suspicious pair of solutions, A and B are indexes into the compared solutions
+type Pair struct {
+	A      int
+	B      int
+	Score  float64
+	Shared int
+}
+
+// Rank compares every pair of solutions and returns the pairs with a similarity of at least minScore,
+// the most similar first
+func Rank(solutions [][]Fingerprint, minScore float64) []Pair {
+	sets := make([]map[uint64]struct{}, len(solutions))
+	for i, fingerprints := range solutions {
+		sets[i] = hashSet(fingerprints)
+	}
+
+	var pairs []Pair
+	for a := range sets {
+		for b := a + 1; b < len(sets); b++ {
+			score, shared := compare(sets[a], sets[b])
+			if shared > 0 && score >= minScore {
+				pairs = append(pairs, Pair{A: a, B: b, Score: score, Shared: shared})
+			}
+		}
+	}
+
+	slices.SortStableFunc(pairs, func(x, y Pair) int {
+		return cmp.Or(cmp.Compare(y.Score, x.Score), cmp.Compare(y.Shared, x.Shared))
+	})
+
+	return pairs
+}
+
+// MatchedLines returns the lines of each solution covered by fingerprints the other one shares
+func MatchedLines(a, b []Fingerprint) (map[int]bool, map[int]bool) {
+	hashesA := hashSet(a)
+	hashesB := hashSet(b)
+	return coveredLines(a, hashesB), coveredLines(b, hashesA)
+}
+
+func coveredLines(fingerprints []Fingerprint, other map[uint64]struct{}) map[int]bool {
+	lines := make(map[int]bool)
+	for _, f := range fingerprints {
+		if _, ok := other[f.Hash]; !ok {
+			continue
+		}
+		for line := f.StartLine; line <= f.EndLine; line++ {
+			lines[line] = true
+		}
+	}
+	return lines
+}
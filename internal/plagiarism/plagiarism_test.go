@@ -0,0 +1,135 @@
+package plagiarism
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const original = `package main
+
+import "fmt"
+
+func main() {
+	var n int
+	fmt.Scan(&n)
+	sum := 0
+	for i := 1; i <= n; i++ {
+		if i%3 == 0 || i%5 == 0 {
+			sum += i
+		}
+	}
+	fmt.Println(sum)
+}
+`
+
+// renamed is original with other names, literals, comments and formatting
+const renamed = `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// solve the problem
+func main() {
+	var count int
+	fmt.Scan(&count)
+	total := 7 /* start */
+	for j := 2; j <= count; j++ { if j%4 == 1 || j%6 == 2 { total += j } }
+	fmt.Println(total)
+	_ = os.Args
+}
+`
+
+const different = `package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	reader := bufio.NewReader(os.Stdin)
+	line, _ := reader.ReadString('\n')
+	words := strings.Fields(line)
+	seen := map[string]bool{}
+	for _, w := range words {
+		seen[w] = true
+	}
+	fmt.Println(len(seen))
+}
+`
+
+type PlagiarismTestSuite struct {
+	suite.Suite
+}
+
+func (s *PlagiarismTestSuite) TestRenamedCopyIsIdentical() {
+	assert.Equal(s.T(), 1.0, Similarity(Fingerprints(original), Fingerprints(renamed)))
+}
+
+func (s *PlagiarismTestSuite) TestDifferentSolutions() {
+	assert.Less(s.T(), Similarity(Fingerprints(original), Fingerprints(different)), 0.3)
+}
+
+func (s *PlagiarismTestSuite) TestHeaderIsSkipped() {
+	header := "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nimport s \"strings\"\n"
+	assert.Empty(s.T(), Fingerprints(header))
+	assert.Equal(s.T(), 1.0, Similarity(Fingerprints(original), Fingerprints(header+original[len("package main\n"):])))
+	assert.Len(s.T(), Fingerprints(header+original[len("package main\n"):]), len(Fingerprints(original)))
+}
+
+func (s *PlagiarismTestSuite) TestPaddingDoesNotHideCopy() {
+	padded := original + `
+func unused(values []int) int {
+	best := values[0]
+	for _, v := range values {
+		best = max(best, v)
+	}
+	return best
+}
+`
+	assert.Equal(s.T(), 1.0, Similarity(Fingerprints(original), Fingerprints(padded)))
+}
+
+func (s *PlagiarismTestSuite) TestRank() {
+	pairs := Rank([][]Fingerprint{
+		Fingerprints(original),
+		Fingerprints(different),
+		Fingerprints(renamed),
+		nil,
+	}, 0.5)
+
+	require.Len(s.T(), pairs, 1)
+	assert.Equal(s.T(), 0, pairs[0].A)
+	assert.Equal(s.T(), 2, pairs[0].B)
+	assert.Equal(s.T(), 1.0, pairs[0].Score)
+}
+
+func (s *PlagiarismTestSuite) TestSideBySide() {
+	rows := SideBySide(original, renamed)
+
+	assert.Equal(s.T(), Line{Number: 1, Text: "package main"}, rows[0].Left)
+	assert.False(s.T(), rows[0].Changed)
+
+	var matchedLeft, matchedRight int
+	for _, row := range rows {
+		if row.Left.Matched {
+			matchedLeft++
+		}
+		if row.Right.Matched {
+			matchedRight++
+		}
+	}
+	assert.Equal(s.T(), 10, matchedLeft)
+	assert.Equal(s.T(), 6, matchedRight)
+}
+
+func TestPlagiarismTestSuite(t *testing.T) {
+	suite.Run(t, new(PlagiarismTestSuite))
+}
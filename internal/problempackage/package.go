@@ -0,0 +1,320 @@
+package problempackage
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ManifestFileName = "problem.yaml"
+
+	// MaxPackageSize is the largest package archive accepted for import
+	MaxPackageSize = 64 << 20 // 64MB
+	// maxEntrySize caps a single uncompressed file inside the archive
+	maxEntrySize = 16 << 20 // 16MB
+	// maxTotalSize caps the sum of all files the manifest references, counting a file once per reference
+	maxTotalSize = 128 << 20 // 128MB
+	// maxTests caps the number of samples and tests in a single package
+	maxTests = 500
+)
+
+var (
+	ErrMissingManifest = errors.New("package has no " + ManifestFileName)
+	ErrInvalidPackage  = errors.New("invalid problem package")
+)
+
+// Manifest is the on-disk description of a problem package.
+// All file references are slash separated paths relative to the archive root.
+type Manifest struct {
+	Title         string   `yaml:"title"`
+	Statement     string   `yaml:"statement"`
+	TimeLimitMs   int64    `yaml:"time_limit_ms"`
+	MemoryLimitKb int64    `yaml:"memory_limit_kb"`
+	Tags          []string `yaml:"tags,omitempty"`
+	// Checker is only read to reject it, the runner compares outputs itself and never runs a checker
+	Checker string         `yaml:"checker,omitempty"`
+	Samples []ManifestTest `yaml:"samples"`
+	Tests   []ManifestTest `yaml:"tests"`
+}
+
+type ManifestTest struct {
+	Input  string `yaml:"input"`
+	Output string `yaml:"output"`
+	// Explanation is an optional Markdown file, only used for samples
+	Explanation string `yaml:"explanation,omitempty"`
+}
+
+type Test struct {
+	Input       string
+	Output      string
+	Explanation string
+}
+
+// Package is a fully loaded problem package with all referenced files resolved.
+type Package struct {
+	Title         string
+	Statement     string
+	TimeLimitMs   int64
+	MemoryLimitKb int64
+	Tags          []string
+	Samples       []Test
+	Tests         []Test
+}
+
+// Validate checks that the package can be stored as a problem
+func (p *Package) Validate() error {
+	var errs error
+
+	if strings.TrimSpace(p.Title) == "" {
+		errs = errors.Join(errs, errors.New("title is required"))
+	}
+	if strings.TrimSpace(p.Statement) == "" {
+		errs = errors.Join(errs, errors.New("statement is required"))
+	}
+	if p.TimeLimitMs <= 0 {
+		errs = errors.Join(errs, errors.New("time limit must be positive"))
+	}
+	if p.MemoryLimitKb <= 0 {
+		errs = errors.Join(errs, errors.New("memory limit must be positive"))
+	}
+	if len(p.Samples) == 0 {
+		errs = errors.Join(errs, errors.New("at least one sample is required"))
+	}
+	if len(p.Tests) == 0 {
+		errs = errors.Join(errs, errors.New("at least one test is required"))
+	}
+	for _, tag := range p.Tags {
+		if strings.TrimSpace(tag) == "" || len(tag) > 64 {
+			errs = errors.Join(errs, fmt.Errorf("invalid tag %q", tag))
+		}
+	}
+
+	if errs != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPackage, errs)
+	}
+	return nil
+}
+
+// Read parses a zip archive into a Package and validates it
+func Read(r io.ReaderAt, size int64) (*Package, error) {
+	if size > MaxPackageSize {
+		return nil, fmt.Errorf("%w: archive is larger than %d bytes", ErrInvalidPackage, MaxPackageSize)
+	}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not open zip: %w", ErrInvalidPackage, err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		files[path.Clean(f.Name)] = f
+	}
+
+	// every file is decompressed once, but each reference is stored again and so counts toward the total
+	contents := make(map[string]string)
+	var totalSize int64
+	readFile := func(name string) (string, error) {
+		name = path.Clean(name)
+		content, ok := contents[name]
+		if !ok {
+			f, ok := files[name]
+			if !ok {
+				return "", fmt.Errorf("%w: file %q referenced in manifest not found", ErrInvalidPackage, name)
+			}
+			var err error
+			if content, err = readEntry(f); err != nil {
+				return "", err
+			}
+			contents[name] = content
+		}
+
+		totalSize += int64(len(content))
+		if totalSize > maxTotalSize {
+			return "", fmt.Errorf("%w: referenced files are larger than %d bytes in total", ErrInvalidPackage,
+				maxTotalSize)
+		}
+		return content, nil
+	}
+
+	manifestFile, ok := files[ManifestFileName]
+	if !ok {
+		return nil, ErrMissingManifest
+	}
+
+	rawManifest, err := readEntry(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal([]byte(rawManifest), &manifest); err != nil {
+		return nil, fmt.Errorf("%w: could not parse manifest: %w", ErrInvalidPackage, err)
+	}
+
+	pkg := &Package{
+		Title:         manifest.Title,
+		TimeLimitMs:   manifest.TimeLimitMs,
+		MemoryLimitKb: manifest.MemoryLimitKb,
+		Tags:          manifest.Tags,
+	}
+
+	if manifest.Statement == "" {
+		return nil, fmt.Errorf("%w: manifest has no statement file", ErrInvalidPackage)
+	}
+	pkg.Statement, err = readFile(manifest.Statement)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Checker != "" {
+		return nil, fmt.Errorf("%w: custom checkers are not supported, outputs are compared after trimming "+
+			"surrounding whitespace", ErrInvalidPackage)
+	}
+
+	if tests := len(manifest.Samples) + len(manifest.Tests); tests > maxTests {
+		return nil, fmt.Errorf("%w: package has %d samples and tests, at most %d are allowed", ErrInvalidPackage,
+			tests, maxTests)
+	}
+
+	pkg.Samples, err = readTests(manifest.Samples, readFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg.Tests, err = readTests(manifest.Tests, readFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pkg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return pkg, nil
+}
+
+func readTests(entries []ManifestTest, readFile func(string) (string, error)) ([]Test, error) {
+	tests := make([]Test, 0, len(entries))
+	for _, entry := range entries {
+		input, err := readFile(entry.Input)
+		if err != nil {
+			return nil, err
+		}
+		output, err := readFile(entry.Output)
+		if err != nil {
+			return nil, err
+		}
+		var explanation string
+		if entry.Explanation != "" {
+			explanation, err = readFile(entry.Explanation)
+			if err != nil {
+				return nil, err
+			}
+		}
+		tests = append(tests, Test{Input: input, Output: output, Explanation: explanation})
+	}
+	return tests, nil
+}
+
+func readEntry(f *zip.File) (string, error) {
+	if f.UncompressedSize64 > maxEntrySize {
+		return "", fmt.Errorf("%w: file %q is larger than %d bytes", ErrInvalidPackage, f.Name, maxEntrySize)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return "", fmt.Errorf("could not open %q: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	// the header size can lie, so the read itself is limited as well
+	content, err := io.ReadAll(io.LimitReader(rc, maxEntrySize+1))
+	if err != nil {
+		return "", fmt.Errorf("could not read %q: %w", f.Name, err)
+	}
+	if len(content) > maxEntrySize {
+		return "", fmt.Errorf("%w: file %q is larger than %d bytes", ErrInvalidPackage, f.Name, maxEntrySize)
+	}
+
+	return string(content), nil
+}
+
+// Write serializes the package as a zip archive using the default layout
+func (p *Package) Write(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	manifest := Manifest{
+		Title:         p.Title,
+		Statement:     "statement.md",
+		TimeLimitMs:   p.TimeLimitMs,
+		MemoryLimitKb: p.MemoryLimitKb,
+		Tags:          p.Tags,
+	}
+
+	files := map[string]string{manifest.Statement: p.Statement}
+	var order []string
+	order = append(order, manifest.Statement)
+
+	addTests := func(dir string, tests []Test) []ManifestTest {
+		entries := make([]ManifestTest, 0, len(tests))
+		for i, tc := range tests {
+			entry := ManifestTest{
+				Input:  fmt.Sprintf("%s/%02d.in", dir, i+1),
+				Output: fmt.Sprintf("%s/%02d.out", dir, i+1),
+			}
+			files[entry.Input], files[entry.Output] = tc.Input, tc.Output
+			order = append(order, entry.Input, entry.Output)
+			if tc.Explanation != "" {
+				entry.Explanation = fmt.Sprintf("%s/%02d.md", dir, i+1)
+				files[entry.Explanation] = tc.Explanation
+				order = append(order, entry.Explanation)
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	manifest.Samples = addTests("samples", p.Samples)
+	manifest.Tests = addTests("tests", p.Tests)
+
+	var manifestBuf bytes.Buffer
+	encoder := yaml.NewEncoder(&manifestBuf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(manifest); err != nil {
+		return fmt.Errorf("could not encode manifest: %w", err)
+	}
+
+	if err := writeEntry(zw, ManifestFileName, manifestBuf.Bytes()); err != nil {
+		return err
+	}
+
+	for _, name := range order {
+		if err := writeEntry(zw, name, []byte(files[name])); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func writeEntry(zw *zip.Writer, name string, content []byte) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("could not create %q in archive: %w", name, err)
+	}
+	if _, err := fw.Write(content); err != nil {
+		return fmt.Errorf("could not write %q to archive: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package problempackage
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type PackageTestSuite struct {
+	suite.Suite
+	pkg *Package
+}
+
+func (s *PackageTestSuite) SetupTest() {
+	s.pkg = &Package{
+		Title:         "A + B",
+		Statement:     "Print the sum of two integers.",
+		TimeLimitMs:   1000,
+		MemoryLimitKb: 262144,
+		Tags:          []string{"math"},
+		Samples: []Test{
+			{Input: "1 2\n", Output: "3\n", Explanation: "$1 + 2 = 3$"},
+			{Input: "0 0\n", Output: "0\n"},
+		},
+		Tests: []Test{
+			{Input: "1 2\n", Output: "3\n"},
+			{Input: "-5 5\n", Output: "0\n"},
+		},
+	}
+}
+
+func (s *PackageTestSuite) TestRoundTrip() {
+	var buf bytes.Buffer
+	require.NoError(s.T(), s.pkg.Write(&buf))
+
+	read, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), s.pkg, read)
+}
+
+func (s *PackageTestSuite) TestReadErrors() {
+	testCases := []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name:  "missing manifest",
+			files: map[string]string{"statement.md": "hi"},
+		},
+		{
+			name: "missing referenced file",
+			files: map[string]string{
+				ManifestFileName: "title: t\nstatement: statement.md\ntime_limit_ms: 1\nmemory_limit_kb: 1\n",
+			},
+		},
+		{
+			name: "no tests",
+			files: map[string]string{
+				ManifestFileName: "title: t\nstatement: statement.md\ntime_limit_ms: 1\nmemory_limit_kb: 1\n" +
+					"samples:\n  - input: a.in\n    output: a.out\n",
+				"statement.md": "statement",
+				"a.in":         "1",
+				"a.out":        "1",
+			},
+		},
+		{
+			name: "invalid limits",
+			files: map[string]string{
+				ManifestFileName: "title: t\nstatement: statement.md\ntime_limit_ms: 0\nmemory_limit_kb: 1\n" +
+					"samples:\n  - input: a.in\n    output: a.out\ntests:\n  - input: a.in\n    output: a.out\n",
+				"statement.md": "statement",
+				"a.in":         "1",
+				"a.out":        "1",
+			},
+		},
+		{
+			name: "checker",
+			files: map[string]string{
+				ManifestFileName: "title: t\nstatement: statement.md\ntime_limit_ms: 1\nmemory_limit_kb: 1\n" +
+					"checker: checker.go\nsamples:\n  - input: a.in\n    output: a.out\n" +
+					"tests:\n  - input: a.in\n    output: a.out\n",
+				"statement.md": "statement",
+				"checker.go":   "package main",
+				"a.in":         "1",
+				"a.out":        "1",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			archive := s.createArchive(tc.files)
+
+			pkg, err := Read(bytes.NewReader(archive), int64(len(archive)))
+			assert.Error(s.T(), err)
+			assert.Nil(s.T(), pkg)
+		})
+	}
+}
+
+func (s *PackageTestSuite) TestReadLimits() {
+	manifest := func(tests int) string {
+		return "title: t\nstatement: statement.md\ntime_limit_ms: 1\nmemory_limit_kb: 1\n" +
+			"samples:\n  - input: a.in\n    output: a.out\ntests:\n" +
+			strings.Repeat("  - input: a.in\n    output: a.out\n", tests)
+	}
+
+	archive := s.createArchive(map[string]string{
+		ManifestFileName: manifest(maxTests),
+		"statement.md":   "statement",
+		"a.in":           "1",
+		"a.out":          "1",
+	})
+	_, err := Read(bytes.NewReader(archive), int64(len(archive)))
+	assert.ErrorContains(s.T(), err, "at most 500 are allowed")
+
+	// a single large file referenced again and again
+	archive = s.createArchive(map[string]string{
+		ManifestFileName: manifest(maxTotalSize / maxEntrySize),
+		"statement.md":   "statement",
+		"a.in":           strings.Repeat("1", maxEntrySize),
+		"a.out":          "1",
+	})
+	_, err = Read(bytes.NewReader(archive), int64(len(archive)))
+	assert.ErrorContains(s.T(), err, "in total", "references count toward the total size")
+}
+
+func (s *PackageTestSuite) createArchive(files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		fw, err := zw.Create(name)
+		require.NoError(s.T(), err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(s.T(), err)
+	}
+	require.NoError(s.T(), zw.Close())
+
+	return buf.Bytes()
+}
+
+func TestPackageSuite(t *testing.T) {
+	suite.Run(t, new(PackageTestSuite))
+}
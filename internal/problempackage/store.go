@@ -0,0 +1,196 @@
+package problempackage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/samber/lo"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	"github.com/computer-technology-team/go-judge/internal/revisions"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+// Store moves packages in and out of the database
+type Store struct {
+	pool    *pgxpool.Pool
+	querier storage.Querier
+}
+
+func NewStore(pool *pgxpool.Pool, querier storage.Querier) *Store {
+	return &Store{pool: pool, querier: querier}
+}
+
+// Import creates a new problem from the package, or replaces the statement, limits,
+// tests and tags of problemID when it is not nil. Everything happens in one transaction.
+func (s *Store) Import(ctx context.Context, pkg *Package, author pgtype.UUID, problemID *int32) (storage.Problem, error) {
+	if err := pkg.Validate(); err != nil {
+		return storage.Problem{}, err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return storage.Problem{}, fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func(ctx context.Context, tx pgx.Tx) {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}(ctx, tx)
+
+	var problem storage.Problem
+	var before any
+	if problemID == nil {
+		problem, err = s.querier.InsertProblem(ctx, tx, storage.InsertProblemParams{
+			Title:         pkg.Title,
+			Description:   pkg.Statement,
+			TimeLimitMs:   pkg.TimeLimitMs,
+			MemoryLimitKb: pkg.MemoryLimitKb,
+			CreatedBy:     author,
+		})
+		if err != nil {
+			return storage.Problem{}, fmt.Errorf("could not insert problem: %w", err)
+		}
+	} else {
+		existing, err := s.querier.GetProblemByID(ctx, tx, *problemID)
+		if err != nil {
+			return storage.Problem{}, fmt.Errorf("could not get problem: %w", err)
+		}
+		before = auditedProblem(existing)
+
+		problem, err = s.querier.UpdateProblem(ctx, tx, storage.UpdateProblemParams{
+			ID:            *problemID,
+			Title:         pkg.Title,
+			Description:   pkg.Statement,
+			TimeLimitMs:   pkg.TimeLimitMs,
+			MemoryLimitKb: pkg.MemoryLimitKb,
+		})
+		if err != nil {
+			return storage.Problem{}, fmt.Errorf("could not update problem: %w", err)
+		}
+
+		if err := s.querier.DeleteProblemTestCases(ctx, tx, problem.ID); err != nil {
+			return storage.Problem{}, fmt.Errorf("could not reset test cases: %w", err)
+		}
+
+		if err := s.querier.DeleteProblemSamples(ctx, tx, problem.ID); err != nil {
+			return storage.Problem{}, fmt.Errorf("could not reset samples: %w", err)
+		}
+
+		if err := s.querier.DeleteProblemTags(ctx, tx, problem.ID); err != nil {
+			return storage.Problem{}, fmt.Errorf("could not reset tags: %w", err)
+		}
+	}
+
+	for _, sample := range pkg.Samples {
+		_, err = s.querier.InsertSampleTestCase(ctx, tx, storage.InsertSampleTestCaseParams{
+			ProblemID:   problem.ID,
+			Input:       sample.Input,
+			Output:      sample.Output,
+			Explanation: sample.Explanation,
+		})
+		if err != nil {
+			return storage.Problem{}, fmt.Errorf("could not insert sample: %w", err)
+		}
+	}
+
+	for _, tc := range pkg.Tests {
+		_, err = s.querier.InsertTestCase(ctx, tx, storage.InsertTestCaseParams{
+			ProblemID: problem.ID,
+			Input:     tc.Input,
+			Output:    tc.Output,
+		})
+		if err != nil {
+			return storage.Problem{}, fmt.Errorf("could not insert test case: %w", err)
+		}
+	}
+
+	for _, tag := range lo.Uniq(lo.Map(pkg.Tags, func(tag string, _ int) string { return NormalizeTag(tag) })) {
+		if err := s.querier.InsertProblemTag(ctx, tx, problem.ID, tag); err != nil {
+			return storage.Problem{}, fmt.Errorf("could not insert tag: %w", err)
+		}
+	}
+
+	revision, err := revisions.Snapshot(ctx, tx, s.querier, problem.ID, author)
+	if err != nil {
+		return storage.Problem{}, fmt.Errorf("could not record revision: %w", err)
+	}
+	problem.CurrentRevisionID = pgtype.Int4{Int32: revision.ID, Valid: true}
+
+	after := auditedProblem(problem)
+	after["tests"] = len(pkg.Tests)
+	err = audit.Record(ctx, tx, s.querier, audit.Event{
+		Action:     audit.ProblemImport,
+		TargetType: audit.TargetProblem,
+		TargetID:   fmt.Sprint(problem.ID),
+		Before:     before,
+		After:      after,
+	})
+	if err != nil {
+		return storage.Problem{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return storage.Problem{}, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return problem, nil
+}
+
+// auditedProblem is what the audit log keeps of an imported problem
+func auditedProblem(problem storage.Problem) map[string]any {
+	return map[string]any{
+		"title":           problem.Title,
+		"time_limit_ms":   problem.TimeLimitMs,
+		"memory_limit_kb": problem.MemoryLimitKb,
+	}
+}
+
+// Export loads a problem with its tests and tags as a Package
+func (s *Store) Export(ctx context.Context, problemID int32) (*Package, error) {
+	problem, err := s.querier.GetProblemByID(ctx, s.pool, problemID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get problem: %w", err)
+	}
+
+	testCases, err := s.querier.GetTestCasesByProblemID(ctx, s.pool, problemID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get test cases: %w", err)
+	}
+
+	tags, err := s.querier.GetProblemTags(ctx, s.pool, problemID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get tags: %w", err)
+	}
+
+	samples, tests := lo.FilterReject(testCases, func(tc storage.TestCase, _ int) bool { return tc.IsSample })
+
+	return &Package{
+		Title:         problem.Title,
+		Statement:     problem.Description,
+		TimeLimitMs:   problem.TimeLimitMs,
+		MemoryLimitKb: problem.MemoryLimitKb,
+		Tags:          tags,
+		Samples:       toTests(samples),
+		Tests:         toTests(tests),
+	}, nil
+}
+
+func toTests(testCases []storage.TestCase) []Test {
+	return lo.Map(testCases, func(tc storage.TestCase, _ int) Test {
+		return Test{Input: tc.Input, Output: tc.Output, Explanation: tc.Explanation}
+	})
+}
+
+// NormalizeTag is the stored form of a tag, tags are matched case-insensitively
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
@@ -0,0 +1,216 @@
+package problems
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/lo"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+const (
+	maxAttachmentSize = 5 << 20
+	maxAttachments    = 50
+)
+
+var attachmentNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// inlineContentTypes are shown in the browser, everything else is downloaded. SVG is not here since it can run scripts.
+var inlineContentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "text/plain; charset=utf-8"}
+
+// UploadAttachment stores an image or file referenced by the statement, replacing one with the same name
+func (h *DefaultHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := slog.With("function", "UploadAttachment", "package", "problems")
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize+(1<<20))
+	if err := r.ParseMultipartForm(maxAttachmentSize); err != nil {
+		templates.RenderError(ctx, w, fmt.Sprintf("attachments can be at most %d MB", maxAttachmentSize>>20),
+			http.StatusBadRequest, h.templates)
+		return
+	}
+
+	file, header, err := r.FormFile("attachment")
+	if err != nil {
+		templates.RenderError(ctx, w, "attachment is required", http.StatusBadRequest, h.templates)
+		return
+	}
+	defer file.Close()
+
+	name := lo.CoalesceOrEmpty(strings.TrimSpace(r.PostFormValue("name")), header.Filename)
+	if !attachmentNamePattern.MatchString(name) {
+		templates.RenderError(ctx, w, "attachment names can only contain letters, digits, '.', '_' and '-'",
+			http.StatusBadRequest, h.templates)
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		logger.ErrorContext(ctx, "could not read attachment", "error", err)
+		templates.RenderError(ctx, w, "could not read attachment", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	count, err := h.querier.CountProblemAttachments(ctx, h.pool, problem.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "could not count attachments", "error", err)
+		templates.RenderError(ctx, w, "could not store attachment", http.StatusInternalServerError, h.templates)
+		return
+	}
+	if count >= maxAttachments {
+		_, err := h.querier.GetProblemAttachment(ctx, h.pool, problem.ID, name)
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, fmt.Sprintf("a problem can have at most %d attachments", maxAttachments),
+				http.StatusBadRequest, h.templates)
+			return
+		}
+	}
+
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	contentType := http.DetectContentType(data)
+	err = h.withTransaction(ctx, func(tx pgx.Tx) error {
+		err := h.querier.UpsertProblemAttachment(ctx, tx, storage.UpsertProblemAttachmentParams{
+			ProblemID:   problem.ID,
+			Name:        name,
+			ContentType: contentType,
+			Data:        data,
+			UploadedBy:  user.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, tx, h.querier, audit.Event{
+			Action:     audit.ProblemUploadAttachment,
+			TargetType: audit.TargetProblem,
+			TargetID:   strconv.Itoa(int(problem.ID)),
+			After:      auditedAttachment{Name: name, ContentType: contentType, Size: len(data)},
+		})
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "could not store attachment", "error", err)
+		templates.RenderError(ctx, w, "could not store attachment", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/problems/form/%d", problem.ID), http.StatusSeeOther)
+}
+
+// DeleteAttachment removes an attachment of an editable problem
+func (h *DefaultHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.withTransaction(ctx, func(tx pgx.Tx) error {
+		attachment, err := h.querier.DeleteProblemAttachment(ctx, tx, problem.ID, chi.URLParam(r, "name"))
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, tx, h.querier, audit.Event{
+			Action:     audit.ProblemDeleteAttachment,
+			TargetType: audit.TargetProblem,
+			TargetID:   strconv.Itoa(int(problem.ID)),
+			Before: auditedAttachment{Name: attachment.Name, ContentType: attachment.ContentType,
+				Size: int(attachment.Size)},
+		})
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		templates.RenderError(ctx, w, "attachment not found", http.StatusNotFound, h.templates)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "could not delete attachment", "error", err, "problem_id", problem.ID)
+		templates.RenderError(ctx, w, "could not delete attachment", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/problems/form/%d", problem.ID), http.StatusSeeOther)
+}
+
+// auditedAttachment is what the audit log keeps of an attachment, without its data
+type auditedAttachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+// ServeAttachment serves an attachment to anyone who can see the problem, drafts only to their author and admins
+func (h *DefaultHandler) ServeAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// the route is public, visitors who are not logged in only see attachments of published problems
+	var userID pgtype.UUID
+	if user, ok := internalcontext.GetUserFromContext(ctx); ok {
+		userID = user.ID
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid problem id", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	problem, err := h.querier.GetProblemForUser(ctx, h.pool, storage.GetProblemForUserParams{
+		ID:        int32(id),
+		CreatedBy: userID,
+		IsAdmin:   internalcontext.HasPermission(ctx, rbac.ManageProblems),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "problem not found", http.StatusNotFound, h.templates)
+			return
+		}
+		slog.ErrorContext(ctx, "could not get problem", "error", err, "problem_id", id)
+		templates.RenderError(ctx, w, "could not get problem from storage", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	attachment, err := h.querier.GetProblemAttachment(ctx, h.pool, problem.ID, chi.URLParam(r, "name"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "attachment not found", http.StatusNotFound, h.templates)
+			return
+		}
+		slog.ErrorContext(ctx, "could not get attachment", "error", err, "problem_id", id)
+		templates.RenderError(ctx, w, "could not get attachment", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	if lo.Contains(inlineContentTypes, attachment.ContentType) {
+		w.Header().Set("Content-Type", attachment.ContentType)
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", attachment.Name))
+	}
+
+	http.ServeContent(w, r, attachment.Name, attachment.CreatedAt.Time, bytes.NewReader(attachment.Data))
+}
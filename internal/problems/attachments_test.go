@@ -0,0 +1,261 @@
+package problems
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// fakeDatabase hands out transactions that only count commits
+type fakeDatabase struct {
+	storage.DBTX
+	committed int
+}
+
+func (d *fakeDatabase) Begin(context.Context) (pgx.Tx, error) {
+	return &fakeTx{db: d}, nil
+}
+
+type fakeTx struct {
+	pgx.Tx
+	db     *fakeDatabase
+	closed bool
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	tx.closed = true
+	tx.db.committed++
+	return nil
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.closed = true
+	return nil
+}
+
+// fakeQuerier keeps problems and their attachments in memory, filtering drafts like GetProblemForUser does
+type fakeQuerier struct {
+	storage.Querier
+	problems    map[int32]storage.Problem
+	attachments map[int32]storage.ProblemAttachment
+	events      []storage.CreateAuditEventParams
+	// auditErr fails recording audit events
+	auditErr error
+}
+
+func (q *fakeQuerier) GetProblemForUser(_ context.Context, _ storage.DBTX,
+	arg storage.GetProblemForUserParams) (storage.Problem, error) {
+	problem, ok := q.problems[arg.ID]
+	if !ok || (problem.Draft && problem.CreatedBy != arg.CreatedBy && !arg.IsAdmin) {
+		return storage.Problem{}, pgx.ErrNoRows
+	}
+	return problem, nil
+}
+
+func (q *fakeQuerier) GetProblemAttachment(_ context.Context, _ storage.DBTX, problemID int32,
+	name string) (storage.ProblemAttachment, error) {
+	attachment, ok := q.attachments[problemID]
+	if !ok || attachment.Name != name {
+		return storage.ProblemAttachment{}, pgx.ErrNoRows
+	}
+	return attachment, nil
+}
+
+func (q *fakeQuerier) CountProblemAttachments(_ context.Context, _ storage.DBTX, problemID int32) (int64, error) {
+	if _, ok := q.attachments[problemID]; ok {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (q *fakeQuerier) UpsertProblemAttachment(_ context.Context, _ storage.DBTX,
+	arg storage.UpsertProblemAttachmentParams) error {
+	q.attachments[arg.ProblemID] = storage.ProblemAttachment{
+		ProblemID:   arg.ProblemID,
+		Name:        arg.Name,
+		ContentType: arg.ContentType,
+		Data:        arg.Data,
+	}
+	return nil
+}
+
+func (q *fakeQuerier) DeleteProblemAttachment(_ context.Context, _ storage.DBTX, problemID int32,
+	name string) (storage.DeleteProblemAttachmentRow, error) {
+	attachment, ok := q.attachments[problemID]
+	if !ok || attachment.Name != name {
+		return storage.DeleteProblemAttachmentRow{}, pgx.ErrNoRows
+	}
+	delete(q.attachments, problemID)
+	return storage.DeleteProblemAttachmentRow{
+		Name:        attachment.Name,
+		ContentType: attachment.ContentType,
+		Size:        int32(len(attachment.Data)),
+	}, nil
+}
+
+func (q *fakeQuerier) CreateAuditEvent(_ context.Context, _ storage.DBTX, arg storage.CreateAuditEventParams) error {
+	if q.auditErr != nil {
+		return q.auditErr
+	}
+	q.events = append(q.events, arg)
+	return nil
+}
+
+type AttachmentsTestSuite struct {
+	suite.Suite
+	author  *storage.User
+	querier *fakeQuerier
+	db      *fakeDatabase
+	router  chi.Router
+}
+
+func (s *AttachmentsTestSuite) SetupTest() {
+	tmpl, err := templates.GetSharedTemplates()
+	require.NoError(s.T(), err)
+
+	s.author = &storage.User{ID: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Username: "author"}
+
+	s.querier = &fakeQuerier{
+		problems: map[int32]storage.Problem{
+			1: {ID: 1, CreatedBy: s.author.ID},
+			2: {ID: 2, CreatedBy: s.author.ID, Draft: true},
+		},
+		attachments: map[int32]storage.ProblemAttachment{},
+	}
+	for id := range s.querier.problems {
+		s.querier.attachments[id] = storage.ProblemAttachment{
+			ProblemID:   id,
+			Name:        "figure.png",
+			ContentType: "image/png",
+			Data:        []byte("png"),
+			CreatedAt:   pgtype.Timestamptz{Time: time.Unix(1_700_000_000, 0), Valid: true},
+		}
+	}
+
+	s.db = &fakeDatabase{}
+
+	h := &DefaultHandler{templates: tmpl, pool: s.db, querier: s.querier}
+	s.router = chi.NewRouter()
+	s.router.Get("/problems/{id}/attachments/{name}", h.ServeAttachment)
+	s.router.Post("/problems/{id}/attachments", h.UploadAttachment)
+	s.router.Post("/problems/{id}/attachments/{name}/delete", h.DeleteAttachment)
+}
+
+func (s *AttachmentsTestSuite) get(path string, user *storage.User) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if user != nil {
+		req = req.WithContext(context.WithValue(req.Context(), internalcontext.UserContextKey, user))
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *AttachmentsTestSuite) upload(path, name string, data []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("attachment", name)
+	require.NoError(s.T(), err)
+	_, err = part.Write(data)
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req = req.WithContext(context.WithValue(req.Context(), internalcontext.UserContextKey, s.author))
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *AttachmentsTestSuite) post(path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	req = req.WithContext(context.WithValue(req.Context(), internalcontext.UserContextKey, s.author))
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *AttachmentsTestSuite) TestAnonymousVisitor() {
+	rec := s.get("/problems/1/attachments/figure.png", nil)
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Equal(s.T(), "image/png", rec.Header().Get("Content-Type"))
+	assert.Equal(s.T(), "png", rec.Body.String())
+
+	rec = s.get("/problems/2/attachments/figure.png", nil)
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code, "attachments of drafts are hidden")
+}
+
+func (s *AttachmentsTestSuite) TestDraftAuthor() {
+	rec := s.get("/problems/2/attachments/figure.png", s.author)
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+
+	other := &storage.User{ID: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Username: "other"}
+	rec = s.get("/problems/2/attachments/figure.png", other)
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code)
+
+	rec = s.get("/problems/1/attachments/missing.png", s.author)
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code)
+}
+
+func (s *AttachmentsTestSuite) TestUploadIsAudited() {
+	rec := s.upload("/problems/1/attachments", "notes.txt", []byte("hello"))
+	require.Equal(s.T(), http.StatusSeeOther, rec.Code)
+	assert.Equal(s.T(), 1, s.db.committed)
+
+	require.Len(s.T(), s.querier.events, 1)
+	event := s.querier.events[0]
+	assert.Equal(s.T(), string(audit.ProblemUploadAttachment), event.Action)
+	assert.Equal(s.T(), "1", event.TargetID)
+	assert.Equal(s.T(), s.author.Username, event.ActorName)
+	assert.JSONEq(s.T(), `{"name": "notes.txt", "content_type": "text/plain; charset=utf-8", "size": 5}`,
+		string(event.After))
+}
+
+func (s *AttachmentsTestSuite) TestDeleteIsAudited() {
+	rec := s.post("/problems/1/attachments/figure.png/delete")
+	require.Equal(s.T(), http.StatusSeeOther, rec.Code)
+	assert.Equal(s.T(), 1, s.db.committed)
+
+	require.Len(s.T(), s.querier.events, 1)
+	assert.Equal(s.T(), string(audit.ProblemDeleteAttachment), s.querier.events[0].Action)
+	assert.JSONEq(s.T(), `{"name": "figure.png", "content_type": "image/png", "size": 3}`,
+		string(s.querier.events[0].Before))
+
+	rec = s.post("/problems/1/attachments/figure.png/delete")
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code)
+	assert.Len(s.T(), s.querier.events, 1, "nothing was deleted")
+}
+
+func (s *AttachmentsTestSuite) TestChangeFailsWithoutAuditEvent() {
+	s.querier.auditErr = errors.New("audit log unavailable")
+
+	rec := s.post("/problems/1/attachments/figure.png/delete")
+	assert.Equal(s.T(), http.StatusInternalServerError, rec.Code)
+	assert.Zero(s.T(), s.db.committed, "the deletion is rolled back")
+}
+
+func TestAttachmentsTestSuite(t *testing.T) {
+	suite.Run(t, new(AttachmentsTestSuite))
+}
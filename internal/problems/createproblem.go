@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/computer-technology-team/go-judge/internal/audit"
 	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/revisions"
 	"github.com/computer-technology-team/go-judge/internal/storage"
 	"github.com/computer-technology-team/go-judge/web/templates"
 	"github.com/jackc/pgx/v5"
@@ -26,8 +28,6 @@ func (h *DefaultHandler) CreateProblem(w http.ResponseWriter, r *http.Request) {
 	// Extract form data
 	title := r.PostFormValue("title")
 	description := r.PostFormValue("description")
-	sampleInput := r.PostFormValue("sample_input")
-	sampleOutput := r.PostFormValue("sample_output")
 	timeLimit := r.PostFormValue("time_limit")
 	memoryLimit := r.PostFormValue("memory_limit")
 	testCases := []storage.TestCase{}
@@ -45,9 +45,15 @@ func (h *DefaultHandler) CreateProblem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate required fields
-	if title == "" || description == "" || sampleInput == "" || sampleOutput == "" {
+	if title == "" || description == "" {
 		slog.Error("missing required fields")
-		templates.RenderError(r.Context(), w, "title, description, sample input, and sample output are required", http.StatusBadRequest, h.templates)
+		templates.RenderError(r.Context(), w, "title and description are required", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	samples, err := parseSamples(r)
+	if err != nil {
+		templates.RenderError(r.Context(), w, err.Error(), http.StatusBadRequest, h.templates)
 		return
 	}
 
@@ -74,6 +80,24 @@ func (h *DefaultHandler) CreateProblem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tags, err := parseTags(r.PostFormValue("tags"))
+	if err != nil {
+		templates.RenderError(r.Context(), w, err.Error(), http.StatusBadRequest, h.templates)
+		return
+	}
+
+	difficulty, err := parseDifficulty(r.PostFormValue("difficulty"))
+	if err != nil {
+		templates.RenderError(r.Context(), w, err.Error(), http.StatusBadRequest, h.templates)
+		return
+	}
+
+	settings, err := parseSubmissionSettings(r)
+	if err != nil {
+		templates.RenderError(r.Context(), w, err.Error(), http.StatusBadRequest, h.templates)
+		return
+	}
+
 	created_by, _ := internalcontext.GetUserFromContext(r.Context())
 
 	tx, err := h.pool.Begin(ctx)
@@ -94,8 +118,6 @@ func (h *DefaultHandler) CreateProblem(w http.ResponseWriter, r *http.Request) {
 	p, err := h.querier.InsertProblem(ctx, tx, storage.InsertProblemParams{
 		Title:         title,
 		Description:   description,
-		SampleInput:   sampleInput,
-		SampleOutput:  sampleOutput,
 		TimeLimitMs:   int64(timeLimitInt),
 		MemoryLimitKb: int64(memoryLimitInt),
 		CreatedBy:     created_by.ID,
@@ -120,6 +142,36 @@ func (h *DefaultHandler) CreateProblem(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := h.replaceSamples(ctx, tx, p.ID, samples); err != nil {
+		slog.Error("could not save samples", "error", err)
+		templates.RenderError(r.Context(), w, "could not save samples", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	if err := h.saveProblemMetadata(ctx, tx, p.ID, tags, difficulty, settings); err != nil {
+		slog.Error("could not save problem metadata", "error", err)
+		templates.RenderError(r.Context(), w, "could not save tags and difficulty", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	if _, err := revisions.Snapshot(ctx, tx, h.querier, p.ID, created_by.ID); err != nil {
+		slog.Error("could not record revision", "error", err)
+		templates.RenderError(r.Context(), w, "could not record revision", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	err = audit.Record(ctx, tx, h.querier, audit.Event{
+		Action:     audit.ProblemCreate,
+		TargetType: audit.TargetProblem,
+		TargetID:   strconv.Itoa(int(p.ID)),
+		After:      newAuditedProblem(p, len(testCases)),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err)
+		templates.RenderError(ctx, w, "could not record audit event", http.StatusInternalServerError, h.templates)
+		return
+	}
+
 	err = tx.Commit(ctx)
 	if err != nil {
 		slog.Error("could not commit transaction", "error", err)
@@ -0,0 +1,91 @@
+package problems
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/samber/lo"
+
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/internal/testarchive"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// ExportProblem downloads a problem with its tests as a package archive
+func (h *DefaultHandler) ExportProblem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid problem id", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	pkg, err := h.packages.Export(ctx, int32(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "problem not found", http.StatusNotFound, h.templates)
+			return
+		}
+		slog.ErrorContext(ctx, "could not export problem", "error", err, "problem_id", id)
+		templates.RenderError(ctx, w, "could not export problem", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	// build in memory first so a failure can still render an error page
+	var buf bytes.Buffer
+	if err := pkg.Write(&buf); err != nil {
+		slog.ErrorContext(ctx, "could not write package", "error", err, "problem_id", id)
+		templates.RenderError(ctx, w, "could not export problem", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="problem-%d.zip"`, id))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	_, _ = buf.WriteTo(w)
+}
+
+// DownloadTests downloads the tests of an editable problem as an archive that can be uploaded again,
+// samples are named sample-NN and the other tests NN in judging order
+func (h *DefaultHandler) DownloadTests(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	testCases, err := h.querier.GetTestCasesByProblemID(ctx, h.pool, problem.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get test cases", "error", err, "problem_id", problem.ID)
+		templates.RenderError(ctx, w, "could not get tests", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	samples, tests := lo.FilterReject(testCases, func(tc storage.TestCase, _ int) bool { return tc.IsSample })
+	toArchive := func(prefix string, testCases []storage.TestCase) []testarchive.Test {
+		return lo.Map(testCases, func(tc storage.TestCase, i int) testarchive.Test {
+			return testarchive.Test{Name: fmt.Sprintf("%s%02d", prefix, i+1), Input: tc.Input, Output: tc.Output}
+		})
+	}
+
+	var buf bytes.Buffer
+	err = testarchive.Write(&buf, append(toArchive("sample-", samples), toArchive("", tests)...))
+	if err != nil {
+		slog.ErrorContext(ctx, "could not write test archive", "error", err, "problem_id", problem.ID)
+		templates.RenderError(ctx, w, "could not download tests", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="problem-%d-tests.zip"`, problem.ID))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	_, _ = buf.WriteTo(w)
+}
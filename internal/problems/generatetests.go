@@ -0,0 +1,166 @@
+package problems
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/internal/testgen"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// AddGeneratedTests adds one generated test per non empty line of arguments, e.g. "10 1000 seed=7".
+// No revision is recorded here, the problem cannot be judged until the new tests are generated.
+func (h *DefaultHandler) AddGeneratedTests(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := slog.With("function", "AddGeneratedTests", "package", "problems")
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		templates.RenderError(ctx, w, "invalid form data", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	generatorID, err := strconv.Atoi(r.PostFormValue("generator_id"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid generator", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	generator, err := h.querier.GetProblemProgram(ctx, h.pool, int32(generatorID), problem.ID)
+	if err != nil || generator.Kind != storage.ProgramKindGENERATOR {
+		templates.RenderError(ctx, w, "generator not found", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	var argLines []string
+	for _, line := range strings.Split(r.PostFormValue("args"), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			argLines = append(argLines, line)
+		}
+	}
+	if len(argLines) == 0 {
+		templates.RenderError(ctx, w, "at least one line of arguments is required", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	err = h.withTransaction(ctx, func(tx pgx.Tx) error {
+		testIDs := make([]int32, len(argLines))
+		for i, args := range argLines {
+			testCase, err := h.querier.InsertGeneratedTestCase(ctx, tx, storage.InsertGeneratedTestCaseParams{
+				ProblemID:     problem.ID,
+				GeneratorID:   pgtype.Int4{Int32: generator.ID, Valid: true},
+				GeneratorArgs: pgtype.Text{String: args, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+			testIDs[i] = testCase.ID
+		}
+
+		return audit.Record(ctx, tx, h.querier, audit.Event{
+			Action:     audit.ProblemAddGeneratedTests,
+			TargetType: audit.TargetProblem,
+			TargetID:   strconv.Itoa(int(problem.ID)),
+			After:      map[string]any{"generator": generator.Name, "args": argLines, "test_ids": testIDs},
+		})
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "could not insert generated test cases", "error", err)
+		templates.RenderError(ctx, w, "could not insert generated test cases", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, programsURL(problem.ID), http.StatusSeeOther)
+}
+
+// DeleteGeneratedTest removes a single generated test
+func (h *DefaultHandler) DeleteGeneratedTest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	testID, err := strconv.Atoi(chi.URLParam(r, "test_id"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid test id", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	err = h.withRevision(ctx, problem.ID, func(tx pgx.Tx) error {
+		testCase, err := h.querier.DeleteGeneratedTestCase(ctx, tx, int32(testID), problem.ID)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, tx, h.querier, audit.Event{
+			Action:     audit.ProblemDeleteGeneratedTest,
+			TargetType: audit.TargetProblem,
+			TargetID:   strconv.Itoa(int(problem.ID)),
+			Before: map[string]any{
+				"test_id":      testCase.ID,
+				"generator_id": testCase.GeneratorID.Int32,
+				"args":         testCase.GeneratorArgs.String,
+			},
+		})
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		templates.RenderError(ctx, w, "generated test not found", http.StatusNotFound, h.templates)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "could not delete generated test case", "error", err, "test_id", testID)
+		templates.RenderError(ctx, w, "could not delete generated test case", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, programsURL(problem.ID), http.StatusSeeOther)
+}
+
+// GenerateTests starts generating, validating and solving tests in the background
+func (h *DefaultHandler) GenerateTests(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	generation, err := h.generator.Start(ctx, problem.ID)
+	if err != nil {
+		if errors.Is(err, testgen.ErrGenerationRunning) {
+			templates.RenderError(ctx, w, err.Error(), http.StatusConflict, h.templates)
+			return
+		}
+		slog.ErrorContext(ctx, "could not start test generation", "error", err, "problem_id", problem.ID)
+		templates.RenderError(ctx, w, "could not start test generation", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	// the generation runs in the background, so the event records that it was started
+	err = audit.Record(ctx, h.pool, h.querier, audit.Event{
+		Action:     audit.ProblemGenerate,
+		TargetType: audit.TargetProblem,
+		TargetID:   strconv.Itoa(int(problem.ID)),
+		After:      map[string]int32{"generation_id": generation.ID},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err, "problem_id", problem.ID)
+	}
+
+	http.Redirect(w, r, programsURL(problem.ID), http.StatusSeeOther)
+}
@@ -0,0 +1,89 @@
+package problems
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/problempackage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// ShowImportProblem renders the problem package upload form
+func (h *DefaultHandler) ShowImportProblem(w http.ResponseWriter, r *http.Request) {
+	err := h.templates.Render(r.Context(), "importproblempage", w, nil)
+	if err != nil {
+		slog.Error("could not render importproblempage", "error", err)
+		templates.RenderError(r.Context(), w, "could not render", http.StatusInternalServerError, h.templates)
+		return
+	}
+}
+
+// ImportProblem creates a problem from an uploaded package, or updates the problem given in problem_id
+func (h *DefaultHandler) ImportProblem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := slog.With("function", "ImportProblem", "package", "problems")
+
+	r.Body = http.MaxBytesReader(w, r.Body, problempackage.MaxPackageSize+(1<<20))
+	if err := r.ParseMultipartForm(problempackage.MaxPackageSize); err != nil {
+		templates.RenderError(ctx, w, "could not parse form", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	var problemID *int32
+	if problemIDStr := r.PostFormValue("problem_id"); problemIDStr != "" {
+		id, err := strconv.Atoi(problemIDStr)
+		if err != nil {
+			templates.RenderError(ctx, w, "invalid problem id", http.StatusBadRequest, h.templates)
+			return
+		}
+
+		_, err = h.querier.GetProblemByID(ctx, h.pool, int32(id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				templates.RenderError(ctx, w, "problem not found", http.StatusNotFound, h.templates)
+				return
+			}
+			logger.ErrorContext(ctx, "could not get problem", "error", err, "problem_id", id)
+			templates.RenderError(ctx, w, "could not retrieve problem", http.StatusInternalServerError, h.templates)
+			return
+		}
+
+		problemID = &[]int32{int32(id)}[0]
+	}
+
+	file, header, err := r.FormFile("package")
+	if err != nil {
+		templates.RenderError(ctx, w, "package file is required", http.StatusBadRequest, h.templates)
+		return
+	}
+	defer file.Close()
+
+	pkg, err := problempackage.Read(file, header.Size)
+	if err != nil {
+		if errors.Is(err, problempackage.ErrInvalidPackage) || errors.Is(err, problempackage.ErrMissingManifest) {
+			templates.RenderError(ctx, w, err.Error(), http.StatusBadRequest, h.templates)
+			return
+		}
+		logger.ErrorContext(ctx, "could not read package", "error", err)
+		templates.RenderError(ctx, w, "could not read package", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	problem, err := h.packages.Import(ctx, pkg, user.ID, problemID)
+	if err != nil {
+		logger.ErrorContext(ctx, "could not import package", "error", err)
+		templates.RenderError(ctx, w, "could not import package", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	slog.Info("Problem imported successfully", "problem_id", problem.ID)
+
+	http.Redirect(w, r, "/problems/"+strconv.Itoa(int(problem.ID)), http.StatusSeeOther)
+}
@@ -3,9 +3,9 @@ package problems
 import (
 	"log/slog"
 	"net/http"
-	"strconv"
 
 	"github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
 	"github.com/computer-technology-team/go-judge/internal/storage"
 	"github.com/computer-technology-team/go-judge/web/templates"
 	"github.com/samber/lo"
@@ -19,40 +19,16 @@ func (h *DefaultHandler) ListMyProblems(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	pageStr := r.URL.Query().Get("page")
-	pageSizeStr := r.URL.Query().Get("page-size")
-
-	var page int
-	if pageStr != "" {
-		var err error
-		page, err = strconv.Atoi(pageStr)
-		if err != nil {
-			slog.WarnContext(ctx, "could not convert page param", "error", err)
-			templates.RenderError(ctx, w, "invalid page param", http.StatusBadRequest, h.templates)
-			return
-		}
-	} else {
-		page = 1 // Default to first page
-	}
-
-	var pageSize int
-	if pageSizeStr != "" {
-		var err error
-		pageSize, err = strconv.Atoi(pageSizeStr)
-		if err != nil {
-			slog.WarnContext(ctx, "could not convert page size param", "error", err)
-			templates.RenderError(ctx, w, "invalid page size param", http.StatusBadRequest, h.templates)
-			return
-		}
-	} else {
-		pageSize = defaultPageSize
+	page, pageSize, ok := h.parsePagination(w, r)
+	if !ok {
+		return
 	}
 
 	limit := pageSize
 	offset := pageSize * (page - 1)
 	var problems []any
 
-	if !user.Superuser {
+	if !context.HasPermission(ctx, rbac.ManageProblems) {
 		userProbs, err := h.querier.GetUserProblemsSorted(ctx, h.pool, storage.GetUserProblemsSortedParams{
 			Limit:     int32(limit),
 			Offset:    int32(offset),
@@ -1,15 +1,36 @@
 package problems
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
-	"github.com/computer-technology-team/go-judge/web/templates"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/samber/lo"
+
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/problempackage"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+const (
+	defaultPageSize = 5
+	maxPageSize     = 100
+
+	minDifficulty = 1
+	maxDifficulty = 10
+
+	maxTagLength = 64
+	maxTags      = 10
 )
 
-const defaultPageSize = 5
+var problemSorts = []string{"newest", "popularity", "acceptance", "difficulty", "relevance"}
 
 type listProblemsData struct {
 	Problems    []any
@@ -17,54 +38,278 @@ type listProblemsData struct {
 	PageSize    int
 }
 
+// problemFilters are the query params of the problem list, they are kept in pagination links
+type problemFilters struct {
+	Query         string
+	Tag           string
+	MinDifficulty string
+	MaxDifficulty string
+	Author        string
+	Status        string
+	Sort          string
+}
+
+type searchProblemsData struct {
+	Problems    []storage.SearchProblemsRow
+	Filters     problemFilters
+	Tags        []string
+	Sorts       []string
+	LoggedIn    bool
+	CurrentPage int
+	PageSize    int
+	PrevURL     string
+	NextURL     string
+}
+
+// ListProblems lists published problems filtered by the query params q, tag, min_difficulty, max_difficulty,
+// author and status (solved or unsolved), sorted by sort. With format=json the page is returned as JSON.
 func (h *DefaultHandler) ListProblems(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	query := r.URL.Query()
+
+	page, pageSize, ok := h.parsePagination(w, r)
+	if !ok {
+		return
+	}
+
+	user, loggedIn := internalcontext.GetUserFromContext(ctx)
+
+	filters := problemFilters{
+		Query:         strings.TrimSpace(query.Get("q")),
+		Tag:           problempackage.NormalizeTag(query.Get("tag")),
+		MinDifficulty: query.Get("min_difficulty"),
+		MaxDifficulty: query.Get("max_difficulty"),
+		Author:        strings.TrimSpace(query.Get("author")),
+		Status:        query.Get("status"),
+		Sort:          query.Get("sort"),
+	}
+
+	params := storage.SearchProblemsParams{
+		Query:      pgtype.Text{String: filters.Query, Valid: filters.Query != ""},
+		Tag:        pgtype.Text{String: filters.Tag, Valid: filters.Tag != ""},
+		Author:     pgtype.Text{String: filters.Author, Valid: filters.Author != ""},
+		Sort:       filters.Sort,
+		PageLimit:  int32(pageSize),
+		PageOffset: int32(pageSize * (page - 1)),
+	}
+
+	if filters.Sort == "" {
+		params.Sort = lo.Ternary(filters.Query != "", "relevance", "newest")
+	} else if !lo.Contains(problemSorts, filters.Sort) {
+		templates.RenderError(ctx, w, "invalid sort param", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	for _, d := range []struct {
+		value  string
+		target *pgtype.Int4
+	}{
+		{filters.MinDifficulty, &params.MinDifficulty},
+		{filters.MaxDifficulty, &params.MaxDifficulty},
+	} {
+		if d.value == "" {
+			continue
+		}
+		difficulty, err := strconv.Atoi(d.value)
+		if err != nil || difficulty < minDifficulty || difficulty > maxDifficulty {
+			templates.RenderError(ctx, w, "invalid difficulty param", http.StatusBadRequest, h.templates)
+			return
+		}
+		*d.target = pgtype.Int4{Int32: int32(difficulty), Valid: true}
+	}
+
+	if loggedIn {
+		params.ViewerID = user.ID
+	}
+
+	switch filters.Status {
+	case "":
+	case "solved", "unsolved":
+		if !loggedIn {
+			templates.RenderError(ctx, w, "log in to filter by solved status", http.StatusUnauthorized, h.templates)
+			return
+		}
+		params.Solved = pgtype.Bool{Bool: filters.Status == "solved", Valid: true}
+	default:
+		templates.RenderError(ctx, w, "invalid status param", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	problems, err := h.querier.SearchProblems(ctx, h.pool, params)
+	if err != nil {
+		slog.Error("could not fetch problems", "error", err)
+		templates.RenderError(ctx, w, "could not fetch problems", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	if query.Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(map[string]any{
+			"problems":  lo.Ternary(problems == nil, []storage.SearchProblemsRow{}, problems),
+			"page":      page,
+			"page_size": pageSize,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "could not encode problems", "error", err)
+		}
+		return
+	}
+
+	tags, err := h.querier.GetAllTags(ctx, h.pool)
+	if err != nil {
+		slog.Error("could not fetch tags", "error", err)
+		templates.RenderError(ctx, w, "could not fetch tags", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	data := searchProblemsData{
+		Problems:    problems,
+		Filters:     filters,
+		Tags:        tags,
+		Sorts:       problemSorts,
+		LoggedIn:    loggedIn,
+		CurrentPage: page,
+		PageSize:    pageSize,
+	}
+	if page > 1 {
+		data.PrevURL = pageURL(query, page-1)
+	}
+	if len(problems) == pageSize {
+		data.NextURL = pageURL(query, page+1)
+	}
+
+	err = h.templates.Render(ctx, "listproblemspage", w, data)
+	if err != nil {
+		slog.Error("could not render listproblemspage", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, h.templates)
+		return
+	}
+}
+
+// parsePagination reads the page and page-size query params, rendering the error itself when they are invalid
+func (h *DefaultHandler) parsePagination(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	ctx := r.Context()
 
 	pageStr := r.URL.Query().Get("page")
 	pageSizeStr := r.URL.Query().Get("page-size")
 
-	var page int
+	page := 1 // Default to first page
 	if pageStr != "" {
 		var err error
 		page, err = strconv.Atoi(pageStr)
-		if err != nil {
+		if err != nil || page < 1 {
 			slog.WarnContext(ctx, "could not convert page param", "error", err)
 			templates.RenderError(ctx, w, "invalid page param", http.StatusBadRequest, h.templates)
-			return
+			return 0, 0, false
 		}
-	} else {
-		page = 1 // Default to first page
 	}
 
-	var pageSize int
+	pageSize := defaultPageSize
 	if pageSizeStr != "" {
 		var err error
 		pageSize, err = strconv.Atoi(pageSizeStr)
-		if err != nil {
+		if err != nil || pageSize < 1 || pageSize > maxPageSize {
 			slog.WarnContext(ctx, "could not convert page size param", "error", err)
 			templates.RenderError(ctx, w, "invalid page size param", http.StatusBadRequest, h.templates)
-			return
+			return 0, 0, false
 		}
-	} else {
-		pageSize = defaultPageSize
 	}
 
-	limit := pageSize
-	offset := pageSize * (page - 1)
+	return page, pageSize, true
+}
 
-	problems, err := h.querier.GetAllPublishedProblemsSorted(ctx, h.pool, int32(limit), int32(offset))
-	if err != nil {
-		slog.Error("could not fetch problems", "error", err)
-		templates.RenderError(ctx, w, "could not fetch problems", http.StatusInternalServerError, h.templates)
-		return
+func pageURL(query url.Values, page int) string {
+	values := url.Values{}
+	for key, value := range query {
+		values[key] = value
+	}
+	values.Set("page", strconv.Itoa(page))
+	return "/problems?" + values.Encode()
+}
+
+// parseTags splits a comma separated list of tags, dropping empty and duplicate ones
+func parseTags(s string) ([]string, error) {
+	tags := lo.Uniq(lo.Filter(lo.Map(strings.Split(s, ","), func(tag string, _ int) string {
+		return problempackage.NormalizeTag(tag)
+	}), func(tag string, _ int) bool {
+		return tag != ""
+	}))
+
+	if len(tags) > maxTags {
+		return nil, fmt.Errorf("a problem can have at most %d tags", maxTags)
+	}
+	if lo.SomeBy(tags, func(tag string) bool { return len(tag) > maxTagLength }) {
+		return nil, fmt.Errorf("tags can be at most %d characters", maxTagLength)
 	}
 
-	err = h.templates.Render(ctx, "listproblemspage", w, listProblemsData{
-		Problems: lo.ToAnySlice(problems), CurrentPage: page, PageSize: pageSize,
+	return tags, nil
+}
+
+// parseDifficulty reads the difficulty form field, empty means not rated
+func parseDifficulty(s string) (pgtype.Int4, error) {
+	if s == "" {
+		return pgtype.Int4{}, nil
+	}
+	difficulty, err := strconv.Atoi(s)
+	if err != nil || difficulty < minDifficulty || difficulty > maxDifficulty {
+		return pgtype.Int4{}, fmt.Errorf("difficulty must be between %d and %d", minDifficulty, maxDifficulty)
+	}
+	return pgtype.Int4{Int32: int32(difficulty), Valid: true}, nil
+}
+
+// submissionSettings control what users see of submissions to a problem
+type submissionSettings struct {
+	// Visibility is who can read the code of submissions besides the submitter
+	Visibility storage.SubmissionVisibility
+	// RevealFailingTest shows the first failed test to the submitter, for practice problems
+	RevealFailingTest bool
+}
+
+// parseSubmissionSettings reads the submission settings form fields, an empty visibility means only the submitter
+func parseSubmissionSettings(r *http.Request) (submissionSettings, error) {
+	settings := submissionSettings{
+		Visibility:        storage.SubmissionVisibility(r.PostFormValue("submission_visibility")),
+		RevealFailingTest: r.PostFormValue("reveal_failing_test") != "",
+	}
+
+	switch settings.Visibility {
+	case "":
+		settings.Visibility = storage.SubmissionVisibilityPRIVATE
+	case storage.SubmissionVisibilityPRIVATE, storage.SubmissionVisibilitySTAFF:
+	default:
+		return submissionSettings{}, fmt.Errorf("invalid submission visibility %q", settings.Visibility)
+	}
+
+	return settings, nil
+}
+
+// saveProblemMetadata replaces the tags, difficulty and submission settings of a problem,
+// they are not part of its revisions
+func (h *DefaultHandler) saveProblemMetadata(ctx context.Context, db storage.DBTX, problemID int32, tags []string,
+	difficulty pgtype.Int4, settings submissionSettings) error {
+
+	if err := h.querier.UpdateProblemDifficulty(ctx, db, problemID, difficulty); err != nil {
+		return fmt.Errorf("could not update difficulty: %w", err)
+	}
+
+	err := h.querier.UpdateProblemSubmissionSettings(ctx, db, storage.UpdateProblemSubmissionSettingsParams{
+		ID:                   problemID,
+		SubmissionVisibility: settings.Visibility,
+		RevealFailingTest:    settings.RevealFailingTest,
 	})
 	if err != nil {
-		slog.Error("could not render listproblemspage", "error", err)
-		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, h.templates)
-		return
+		return fmt.Errorf("could not update submission settings: %w", err)
 	}
+
+	if err := h.querier.DeleteProblemTags(ctx, db, problemID); err != nil {
+		return fmt.Errorf("could not reset tags: %w", err)
+	}
+
+	for _, tag := range tags {
+		if err := h.querier.InsertProblemTag(ctx, db, problemID, tag); err != nil {
+			return fmt.Errorf("could not insert tag: %w", err)
+		}
+	}
+
+	return nil
 }
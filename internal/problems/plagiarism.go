@@ -0,0 +1,134 @@
+package problems
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/lo"
+
+	"github.com/computer-technology-team/go-judge/internal/plagiarism"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+const (
+	defaultPlagiarismMinScore = 0.6
+	maxPlagiarismPairs        = 200
+)
+
+type plagiarismPageData struct {
+	Problem   storage.Problem
+	MinScore  float64
+	Solutions int
+	Pairs     []plagiarismPair
+}
+
+type plagiarismPair struct {
+	A     storage.GetLatestAcceptedSubmissionsRow
+	B     storage.GetLatestAcceptedSubmissionsRow
+	Score float64
+}
+
+type plagiarismComparePageData struct {
+	Problem storage.Problem
+	A       storage.GetProblemSubmissionRow
+	B       storage.GetProblemSubmissionRow
+	Score   float64
+	Rows    []plagiarism.Row
+}
+
+// ShowPlagiarism ranks pairs of users by the similarity of their latest accepted solutions to a problem
+func (h *DefaultHandler) ShowPlagiarism(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := slog.With("function", "ShowPlagiarism", "package", "problems")
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	minScore := defaultPlagiarismMinScore
+	if value := r.URL.Query().Get("min_score"); value != "" {
+		percent, err := strconv.ParseFloat(value, 64)
+		if err != nil || percent < 0 || percent > 100 {
+			templates.RenderError(ctx, w, "minimum similarity must be a percentage", http.StatusBadRequest, h.templates)
+			return
+		}
+		minScore = percent / 100
+	}
+
+	solutions, err := h.querier.GetLatestAcceptedSubmissions(ctx, h.pool, problem.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "could not get accepted submissions", "error", err)
+		templates.RenderError(ctx, w, "could not get accepted submissions", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	fingerprints := lo.Map(solutions, func(s storage.GetLatestAcceptedSubmissionsRow, _ int) []plagiarism.Fingerprint {
+		return plagiarism.Fingerprints(s.Submission.SolutionCode)
+	})
+
+	pairs := plagiarism.Rank(fingerprints, minScore)
+	data := plagiarismPageData{Problem: problem, MinScore: minScore, Solutions: len(solutions)}
+	for _, pair := range pairs[:min(len(pairs), maxPlagiarismPairs)] {
+		data.Pairs = append(data.Pairs, plagiarismPair{A: solutions[pair.A], B: solutions[pair.B], Score: pair.Score})
+	}
+
+	err = h.templates.Render(ctx, "plagiarismpage", w, data)
+	if err != nil {
+		logger.ErrorContext(ctx, "could not render plagiarismpage", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, h.templates)
+		return
+	}
+}
+
+// ComparePlagiarism shows two submissions to a problem side by side with the code they share marked
+func (h *DefaultHandler) ComparePlagiarism(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := slog.With("function", "ComparePlagiarism", "package", "problems")
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	var submissions [2]storage.GetProblemSubmissionRow
+	for i, param := range []string{"a", "b"} {
+		id, err := uuid.Parse(r.URL.Query().Get(param))
+		if err != nil {
+			templates.RenderError(ctx, w, "invalid submission id", http.StatusBadRequest, h.templates)
+			return
+		}
+
+		submissions[i], err = h.querier.GetProblemSubmission(ctx, h.pool, problem.ID, pgtype.UUID{Bytes: id, Valid: true})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				templates.RenderError(ctx, w, "submission not found", http.StatusNotFound, h.templates)
+				return
+			}
+			logger.ErrorContext(ctx, "could not get submission", "error", err)
+			templates.RenderError(ctx, w, "could not get submission", http.StatusInternalServerError, h.templates)
+			return
+		}
+	}
+
+	a, b := submissions[0], submissions[1]
+	err := h.templates.Render(ctx, "plagiarismcomparepage", w, plagiarismComparePageData{
+		Problem: problem,
+		A:       a,
+		B:       b,
+		Score: plagiarism.Similarity(plagiarism.Fingerprints(a.Submission.SolutionCode),
+			plagiarism.Fingerprints(b.Submission.SolutionCode)),
+		Rows: plagiarism.SideBySide(a.Submission.SolutionCode, b.Submission.SolutionCode),
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "could not render plagiarismcomparepage", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, h.templates)
+		return
+	}
+}
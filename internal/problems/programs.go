@@ -0,0 +1,359 @@
+package problems
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/samber/lo"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/internal/testgen"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+const maxProgramNameLength = 64
+
+var programKinds = []storage.ProgramKind{
+	storage.ProgramKindGENERATOR,
+	storage.ProgramKindVALIDATOR,
+	storage.ProgramKindSOLUTION,
+}
+
+var expectedStatuses = []storage.SubmissionStatus{
+	storage.SubmissionStatusACCEPTED,
+	storage.SubmissionStatusWRONGANSWER,
+	storage.SubmissionStatusTIMELIMITEXCEEDED,
+	storage.SubmissionStatusMEMORYLIMITEXCEEDED,
+	storage.SubmissionStatusRUNTIMEERROR,
+}
+
+type generatedTestView struct {
+	storage.TestCase
+	Number        int
+	GeneratorName string
+	Generated     bool
+	Outdated      bool
+}
+
+type programsPageData struct {
+	Problem          storage.Problem
+	Programs         []storage.ProblemProgram
+	Generators       []storage.ProblemProgram
+	Kinds            []storage.ProgramKind
+	ExpectedStatuses []storage.SubmissionStatus
+	GeneratedTests   []generatedTestView
+	Generation       *storage.TestGeneration
+	Verification     *storage.ProblemVerification
+}
+
+// ShowPrograms lists the generators, validator and solutions of a problem with its generated tests and verification
+func (h *DefaultHandler) ShowPrograms(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := slog.With("function", "ShowPrograms", "package", "problems")
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	programs, err := h.querier.GetProblemPrograms(ctx, h.pool, problem.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "could not get programs", "error", err)
+		templates.RenderError(ctx, w, "could not get programs", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	testCases, err := h.querier.GetTestCasesByProblemID(ctx, h.pool, problem.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "could not get test cases", "error", err)
+		templates.RenderError(ctx, w, "could not get test cases", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	var generation *storage.TestGeneration
+	latest, err := h.querier.GetLatestTestGeneration(ctx, h.pool, problem.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		logger.ErrorContext(ctx, "could not get latest generation", "error", err)
+		templates.RenderError(ctx, w, "could not get latest generation", http.StatusInternalServerError, h.templates)
+		return
+	} else if err == nil {
+		generation = &latest
+	}
+
+	var problemVerification *storage.ProblemVerification
+	latestVerification, err := h.querier.GetLatestProblemVerification(ctx, h.pool, problem.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		logger.ErrorContext(ctx, "could not get latest verification", "error", err)
+		templates.RenderError(ctx, w, "could not get latest verification", http.StatusInternalServerError, h.templates)
+		return
+	} else if err == nil {
+		problemVerification = &latestVerification
+	}
+
+	byID := lo.SliceToMap(programs, func(p storage.ProblemProgram) (int32, storage.ProblemProgram) {
+		return p.ID, p
+	})
+	solution, hasSolution := lo.Find(programs, func(p storage.ProblemProgram) bool {
+		return p.Main
+	})
+
+	var generatedTests []generatedTestView
+	for i, tc := range testCases {
+		if !tc.GeneratorID.Valid {
+			continue
+		}
+
+		generator := byID[tc.GeneratorID.Int32]
+		generatedTests = append(generatedTests, generatedTestView{
+			TestCase:      tc,
+			Number:        i + 1,
+			GeneratorName: generator.Name,
+			Generated:     tc.InputKey.Valid && tc.OutputKey.Valid,
+			Outdated:      testgen.Outdated(tc, &generator, lo.Ternary(hasSolution, &solution, nil)),
+		})
+	}
+
+	err = h.templates.Render(ctx, "programspage", w, programsPageData{
+		Problem:  problem,
+		Programs: programs,
+		Generators: lo.Filter(programs, func(p storage.ProblemProgram, _ int) bool {
+			return p.Kind == storage.ProgramKindGENERATOR
+		}),
+		Kinds:            programKinds,
+		ExpectedStatuses: expectedStatuses,
+		GeneratedTests:   generatedTests,
+		Generation:       generation,
+		Verification:     problemVerification,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "could not render programspage", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, h.templates)
+		return
+	}
+}
+
+// CreateProgram adds a generator, validator or solution to a problem
+func (h *DefaultHandler) CreateProgram(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := slog.With("function", "CreateProgram", "package", "problems")
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		templates.RenderError(ctx, w, "invalid form data", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	kind := storage.ProgramKind(r.PostFormValue("kind"))
+	name := strings.TrimSpace(r.PostFormValue("name"))
+	source := r.PostFormValue("source")
+
+	if !lo.Contains(programKinds, kind) {
+		templates.RenderError(ctx, w, "invalid program kind", http.StatusBadRequest, h.templates)
+		return
+	}
+	if name == "" || len(name) > maxProgramNameLength || strings.TrimSpace(source) == "" {
+		templates.RenderError(ctx, w, fmt.Sprintf("name (at most %d characters) and source are required", maxProgramNameLength),
+			http.StatusBadRequest, h.templates)
+		return
+	}
+
+	var expectedStatus storage.NullSubmissionStatus
+	isMain := false
+	if kind == storage.ProgramKindSOLUTION {
+		expectedStatus = storage.NullSubmissionStatus{
+			SubmissionStatus: storage.SubmissionStatus(r.PostFormValue("expected_status")),
+			Valid:            true,
+		}
+		if !lo.Contains(expectedStatuses, expectedStatus.SubmissionStatus) {
+			templates.RenderError(ctx, w, "invalid expected verdict", http.StatusBadRequest, h.templates)
+			return
+		}
+
+		isMain = r.PostFormValue("main") == "on"
+		if isMain && expectedStatus.SubmissionStatus != storage.SubmissionStatusACCEPTED {
+			templates.RenderError(ctx, w, "the main solution must be expected to be accepted", http.StatusBadRequest, h.templates)
+			return
+		}
+	}
+
+	err := h.withTransaction(ctx, func(tx pgx.Tx) error {
+		program, err := h.querier.InsertProblemProgram(ctx, tx, storage.InsertProblemProgramParams{
+			ProblemID:      problem.ID,
+			Kind:           kind,
+			Name:           name,
+			Source:         source,
+			ExpectedStatus: expectedStatus,
+			Main:           isMain,
+		})
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, tx, h.querier, audit.Event{
+			Action:     audit.ProblemAddProgram,
+			TargetType: audit.TargetProblem,
+			TargetID:   strconv.Itoa(int(problem.ID)),
+			After:      newAuditedProgram(program),
+		})
+	})
+	if err != nil {
+		if storage.IsUniqueViolation(err) {
+			templates.RenderError(ctx, w, "program names must be unique, and a problem has at most one validator and one main solution",
+				http.StatusBadRequest, h.templates)
+			return
+		}
+		logger.ErrorContext(ctx, "could not insert program", "error", err)
+		templates.RenderError(ctx, w, "could not insert program", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, programsURL(problem.ID), http.StatusSeeOther)
+}
+
+// UpdateProgram replaces the source of a program, generated tests become outdated until generated again
+func (h *DefaultHandler) UpdateProgram(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := slog.With("function", "UpdateProgram", "package", "problems")
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	programID, err := strconv.Atoi(chi.URLParam(r, "program_id"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid program id", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		templates.RenderError(ctx, w, "invalid form data", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	source := r.PostFormValue("source")
+	if strings.TrimSpace(source) == "" {
+		templates.RenderError(ctx, w, "source is required", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	err = h.withTransaction(ctx, func(tx pgx.Tx) error {
+		before, err := h.querier.GetProblemProgram(ctx, tx, int32(programID), problem.ID)
+		if err != nil {
+			return err
+		}
+
+		after, err := h.querier.UpdateProblemProgramSource(ctx, tx, storage.UpdateProblemProgramSourceParams{
+			ID:        int32(programID),
+			ProblemID: problem.ID,
+			Source:    source,
+		})
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, tx, h.querier, audit.Event{
+			Action:     audit.ProblemUpdateProgram,
+			TargetType: audit.TargetProblem,
+			TargetID:   strconv.Itoa(int(problem.ID)),
+			Before:     newAuditedProgram(before),
+			After:      newAuditedProgram(after),
+		})
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "program not found", http.StatusNotFound, h.templates)
+			return
+		}
+		logger.ErrorContext(ctx, "could not update program", "error", err)
+		templates.RenderError(ctx, w, "could not update program", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, programsURL(problem.ID), http.StatusSeeOther)
+}
+
+// DeleteProgram removes a program, generators are only removed once they generate no tests
+func (h *DefaultHandler) DeleteProgram(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	programID, err := strconv.Atoi(chi.URLParam(r, "program_id"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid program id", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	err = h.withRevision(ctx, problem.ID, func(tx pgx.Tx) error {
+		program, err := h.querier.DeleteProblemProgram(ctx, tx, int32(programID), problem.ID)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, tx, h.querier, audit.Event{
+			Action:     audit.ProblemDeleteProgram,
+			TargetType: audit.TargetProblem,
+			TargetID:   strconv.Itoa(int(problem.ID)),
+			Before:     newAuditedProgram(program),
+		})
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		templates.RenderError(ctx, w, "program not found", http.StatusNotFound, h.templates)
+		return
+	}
+	if storage.IsForeignKeyViolation(err) {
+		templates.RenderError(ctx, w, "this generator still produces tests, delete its generated tests first",
+			http.StatusConflict, h.templates)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "could not delete program", "error", err, "program_id", programID)
+		templates.RenderError(ctx, w, "could not delete program", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, programsURL(problem.ID), http.StatusSeeOther)
+}
+
+// auditedProgram is what the audit log keeps of a program, sources are only kept as their hash
+type auditedProgram struct {
+	ID             int32  `json:"id"`
+	Kind           string `json:"kind"`
+	Name           string `json:"name"`
+	Main           bool   `json:"main"`
+	ExpectedStatus string `json:"expected_status,omitempty"`
+	SourceSHA256   string `json:"source_sha256"`
+}
+
+func newAuditedProgram(p storage.ProblemProgram) auditedProgram {
+	sum := sha256.Sum256([]byte(p.Source))
+	return auditedProgram{
+		ID:             p.ID,
+		Kind:           string(p.Kind),
+		Name:           p.Name,
+		Main:           p.Main,
+		ExpectedStatus: string(p.ExpectedStatus.SubmissionStatus),
+		SourceSHA256:   hex.EncodeToString(sum[:]),
+	}
+}
+
+func programsURL(problemID int32) string {
+	return "/problems/" + strconv.Itoa(int(problemID)) + "/programs"
+}
@@ -0,0 +1,243 @@
+package problems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/revisions"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+type revisionsPageData struct {
+	Problem   storage.Problem
+	Revisions []storage.GetProblemRevisionsRow
+}
+
+type revisionDiffPageData struct {
+	Problem storage.Problem
+	From    storage.ProblemRevision
+	To      storage.ProblemRevision
+	Diffs   []revisions.FileDiff
+}
+
+// ShowRevisions lists the revisions of a problem, newest first
+func (h *DefaultHandler) ShowRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	problemRevisions, err := h.querier.GetProblemRevisions(ctx, h.pool, problem.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get revisions", "error", err, "problem_id", problem.ID)
+		templates.RenderError(ctx, w, "could not get revisions", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	err = h.templates.Render(ctx, "revisionspage", w, revisionsPageData{
+		Problem:   problem,
+		Revisions: problemRevisions,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render revisionspage", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, h.templates)
+		return
+	}
+}
+
+// DiffRevisions shows what changed between the revisions in the from and to query params
+func (h *DefaultHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := slog.With("function", "DiffRevisions", "package", "problems")
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	fromNumber, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid from revision", http.StatusBadRequest, h.templates)
+		return
+	}
+	toNumber, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid to revision", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	from, fromTests, err := h.getRevision(ctx, problem.ID, int32(fromNumber))
+	if err != nil {
+		if errors.Is(err, revisions.ErrRevisionNotFound) {
+			templates.RenderError(ctx, w, err.Error(), http.StatusNotFound, h.templates)
+			return
+		}
+		logger.ErrorContext(ctx, "could not get revision", "error", err)
+		templates.RenderError(ctx, w, "could not get revision", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	to, toTests, err := h.getRevision(ctx, problem.ID, int32(toNumber))
+	if err != nil {
+		if errors.Is(err, revisions.ErrRevisionNotFound) {
+			templates.RenderError(ctx, w, err.Error(), http.StatusNotFound, h.templates)
+			return
+		}
+		logger.ErrorContext(ctx, "could not get revision", "error", err)
+		templates.RenderError(ctx, w, "could not get revision", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	diffs, err := revisions.Diff(from, fromTests, to, toTests)
+	if err != nil {
+		logger.ErrorContext(ctx, "could not diff revisions", "error", err)
+		templates.RenderError(ctx, w, "could not diff revisions", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	err = h.templates.Render(ctx, "revisiondiffpage", w, revisionDiffPageData{
+		Problem: problem,
+		From:    from,
+		To:      to,
+		Diffs:   diffs,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "could not render revisiondiffpage", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, h.templates)
+		return
+	}
+}
+
+// RollbackRevision restores an older revision of a problem as its newest revision
+func (h *DefaultHandler) RollbackRevision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid revision", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		templates.RenderError(ctx, w, "could not begin rollback", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	defer func(ctx context.Context, tx pgx.Tx) {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}(ctx, tx)
+
+	revision, err := revisions.Rollback(ctx, tx, h.querier, problem.ID, int32(number), user.ID)
+	if err != nil {
+		if errors.Is(err, revisions.ErrRevisionNotFound) {
+			templates.RenderError(ctx, w, err.Error(), http.StatusNotFound, h.templates)
+			return
+		}
+		slog.ErrorContext(ctx, "could not roll back problem", "error", err, "problem_id", problem.ID, "revision", number)
+		templates.RenderError(ctx, w, "could not roll back problem", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	err = audit.Record(ctx, tx, h.querier, audit.Event{
+		Action:     audit.ProblemRollback,
+		TargetType: audit.TargetProblem,
+		TargetID:   strconv.Itoa(int(problem.ID)),
+		After:      map[string]int32{"restored_revision": int32(number), "revision": revision.Revision},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err)
+		templates.RenderError(ctx, w, "could not record audit event", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "could not commit transaction", "error", err)
+		templates.RenderError(ctx, w, "could not finalize rollback", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	slog.Info("Problem rolled back", "problem_id", problem.ID, "from_revision", number, "revision", revision.Revision)
+
+	http.Redirect(w, r, revisionsURL(problem.ID), http.StatusSeeOther)
+}
+
+func (h *DefaultHandler) getRevision(ctx context.Context, problemID, number int32) (
+	storage.ProblemRevision, []storage.ProblemRevisionTest, error) {
+
+	revision, err := h.querier.GetProblemRevisionByNumber(ctx, h.pool, problemID, number)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ProblemRevision{}, nil, revisions.ErrRevisionNotFound
+		}
+		return storage.ProblemRevision{}, nil, fmt.Errorf("could not get revision: %w", err)
+	}
+
+	tests, err := h.querier.GetProblemRevisionTests(ctx, h.pool, revision.ID)
+	if err != nil {
+		return storage.ProblemRevision{}, nil, fmt.Errorf("could not get revision tests: %w", err)
+	}
+
+	return revision, tests, nil
+}
+
+// withRevision runs change in a transaction and records the resulting revision of the problem in it
+func (h *DefaultHandler) withRevision(ctx context.Context, problemID int32, change func(tx pgx.Tx) error) error {
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	return h.withTransaction(ctx, func(tx pgx.Tx) error {
+		if err := change(tx); err != nil {
+			return err
+		}
+
+		_, err := revisions.Snapshot(ctx, tx, h.querier, problemID, user.ID)
+		return err
+	})
+}
+
+// withTransaction runs change in a transaction, committing it when change succeeds
+func (h *DefaultHandler) withTransaction(ctx context.Context, change func(tx pgx.Tx) error) error {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func(ctx context.Context, tx pgx.Tx) {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}(ctx, tx)
+
+	if err := change(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func revisionsURL(problemID int32) string {
+	return "/problems/" + strconv.Itoa(int(problemID)) + "/revisions"
+}
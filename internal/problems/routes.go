@@ -1,13 +1,20 @@
 package problems
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	runnerPb "github.com/computer-technology-team/go-judge/api/gen/runner"
 	"github.com/computer-technology-team/go-judge/internal/middleware"
+	"github.com/computer-technology-team/go-judge/internal/problempackage"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
 	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/internal/testgen"
+	"github.com/computer-technology-team/go-judge/internal/verification"
 	"github.com/computer-technology-team/go-judge/web/templates"
 )
 
@@ -21,6 +28,34 @@ type Handler interface {
 	ProblemForm(w http.ResponseWriter, r *http.Request)
 
 	ToggleStatus(w http.ResponseWriter, r *http.Request)
+
+	PreviewTestUpload(w http.ResponseWriter, r *http.Request)
+	ConfirmTestUpload(w http.ResponseWriter, r *http.Request)
+
+	ShowPrograms(w http.ResponseWriter, r *http.Request)
+	CreateProgram(w http.ResponseWriter, r *http.Request)
+	UpdateProgram(w http.ResponseWriter, r *http.Request)
+	DeleteProgram(w http.ResponseWriter, r *http.Request)
+	AddGeneratedTests(w http.ResponseWriter, r *http.Request)
+	DeleteGeneratedTest(w http.ResponseWriter, r *http.Request)
+	GenerateTests(w http.ResponseWriter, r *http.Request)
+	VerifyProblem(w http.ResponseWriter, r *http.Request)
+
+	ShowRevisions(w http.ResponseWriter, r *http.Request)
+	DiffRevisions(w http.ResponseWriter, r *http.Request)
+	RollbackRevision(w http.ResponseWriter, r *http.Request)
+
+	UploadAttachment(w http.ResponseWriter, r *http.Request)
+	DeleteAttachment(w http.ResponseWriter, r *http.Request)
+	ServeAttachment(w http.ResponseWriter, r *http.Request)
+
+	ShowImportProblem(w http.ResponseWriter, r *http.Request)
+	ImportProblem(w http.ResponseWriter, r *http.Request)
+	ExportProblem(w http.ResponseWriter, r *http.Request)
+	DownloadTests(w http.ResponseWriter, r *http.Request)
+
+	ShowPlagiarism(w http.ResponseWriter, r *http.Request)
+	ComparePlagiarism(w http.ResponseWriter, r *http.Request)
 }
 
 // NewRoutes returns a function that registers routes with the given handler
@@ -30,24 +65,66 @@ func NewRoutes(h Handler, sharedTmpls *templates.Templates) func(r chi.Router) {
 		r.Get("/", h.ListProblems)
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.NewRequireAuthMiddleware(sharedTmpls))
-			r.Post("/", h.CreateProblem)
+			r.With(middleware.NewRequirePermissionMiddleware(sharedTmpls, rbac.CreateProblems)).
+				Post("/", h.CreateProblem)
 			r.Get("/form/{id}", h.ProblemForm)
 			r.Post("/{id}", h.UpdateProblem)
 			r.Post("/{id}/toggle-status", h.ToggleStatus)
+			r.Get("/{id}/tests.zip", h.DownloadTests)
+			r.Post("/{id}/tests/upload", h.PreviewTestUpload)
+			r.Post("/{id}/tests/upload/{upload_id}", h.ConfirmTestUpload)
+			r.Get("/{id}/programs", h.ShowPrograms)
+			r.Post("/{id}/programs", h.CreateProgram)
+			r.Post("/{id}/programs/{program_id}", h.UpdateProgram)
+			r.Post("/{id}/programs/{program_id}/delete", h.DeleteProgram)
+			r.Post("/{id}/tests/generated", h.AddGeneratedTests)
+			r.Post("/{id}/tests/generated/{test_id}/delete", h.DeleteGeneratedTest)
+			r.Post("/{id}/tests/generate", h.GenerateTests)
+			r.Post("/{id}/verify", h.VerifyProblem)
+			r.Get("/{id}/revisions", h.ShowRevisions)
+			r.Get("/{id}/revisions/diff", h.DiffRevisions)
+			r.Post("/{id}/revisions/{revision}/rollback", h.RollbackRevision)
+			r.Post("/{id}/attachments", h.UploadAttachment)
+			r.Post("/{id}/attachments/{name}/delete", h.DeleteAttachment)
 			r.Get("/my", h.ListMyProblems)
 		})
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.NewRequirePermissionMiddleware(sharedTmpls, rbac.ManageProblems))
+			r.Get("/import", h.ShowImportProblem)
+			r.Post("/import", h.ImportProblem)
+			r.Get("/{id}/export", h.ExportProblem)
+			r.Get("/{id}/plagiarism", h.ShowPlagiarism)
+			r.Get("/{id}/plagiarism/compare", h.ComparePlagiarism)
+		})
 		r.Get("/{id}", h.ViewProblem)
+		r.Get("/{id}/attachments/{name}", h.ServeAttachment)
 	}
 }
 
+// database is the part of the pool the handler uses
+type database interface {
+	storage.DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 // DefaultHandler is the default implementation of the Handler interface
 type DefaultHandler struct {
 	templates *templates.Templates
-	pool      *pgxpool.Pool
+	pool      database
 	querier   storage.Querier
+	packages  *problempackage.Store
+	generator *testgen.Generator
+	verifier  *verification.Verifier
 }
 
 // NewHandler creates a new instance of the default problem handler
-func NewHandler(templates *templates.Templates, pool *pgxpool.Pool, querier storage.Querier) Handler {
-	return &DefaultHandler{templates: templates, pool: pool, querier: querier}
+func NewHandler(templates *templates.Templates, pool *pgxpool.Pool, querier storage.Querier, runnerClient runnerPb.RunnerClient) Handler {
+	return &DefaultHandler{
+		templates: templates,
+		pool:      pool,
+		querier:   querier,
+		packages:  problempackage.NewStore(pool, querier),
+		generator: testgen.NewGenerator(pool, querier, runnerClient),
+		verifier:  verification.NewVerifier(pool, querier, runnerClient),
+	}
 }
@@ -0,0 +1,64 @@
+package problems
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+const maxSamples = 10
+
+// parseSamples reads the numbered sample_input_N, sample_output_N and sample_explanation_N form fields,
+// stopping at the first sample without input or output
+func parseSamples(r *http.Request) ([]storage.TestCase, error) {
+	samples := []storage.TestCase{}
+
+	for i := 1; ; i++ {
+		input := r.PostFormValue("sample_input_" + strconv.Itoa(i))
+		output := r.PostFormValue("sample_output_" + strconv.Itoa(i))
+		if input == "" || output == "" {
+			break
+		}
+		samples = append(samples, storage.TestCase{
+			Input:       input,
+			Output:      output,
+			Explanation: r.PostFormValue("sample_explanation_" + strconv.Itoa(i)),
+			IsSample:    true,
+		})
+	}
+
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("at least one sample is required")
+	}
+	if len(samples) > maxSamples {
+		return nil, fmt.Errorf("a problem can have at most %d samples", maxSamples)
+	}
+
+	return samples, nil
+}
+
+// replaceSamples swaps the samples of a problem, they are judged like the other tests
+func (h *DefaultHandler) replaceSamples(ctx context.Context, db storage.DBTX, problemID int32,
+	samples []storage.TestCase) error {
+
+	if err := h.querier.DeleteProblemSamples(ctx, db, problemID); err != nil {
+		return fmt.Errorf("could not reset samples: %w", err)
+	}
+
+	for _, sample := range samples {
+		_, err := h.querier.InsertSampleTestCase(ctx, db, storage.InsertSampleTestCaseParams{
+			ProblemID:   problemID,
+			Input:       sample.Input,
+			Output:      sample.Output,
+			Explanation: sample.Explanation,
+		})
+		if err != nil {
+			return fmt.Errorf("could not insert sample: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -1,58 +1,71 @@
 package problems
 
 import (
-	"errors"
 	"log/slog"
 	"net/http"
-	"strconv"
+	"strings"
 
 	"github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
 	"github.com/computer-technology-team/go-judge/internal/storage"
 	"github.com/computer-technology-team/go-judge/web/templates"
 	"github.com/go-chi/chi/v5"
-	"github.com/jackc/pgx/v5"
 )
 
 type problemFormData struct {
-	Problem   storage.Problem
-	TestCases []storage.TestCase
+	Problem     storage.Problem
+	Samples     []storage.TestCase
+	TestCases   []storage.TestCase
+	Tags        string
+	Attachments []storage.GetProblemAttachmentsRow
 }
 
 func (h *DefaultHandler) ProblemForm(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	idStr := chi.URLParam(r, "id")
-	user, _ := context.GetUserFromContext(ctx)
+
+	if idStr == "new" && !context.HasPermission(ctx, rbac.CreateProblems) {
+		templates.RenderError(ctx, w, "you are not allowed to create problems", http.StatusForbidden, h.templates)
+		return
+	}
 
 	var data *problemFormData
 	if idStr != "new" {
-		id, err := strconv.Atoi(idStr)
+		problem, ok := h.getEditableProblem(w, r)
+		if !ok {
+			return
+		}
+
+		testCases, err := h.querier.GetManualTestCasesByProblemID(ctx, h.pool, problem.ID)
 		if err != nil {
-			templates.RenderError(ctx, w, "invalid problem id", http.StatusBadRequest, h.templates)
+			templates.RenderError(ctx, w, "could not get problem from storage", http.StatusBadRequest, h.templates)
 			return
 		}
-		problem, err := h.querier.GetProblemForUser(ctx, h.pool, storage.GetProblemForUserParams{
-			ID:        int32(id),
-			CreatedBy: user.ID,
-			IsAdmin:   user.Superuser,
-		})
+
+		samples, err := h.querier.GetProblemSamples(ctx, h.pool, problem.ID)
+		if err != nil {
+			templates.RenderError(ctx, w, "could not get problem from storage", http.StatusBadRequest, h.templates)
+			return
+		}
+
+		tags, err := h.querier.GetProblemTags(ctx, h.pool, problem.ID)
 		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				templates.RenderError(ctx, w, "problem not found", http.StatusBadRequest, h.templates)
-				return
-			}
 			templates.RenderError(ctx, w, "could not get problem from storage", http.StatusBadRequest, h.templates)
 			return
 		}
 
-		testCases, err := h.querier.GetTestCasesByProblemID(ctx, h.pool, problem.ID)
+		attachments, err := h.querier.GetProblemAttachments(ctx, h.pool, problem.ID)
 		if err != nil {
 			templates.RenderError(ctx, w, "could not get problem from storage", http.StatusBadRequest, h.templates)
 			return
 		}
 
 		data = &problemFormData{
-			Problem:   problem,
-			TestCases: testCases,
+			Problem:     problem,
+			Samples:     samples,
+			TestCases:   testCases,
+			Tags:        strings.Join(tags, ", "),
+			Attachments: attachments,
 		}
 	}
 
@@ -2,10 +2,14 @@ package problems
 
 import (
 	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 
+	"github.com/computer-technology-team/go-judge/internal/audit"
 	"github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+	"github.com/computer-technology-team/go-judge/internal/verification"
 	"github.com/computer-technology-team/go-judge/web/templates"
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
@@ -13,8 +17,7 @@ import (
 
 func (h *DefaultHandler) ToggleStatus(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	user, _ := context.GetUserFromContext(ctx)
-	if !user.Superuser {
+	if !context.HasPermission(ctx, rbac.ManageProblems) {
 		templates.RenderError(ctx, w, "only admins can publish", http.StatusUnauthorized, h.templates)
 		return
 	}
@@ -37,17 +40,62 @@ func (h *DefaultHandler) ToggleStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if problem.Draft {
-		err = h.querier.PublishProblem(ctx, h.pool, int32(id))
-		if err != nil {
-			templates.RenderError(ctx, w, "could not publish problem", http.StatusBadRequest, h.templates)
+		if err := h.verifier.CheckPublishable(ctx, problem.ID); err != nil {
+			if errors.Is(err, verification.ErrNoMainSolution) {
+				templates.RenderError(ctx, w, "add a main solution expected to be accepted before publishing", http.StatusBadRequest, h.templates)
+				return
+			}
+			if errors.Is(err, verification.ErrNotVerified) {
+				templates.RenderError(ctx, w, "verify the problem solutions on the current tests before publishing", http.StatusBadRequest, h.templates)
+				return
+			}
+			templates.RenderError(ctx, w, "could not check problem verification", http.StatusInternalServerError, h.templates)
 			return
 		}
-	} else {
-		err = h.querier.DraftProblem(ctx, h.pool, int32(id))
-		if err != nil {
-			templates.RenderError(ctx, w, "could not publish problem", http.StatusBadRequest, h.templates)
-			return
+	}
+
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		templates.RenderError(ctx, w, "could not begin update", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
 		}
+	}()
+
+	action := audit.ProblemUnpublish
+	if problem.Draft {
+		action = audit.ProblemPublish
+		err = h.querier.PublishProblem(ctx, tx, int32(id))
+	} else {
+		err = h.querier.DraftProblem(ctx, tx, int32(id))
+	}
+	if err != nil {
+		templates.RenderError(ctx, w, "could not publish problem", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	err = audit.Record(ctx, tx, h.querier, audit.Event{
+		Action:     action,
+		TargetType: audit.TargetProblem,
+		TargetID:   strconv.Itoa(id),
+		Before:     map[string]bool{"draft": problem.Draft},
+		After:      map[string]bool{"draft": !problem.Draft},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err)
+		templates.RenderError(ctx, w, "could not record audit event", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "could not commit transaction", "error", err)
+		templates.RenderError(ctx, w, "could not finalize update", http.StatusInternalServerError, h.templates)
+		return
 	}
 
 	http.Redirect(w, r, "/problems/my", http.StatusMovedPermanently)
@@ -7,9 +7,11 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/revisions"
 	"github.com/computer-technology-team/go-judge/internal/storage"
 	"github.com/computer-technology-team/go-judge/web/templates"
-	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
 )
 
@@ -17,15 +19,13 @@ import (
 func (h *DefaultHandler) UpdateProblem(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	idStr := chi.URLParam(r, "id")
-
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		templates.RenderError(ctx, w, "invalid problem id", http.StatusBadRequest, h.templates)
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
 		return
 	}
+	id := int(problem.ID)
 
-	err = r.ParseForm()
+	err := r.ParseForm()
 	if err != nil {
 		slog.Error("could not parse form data", "error", err)
 		templates.RenderError(r.Context(), w, "invalid form data", http.StatusBadRequest, h.templates)
@@ -35,8 +35,6 @@ func (h *DefaultHandler) UpdateProblem(w http.ResponseWriter, r *http.Request) {
 	// Extract form data
 	title := r.PostFormValue("title")
 	description := r.PostFormValue("description")
-	sampleInput := r.PostFormValue("sample_input")
-	sampleOutput := r.PostFormValue("sample_output")
 	timeLimit := r.PostFormValue("time_limit")
 	memoryLimit := r.PostFormValue("memory_limit")
 	testCases := []storage.TestCase{}
@@ -54,9 +52,15 @@ func (h *DefaultHandler) UpdateProblem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate required fields
-	if title == "" || description == "" || sampleInput == "" || sampleOutput == "" {
+	if title == "" || description == "" {
 		slog.Error("missing required fields")
-		templates.RenderError(r.Context(), w, "title, description, sample input, and sample output are required", http.StatusBadRequest, h.templates)
+		templates.RenderError(r.Context(), w, "title and description are required", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	samples, err := parseSamples(r)
+	if err != nil {
+		templates.RenderError(r.Context(), w, err.Error(), http.StatusBadRequest, h.templates)
 		return
 	}
 
@@ -83,6 +87,24 @@ func (h *DefaultHandler) UpdateProblem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tags, err := parseTags(r.PostFormValue("tags"))
+	if err != nil {
+		templates.RenderError(r.Context(), w, err.Error(), http.StatusBadRequest, h.templates)
+		return
+	}
+
+	difficulty, err := parseDifficulty(r.PostFormValue("difficulty"))
+	if err != nil {
+		templates.RenderError(r.Context(), w, err.Error(), http.StatusBadRequest, h.templates)
+		return
+	}
+
+	settings, err := parseSubmissionSettings(r)
+	if err != nil {
+		templates.RenderError(r.Context(), w, err.Error(), http.StatusBadRequest, h.templates)
+		return
+	}
+
 	tx, err := h.pool.Begin(ctx)
 	if err != nil {
 		templates.RenderError(ctx, w, "could not begin update", http.StatusInternalServerError, h.templates)
@@ -96,6 +118,13 @@ func (h *DefaultHandler) UpdateProblem(w http.ResponseWriter, r *http.Request) {
 		}
 	}(ctx, tx)
 
+	before, err := h.getAuditedProblem(ctx, tx, int32(id))
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get problem", "error", err, "problem_id", id)
+		templates.RenderError(ctx, w, "could not get problem", http.StatusInternalServerError, h.templates)
+		return
+	}
+
 	// Delete existing test cases
 	err = h.querier.DeleteProblemTestCases(ctx, tx, int32(id))
 	if err != nil {
@@ -109,8 +138,6 @@ func (h *DefaultHandler) UpdateProblem(w http.ResponseWriter, r *http.Request) {
 		ID:            int32(id),
 		Title:         title,
 		Description:   description,
-		SampleInput:   sampleInput,
-		SampleOutput:  sampleOutput,
 		TimeLimitMs:   int64(timeLimitInt),
 		MemoryLimitKb: int64(memoryLimitInt),
 	})
@@ -134,6 +161,45 @@ func (h *DefaultHandler) UpdateProblem(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := h.replaceSamples(ctx, tx, p.ID, samples); err != nil {
+		slog.Error("could not save samples", "error", err)
+		templates.RenderError(r.Context(), w, "could not save samples", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	if err := h.saveProblemMetadata(ctx, tx, p.ID, tags, difficulty, settings); err != nil {
+		slog.Error("could not save problem metadata", "error", err)
+		templates.RenderError(ctx, w, "could not save tags and difficulty", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	user, _ := internalcontext.GetUserFromContext(ctx)
+	if _, err := revisions.Snapshot(ctx, tx, h.querier, p.ID, user.ID); err != nil {
+		slog.Error("could not record revision", "error", err)
+		templates.RenderError(ctx, w, "could not record revision", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	after, err := h.getAuditedProblem(ctx, tx, p.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get problem", "error", err, "problem_id", p.ID)
+		templates.RenderError(ctx, w, "could not get problem", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	err = audit.Record(ctx, tx, h.querier, audit.Event{
+		Action:     audit.ProblemUpdate,
+		TargetType: audit.TargetProblem,
+		TargetID:   strconv.Itoa(int(p.ID)),
+		Before:     before,
+		After:      after,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err)
+		templates.RenderError(ctx, w, "could not record audit event", http.StatusInternalServerError, h.templates)
+		return
+	}
+
 	err = tx.Commit(ctx)
 	if err != nil {
 		slog.Error("could not commit transaction", "error", err)
@@ -145,3 +211,36 @@ func (h *DefaultHandler) UpdateProblem(w http.ResponseWriter, r *http.Request) {
 
 	http.Redirect(w, r, "/problems/"+strconv.Itoa(int(p.ID)), http.StatusSeeOther)
 }
+
+// auditedProblem is what the audit log keeps of a problem when it changes
+type auditedProblem struct {
+	Title         string `json:"title"`
+	TimeLimitMs   int64  `json:"time_limit_ms"`
+	MemoryLimitKb int64  `json:"memory_limit_kb"`
+	Draft         bool   `json:"draft"`
+	Tests         int    `json:"tests"`
+}
+
+func newAuditedProblem(p storage.Problem, tests int) auditedProblem {
+	return auditedProblem{
+		Title:         p.Title,
+		TimeLimitMs:   p.TimeLimitMs,
+		MemoryLimitKb: p.MemoryLimitKb,
+		Draft:         p.Draft,
+		Tests:         tests,
+	}
+}
+
+func (h *DefaultHandler) getAuditedProblem(ctx context.Context, db storage.DBTX, problemID int32) (
+	auditedProblem, error) {
+	problem, err := h.querier.GetProblemByID(ctx, db, problemID)
+	if err != nil {
+		return auditedProblem{}, err
+	}
+
+	tests, err := h.querier.GetTestCasesByProblemID(ctx, db, problemID)
+	if err != nil {
+		return auditedProblem{}, err
+	}
+	return newAuditedProblem(problem, len(tests)), nil
+}
@@ -0,0 +1,79 @@
+package problems
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+type UpdateProblemTestSuite struct {
+	suite.Suite
+	author  *storage.User
+	querier *fakeQuerier
+	db      *fakeDatabase
+	router  chi.Router
+}
+
+func (s *UpdateProblemTestSuite) SetupTest() {
+	tmpl, err := templates.GetSharedTemplates()
+	require.NoError(s.T(), err)
+
+	s.author = &storage.User{ID: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Username: "author"}
+	s.querier = &fakeQuerier{
+		problems:    map[int32]storage.Problem{1: {ID: 1, CreatedBy: s.author.ID}},
+		attachments: map[int32]storage.ProblemAttachment{},
+	}
+	s.db = &fakeDatabase{}
+
+	h := &DefaultHandler{templates: tmpl, pool: s.db, querier: s.querier}
+	s.router = chi.NewRouter()
+	s.router.Get("/problems/form/{id}", h.ProblemForm)
+	s.router.Post("/problems/{id}", h.UpdateProblem)
+}
+
+func (s *UpdateProblemTestSuite) serve(req *http.Request, user *storage.User) *httptest.ResponseRecorder {
+	req = req.WithContext(context.WithValue(req.Context(), internalcontext.UserContextKey, user))
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *UpdateProblemTestSuite) TestNonAuthorCannotEdit() {
+	contestant := &storage.User{ID: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Username: "alice"}
+
+	form := url.Values{
+		"title":         {"Replaced"},
+		"description":   {"Replaced"},
+		"time_limit":    {"1000"},
+		"memory_limit":  {"65536"},
+		"test_input_1":  {"1"},
+		"test_output_1": {"1"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/problems/1", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := s.serve(req, contestant)
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code)
+	assert.Zero(s.T(), s.db.committed)
+	assert.Empty(s.T(), s.querier.events)
+
+	rec = s.serve(httptest.NewRequest(http.MethodGet, "/problems/form/1", nil), contestant)
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code, "the form shows the hidden tests")
+}
+
+func TestUpdateProblemTestSuite(t *testing.T) {
+	suite.Run(t, new(UpdateProblemTestSuite))
+}
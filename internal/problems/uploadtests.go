@@ -0,0 +1,314 @@
+package problems
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/lo"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+	"github.com/computer-technology-team/go-judge/internal/revisions"
+	"github.com/computer-technology-team/go-judge/internal/sourceview"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/internal/testarchive"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+const testPreviewLength = 256
+
+type testPreview struct {
+	Name          string
+	InputSize     int
+	OutputSize    int
+	InputPreview  string
+	OutputPreview string
+}
+
+type testUploadPreviewData struct {
+	Problem       storage.Problem
+	UploadID      string
+	Tests         []testPreview
+	Ignored       []string
+	ExistingTests int
+}
+
+// PreviewTestUpload parses an uploaded zip of tests, keeps it aside and shows what will be saved
+func (h *DefaultHandler) PreviewTestUpload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := slog.With("function", "PreviewTestUpload", "package", "problems")
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, testarchive.MaxArchiveSize+(1<<20))
+	if err := r.ParseMultipartForm(testarchive.MaxArchiveSize); err != nil {
+		templates.RenderError(ctx, w, "could not parse form", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	opts := testarchive.Options{
+		InputPattern:  lo.CoalesceOrEmpty(r.PostFormValue("input_pattern"), testarchive.DefaultInputPattern),
+		OutputPattern: lo.CoalesceOrEmpty(r.PostFormValue("output_pattern"), testarchive.DefaultOutputPattern),
+	}
+
+	file, header, err := r.FormFile("archive")
+	if err != nil {
+		templates.RenderError(ctx, w, "test archive is required", http.StatusBadRequest, h.templates)
+		return
+	}
+	defer file.Close()
+
+	archive := make([]byte, header.Size)
+	if _, err := file.ReadAt(archive, 0); err != nil {
+		logger.ErrorContext(ctx, "could not read test archive", "error", err)
+		templates.RenderError(ctx, w, "could not read test archive", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	result, err := testarchive.Parse(bytes.NewReader(archive), int64(len(archive)), opts)
+	if err != nil {
+		if errors.Is(err, testarchive.ErrInvalidArchive) {
+			templates.RenderError(ctx, w, err.Error(), http.StatusBadRequest, h.templates)
+			return
+		}
+		logger.ErrorContext(ctx, "could not parse test archive", "error", err)
+		templates.RenderError(ctx, w, "could not parse test archive", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	if err := h.querier.DeleteStaleTestUploads(ctx, h.pool); err != nil {
+		logger.WarnContext(ctx, "could not delete stale test uploads", "error", err)
+	}
+
+	upload, err := h.querier.CreateTestUpload(ctx, h.pool, storage.CreateTestUploadParams{
+		ProblemID:     problem.ID,
+		UploadedBy:    user.ID,
+		Archive:       archive,
+		InputPattern:  opts.InputPattern,
+		OutputPattern: opts.OutputPattern,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "could not store test upload", "error", err)
+		templates.RenderError(ctx, w, "could not store test upload", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	existing, err := h.querier.GetManualTestCasesByProblemID(ctx, h.pool, problem.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "could not get test cases", "error", err)
+		templates.RenderError(ctx, w, "could not get test cases", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	err = h.templates.Render(ctx, "testuploadpreviewpage", w, testUploadPreviewData{
+		Problem:       problem,
+		UploadID:      upload.ID.String(),
+		Ignored:       result.Ignored,
+		ExistingTests: len(existing),
+		Tests: lo.Map(result.Tests, func(tc testarchive.Test, _ int) testPreview {
+			return testPreview{
+				Name:          tc.Name,
+				InputSize:     len(tc.Input),
+				OutputSize:    len(tc.Output),
+				InputPreview:  preview(tc.Input),
+				OutputPreview: preview(tc.Output),
+			}
+		}),
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "could not render testuploadpreviewpage", "error", err)
+		templates.RenderError(ctx, w, "could not render", http.StatusInternalServerError, h.templates)
+		return
+	}
+}
+
+// ConfirmTestUpload saves a previewed upload, replacing or appending to the problem tests
+func (h *DefaultHandler) ConfirmTestUpload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := slog.With("function", "ConfirmTestUpload", "package", "problems")
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	uploadID, err := uuid.Parse(chi.URLParam(r, "upload_id"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid upload id", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		templates.RenderError(ctx, w, "invalid form data", http.StatusBadRequest, h.templates)
+		return
+	}
+	replace := r.PostFormValue("mode") != "append"
+
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	upload, err := h.querier.GetTestUpload(ctx, h.pool, pgtype.UUID{Bytes: uploadID, Valid: true}, user.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "upload not found, it may have expired", http.StatusNotFound, h.templates)
+			return
+		}
+		logger.ErrorContext(ctx, "could not get test upload", "error", err)
+		templates.RenderError(ctx, w, "could not get test upload", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	if upload.ProblemID != problem.ID {
+		templates.RenderError(ctx, w, "upload does not belong to this problem", http.StatusBadRequest, h.templates)
+		return
+	}
+
+	result, err := testarchive.Parse(bytes.NewReader(upload.Archive), int64(len(upload.Archive)), testarchive.Options{
+		InputPattern:  upload.InputPattern,
+		OutputPattern: upload.OutputPattern,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "could not parse stored test archive", "error", err)
+		templates.RenderError(ctx, w, "could not parse test archive", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		templates.RenderError(ctx, w, "could not begin update", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	defer func(ctx context.Context, tx pgx.Tx) {
+		err := tx.Rollback(ctx)
+		if !errors.Is(err, pgx.ErrTxClosed) && err != nil {
+			slog.Error("could not rollback", "error", err)
+		}
+	}(ctx, tx)
+
+	before, err := h.getAuditedProblem(ctx, tx, problem.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "could not get problem", "error", err)
+		templates.RenderError(ctx, w, "could not get problem", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	if replace {
+		if err := h.querier.DeleteProblemTestCases(ctx, tx, problem.ID); err != nil {
+			logger.ErrorContext(ctx, "could not reset testcases", "error", err)
+			templates.RenderError(ctx, w, "could not reset testcases", http.StatusInternalServerError, h.templates)
+			return
+		}
+	}
+
+	for _, tc := range result.Tests {
+		_, err = h.querier.InsertTestCase(ctx, tx, storage.InsertTestCaseParams{
+			ProblemID: problem.ID,
+			Input:     tc.Input,
+			Output:    tc.Output,
+		})
+		if err != nil {
+			logger.ErrorContext(ctx, "could not insert test case", "error", err)
+			templates.RenderError(ctx, w, "could not insert test case", http.StatusInternalServerError, h.templates)
+			return
+		}
+	}
+
+	if err := h.querier.DeleteTestUpload(ctx, tx, upload.ID); err != nil {
+		logger.ErrorContext(ctx, "could not delete test upload", "error", err)
+		templates.RenderError(ctx, w, "could not finalize upload", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	if _, err := revisions.Snapshot(ctx, tx, h.querier, problem.ID, user.ID); err != nil {
+		logger.ErrorContext(ctx, "could not record revision", "error", err)
+		templates.RenderError(ctx, w, "could not record revision", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	after, err := h.getAuditedProblem(ctx, tx, problem.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "could not get problem", "error", err)
+		templates.RenderError(ctx, w, "could not get problem", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	err = audit.Record(ctx, tx, h.querier, audit.Event{
+		Action:     audit.ProblemUploadTest,
+		TargetType: audit.TargetProblem,
+		TargetID:   strconv.Itoa(int(problem.ID)),
+		Before:     before,
+		After:      after,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "could not record audit event", "error", err)
+		templates.RenderError(ctx, w, "could not record audit event", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.ErrorContext(ctx, "could not commit transaction", "error", err)
+		templates.RenderError(ctx, w, "could not finalize upload", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	slog.Info("Test cases uploaded successfully", "problem_id", problem.ID, "tests", len(result.Tests), "replace", replace)
+
+	http.Redirect(w, r, "/problems/form/"+strconv.Itoa(int(problem.ID)), http.StatusSeeOther)
+}
+
+// getEditableProblem loads the problem in the id url param if the current user may edit it.
+// It renders the error itself and returns false when the request should stop.
+func (h *DefaultHandler) getEditableProblem(w http.ResponseWriter, r *http.Request) (storage.Problem, bool) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid problem id", http.StatusBadRequest, h.templates)
+		return storage.Problem{}, false
+	}
+
+	problem, err := h.querier.GetProblemForUser(ctx, h.pool, storage.GetProblemForUserParams{
+		ID:        int32(id),
+		CreatedBy: user.ID,
+		IsAdmin:   internalcontext.HasPermission(ctx, rbac.ManageProblems),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "problem not found", http.StatusNotFound, h.templates)
+			return storage.Problem{}, false
+		}
+		slog.ErrorContext(ctx, "could not get problem", "error", err, "problem_id", id)
+		templates.RenderError(ctx, w, "could not get problem from storage", http.StatusInternalServerError, h.templates)
+		return storage.Problem{}, false
+	}
+
+	if !internalcontext.HasPermission(ctx, rbac.ManageProblems) && problem.CreatedBy != user.ID {
+		templates.RenderError(ctx, w, "only the author can edit this problem", http.StatusForbidden, h.templates)
+		return storage.Problem{}, false
+	}
+
+	return problem, true
+}
+
+// preview cuts a test to testPreviewLength bytes, marking that it was cut
+func preview(s string) string {
+	if s, truncated := sourceview.Truncate(s, testPreviewLength); truncated {
+		return s + "…"
+	}
+	return s
+}
@@ -0,0 +1,32 @@
+package problems
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/computer-technology-team/go-judge/internal/verification"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// VerifyProblem starts judging every solution of the problem on its current tests in the background
+func (h *DefaultHandler) VerifyProblem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	problem, ok := h.getEditableProblem(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.verifier.Start(ctx, problem.ID); err != nil {
+		if errors.Is(err, verification.ErrVerificationRunning) {
+			templates.RenderError(ctx, w, err.Error(), http.StatusConflict, h.templates)
+			return
+		}
+		slog.ErrorContext(ctx, "could not start verification", "error", err, "problem_id", problem.ID)
+		templates.RenderError(ctx, w, "could not start verification", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	http.Redirect(w, r, programsURL(problem.ID), http.StatusSeeOther)
+}
@@ -2,15 +2,36 @@ package problems
 
 import (
 	"errors"
+	"fmt"
+	"html/template"
 	"log/slog"
 	"net/http"
 	"strconv"
 
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+	"github.com/computer-technology-team/go-judge/internal/statement"
+	"github.com/computer-technology-team/go-judge/internal/storage"
 	"github.com/computer-technology-team/go-judge/web/templates"
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
 )
 
+type viewProblemData struct {
+	storage.Problem
+	Tags      []string
+	Statement template.HTML
+	Samples   []sampleView
+	// SolutionStats is only loaded for admins
+	SolutionStats *storage.GetProblemSolutionStatsRow
+}
+
+type sampleView struct {
+	Input       string
+	Output      string
+	Explanation template.HTML
+}
+
 // ViewProblem returns a specific problem
 func (h *DefaultHandler) ViewProblem(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -33,7 +54,51 @@ func (h *DefaultHandler) ViewProblem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.templates.Render(r.Context(), "viewproblempage", w, p)
+	tags, err := h.querier.GetProblemTags(r.Context(), h.pool, p.ID)
+	if err != nil {
+		slog.Error("could not get problem tags", "error", err)
+		templates.RenderError(r.Context(), w, "could not get problem", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	samples, err := h.querier.GetProblemSamples(r.Context(), h.pool, p.ID)
+	if err != nil {
+		slog.Error("could not get problem samples", "error", err)
+		templates.RenderError(r.Context(), w, "could not get problem", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	attachmentBase := fmt.Sprintf("/problems/%d/attachments/", p.ID)
+	data := viewProblemData{Problem: p, Tags: tags}
+
+	data.Statement, err = statement.Render(p.Description, attachmentBase)
+	if err != nil {
+		slog.Error("could not render problem statement", "error", err)
+		templates.RenderError(r.Context(), w, "could not render problem statement", http.StatusInternalServerError, h.templates)
+		return
+	}
+
+	for _, sample := range samples {
+		explanation, err := statement.Render(sample.Explanation, attachmentBase)
+		if err != nil {
+			slog.Error("could not render sample explanation", "error", err)
+			templates.RenderError(r.Context(), w, "could not render problem statement", http.StatusInternalServerError, h.templates)
+			return
+		}
+		data.Samples = append(data.Samples, sampleView{Input: sample.Input, Output: sample.Output, Explanation: explanation})
+	}
+
+	if internalcontext.HasPermission(r.Context(), rbac.ManageProblems) {
+		stats, err := h.querier.GetProblemSolutionStats(r.Context(), h.pool, p.ID)
+		if err != nil {
+			slog.Error("could not get problem solution stats", "error", err)
+			templates.RenderError(r.Context(), w, "could not get problem", http.StatusInternalServerError, h.templates)
+			return
+		}
+		data.SolutionStats = &stats
+	}
+
+	err = h.templates.Render(r.Context(), "viewproblempage", w, data)
 	if err != nil {
 		slog.Error("could not render viewproblempage", "error", err)
 		templates.RenderError(r.Context(), w, "could not render", http.StatusInternalServerError, h.templates)
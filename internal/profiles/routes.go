@@ -6,13 +6,15 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/computer-technology-team/go-judge/internal/middleware"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
 	"github.com/computer-technology-team/go-judge/web/templates"
 )
 
 // Servicer defines the interface for profile handlers
 type Servicer interface {
 	GetProfile(w http.ResponseWriter, r *http.Request)
-	ToggleSuperUser(w http.ResponseWriter, r *http.Request)
+	SetUserRole(w http.ResponseWriter, r *http.Request)
+	SetSubmissionRateLimit(w http.ResponseWriter, r *http.Request)
 }
 
 // NewRoutes returns a function that registers routes with the given handler
@@ -20,7 +22,10 @@ type Servicer interface {
 func NewRoutes(h Servicer, sharedTemplates *templates.Templates) func(r chi.Router) {
 	return func(r chi.Router) {
 		r.Get("/{username}", h.GetProfile)
-		r.With(middleware.NewRequireSuperUserMiddleware(sharedTemplates)).
-			Post("/{username}/toggle-superuser", h.ToggleSuperUser)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.NewRequirePermissionMiddleware(sharedTemplates, rbac.ManageUsers))
+			r.Post("/{username}/roles", h.SetUserRole)
+			r.Post("/{username}/rate-limit", h.SetSubmissionRateLimit)
+		})
 	}
 }
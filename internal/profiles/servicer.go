@@ -1,21 +1,34 @@
 package profiles
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
 	"github.com/computer-technology-team/go-judge/internal/storage"
 	"github.com/computer-technology-team/go-judge/web/templates"
 )
 
+// database is the part of the pool the profile servicer uses
+type database interface {
+	storage.DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 // servicerImpl is the default implementation of the Handler interface
 type servicerImpl struct {
-	pool *pgxpool.Pool
+	pool database
 
 	querier storage.Querier
 
@@ -58,12 +71,34 @@ func (s *servicerImpl) GetProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	roles, err := s.querier.GetUserRoles(ctx, s.pool, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get roles", slog.String("username", username), "error", err)
+		templates.RenderError(ctx, w, "could not retrieve roles", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	var allRoles []storage.Role
+	if internalcontext.HasPermission(ctx, rbac.ManageUsers) {
+		allRoles, err = s.querier.ListRoles(ctx, s.pool)
+		if err != nil {
+			slog.ErrorContext(ctx, "could not list roles", "error", err)
+			templates.RenderError(ctx, w, "could not retrieve roles", http.StatusInternalServerError, s.templates)
+			return
+		}
+	}
+
 	model := struct {
 		User        storage.User // your SQLC User type
 		Submissions []storage.GetUserSubmissionsRow
+		Roles       []string
+		// AllRoles are listed only for admins who can grant them
+		AllRoles []storage.Role
 	}{
 		User:        user,
 		Submissions: subs[:min(len(subs), 5)],
+		Roles:       roles,
+		AllRoles:    allRoles,
 	}
 
 	err = s.templates.Render(ctx, "profilepage", w, model)
@@ -76,16 +111,17 @@ func (s *servicerImpl) GetProfile(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *servicerImpl) ToggleSuperUser(w http.ResponseWriter, r *http.Request) {
+// SetUserRole grants a role to a user or revokes it, nobody can revoke the last way to manage users
+func (s *servicerImpl) SetUserRole(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	username := chi.URLParam(r, "username")
+	role := r.PostFormValue("role")
 
 	user, err := s.querier.GetUserByUsername(ctx, s.pool, username)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			templates.RenderError(ctx, w, "user not found", http.StatusNotFound, s.templates)
-
 			return
 		}
 
@@ -95,15 +131,236 @@ func (s *servicerImpl) ToggleSuperUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = s.querier.ToggleUserSuperLevel(ctx, s.pool, user.ID)
+	switch r.PostFormValue("action") {
+	case "grant":
+		if !s.grantUserRole(w, r, user, role) {
+			return
+		}
+	case "revoke":
+		if !s.revokeUserRole(w, r, user, role) {
+			return
+		}
+	default:
+		templates.RenderError(ctx, w, "action must be grant or revoke", http.StatusBadRequest, s.templates)
+		return
+	}
+
+	http.Redirect(w, r, "/profiles/"+username, http.StatusSeeOther)
+}
+
+func (s *servicerImpl) grantUserRole(w http.ResponseWriter, r *http.Request, user storage.User, role string) bool {
+	ctx := r.Context()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not begin transaction", "error", err)
+		templates.RenderError(ctx, w, "could not grant role", http.StatusInternalServerError, s.templates)
+		return false
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	before, err := s.querier.GetUserRoles(ctx, tx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get user roles", slog.String("username", user.Username), "error", err)
+		templates.RenderError(ctx, w, "could not grant role", http.StatusInternalServerError, s.templates)
+		return false
+	}
+
+	err = s.querier.GrantUserRole(ctx, tx, user.ID, role)
+	if err != nil {
+		if storage.IsForeignKeyViolation(err) {
+			templates.RenderError(ctx, w, "role not found", http.StatusBadRequest, s.templates)
+			return false
+		}
+		slog.ErrorContext(ctx, "could not grant role", slog.String("username", user.Username),
+			slog.String("role", role), "error", err)
+		templates.RenderError(ctx, w, "could not grant role", http.StatusInternalServerError, s.templates)
+		return false
+	}
+
+	if err := s.recordRoleChange(ctx, tx, audit.UserGrantRole, user, before); err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err)
+		templates.RenderError(ctx, w, "could not grant role", http.StatusInternalServerError, s.templates)
+		return false
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "could not commit transaction", "error", err)
+		templates.RenderError(ctx, w, "could not grant role", http.StatusInternalServerError, s.templates)
+		return false
+	}
+
+	return true
+}
+
+func (s *servicerImpl) revokeUserRole(w http.ResponseWriter, r *http.Request, user storage.User, role string) bool {
+	ctx := r.Context()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not begin transaction", "error", err)
+		templates.RenderError(ctx, w, "could not revoke role", http.StatusInternalServerError, s.templates)
+		return false
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	// roles are checked together, so two admins revoking each other can not both succeed
+	err = s.querier.LockRoles(ctx, tx)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not lock roles", "error", err)
+		templates.RenderError(ctx, w, "could not revoke role", http.StatusInternalServerError, s.templates)
+		return false
+	}
+
+	before, err := s.querier.GetUserRoles(ctx, tx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get user roles", slog.String("username", user.Username), "error", err)
+		templates.RenderError(ctx, w, "could not revoke role", http.StatusInternalServerError, s.templates)
+		return false
+	}
+
+	_, err = s.querier.RevokeUserRole(ctx, tx, user.ID, role)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not revoke role", slog.String("username", user.Username),
+			slog.String("role", role), "error", err)
+		templates.RenderError(ctx, w, "could not revoke role", http.StatusInternalServerError, s.templates)
+		return false
+	}
+
+	managers, err := s.querier.CountUsersWithPermission(ctx, tx, string(rbac.ManageUsers))
+	if err != nil {
+		slog.ErrorContext(ctx, "could not count user managers", "error", err)
+		templates.RenderError(ctx, w, "could not revoke role", http.StatusInternalServerError, s.templates)
+		return false
+	}
+	if managers == 0 {
+		templates.RenderError(ctx, w, "nobody else could manage users, grant the permission to someone first",
+			http.StatusBadRequest, s.templates)
+		return false
+	}
+
+	if err := s.recordRoleChange(ctx, tx, audit.UserRevokeRole, user, before); err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err)
+		templates.RenderError(ctx, w, "could not revoke role", http.StatusInternalServerError, s.templates)
+		return false
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "could not commit transaction", "error", err)
+		templates.RenderError(ctx, w, "could not revoke role", http.StatusInternalServerError, s.templates)
+		return false
+	}
+
+	return true
+}
+
+// recordRoleChange records the roles of user before and after a grant or a revoke
+func (s *servicerImpl) recordRoleChange(ctx context.Context, tx pgx.Tx, action audit.Action, user storage.User,
+	before []string) error {
+	after, err := s.querier.GetUserRoles(ctx, tx, user.ID)
+	if err != nil {
+		return fmt.Errorf("could not get user roles: %w", err)
+	}
+
+	return audit.Record(ctx, tx, s.querier, audit.Event{
+		Action:     action,
+		TargetType: audit.TargetUser,
+		TargetID:   user.Username,
+		Before:     map[string][]string{"roles": before},
+		After:      map[string][]string{"roles": after},
+	})
+}
+
+// SetSubmissionRateLimit overrides the submission rate limit of a user, clearing it restores the configured limit
+func (s *servicerImpl) SetSubmissionRateLimit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	username := chi.URLParam(r, "username")
+
+	user, err := s.querier.GetUserByUsername(ctx, s.pool, username)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			http.Error(w, "user not found", http.StatusNotFound)
+			templates.RenderError(ctx, w, "user not found", http.StatusNotFound, s.templates)
 			return
 		}
-		templates.RenderError(ctx, w, "could not toggle user superuser", http.StatusInternalServerError, s.templates)
+
+		slog.ErrorContext(ctx, "could not get user from database",
+			slog.String("username", username), "error", err)
+		templates.RenderError(ctx, w, "could not get user from storage", http.StatusInternalServerError, s.templates)
 		return
 	}
 
-	http.Redirect(w, r, "/profiles/"+username, http.StatusMovedPermanently)
+	params := storage.SetUserSubmissionRateLimitParams{ID: user.ID}
+	if r.PostFormValue("clear") == "" {
+		perMinute, err := strconv.ParseFloat(r.PostFormValue("per_minute"), 64)
+		if err != nil || perMinute < 0 {
+			templates.RenderError(ctx, w, "submissions per minute must be a non-negative number", http.StatusBadRequest, s.templates)
+			return
+		}
+		burst, err := strconv.Atoi(r.PostFormValue("burst"))
+		if err != nil || burst < 1 {
+			templates.RenderError(ctx, w, "burst must be a positive integer", http.StatusBadRequest, s.templates)
+			return
+		}
+
+		params.PerMinute = pgtype.Float8{Float64: perMinute, Valid: true}
+		params.Burst = pgtype.Int4{Int32: int32(burst), Valid: true}
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not begin transaction", "error", err)
+		templates.RenderError(ctx, w, "could not set submission rate limit", http.StatusInternalServerError, s.templates)
+		return
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	updated, err := s.querier.SetUserSubmissionRateLimit(ctx, tx, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not set submission rate limit",
+			slog.String("username", username), "error", err)
+		templates.RenderError(ctx, w, "could not set submission rate limit", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	err = audit.Record(ctx, tx, s.querier, audit.Event{
+		Action:     audit.UserSetRateLimit,
+		TargetType: audit.TargetUser,
+		TargetID:   user.Username,
+		Before:     auditedRateLimit(user),
+		After:      auditedRateLimit(updated),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err)
+		templates.RenderError(ctx, w, "could not set submission rate limit", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "could not commit transaction", "error", err)
+		templates.RenderError(ctx, w, "could not set submission rate limit", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	http.Redirect(w, r, "/profiles/"+username, http.StatusSeeOther)
+}
+
+// auditedRateLimit is what the audit log keeps of the rate limit of a user, null when it is the configured one
+func auditedRateLimit(user storage.User) map[string]any {
+	return map[string]any{
+		"per_minute": user.SubmissionRatePerMinute,
+		"burst":      user.SubmissionRateBurst,
+	}
 }
@@ -0,0 +1,168 @@
+package profiles
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// fakeDatabase hands out transactions that only count commits
+type fakeDatabase struct {
+	storage.DBTX
+	committed int
+}
+
+func (d *fakeDatabase) Begin(context.Context) (pgx.Tx, error) {
+	return &fakeTx{db: d}, nil
+}
+
+type fakeTx struct {
+	pgx.Tx
+	db     *fakeDatabase
+	closed bool
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	tx.closed = true
+	tx.db.committed++
+	return nil
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.closed = true
+	return nil
+}
+
+// fakeQuerier keeps users and their roles in memory, only admins manage users
+type fakeQuerier struct {
+	storage.Querier
+	users     map[string]storage.User
+	userRoles map[pgtype.UUID][]string
+	events    []storage.CreateAuditEventParams
+}
+
+func (q *fakeQuerier) GetUserByUsername(_ context.Context, _ storage.DBTX, username string) (storage.User, error) {
+	user, ok := q.users[username]
+	if !ok {
+		return storage.User{}, pgx.ErrNoRows
+	}
+	return user, nil
+}
+
+func (q *fakeQuerier) LockRoles(context.Context, storage.DBTX) error {
+	return nil
+}
+
+func (q *fakeQuerier) GetUserRoles(_ context.Context, _ storage.DBTX, userID pgtype.UUID) ([]string, error) {
+	return slices.Clone(q.userRoles[userID]), nil
+}
+
+func (q *fakeQuerier) RevokeUserRole(_ context.Context, _ storage.DBTX, userID pgtype.UUID, role string) (int64, error) {
+	before := len(q.userRoles[userID])
+	q.userRoles[userID] = slices.DeleteFunc(q.userRoles[userID], func(r string) bool { return r == role })
+	return int64(before - len(q.userRoles[userID])), nil
+}
+
+func (q *fakeQuerier) CountUsersWithPermission(_ context.Context, _ storage.DBTX, permission string) (int64, error) {
+	var count int64
+	for _, roles := range q.userRoles {
+		if permission == "manage_users" && slices.Contains(roles, "admin") {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *fakeQuerier) CreateAuditEvent(_ context.Context, _ storage.DBTX, arg storage.CreateAuditEventParams) error {
+	q.events = append(q.events, arg)
+	return nil
+}
+
+type ProfilesTestSuite struct {
+	suite.Suite
+	querier *fakeQuerier
+	db      *fakeDatabase
+	router  chi.Router
+}
+
+func (s *ProfilesTestSuite) SetupTest() {
+	tmpl, err := templates.GetSharedTemplates()
+	require.NoError(s.T(), err)
+
+	s.querier = &fakeQuerier{users: map[string]storage.User{}, userRoles: map[pgtype.UUID][]string{}}
+	s.addUser("root", "admin", "contestant")
+	s.addUser("alice", "contestant")
+	s.db = &fakeDatabase{}
+
+	servicer := &servicerImpl{pool: s.db, querier: s.querier, templates: tmpl}
+	s.router = chi.NewRouter()
+	s.router.Post("/profiles/{username}/roles", servicer.SetUserRole)
+}
+
+func (s *ProfilesTestSuite) addUser(username string, roles ...string) {
+	user := storage.User{ID: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Username: username}
+	s.querier.users[username] = user
+	s.querier.userRoles[user.ID] = roles
+}
+
+func (s *ProfilesTestSuite) revoke(username, role string) *httptest.ResponseRecorder {
+	form := url.Values{"action": {"revoke"}, "role": {role}}
+	req := httptest.NewRequest(http.MethodPost, "/profiles/"+username+"/roles", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *ProfilesTestSuite) TestRevokeLastAdmin() {
+	rec := s.revoke("root", "admin")
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Contains(s.T(), rec.Body.String(), "nobody else could manage users")
+	assert.Zero(s.T(), s.db.committed)
+	assert.Empty(s.T(), s.querier.events)
+}
+
+func (s *ProfilesTestSuite) TestRevokeAdminWithAnotherLeft() {
+	s.addUser("bob", "admin")
+
+	rec := s.revoke("root", "admin")
+	assert.Equal(s.T(), http.StatusSeeOther, rec.Code)
+	assert.Equal(s.T(), "/profiles/root", rec.Header().Get("Location"))
+	assert.Equal(s.T(), 1, s.db.committed)
+
+	require.Len(s.T(), s.querier.events, 1)
+	assert.Equal(s.T(), string(audit.UserRevokeRole), s.querier.events[0].Action)
+	assert.Equal(s.T(), "root", s.querier.events[0].TargetID)
+
+	rec = s.revoke("bob", "admin")
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code, "bob is the last admin now")
+}
+
+func (s *ProfilesTestSuite) TestRevokeOtherRole() {
+	rec := s.revoke("root", "contestant")
+	assert.Equal(s.T(), http.StatusSeeOther, rec.Code)
+	assert.Equal(s.T(), 1, s.db.committed)
+}
+
+func TestProfilesTestSuite(t *testing.T) {
+	suite.Run(t, new(ProfilesTestSuite))
+}
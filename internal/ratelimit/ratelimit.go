@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/computer-technology-team/go-judge/config"
+)
+
+// sweepInterval is how often buckets that refilled completely are dropped
+const sweepInterval = time.Minute
+
+// Limiter is an in-memory token bucket per key, such as a user id or a client address
+type Limiter struct {
+	limit rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// New creates a limiter allowing cfg.PerMinute requests per minute per key, with bursts of cfg.Burst.
+// A PerMinute of zero or less disables the limit.
+func New(cfg config.RateLimitConfig) *Limiter {
+	limit, burst := toRate(cfg)
+	return &Limiter{
+		limit:   limit,
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func toRate(cfg config.RateLimitConfig) (rate.Limit, int) {
+	if cfg.PerMinute <= 0 {
+		return rate.Inf, 0
+	}
+	return rate.Limit(cfg.PerMinute / 60), max(cfg.Burst, 1)
+}
+
+// Allow takes a token from the bucket of key. When it is empty, it returns how long until the next token.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	allowed, retryAfter, _ := l.reserve(key, l.limit, l.burst)
+	return allowed, retryAfter
+}
+
+// AllowWith is like Allow but uses cfg instead of the limiter's own limit for the bucket of key,
+// for keys whose limit was overridden
+func (l *Limiter) AllowWith(key string, cfg config.RateLimitConfig) (bool, time.Duration) {
+	limit, burst := toRate(cfg)
+	allowed, retryAfter, _ := l.reserve(key, limit, burst)
+	return allowed, retryAfter
+}
+
+// ReserveWith is like AllowWith but also returns cancel, which gives the token back for requests that another
+// limit rejects afterwards. cancel does nothing when the request was not allowed.
+func (l *Limiter) ReserveWith(key string, cfg config.RateLimitConfig) (allowed bool, retryAfter time.Duration,
+	cancel func()) {
+	limit, burst := toRate(cfg)
+	return l.reserve(key, limit, burst)
+}
+
+func (l *Limiter) reserve(key string, limit rate.Limit, burst int) (bool, time.Duration, func()) {
+	if limit == rate.Inf {
+		return true, 0, func() {}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(limit, burst)}
+		l.buckets[key] = b
+	}
+	if b.limiter.Limit() != limit || b.limiter.Burst() != burst {
+		b.limiter.SetLimitAt(now, limit)
+		b.limiter.SetBurstAt(now, burst)
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, sweepInterval, func() {}
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay, func() {}
+	}
+
+	return true, 0, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		reservation.CancelAt(l.now())
+	}
+}
+
+// sweep drops buckets that have not been used for long enough to be full again, they behave like new ones
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		refill := max(sweepInterval,
+			time.Duration(float64(b.limiter.Burst())/float64(b.limiter.Limit())*float64(time.Second)))
+		if now.Sub(b.lastSeen) > refill {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/computer-technology-team/go-judge/config"
+)
+
+type LimiterTestSuite struct {
+	suite.Suite
+	now     time.Time
+	limiter *Limiter
+}
+
+func (s *LimiterTestSuite) SetupTest() {
+	s.now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.limiter = New(config.RateLimitConfig{PerMinute: 6, Burst: 2})
+	s.limiter.now = func() time.Time { return s.now }
+}
+
+func (s *LimiterTestSuite) TestBurstThenRefill() {
+	for range 2 {
+		allowed, _ := s.limiter.Allow("alice")
+		assert.True(s.T(), allowed)
+	}
+
+	allowed, retryAfter := s.limiter.Allow("alice")
+	assert.False(s.T(), allowed)
+	assert.Equal(s.T(), 10*time.Second, retryAfter)
+
+	// other keys have their own bucket
+	allowed, _ = s.limiter.Allow("bob")
+	assert.True(s.T(), allowed)
+
+	s.now = s.now.Add(10 * time.Second)
+	allowed, _ = s.limiter.Allow("alice")
+	assert.True(s.T(), allowed)
+}
+
+func (s *LimiterTestSuite) TestRejectedRequestsDoNotConsumeTokens() {
+	for range 5 {
+		s.limiter.Allow("alice")
+	}
+
+	s.now = s.now.Add(10 * time.Second)
+	allowed, _ := s.limiter.Allow("alice")
+	assert.True(s.T(), allowed)
+}
+
+func (s *LimiterTestSuite) TestAllowWithOverride() {
+	override := config.RateLimitConfig{PerMinute: 60, Burst: 4}
+	for range 4 {
+		allowed, _ := s.limiter.AllowWith("alice", override)
+		assert.True(s.T(), allowed)
+	}
+
+	allowed, retryAfter := s.limiter.AllowWith("alice", override)
+	assert.False(s.T(), allowed)
+	assert.Equal(s.T(), time.Second, retryAfter)
+}
+
+func (s *LimiterTestSuite) TestCancelReservation() {
+	limit := config.RateLimitConfig{PerMinute: 6, Burst: 2}
+
+	allowed, _, cancel := s.limiter.ReserveWith("alice", limit)
+	assert.True(s.T(), allowed)
+	cancel()
+
+	for range 2 {
+		allowed, _ := s.limiter.Allow("alice")
+		assert.True(s.T(), allowed, "the cancelled token was given back")
+	}
+
+	allowed, _, cancel = s.limiter.ReserveWith("alice", limit)
+	assert.False(s.T(), allowed)
+	cancel()
+
+	allowed, _ = s.limiter.Allow("alice")
+	assert.False(s.T(), allowed, "cancelling a rejected request gives nothing back")
+}
+
+func (s *LimiterTestSuite) TestUnlimited() {
+	for range 100 {
+		allowed, _ := s.limiter.AllowWith("alice", config.RateLimitConfig{})
+		assert.True(s.T(), allowed)
+	}
+
+	limiter := New(config.RateLimitConfig{PerMinute: 0, Burst: 1})
+	for range 100 {
+		allowed, _ := limiter.Allow("alice")
+		assert.True(s.T(), allowed)
+	}
+	assert.Empty(s.T(), limiter.buckets)
+}
+
+func (s *LimiterTestSuite) TestSweep() {
+	s.limiter.Allow("alice")
+	s.now = s.now.Add(time.Hour)
+	s.limiter.Allow("bob")
+
+	assert.NotContains(s.T(), s.limiter.buckets, "alice")
+	assert.Contains(s.T(), s.limiter.buckets, "bob")
+}
+
+func TestLimiterTestSuite(t *testing.T) {
+	suite.Run(t, new(LimiterTestSuite))
+}
@@ -0,0 +1,53 @@
+// Package rbac names the permissions the judge checks. Roles and the permissions they grant are stored in the
+// database, so admins can change them without a deploy.
+package rbac
+
+import "slices"
+
+// Permission is the name of a row in the permissions table
+type Permission string
+
+const (
+	// Submit allows submitting solutions and running code against custom input
+	Submit Permission = "submit"
+	// CreateProblems allows creating problems, authors can always manage their own problems
+	CreateProblems Permission = "create_problems"
+	// ManageProblems allows editing and publishing every problem
+	ManageProblems Permission = "manage_problems"
+	// ViewSubmissions allows viewing the submissions of others to problems that share them with staff
+	ViewSubmissions Permission = "view_submissions"
+	// ManageContests allows creating and running contests
+	ManageContests Permission = "manage_contests"
+	// ManageUsers allows granting roles, changing the permissions of roles and setting rate limits
+	ManageUsers Permission = "manage_users"
+	// UnlimitedSubmissions exempts from submission rate limits
+	UnlimitedSubmissions Permission = "unlimited_submissions"
+	// CreateGroups allows creating groups, owners of a group manage it and its assignments
+	CreateGroups Permission = "create_groups"
+	// ViewAuditLog allows viewing and exporting the audit log
+	ViewAuditLog Permission = "view_audit_log"
+)
+
+const (
+	// AdminRole holds every permission
+	AdminRole = "admin"
+	// DefaultRole is given to every new user
+	DefaultRole = "contestant"
+)
+
+// Permissions are the permissions a user holds through their roles
+type Permissions []Permission
+
+// FromNames converts the permission names stored in the database
+func FromNames(names []string) Permissions {
+	permissions := make(Permissions, len(names))
+	for i, name := range names {
+		permissions[i] = Permission(name)
+	}
+	return permissions
+}
+
+// Has reports whether permission is held
+func (p Permissions) Has(permission Permission) bool {
+	return slices.Contains(p, permission)
+}
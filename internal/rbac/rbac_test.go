@@ -0,0 +1,28 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RBACTestSuite struct {
+	suite.Suite
+}
+
+func (s *RBACTestSuite) TestHas() {
+	permissions := FromNames([]string{"submit", "view_submissions"})
+
+	assert.True(s.T(), permissions.Has(Submit))
+	assert.True(s.T(), permissions.Has(ViewSubmissions))
+	assert.False(s.T(), permissions.Has(ManageUsers))
+	assert.False(s.T(), permissions.Has(Permission("Submit")), "names are case-sensitive")
+
+	assert.False(s.T(), Permissions(nil).Has(Submit))
+	assert.False(s.T(), FromNames(nil).Has(Submit))
+}
+
+func TestRBACTestSuite(t *testing.T) {
+	suite.Run(t, new(RBACTestSuite))
+}
@@ -0,0 +1,253 @@
+package revisions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/samber/lo"
+
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Snapshot records the current statement, limits and tests of a problem as a new immutable revision
+// and makes it the current one. Nothing is recorded when the content equals the current revision.
+// It must run in the transaction that changed the problem, the problem row is locked until it ends.
+func Snapshot(ctx context.Context, db storage.DBTX, querier storage.Querier, problemID int32,
+	author pgtype.UUID) (storage.ProblemRevision, error) {
+
+	problem, err := querier.LockProblem(ctx, db, problemID)
+	if err != nil {
+		return storage.ProblemRevision{}, fmt.Errorf("could not lock problem: %w", err)
+	}
+
+	testCases, err := querier.GetTestCasesByProblemID(ctx, db, problemID)
+	if err != nil {
+		return storage.ProblemRevision{}, fmt.Errorf("could not get test cases: %w", err)
+	}
+
+	hash := ContentHash(problem, testCases)
+
+	if problem.CurrentRevisionID.Valid {
+		current, err := querier.GetProblemRevision(ctx, db, problem.CurrentRevisionID.Int32)
+		if err != nil {
+			return storage.ProblemRevision{}, fmt.Errorf("could not get current revision: %w", err)
+		}
+		if current.ContentHash == hash {
+			return current, nil
+		}
+	}
+
+	number, err := querier.GetNextRevisionNumber(ctx, db, problemID)
+	if err != nil {
+		return storage.ProblemRevision{}, fmt.Errorf("could not get next revision number: %w", err)
+	}
+
+	revision, err := querier.InsertProblemRevision(ctx, db, storage.InsertProblemRevisionParams{
+		ProblemID:     problemID,
+		Revision:      number,
+		Title:         problem.Title,
+		Description:   problem.Description,
+		TimeLimitMs:   problem.TimeLimitMs,
+		MemoryLimitKb: problem.MemoryLimitKb,
+		ContentHash:   hash,
+		CreatedBy:     author,
+	})
+	if err != nil {
+		return storage.ProblemRevision{}, fmt.Errorf("could not insert revision: %w", err)
+	}
+
+	_, err = querier.InsertProblemRevisionTests(ctx, db, lo.Map(testCases,
+		func(tc storage.TestCase, i int) storage.InsertProblemRevisionTestsParams {
+			return storage.InsertProblemRevisionTestsParams{
+				RevisionID:    revision.ID,
+				Position:      int32(i + 1),
+				Input:         tc.Input,
+				Output:        tc.Output,
+				GeneratorID:   tc.GeneratorID,
+				GeneratorArgs: tc.GeneratorArgs,
+				IsSample:      tc.IsSample,
+				Explanation:   tc.Explanation,
+			}
+		}))
+	if err != nil {
+		return storage.ProblemRevision{}, fmt.Errorf("could not insert revision tests: %w", err)
+	}
+
+	if err := querier.SetProblemCurrentRevision(ctx, db, problemID,
+		pgtype.Int4{Int32: revision.ID, Valid: true}); err != nil {
+		return storage.ProblemRevision{}, fmt.Errorf("could not set current revision: %w", err)
+	}
+
+	return revision, nil
+}
+
+// Rollback restores the statement, limits and tests of an older revision and records them as a new revision.
+// Generated tests keep their generator only if it still exists, otherwise they are restored as manual tests.
+func Rollback(ctx context.Context, db storage.DBTX, querier storage.Querier, problemID, number int32,
+	author pgtype.UUID) (storage.ProblemRevision, error) {
+
+	target, err := querier.GetProblemRevisionByNumber(ctx, db, problemID, number)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ProblemRevision{}, ErrRevisionNotFound
+		}
+		return storage.ProblemRevision{}, fmt.Errorf("could not get revision: %w", err)
+	}
+
+	if _, err := querier.LockProblem(ctx, db, problemID); err != nil {
+		return storage.ProblemRevision{}, fmt.Errorf("could not lock problem: %w", err)
+	}
+
+	tests, err := querier.GetProblemRevisionTests(ctx, db, target.ID)
+	if err != nil {
+		return storage.ProblemRevision{}, fmt.Errorf("could not get revision tests: %w", err)
+	}
+
+	programs, err := querier.GetProblemPrograms(ctx, db, problemID)
+	if err != nil {
+		return storage.ProblemRevision{}, fmt.Errorf("could not get programs: %w", err)
+	}
+	generators := lo.SliceToMap(lo.Filter(programs, func(p storage.ProblemProgram, _ int) bool {
+		return p.Kind == storage.ProgramKindGENERATOR
+	}), func(p storage.ProblemProgram) (int32, bool) {
+		return p.ID, true
+	})
+
+	_, err = querier.UpdateProblem(ctx, db, storage.UpdateProblemParams{
+		ID:            problemID,
+		Title:         target.Title,
+		Description:   target.Description,
+		TimeLimitMs:   target.TimeLimitMs,
+		MemoryLimitKb: target.MemoryLimitKb,
+	})
+	if err != nil {
+		return storage.ProblemRevision{}, fmt.Errorf("could not restore problem: %w", err)
+	}
+
+	if err := querier.DeleteAllProblemTestCases(ctx, db, problemID); err != nil {
+		return storage.ProblemRevision{}, fmt.Errorf("could not delete test cases: %w", err)
+	}
+
+	for _, test := range tests {
+		params := storage.RestoreTestCaseParams{
+			ProblemID:   problemID,
+			Input:       test.Input,
+			Output:      test.Output,
+			IsSample:    test.IsSample,
+			Explanation: test.Explanation,
+		}
+		if test.GeneratorID.Valid && generators[test.GeneratorID.Int32] {
+			// without cache keys the restored tests are generated again before the next submission
+			params.GeneratorID = test.GeneratorID
+			params.GeneratorArgs = test.GeneratorArgs
+		}
+		if err := querier.RestoreTestCase(ctx, db, params); err != nil {
+			return storage.ProblemRevision{}, fmt.Errorf("could not restore test case: %w", err)
+		}
+	}
+
+	return Snapshot(ctx, db, querier, problemID, author)
+}
+
+// ContentHash identifies the judged content of a problem, its statement, limits and tests in order
+func ContentHash(problem storage.Problem, testCases []storage.TestCase) string {
+	h := sha256.New()
+	for _, s := range []string{problem.Title, problem.Description} {
+		fmt.Fprintf(h, "%d\x00%s\x00", len(s), s)
+	}
+	fmt.Fprintf(h, "%d\x00%d\x00", problem.TimeLimitMs, problem.MemoryLimitKb)
+	for _, tc := range testCases {
+		fmt.Fprintf(h, "test\x00%d\x00%s\x00%d\x00%s\x00%d\x00%s\x00%t\x00%d\x00%s\x00", len(tc.Input), tc.Input,
+			len(tc.Output), tc.Output, tc.GeneratorID.Int32, tc.GeneratorArgs.String, tc.IsSample, len(tc.Explanation),
+			tc.Explanation)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// FileDiff is the unified diff of one part of a problem between two revisions
+type FileDiff struct {
+	Name string
+	Diff string
+}
+
+// Diff compares two revisions part by part, only changed parts are returned
+func Diff(from storage.ProblemRevision, fromTests []storage.ProblemRevisionTest,
+	to storage.ProblemRevision, toTests []storage.ProblemRevisionTest) ([]FileDiff, error) {
+
+	type part struct {
+		name     string
+		from, to string
+	}
+
+	parts := []part{
+		{"title", from.Title, to.Title},
+		{"description", from.Description, to.Description},
+		{"limits", limits(from), limits(to)},
+	}
+
+	for i := range max(len(fromTests), len(toTests)) {
+		var a, b storage.ProblemRevisionTest
+		if i < len(fromTests) {
+			a = fromTests[i]
+		}
+		if i < len(toTests) {
+			b = toTests[i]
+		}
+		name := "test " + strconv.Itoa(i+1)
+		parts = append(parts,
+			part{name + " input", a.Input, b.Input},
+			part{name + " output", a.Output, b.Output},
+			part{name + " generator", generator(a), generator(b)},
+			part{name + " sample", sample(a), sample(b)},
+			part{name + " explanation", a.Explanation, b.Explanation},
+		)
+	}
+
+	var diffs []FileDiff
+	for _, p := range parts {
+		if p.from == p.to {
+			continue
+		}
+
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(p.from),
+			B:        difflib.SplitLines(p.to),
+			FromFile: fmt.Sprintf("revision %d", from.Revision),
+			ToFile:   fmt.Sprintf("revision %d", to.Revision),
+			Context:  3,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not diff %s: %w", p.name, err)
+		}
+		diffs = append(diffs, FileDiff{Name: p.name, Diff: diff})
+	}
+
+	return diffs, nil
+}
+
+func limits(r storage.ProblemRevision) string {
+	return fmt.Sprintf("time limit: %d ms\nmemory limit: %d KB\n", r.TimeLimitMs, r.MemoryLimitKb)
+}
+
+func generator(t storage.ProblemRevisionTest) string {
+	if !t.GeneratorID.Valid {
+		return ""
+	}
+	return fmt.Sprintf("generator %d with arguments %q\n", t.GeneratorID.Int32, t.GeneratorArgs.String)
+}
+
+func sample(t storage.ProblemRevisionTest) string {
+	if !t.IsSample {
+		return ""
+	}
+	return "sample test\n"
+}
@@ -0,0 +1,88 @@
+package revisions
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+type RevisionsTestSuite struct {
+	suite.Suite
+	problem   storage.Problem
+	testCases []storage.TestCase
+}
+
+func (s *RevisionsTestSuite) SetupTest() {
+	s.problem = storage.Problem{
+		ID:            1,
+		Title:         "A + B",
+		Description:   "Print the sum of two integers.",
+		TimeLimitMs:   1000,
+		MemoryLimitKb: 262144,
+	}
+	s.testCases = []storage.TestCase{
+		{ID: 1, Input: "1 2\n", Output: "3\n", IsSample: true},
+		{ID: 2, Input: "-5 5\n", Output: "0\n"},
+	}
+}
+
+func (s *RevisionsTestSuite) TestContentHash() {
+	hash := ContentHash(s.problem, s.testCases)
+
+	// ids, drafts and the current revision are not content
+	moved := s.problem
+	moved.Draft = true
+	moved.CurrentRevisionID = pgtype.Int4{Int32: 7, Valid: true}
+	renumbered := lo.Map(s.testCases, func(tc storage.TestCase, i int) storage.TestCase {
+		tc.ID += 10
+		return tc
+	})
+	assert.Equal(s.T(), hash, ContentHash(moved, renumbered))
+
+	changed := s.problem
+	changed.TimeLimitMs = 2000
+	assert.NotEqual(s.T(), hash, ContentHash(changed, s.testCases))
+
+	assert.NotEqual(s.T(), hash, ContentHash(s.problem, []storage.TestCase{s.testCases[1], s.testCases[0]}))
+
+	explained := slices.Clone(s.testCases)
+	explained[0].Explanation = "1 + 2 = 3"
+	assert.NotEqual(s.T(), hash, ContentHash(s.problem, explained))
+}
+
+func (s *RevisionsTestSuite) TestDiff() {
+	from := storage.ProblemRevision{Revision: 1, Title: "A + B", Description: "Sum.\n", TimeLimitMs: 1000, MemoryLimitKb: 1024}
+	to := from
+	to.Revision = 2
+	to.TimeLimitMs = 2000
+
+	fromTests := []storage.ProblemRevisionTest{{Position: 1, Input: "1 2\n", Output: "3\n"}}
+	toTests := []storage.ProblemRevisionTest{
+		{Position: 1, Input: "1 2\n", Output: "3\n"},
+		{Position: 2, Input: "2 2\n", Output: "4\n"},
+	}
+
+	diffs, err := Diff(from, fromTests, to, toTests)
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), []string{"limits", "test 2 input", "test 2 output"},
+		lo.Map(diffs, func(d FileDiff, _ int) string { return d.Name }))
+	assert.Contains(s.T(), diffs[0].Diff, "-time limit: 1000 ms")
+	assert.Contains(s.T(), diffs[0].Diff, "+time limit: 2000 ms")
+	assert.Contains(s.T(), diffs[1].Diff, "+2 2")
+
+	diffs, err = Diff(from, fromTests, from, fromTests)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), diffs)
+}
+
+func TestRevisionsTestSuite(t *testing.T) {
+	suite.Run(t, new(RevisionsTestSuite))
+}
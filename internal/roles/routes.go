@@ -0,0 +1,29 @@
+package roles
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/computer-technology-team/go-judge/internal/middleware"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// Servicer defines the interface for role management handlers
+type Servicer interface {
+	ListRoles(w http.ResponseWriter, r *http.Request)
+	CreateRole(w http.ResponseWriter, r *http.Request)
+	SetRolePermissions(w http.ResponseWriter, r *http.Request)
+}
+
+// NewRoutes returns a function that registers routes with the given handler
+// This allows for dependency injection when setting up routes
+func NewRoutes(s Servicer, sharedTemplates *templates.Templates) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Use(middleware.NewRequirePermissionMiddleware(sharedTemplates, rbac.ManageUsers))
+		r.Get("/", s.ListRoles)
+		r.Post("/", s.CreateRole)
+		r.Post("/{role}", s.SetRolePermissions)
+	}
+}
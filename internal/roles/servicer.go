@@ -0,0 +1,297 @@
+package roles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/samber/lo"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+type rolesPageData struct {
+	Roles       []roleView
+	Permissions []storage.Permission
+}
+
+type roleView struct {
+	storage.Role
+	Permissions []string
+}
+
+// database is the part of the pool the role servicer uses
+type database interface {
+	storage.DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// servicerImpl is the default implementation of the Servicer interface
+type servicerImpl struct {
+	pool database
+
+	querier storage.Querier
+
+	templates *templates.Templates
+}
+
+// NewServicer creates a new instance of the default role servicer
+func NewServicer(templates *templates.Templates, pool *pgxpool.Pool, querier storage.Querier) Servicer {
+	return &servicerImpl{
+		pool:      pool,
+		querier:   querier,
+		templates: templates,
+	}
+}
+
+// ListRoles shows every role with the permissions it grants
+func (s *servicerImpl) ListRoles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	roles, err := s.querier.ListRoles(ctx, s.pool)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not list roles", "error", err)
+		templates.RenderError(ctx, w, "could not list roles", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	permissions, err := s.querier.ListPermissions(ctx, s.pool)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not list permissions", "error", err)
+		templates.RenderError(ctx, w, "could not list permissions", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	rolePermissions, err := s.querier.ListRolePermissions(ctx, s.pool)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not list role permissions", "error", err)
+		templates.RenderError(ctx, w, "could not list permissions", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	data := rolesPageData{Permissions: permissions}
+	for _, role := range roles {
+		data.Roles = append(data.Roles, roleView{
+			Role: role,
+			Permissions: lo.FilterMap(rolePermissions, func(rp storage.RolePermission, _ int) (string, bool) {
+				return rp.Permission, rp.Role == role.Name
+			}),
+		})
+	}
+
+	err = s.templates.Render(ctx, "rolespage", w, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not render roles", "error", err)
+		templates.RenderError(ctx, w, "could not render roles", http.StatusInternalServerError, s.templates)
+		return
+	}
+}
+
+// CreateRole adds a role without permissions
+func (s *servicerImpl) CreateRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	name := r.PostFormValue("name")
+	if !roleNamePattern.MatchString(name) {
+		templates.RenderError(ctx, w, "role names are lowercase letters, digits and '_', at most 32 characters",
+			http.StatusBadRequest, s.templates)
+		return
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not begin transaction", "error", err)
+		templates.RenderError(ctx, w, "could not create role", http.StatusInternalServerError, s.templates)
+		return
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	created, err := s.querier.CreateRole(ctx, tx, name, r.PostFormValue("description"))
+	if err != nil {
+		if storage.IsUniqueViolation(err) {
+			templates.RenderError(ctx, w, "role already exists", http.StatusConflict, s.templates)
+			return
+		}
+		slog.ErrorContext(ctx, "could not create role", slog.String("role", name), "error", err)
+		templates.RenderError(ctx, w, "could not create role", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	err = audit.Record(ctx, tx, s.querier, audit.Event{
+		Action:     audit.RoleCreate,
+		TargetType: audit.TargetRole,
+		TargetID:   name,
+		After:      created,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err)
+		templates.RenderError(ctx, w, "could not create role", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "could not commit transaction", "error", err)
+		templates.RenderError(ctx, w, "could not create role", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	http.Redirect(w, r, "/roles", http.StatusSeeOther)
+}
+
+// SetRolePermissions replaces the permissions of a role with the submitted ones and sets whether the role requires
+// two-factor authentication
+func (s *servicerImpl) SetRolePermissions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	role := chi.URLParam(r, "role")
+
+	if err := r.ParseForm(); err != nil {
+		templates.RenderError(ctx, w, "could not parse form", http.StatusBadRequest, s.templates)
+		return
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not begin transaction", "error", err)
+		templates.RenderError(ctx, w, "could not update role", http.StatusInternalServerError, s.templates)
+		return
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}()
+
+	_, err = s.querier.GetRole(ctx, tx, role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "role not found", http.StatusNotFound, s.templates)
+			return
+		}
+		slog.ErrorContext(ctx, "could not get role", slog.String("role", role), "error", err)
+		templates.RenderError(ctx, w, "could not update role", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	err = s.querier.LockRoles(ctx, tx)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not lock roles", "error", err)
+		templates.RenderError(ctx, w, "could not update role", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	before, err := s.getAuditedRole(ctx, tx, role)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get role", slog.String("role", role), "error", err)
+		templates.RenderError(ctx, w, "could not update role", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	err = s.querier.DeleteRolePermissions(ctx, tx, role)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not delete role permissions", slog.String("role", role), "error", err)
+		templates.RenderError(ctx, w, "could not update role", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	for _, permission := range r.PostForm["permission"] {
+		err = s.querier.GrantRolePermission(ctx, tx, role, permission)
+		if err != nil {
+			if storage.IsForeignKeyViolation(err) {
+				templates.RenderError(ctx, w, "permission not found", http.StatusBadRequest, s.templates)
+				return
+			}
+			slog.ErrorContext(ctx, "could not grant permission", slog.String("role", role),
+				slog.String("permission", permission), "error", err)
+			templates.RenderError(ctx, w, "could not update role", http.StatusInternalServerError, s.templates)
+			return
+		}
+	}
+
+	err = s.querier.SetRoleRequireTwoFactor(ctx, tx, r.PostForm.Get("require_two_factor") == "on", role)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not set role two-factor requirement", slog.String("role", role), "error", err)
+		templates.RenderError(ctx, w, "could not update role", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	// users without two-factor authentication lose the permissions of roles requiring it
+	managers, err := s.querier.CountUsersWithPermission(ctx, tx, string(rbac.ManageUsers))
+	if err != nil {
+		slog.ErrorContext(ctx, "could not count user managers", "error", err)
+		templates.RenderError(ctx, w, "could not update role", http.StatusInternalServerError, s.templates)
+		return
+	}
+	if managers == 0 {
+		templates.RenderError(ctx, w, "nobody could manage users anymore, grant the permission to another role first",
+			http.StatusBadRequest, s.templates)
+		return
+	}
+
+	after, err := s.getAuditedRole(ctx, tx, role)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get role", slog.String("role", role), "error", err)
+		templates.RenderError(ctx, w, "could not update role", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	err = audit.Record(ctx, tx, s.querier, audit.Event{
+		Action:     audit.RoleUpdate,
+		TargetType: audit.TargetRole,
+		TargetID:   role,
+		Before:     before,
+		After:      after,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not record audit event", "error", err)
+		templates.RenderError(ctx, w, "could not update role", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "could not commit transaction", "error", err)
+		templates.RenderError(ctx, w, "could not update role", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	http.Redirect(w, r, "/roles", http.StatusSeeOther)
+}
+
+// auditedRole is what the audit log keeps of a role when it changes
+type auditedRole struct {
+	Permissions      []string `json:"permissions"`
+	RequireTwoFactor bool     `json:"require_two_factor"`
+}
+
+func (s *servicerImpl) getAuditedRole(ctx context.Context, db storage.DBTX, name string) (auditedRole, error) {
+	role, err := s.querier.GetRole(ctx, db, name)
+	if err != nil {
+		return auditedRole{}, fmt.Errorf("could not get role: %w", err)
+	}
+
+	rolePermissions, err := s.querier.ListRolePermissions(ctx, db)
+	if err != nil {
+		return auditedRole{}, fmt.Errorf("could not list role permissions: %w", err)
+	}
+
+	return auditedRole{
+		Permissions: lo.FilterMap(rolePermissions, func(rp storage.RolePermission, _ int) (string, bool) {
+			return rp.Permission, rp.Role == name
+		}),
+		RequireTwoFactor: role.RequireTwoFactor,
+	}, nil
+}
@@ -0,0 +1,207 @@
+package roles
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/computer-technology-team/go-judge/internal/audit"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
+)
+
+// fakeDatabase hands out transactions that only count commits
+type fakeDatabase struct {
+	storage.DBTX
+	committed int
+}
+
+func (d *fakeDatabase) Begin(context.Context) (pgx.Tx, error) {
+	return &fakeTx{db: d}, nil
+}
+
+type fakeTx struct {
+	pgx.Tx
+	db     *fakeDatabase
+	closed bool
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	tx.closed = true
+	tx.db.committed++
+	return nil
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.closed = true
+	return nil
+}
+
+// fakeQuerier keeps roles, their permissions and who holds them in memory, counting users with a permission like
+// CountUsersWithPermission does
+type fakeQuerier struct {
+	storage.Querier
+	roles           map[string]storage.Role
+	rolePermissions []storage.RolePermission
+	// userRoles are the roles of each user, by username
+	userRoles map[string][]string
+	// twoFactor are the users with two-factor authentication enabled
+	twoFactor map[string]bool
+	events    []storage.CreateAuditEventParams
+}
+
+func (q *fakeQuerier) GetRole(_ context.Context, _ storage.DBTX, name string) (storage.Role, error) {
+	role, ok := q.roles[name]
+	if !ok {
+		return storage.Role{}, pgx.ErrNoRows
+	}
+	return role, nil
+}
+
+func (q *fakeQuerier) LockRoles(context.Context, storage.DBTX) error {
+	return nil
+}
+
+func (q *fakeQuerier) ListRolePermissions(context.Context, storage.DBTX) ([]storage.RolePermission, error) {
+	return q.rolePermissions, nil
+}
+
+func (q *fakeQuerier) DeleteRolePermissions(_ context.Context, _ storage.DBTX, role string) error {
+	q.rolePermissions = slices.DeleteFunc(q.rolePermissions, func(rp storage.RolePermission) bool {
+		return rp.Role == role
+	})
+	return nil
+}
+
+func (q *fakeQuerier) GrantRolePermission(_ context.Context, _ storage.DBTX, role string, permission string) error {
+	q.rolePermissions = append(q.rolePermissions, storage.RolePermission{Role: role, Permission: permission})
+	return nil
+}
+
+func (q *fakeQuerier) SetRoleRequireTwoFactor(_ context.Context, _ storage.DBTX, requireTwoFactor bool,
+	name string) error {
+	role := q.roles[name]
+	role.RequireTwoFactor = requireTwoFactor
+	q.roles[name] = role
+	return nil
+}
+
+func (q *fakeQuerier) CountUsersWithPermission(_ context.Context, _ storage.DBTX, permission string) (int64, error) {
+	var count int64
+	for username, roles := range q.userRoles {
+		if slices.ContainsFunc(roles, func(role string) bool {
+			return slices.Contains(q.rolePermissions, storage.RolePermission{Role: role, Permission: permission}) &&
+				(!q.roles[role].RequireTwoFactor || q.twoFactor[username])
+		}) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *fakeQuerier) CreateAuditEvent(_ context.Context, _ storage.DBTX, arg storage.CreateAuditEventParams) error {
+	q.events = append(q.events, arg)
+	return nil
+}
+
+type RolesTestSuite struct {
+	suite.Suite
+	querier *fakeQuerier
+	db      *fakeDatabase
+	router  chi.Router
+}
+
+func (s *RolesTestSuite) SetupTest() {
+	tmpl, err := templates.GetSharedTemplates()
+	require.NoError(s.T(), err)
+
+	s.querier = &fakeQuerier{
+		roles: map[string]storage.Role{
+			"admin":              {Name: "admin"},
+			"teaching_assistant": {Name: "teaching_assistant"},
+		},
+		rolePermissions: []storage.RolePermission{
+			{Role: "admin", Permission: "submit"},
+			{Role: "admin", Permission: "manage_users"},
+			{Role: "teaching_assistant", Permission: "view_submissions"},
+		},
+		userRoles: map[string][]string{
+			"root": {"admin"},
+			"ta":   {"teaching_assistant"},
+		},
+		twoFactor: map[string]bool{},
+	}
+	s.db = &fakeDatabase{}
+
+	servicer := &servicerImpl{pool: s.db, querier: s.querier, templates: tmpl}
+	s.router = chi.NewRouter()
+	s.router.Post("/roles/{role}", servicer.SetRolePermissions)
+}
+
+func (s *RolesTestSuite) post(role string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/roles/"+role, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *RolesTestSuite) TestKeepManagingUsers() {
+	rec := s.post("admin", url.Values{"permission": {"manage_users"}})
+	assert.Equal(s.T(), http.StatusSeeOther, rec.Code)
+	assert.Equal(s.T(), 1, s.db.committed)
+
+	require.Len(s.T(), s.querier.events, 1)
+	assert.Equal(s.T(), string(audit.RoleUpdate), s.querier.events[0].Action)
+	assert.Equal(s.T(), "admin", s.querier.events[0].TargetID)
+}
+
+func (s *RolesTestSuite) TestRemoveLastManageUsers() {
+	rec := s.post("admin", url.Values{"permission": {"submit"}})
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Contains(s.T(), rec.Body.String(), "nobody could manage users anymore")
+	assert.Zero(s.T(), s.db.committed)
+	assert.Empty(s.T(), s.querier.events)
+}
+
+func (s *RolesTestSuite) TestRequireTwoFactorNoManagerHas() {
+	rec := s.post("admin", url.Values{"permission": {"manage_users"}, "require_two_factor": {"on"}})
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Contains(s.T(), rec.Body.String(), "nobody could manage users anymore")
+	assert.Zero(s.T(), s.db.committed)
+}
+
+func (s *RolesTestSuite) TestRequireTwoFactor() {
+	s.querier.twoFactor["root"] = true
+
+	rec := s.post("admin", url.Values{"permission": {"manage_users"}, "require_two_factor": {"on"}})
+	assert.Equal(s.T(), http.StatusSeeOther, rec.Code)
+	assert.Equal(s.T(), 1, s.db.committed)
+	assert.True(s.T(), s.querier.roles["admin"].RequireTwoFactor)
+}
+
+func (s *RolesTestSuite) TestMoveManageUsers() {
+	rec := s.post("teaching_assistant", url.Values{"permission": {"view_submissions", "manage_users"}})
+	require.Equal(s.T(), http.StatusSeeOther, rec.Code)
+
+	rec = s.post("admin", url.Values{"permission": {"submit"}})
+	assert.Equal(s.T(), http.StatusSeeOther, rec.Code, "the teaching assistant still manages users")
+	assert.Equal(s.T(), 2, s.db.committed)
+}
+
+func TestRolesTestSuite(t *testing.T) {
+	suite.Run(t, new(RolesTestSuite))
+}
@@ -45,6 +45,8 @@ type RunStatus struct {
 	Stderr        string
 	Status        runner.SubmissionStatusUpdate_Status
 	ExecutionTime time.Duration
+	// MemoryKb is the peak memory of the program, only known for RunProgram
+	MemoryKb int64
 }
 
 func NewCodeEvaluator(ctx context.Context) (*CodeEvaluator, error) {
@@ -173,15 +175,79 @@ func (c *CodeEvaluator) waitForBuildContainer(ctx context.Context, containerID s
 }
 
 func (c *CodeEvaluator) RunTestCase(ctx context.Context, submissionID string, testInput, testOutput string, timelimitMs, memorylimitKb int64) (*RunStatus, error) {
-	volumeName := fmt.Sprintf("go-judge-volume-%s", submissionID)
-
 	inputBuf, outputBuf := byteFileToTar([]byte(testInput), "test_input"), byteFileToTar([]byte(testOutput), "test_output")
 
+	res, err := c.runContainer(ctx, submissionID,
+		[]string{"/utils/spy", "-timeout", strconv.Itoa(int(timelimitMs))},
+		[]bytes.Buffer{inputBuf, outputBuf}, memorylimitKb)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &RunStatus{
+		Stdout:        res.stdout,
+		Stderr:        res.stderr,
+		Status:        c.getStatusCode(res.stdout, res.exitCode),
+		ExecutionTime: res.executionTime,
+	}
+
+	if res.executionError != nil {
+		return status, res.executionError
+	}
+
+	return status, nil
+}
+
+// RunProgram runs a binary built by BuildCodeBinary with the given arguments and stdin,
+// returning its raw output instead of comparing it with an expected one
+func (c *CodeEvaluator) RunProgram(ctx context.Context, buildID string, input string, args []string, timelimitMs, memorylimitKb int64) (*RunStatus, error) {
+	inputBuf := byteFileToTar([]byte(input), "test_input")
+
+	cmd := append([]string{"/utils/spy", "-raw", "-timeout", strconv.Itoa(int(timelimitMs)), "--"}, args...)
+
+	res, err := c.runContainer(ctx, buildID, cmd, []bytes.Buffer{inputBuf}, memorylimitKb)
+	if err != nil {
+		return nil, err
+	}
+
+	stderr, memoryKb := splitMemoryReport(res.stderr)
+
+	status := &RunStatus{
+		Stdout:        res.stdout,
+		Stderr:        stderr,
+		Status:        c.getProgramStatusCode(stderr, res.exitCode),
+		ExecutionTime: res.executionTime,
+		MemoryKb:      memoryKb,
+	}
+
+	if res.executionError != nil {
+		return status, res.executionError
+	}
+
+	return status, nil
+}
+
+// RemoveBuild removes the volume that holds the binary built for buildID
+func (c *CodeEvaluator) RemoveBuild(ctx context.Context, buildID string) error {
+	return c.dockerClient.VolumeRemove(ctx, fmt.Sprintf("go-judge-volume-%s", buildID), true)
+}
+
+type containerResult struct {
+	stdout         string
+	stderr         string
+	exitCode       int
+	executionTime  time.Duration
+	executionError error
+}
+
+func (c *CodeEvaluator) runContainer(ctx context.Context, buildID string, cmd []string, files []bytes.Buffer, memorylimitKb int64) (*containerResult, error) {
+	volumeName := fmt.Sprintf("go-judge-volume-%s", buildID)
+
 	memSize := memorylimitKb * 1024
 
 	resp, err := c.dockerClient.ContainerCreate(ctx, &container.Config{
 		Image:        "ubuntu:22.04",
-		Cmd:          []string{"/utils/spy", "-timeout", strconv.Itoa(int(timelimitMs))},
+		Cmd:          cmd,
 		Tty:          false,
 		AttachStdin:  true,
 		AttachStdout: true,
@@ -214,25 +280,22 @@ func (c *CodeEvaluator) RunTestCase(ctx context.Context, submissionID string, te
 			OomKillDisable:    &[]bool{false}[0],
 		},
 		NetworkMode: "none",
-	}, nil, nil, fmt.Sprintf("go-runner-execution-%s", submissionID))
+	}, nil, nil, fmt.Sprintf("go-runner-execution-%s", buildID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create runner container: %w", err)
 	}
 
 	runnerContainerID := resp.ID
 
-	err = c.dockerClient.CopyToContainer(ctx, runnerContainerID, "/app", &inputBuf, container.CopyToContainerOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("could not copy input test file into container: %w", err)
-	}
+	defer c.dockerClient.ContainerRemove(ctx, runnerContainerID, container.RemoveOptions{Force: true})
 
-	err = c.dockerClient.CopyToContainer(ctx, runnerContainerID, "/app", &outputBuf, container.CopyToContainerOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("could not copy output test file into container: %w", err)
+	for _, file := range files {
+		err = c.dockerClient.CopyToContainer(ctx, runnerContainerID, "/app", &file, container.CopyToContainerOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("could not copy test file into container: %w", err)
+		}
 	}
 
-	defer c.dockerClient.ContainerRemove(ctx, runnerContainerID, container.RemoveOptions{Force: true})
-
 	if err := c.dockerClient.ContainerStart(ctx, runnerContainerID, container.StartOptions{}); err != nil {
 		return nil, fmt.Errorf("failed to start runner container: %w", err)
 	}
@@ -280,18 +343,13 @@ func (c *CodeEvaluator) RunTestCase(ctx context.Context, submissionID string, te
 		return nil, fmt.Errorf("failed to read logs: %w", err)
 	}
 
-	status := &RunStatus{
-		Stdout:        stdout.String(),
-		Stderr:        stderr.String(),
-		Status:        c.getStatusCode(stdout.String(), exitCode),
-		ExecutionTime: executionTime,
-	}
-
-	if executionError != nil {
-		return status, executionError
-	}
-
-	return status, nil
+	return &containerResult{
+		stdout:         stdout.String(),
+		stderr:         stderr.String(),
+		exitCode:       exitCode,
+		executionTime:  executionTime,
+		executionError: executionError,
+	}, nil
 }
 
 // sanitizeUTF8 removes null bytes and ensures the string is valid UTF-8
@@ -319,6 +377,32 @@ func byteFileToTar(content []byte, name string) bytes.Buffer {
 	return buf
 }
 
+// memoryReportPrefix must match the one in utils/spy.go
+const memoryReportPrefix = "SPY MAX RSS KB: "
+
+// splitMemoryReport removes the peak memory line spy appends to stderr in raw mode and returns its value
+func splitMemoryReport(stderr string) (string, int64) {
+	i := strings.LastIndex(stderr, "\n"+memoryReportPrefix)
+	if i < 0 {
+		return stderr, 0
+	}
+	memoryKb, err := strconv.ParseInt(strings.TrimSpace(stderr[i+1+len(memoryReportPrefix):]), 10, 64)
+	if err != nil {
+		return stderr, 0
+	}
+	return stderr[:i], memoryKb
+}
+
+// userOutput extracts the output of the submission from the report spy prints on a wrong answer
+func userOutput(report string) string {
+	_, output, found := strings.Cut(report, "--- User Output ---\n")
+	if !found {
+		return ""
+	}
+	output, _, _ = strings.Cut(output, "\n--- Expected Output ---")
+	return output
+}
+
 func (*CodeEvaluator) getStatusCode(stdout string, exitCode int) runner.SubmissionStatusUpdate_Status {
 	if exitCode == 0 {
 		if strings.HasPrefix(stdout, "CORRECT") {
@@ -340,3 +424,20 @@ func (*CodeEvaluator) getStatusCode(stdout string, exitCode int) runner.Submissi
 
 	return runner.SubmissionStatusUpdate_INTERNAL_ERROR
 }
+
+func (*CodeEvaluator) getProgramStatusCode(stderr string, exitCode int) runner.SubmissionStatusUpdate_Status {
+	if exitCode == 0 {
+		return runner.SubmissionStatusUpdate_ACCEPTED
+	}
+
+	if strings.HasPrefix(stderr, "RUNTIME ERROR") {
+		return runner.SubmissionStatusUpdate_RUNTIME_ERROR
+	}
+
+	st, ok := exitCodeToStatus[exitCode]
+	if ok {
+		return st
+	}
+
+	return runner.SubmissionStatusUpdate_INTERNAL_ERROR
+}
@@ -3,17 +3,27 @@ package runner
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 
+	"github.com/google/uuid"
 	"golang.org/x/sync/semaphore"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	runnerPb "github.com/computer-technology-team/go-judge/api/gen/runner"
+	"github.com/computer-technology-team/go-judge/internal/sourceview"
 	"github.com/samber/lo"
 )
 
+const (
+	// maxSampleOutput caps the output of a failing sample sent back to the user
+	maxSampleOutput = 64 << 10
+	// maxProgramOutput caps the output of a program run, it is the largest test file a generator may produce
+	maxProgramOutput = 16 << 20
+)
+
 type runnerServer struct {
 	runnerPb.UnimplementedRunnerServer
 
@@ -116,18 +126,17 @@ func (rs *runnerServer) ExecuteSubmission(
 				logger.Info("exection failed", "error", err, "status", runStatus.Status, "stdout", runStatus.Stdout,
 					"stderr", runStatus.Stderr)
 
-				stream.Send(&runnerPb.SubmissionStatusUpdate{
+				err = stream.Send(&runnerPb.SubmissionStatusUpdate{
 					SubmissionId:   request.GetSubmissionId(),
 					Status:         runStatus.Status,
-					StatusMessage:  runStatus.Stdout,
+					StatusMessage:  sanitizeUTF8([]byte(runStatus.Stdout)),
 					TestsCompleted: int32(i),
 					TotalTests:     int32(len(request.GetTestCases())),
 					MaxTimeSpentMs: maxTimeSpendMs,
 				})
-
 			} else {
 				logger.Error("run test case failed", "error", err)
-				stream.Send(&runnerPb.SubmissionStatusUpdate{
+				err = stream.Send(&runnerPb.SubmissionStatusUpdate{
 					SubmissionId:   request.GetSubmissionId(),
 					Status:         runnerPb.SubmissionStatusUpdate_INTERNAL_ERROR,
 					TestsCompleted: int32(i),
@@ -135,17 +144,28 @@ func (rs *runnerServer) ExecuteSubmission(
 					MaxTimeSpentMs: maxTimeSpendMs,
 				})
 			}
+			if err != nil {
+				logger.Error("could not send update in stream", "error", err)
+				return status.Error(codes.Internal, "could not send last message in stream")
+			}
 			return nil
 		}
 
 		if runStatus.Status == runnerPb.SubmissionStatusUpdate_WRONG_ANSWER {
-			stream.Send(&runnerPb.SubmissionStatusUpdate{
+			update := &runnerPb.SubmissionStatusUpdate{
 				SubmissionId:   request.GetSubmissionId(),
 				Status:         runnerPb.SubmissionStatusUpdate_WRONG_ANSWER,
 				TestsCompleted: int32(i),
 				TotalTests:     int32(len(request.GetTestCases())),
 				MaxTimeSpentMs: maxTimeSpendMs,
-			})
+			}
+			if tc.GetSample() {
+				update.Output = sampleOutput(runStatus.Stdout)
+			}
+			if err := stream.Send(update); err != nil {
+				logger.Error("could not send update in stream", "error", err)
+				return status.Error(codes.Internal, "could not send last message in stream")
+			}
 			return nil
 		}
 
@@ -157,7 +177,7 @@ func (rs *runnerServer) ExecuteSubmission(
 		Status:         runnerPb.SubmissionStatusUpdate_ACCEPTED,
 		TestsCompleted: int32(len(request.GetTestCases())),
 		TotalTests:     int32(len(request.GetTestCases())),
-		MaxTimeSpentMs: 100,
+		MaxTimeSpentMs: maxTimeSpendMs,
 	})
 	if err != nil {
 		logger.Error("could not send update in stream", "error", err)
@@ -166,3 +186,93 @@ func (rs *runnerServer) ExecuteSubmission(
 
 	return nil
 }
+
+// sampleOutput is the output of a failing sample as it is shown to the user, cut to maxSampleOutput bytes
+func sampleOutput(report string) string {
+	output, truncated := sourceview.Truncate(sanitizeUTF8([]byte(userOutput(report))), maxSampleOutput)
+	if truncated {
+		return output + "\n[output truncated]"
+	}
+	return output
+}
+
+func (rs *runnerServer) RunProgram(
+	request *runnerPb.ProgramRequest,
+	stream grpc.ServerStreamingServer[runnerPb.ProgramRunResult],
+) error {
+	// builds are keyed per request so that concurrent runs of the same program do not collide
+	buildID := fmt.Sprintf("program-%s-%s", request.GetProgramId(), uuid.NewString())
+
+	logger := slog.With("memory_limit", request.GetMemoryLimitKb(), "timelimit", request.GetTimeLimitMs(),
+		"program_id", request.GetProgramId(), "build_id", buildID, "runs", len(request.GetRuns()))
+	logger.Info("recieved program request")
+
+	err := rs.resourceLimiter.Acquire(stream.Context(), 1)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			logger.Warn("failed to acquire resource in time")
+			return nil
+		}
+		logger.Error("failed to acquire resource", "error", err)
+		return status.Error(codes.Internal, "could not acquire resource")
+	}
+	defer rs.resourceLimiter.Release(1)
+
+	err = rs.codeEvaluator.BuildCodeBinary(stream.Context(), buildID, request.GetCode())
+	if err != nil {
+		if buildErr, ok := lo.ErrorsAs[*BuildError](err); ok {
+			logger.Warn("compilation failed", "error", err)
+			return stream.Send(&runnerPb.ProgramRunResult{
+				Status: runnerPb.SubmissionStatusUpdate_COMPILATION_ERROR,
+				Stderr: buildErr.Logs,
+			})
+		}
+		logger.Error("unexpected error in building code volume", "error", err)
+		return stream.Send(&runnerPb.ProgramRunResult{
+			Status: runnerPb.SubmissionStatusUpdate_INTERNAL_ERROR,
+		})
+	}
+
+	defer func() {
+		if err := rs.codeEvaluator.RemoveBuild(context.WithoutCancel(stream.Context()), buildID); err != nil {
+			logger.Warn("could not remove build volume", "error", err)
+		}
+	}()
+
+	for i, run := range request.GetRuns() {
+		runStatus, err := rs.codeEvaluator.RunProgram(stream.Context(), buildID, run.GetInput(), run.GetArgs(),
+			request.GetTimeLimitMs(), request.GetMemoryLimitKb())
+		if err != nil && !errors.Is(err, ErrExecutionFailed) {
+			logger.Error("run program failed", "error", err, "i", i)
+			return stream.Send(&runnerPb.ProgramRunResult{
+				Index:  int32(i),
+				Status: runnerPb.SubmissionStatusUpdate_INTERNAL_ERROR,
+			})
+		}
+
+		stdout, truncated := sourceview.Truncate(sanitizeUTF8([]byte(runStatus.Stdout)), maxProgramOutput)
+		result := &runnerPb.ProgramRunResult{
+			Index:       int32(i),
+			Status:      runStatus.Status,
+			Stdout:      stdout,
+			Stderr:      sanitizeUTF8([]byte(runStatus.Stderr)),
+			TimeSpentMs: runStatus.ExecutionTime.Milliseconds(),
+			MemoryKb:    runStatus.MemoryKb,
+		}
+		if truncated {
+			// a cut output would silently become a broken generated test, so the run fails instead
+			if result.Status == runnerPb.SubmissionStatusUpdate_ACCEPTED {
+				result.Status = runnerPb.SubmissionStatusUpdate_RUNTIME_ERROR
+			}
+			result.Stderr += fmt.Sprintf("\noutput is larger than %d bytes", maxProgramOutput)
+		}
+
+		err = stream.Send(result)
+		if err != nil {
+			logger.Error("could not send result in stream", "error", err)
+			return status.Error(codes.Internal, "could not send result in stream")
+		}
+	}
+
+	return nil
+}
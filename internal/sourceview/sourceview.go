@@ -0,0 +1,89 @@
+package sourceview
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+var formatter = html.New(
+	html.WithLineNumbers(true),
+	html.LineNumbersInTable(true),
+	html.WithLinkableLineNumbers(true, "L"),
+	html.TabWidth(4),
+)
+
+// Highlight renders Go source as HTML with inline styles and numbered lines that can be linked as #L<number>
+func Highlight(code string) (template.HTML, error) {
+	iterator, err := lexers.Get("go").Tokenise(nil, code)
+	if err != nil {
+		return "", fmt.Errorf("could not tokenize code: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := formatter.Format(&buf, styles.Get("github"), iterator); err != nil {
+		return "", fmt.Errorf("could not format code: %w", err)
+	}
+
+	// chroma escapes the source, the output is safe to embed
+	return template.HTML(buf.String()), nil
+}
+
+// DiffLine is a line of a unified diff, Kind is one of "header", "hunk", "added", "removed" and "context"
+type DiffLine struct {
+	Kind string
+	Text string
+}
+
+// Diff returns the unified diff of two versions of a source file, empty when they are equal
+func Diff(from, to, fromName, toName string) ([]DiffLine, error) {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		// SplitLines already ends the last line with a newline
+		A:        difflib.SplitLines(strings.TrimSuffix(from, "\n")),
+		B:        difflib.SplitLines(strings.TrimSuffix(to, "\n")),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not diff code: %w", err)
+	}
+
+	var lines []DiffLine
+	inHunk := false
+	for _, text := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		if text == "" {
+			continue
+		}
+
+		kind := "context"
+		switch {
+		case strings.HasPrefix(text, "@@"):
+			kind = "hunk"
+			inHunk = true
+		case !inHunk:
+			kind = "header"
+		case strings.HasPrefix(text, "+"):
+			kind = "added"
+		case strings.HasPrefix(text, "-"):
+			kind = "removed"
+		}
+		lines = append(lines, DiffLine{Kind: kind, Text: text})
+	}
+
+	return lines, nil
+}
+
+// Truncate cuts text to at most size bytes without splitting a character, reporting whether anything was cut
+func Truncate(text string, size int) (string, bool) {
+	if len(text) <= size {
+		return text, false
+	}
+	return strings.ToValidUTF8(text[:size], ""), true
+}
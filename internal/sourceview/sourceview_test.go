@@ -0,0 +1,67 @@
+package sourceview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type SourceViewTestSuite struct {
+	suite.Suite
+}
+
+func (s *SourceViewTestSuite) TestHighlight() {
+	html, err := Highlight("package main\n\n// a < b\nfunc main() {}\n")
+	require.NoError(s.T(), err)
+
+	assert.Contains(s.T(), string(html), "a &lt; b")
+	assert.Contains(s.T(), string(html), `id="L4"`)
+	assert.NotContains(s.T(), string(html), "<script")
+}
+
+func (s *SourceViewTestSuite) TestDiff() {
+	lines, err := Diff("a\n-- b\nc\n", "a\nb\nc\n", "first", "second")
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), []DiffLine{
+		{Kind: "header", Text: "--- first"},
+		{Kind: "header", Text: "+++ second"},
+		{Kind: "hunk", Text: "@@ -1,3 +1,3 @@"},
+		{Kind: "context", Text: " a"},
+		{Kind: "removed", Text: "--- b"},
+		{Kind: "added", Text: "+b"},
+		{Kind: "context", Text: " c"},
+	}, lines)
+
+	lines, err = Diff("a\n", "a\n", "first", "second")
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), lines)
+}
+
+func (s *SourceViewTestSuite) TestTruncate() {
+	testCases := []struct {
+		text      string
+		size      int
+		expected  string
+		truncated bool
+	}{
+		{"abc", 3, "abc", false},
+		{"abcd", 3, "abc", true},
+		{"", 0, "", false},
+		{"héllo", 2, "h", true},
+		{"héllo", 3, "hé", true},
+		{"日本", 4, "日", true},
+	}
+
+	for _, tc := range testCases {
+		text, truncated := Truncate(tc.text, tc.size)
+		assert.Equal(s.T(), tc.expected, text, "%q cut to %d bytes", tc.text, tc.size)
+		assert.Equal(s.T(), tc.truncated, truncated, "%q cut to %d bytes", tc.text, tc.size)
+	}
+}
+
+func TestSourceViewTestSuite(t *testing.T) {
+	suite.Run(t, new(SourceViewTestSuite))
+}
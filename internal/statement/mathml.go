@@ -0,0 +1,504 @@
+package statement
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// LaTeX math is converted to MathML on the server, browsers render MathML Core without any script.
+// Only the subset of LaTeX used in problem statements is supported, unknown commands are shown as text.
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokCommand
+	tokText
+	tokOpen
+	tokClose
+	tokSup
+	tokSub
+	tokNumber
+	tokLetter
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// command is the text command that produced a tokText, e.g. "text" or "mathbb"
+	command string
+}
+
+// textCommands take their argument as raw text instead of math
+var textCommands = map[string]bool{
+	"text": true, "textrm": true, "textit": true, "textbf": true, "mbox": true,
+	"mathrm": true, "operatorname": true, "mathit": true, "mathbf": true, "mathbb": true, "mathcal": true,
+}
+
+var identifiers = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε", "zeta": "ζ",
+	"eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ", "lambda": "λ", "mu": "μ", "nu": "ν",
+	"xi": "ξ", "pi": "π", "varpi": "ϖ", "rho": "ρ", "varrho": "ϱ", "sigma": "σ", "varsigma": "ς", "tau": "τ",
+	"upsilon": "υ", "phi": "ϕ", "varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"infty": "∞", "emptyset": "∅", "varnothing": "∅", "partial": "∂", "nabla": "∇", "ell": "ℓ", "hbar": "ℏ",
+	"aleph": "ℵ",
+}
+
+// uprightIdentifiers are single characters that are not italic, like capital greek letters
+var uprightIdentifiers = map[string]string{
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π", "Sigma": "Σ",
+	"Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+	"$": "$", "#": "#", "_": "_",
+}
+
+var operators = map[string]string{
+	"le": "≤", "leq": "≤", "ge": "≥", "geq": "≥", "ne": "≠", "neq": "≠", "lt": "<", "gt": ">", "ll": "≪", "gg": "≫",
+	"cdot": "⋅", "times": "×", "div": "÷", "pm": "±", "mp": "∓", "ast": "∗", "star": "⋆", "circ": "∘", "bullet": "∙",
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←", "leftrightarrow": "↔", "Rightarrow": "⇒",
+	"Leftarrow": "⇐", "Leftrightarrow": "⇔", "implies": "⟹", "iff": "⟺", "mapsto": "↦", "uparrow": "↑",
+	"downarrow": "↓", "ldots": "…", "dots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱",
+	"in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂", "subseteq": "⊆", "supset": "⊃", "supseteq": "⊇",
+	"cup": "∪", "cap": "∩", "setminus": "∖", "forall": "∀", "exists": "∃",
+	"approx": "≈", "equiv": "≡", "sim": "∼", "simeq": "≃", "cong": "≅", "propto": "∝",
+	"mid": "∣", "parallel": "∥", "perp": "⊥", "oplus": "⊕", "otimes": "⊗",
+	"land": "∧", "wedge": "∧", "lor": "∨", "vee": "∨", "neg": "¬", "lnot": "¬", "prime": "′",
+	"lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉", "langle": "⟨", "rangle": "⟩",
+	"vert": "|", "|": "‖", "Vert": "‖", "{": "{", "}": "}", "lbrace": "{", "rbrace": "}", "colon": ":",
+	"%": "%", "&": "&",
+}
+
+var bigOperators = map[string]string{
+	"sum": "∑", "prod": "∏", "coprod": "∐", "int": "∫", "iint": "∬", "oint": "∮",
+	"bigcup": "⋃", "bigcap": "⋂", "bigoplus": "⨁", "bigvee": "⋁", "bigwedge": "⋀",
+}
+
+var functions = map[string]bool{
+	"log": true, "ln": true, "lg": true, "exp": true, "sin": true, "cos": true, "tan": true, "cot": true,
+	"sec": true, "csc": true, "arcsin": true, "arccos": true, "arctan": true, "sinh": true, "cosh": true,
+	"tanh": true, "det": true, "dim": true, "ker": true, "deg": true, "arg": true, "gcd": true, "lcm": true,
+	"Pr": true, "hom": true,
+}
+
+// limitFunctions put their subscript under the name in display math, like \lim_{n \to \infty}
+var limitFunctions = map[string]bool{
+	"lim": true, "max": true, "min": true, "sup": true, "inf": true, "limsup": true, "liminf": true,
+}
+
+var spaces = map[string]string{
+	",": "0.1667em", ":": "0.2222em", ">": "0.2222em", ";": "0.2778em", " ": "0.25em",
+	"quad": "1em", "qquad": "2em",
+}
+
+var accents = map[string]string{
+	"overline": "‾", "bar": "¯", "hat": "^", "widehat": "^", "tilde": "~", "widetilde": "~", "vec": "→",
+	"dot": "˙", "ddot": "¨", "overrightarrow": "→",
+}
+
+// ignoredCommands change only spacing or style details MathML handles itself
+var ignoredCommands = map[string]bool{
+	"": true, "!": true, "\\": true, "displaystyle": true, "textstyle": true, "limits": true, "nolimits": true,
+	"left": true, "right": true, "big": true, "Big": true, "bigg": true, "Bigg": true,
+}
+
+var symbols = map[string]string{"-": "−", "*": "∗", "'": "′"}
+
+// LaTeXToMathML converts LaTeX math to a MathML element, display math is rendered as a block
+func LaTeXToMathML(tex string, display bool) string {
+	p := &mathParser{tokens: tokenize(tex), display: display}
+	row := p.row(p.parseList(untilEOF))
+
+	var sb strings.Builder
+	if display {
+		sb.WriteString(`<math display="block">`)
+	} else {
+		sb.WriteString(`<math>`)
+	}
+	sb.WriteString(row)
+	sb.WriteString(`</math>`)
+	return sb.String()
+}
+
+func tokenize(s string) []token {
+	var tokens []token
+	rs := []rune(s)
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\\':
+			j := i + 1
+			for j < len(rs) && isASCIILetter(rs[j]) {
+				j++
+			}
+			if j == i+1 && j < len(rs) {
+				j++
+			}
+			name := string(rs[i+1 : j])
+			i = j
+
+			if textCommands[name] {
+				if text, end, ok := rawGroup(rs, i); ok {
+					tokens = append(tokens, token{kind: tokText, text: text, command: name})
+					i = end
+					continue
+				}
+			}
+			tokens = append(tokens, token{kind: tokCommand, text: name})
+		case r == '{':
+			tokens = append(tokens, token{kind: tokOpen, text: "{"})
+			i++
+		case r == '}':
+			tokens = append(tokens, token{kind: tokClose, text: "}"})
+			i++
+		case r == '^':
+			tokens = append(tokens, token{kind: tokSup, text: "^"})
+			i++
+		case r == '_':
+			tokens = append(tokens, token{kind: tokSub, text: "_"})
+			i++
+		case r >= '0' && r <= '9':
+			j := i
+			for j < len(rs) && (isDigit(rs[j]) || (rs[j] == '.' && j+1 < len(rs) && isDigit(rs[j+1]))) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(rs[i:j])})
+			i = j
+		case unicode.IsLetter(r):
+			tokens = append(tokens, token{kind: tokLetter, text: string(r)})
+			i++
+		default:
+			tokens = append(tokens, token{kind: tokSymbol, text: string(r)})
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// rawGroup reads the text of a balanced {...} group starting at or after spaces from i
+func rawGroup(rs []rune, i int) (string, int, bool) {
+	for i < len(rs) && unicode.IsSpace(rs[i]) {
+		i++
+	}
+	if i >= len(rs) || rs[i] != '{' {
+		return "", i, false
+	}
+
+	depth := 0
+	for j := i; j < len(rs); j++ {
+		switch rs[j] {
+		case '\\':
+			j++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return string(rs[i+1 : j]), j + 1, true
+			}
+		}
+	}
+	return "", i, false
+}
+
+type listEnd int
+
+const (
+	untilEOF listEnd = iota
+	untilClose
+	untilRight
+	untilBracket
+)
+
+type mathParser struct {
+	tokens  []token
+	pos     int
+	display bool
+}
+
+func (p *mathParser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{kind: tokEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *mathParser) next() token {
+	t := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+// parseList parses nodes until end, unbalanced closing tokens are skipped
+func (p *mathParser) parseList(end listEnd) []string {
+	var nodes []string
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokEOF:
+			return nodes
+		case t.kind == tokClose:
+			if end == untilRight {
+				return nodes
+			}
+			p.next()
+			if end == untilClose {
+				return nodes
+			}
+			continue
+		case t.kind == tokCommand && t.text == "right":
+			if end == untilRight {
+				return nodes
+			}
+			p.next()
+			p.delimiter()
+			continue
+		case end == untilBracket && t.kind == tokSymbol && t.text == "]":
+			p.next()
+			return nodes
+		}
+
+		nodes = append(nodes, p.parseScripted())
+	}
+}
+
+func (p *mathParser) parseScripted() string {
+	base, limits := p.parseAtom()
+
+	var sub, sup string
+	hasSub, hasSup := false, false
+	for {
+		t := p.peek()
+		if t.kind == tokSub && !hasSub {
+			p.next()
+			sub, hasSub = p.parseArg(), true
+			continue
+		}
+		if t.kind == tokSup && !hasSup {
+			p.next()
+			sup, hasSup = p.parseArg(), true
+			continue
+		}
+		break
+	}
+
+	under, over, both := "msub", "msup", "msubsup"
+	if limits && p.display {
+		under, over, both = "munder", "mover", "munderover"
+	}
+
+	switch {
+	case hasSub && hasSup:
+		return fmt.Sprintf("<%s>%s%s%s</%s>", both, base, sub, sup, both)
+	case hasSub:
+		return fmt.Sprintf("<%s>%s%s</%s>", under, base, sub, under)
+	case hasSup:
+		return fmt.Sprintf("<%s>%s%s</%s>", over, base, sup, over)
+	}
+	return base
+}
+
+// parseArg parses a single argument of a command or script, a group or one atom
+func (p *mathParser) parseArg() string {
+	if p.peek().kind == tokOpen {
+		p.next()
+		return p.row(p.parseList(untilClose))
+	}
+	atom, _ := p.parseAtom()
+	return atom
+}
+
+// parseAtom parses one node without scripts, limits reports whether its scripts go under and over it
+func (p *mathParser) parseAtom() (string, bool) {
+	t := p.peek()
+	switch t.kind {
+	case tokEOF, tokClose, tokSup, tokSub:
+		return "<mrow></mrow>", false
+	}
+
+	p.next()
+	switch t.kind {
+	case tokOpen:
+		return p.row(p.parseList(untilClose)), false
+	case tokNumber:
+		return element("mn", t.text), false
+	case tokLetter:
+		return element("mi", t.text), false
+	case tokText:
+		return textElement(t.command, t.text), false
+	case tokSymbol:
+		if s, ok := symbols[t.text]; ok {
+			return element("mo", s), false
+		}
+		return element("mo", t.text), false
+	}
+
+	return p.command(t.text)
+}
+
+func (p *mathParser) command(name string) (string, bool) {
+	if s, ok := identifiers[name]; ok {
+		return element("mi", s), false
+	}
+	if s, ok := uprightIdentifiers[name]; ok {
+		return `<mi mathvariant="normal">` + html.EscapeString(s) + `</mi>`, false
+	}
+	if s, ok := operators[name]; ok {
+		return element("mo", s), false
+	}
+	if s, ok := bigOperators[name]; ok {
+		return element("mo", s), true
+	}
+	if functions[name] {
+		return element("mi", name), false
+	}
+	if limitFunctions[name] {
+		return element("mi", name), true
+	}
+	if width, ok := spaces[name]; ok {
+		return `<mspace width="` + width + `"></mspace>`, false
+	}
+	if accent, ok := accents[name]; ok {
+		return `<mover accent="true">` + p.parseArg() + element("mo", accent) + `</mover>`, false
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac":
+		numerator := p.parseArg()
+		return "<mfrac>" + numerator + p.parseArg() + "</mfrac>", false
+	case "binom":
+		n := p.parseArg()
+		return `<mrow><mo>(</mo><mfrac linethickness="0">` + n + p.parseArg() + `</mfrac><mo>)</mo></mrow>`, false
+	case "sqrt":
+		if t := p.peek(); t.kind == tokSymbol && t.text == "[" {
+			p.next()
+			index := p.row(p.parseList(untilBracket))
+			return "<mroot>" + p.parseArg() + index + "</mroot>", false
+		}
+		return "<msqrt>" + p.parseArg() + "</msqrt>", false
+	case "underline":
+		return `<munder>` + p.parseArg() + element("mo", "_") + `</munder>`, false
+	case "bmod", "mod":
+		return element("mo", "mod"), false
+	case "pmod":
+		return `<mrow><mspace width="0.4em"></mspace><mo>(</mo><mi>mod</mi><mspace width="0.3333em"></mspace>` +
+			p.parseArg() + `<mo>)</mo></mrow>`, false
+	case "left":
+		open := p.delimiter()
+		content := p.parseList(untilRight)
+		var closing string
+		if t := p.peek(); t.kind == tokCommand && t.text == "right" {
+			p.next()
+			closing = p.delimiter()
+		}
+		return "<mrow>" + stretchy(open) + strings.Join(content, "") + stretchy(closing) + "</mrow>", false
+	}
+
+	if ignoredCommands[name] {
+		return "", false
+	}
+
+	return element("mtext", `\`+name), false
+}
+
+// delimiter reads the delimiter after \left or \right, "." is an invisible one
+func (p *mathParser) delimiter() string {
+	t := p.next()
+	switch t.kind {
+	case tokSymbol:
+		if t.text == "." {
+			return ""
+		}
+		return t.text
+	case tokCommand:
+		return operators[t.text]
+	}
+	return ""
+}
+
+func (p *mathParser) row(nodes []string) string {
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+	return "<mrow>" + strings.Join(nodes, "") + "</mrow>"
+}
+
+func stretchy(delimiter string) string {
+	if delimiter == "" {
+		return ""
+	}
+	return `<mo stretchy="true">` + html.EscapeString(delimiter) + `</mo>`
+}
+
+func element(name, text string) string {
+	return "<" + name + ">" + html.EscapeString(text) + "</" + name + ">"
+}
+
+func textElement(command, text string) string {
+	switch command {
+	case "mathrm", "operatorname":
+		if len([]rune(text)) == 1 {
+			return `<mi mathvariant="normal">` + html.EscapeString(text) + `</mi>`
+		}
+		return element("mi", text)
+	case "mathit":
+		return element("mi", text)
+	case "mathbf":
+		return element("mi", mapLetters(text, boldLetter))
+	case "mathbb":
+		return element("mi", mapLetters(text, doubleStruckLetter))
+	case "mathcal":
+		return element("mi", text)
+	}
+	return element("mtext", text)
+}
+
+func mapLetters(s string, mapping func(rune) rune) string {
+	return strings.Map(mapping, s)
+}
+
+// boldLetter and doubleStruckLetter map to the Mathematical Alphanumeric Symbols block,
+// MathML Core dropped mathvariant for everything but normal
+func boldLetter(r rune) rune {
+	switch {
+	case r >= 'A' && r <= 'Z':
+		return 0x1D400 + r - 'A'
+	case r >= 'a' && r <= 'z':
+		return 0x1D41A + r - 'a'
+	case r >= '0' && r <= '9':
+		return 0x1D7CE + r - '0'
+	}
+	return r
+}
+
+var doubleStruckExceptions = map[rune]rune{'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ'}
+
+func doubleStruckLetter(r rune) rune {
+	if e, ok := doubleStruckExceptions[r]; ok {
+		return e
+	}
+	switch {
+	case r >= 'A' && r <= 'Z':
+		return 0x1D538 + r - 'A'
+	case r >= 'a' && r <= 'z':
+		return 0x1D552 + r - 'a'
+	case r >= '0' && r <= '9':
+		return 0x1D7D8 + r - '0'
+	}
+	return r
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
@@ -0,0 +1,260 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var attachmentBaseKey = parser.NewContextKey()
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.Table, extension.Strikethrough, mathExtension{}),
+	goldmark.WithParserOptions(
+		parser.WithASTTransformers(util.Prioritized(attachmentLinks{}, 100)),
+	),
+)
+
+var policy = newPolicy()
+
+// Render converts a Markdown statement with $...$ and $$...$$ LaTeX math to sanitized HTML.
+// Relative links and images point to the problem attachments under attachmentBase, e.g. "/problems/42/attachments/".
+func Render(source, attachmentBase string) (template.HTML, error) {
+	pc := parser.NewContext()
+	pc.Set(attachmentBaseKey, attachmentBase)
+
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf, parser.WithContext(pc)); err != nil {
+		return "", fmt.Errorf("could not render markdown: %w", err)
+	}
+
+	return template.HTML(policy.SanitizeBytes(buf.Bytes())), nil
+}
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	mathElements := []string{"math", "mrow", "mi", "mn", "mo", "mtext", "mspace", "msub", "msup", "msubsup",
+		"munder", "mover", "munderover", "mfrac", "msqrt", "mroot"}
+	p.AllowElements(mathElements...)
+	p.AllowNoAttrs().OnElements(mathElements...)
+	p.AllowAttrs("display").Matching(regexp.MustCompile(`^block$`)).OnElements("math")
+	p.AllowAttrs("mathvariant").Matching(regexp.MustCompile(`^normal$`)).OnElements("mi")
+	p.AllowAttrs("stretchy").Matching(regexp.MustCompile(`^true$`)).OnElements("mo")
+	p.AllowAttrs("accent").Matching(regexp.MustCompile(`^true$`)).OnElements("mover")
+	p.AllowAttrs("linethickness").Matching(regexp.MustCompile(`^0$`)).OnElements("mfrac")
+	p.AllowAttrs("width").Matching(regexp.MustCompile(`^[0-9.]+em$`)).OnElements("mspace")
+
+	return p
+}
+
+// attachmentLinks points relative link and image destinations to the problem attachments
+type attachmentLinks struct{}
+
+func (attachmentLinks) Transform(doc *ast.Document, _ text.Reader, pc parser.Context) {
+	base, _ := pc.Get(attachmentBaseKey).(string)
+	if base == "" {
+		return
+	}
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Image:
+			n.Destination = resolveAttachment(base, n.Destination)
+		case *ast.Link:
+			n.Destination = resolveAttachment(base, n.Destination)
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func resolveAttachment(base string, destination []byte) []byte {
+	u, err := url.Parse(string(destination))
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+		return destination
+	}
+	return []byte(base + url.PathEscape(strings.TrimPrefix(u.Path, "./")))
+}
+
+var (
+	kindMathInline = ast.NewNodeKind("MathInline")
+	kindMathBlock  = ast.NewNodeKind("MathBlock")
+)
+
+// mathInline is $...$, or $$...$$ inside a paragraph which is rendered as display math
+type mathInline struct {
+	ast.BaseInline
+	tex     string
+	display bool
+}
+
+func (n *mathInline) Kind() ast.NodeKind { return kindMathInline }
+
+func (n *mathInline) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+// mathBlock is display math between lines starting and ending with $$
+type mathBlock struct {
+	ast.BaseBlock
+	tex    strings.Builder
+	closed bool
+}
+
+func (n *mathBlock) Kind() ast.NodeKind { return kindMathBlock }
+
+func (n *mathBlock) IsRaw() bool { return true }
+
+func (n *mathBlock) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+type mathExtension struct{}
+
+func (mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithInlineParsers(util.Prioritized(mathInlineParser{}, 150)),
+		parser.WithBlockParsers(util.Prioritized(mathBlockParser{}, 150)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(mathRenderer{}, 150)))
+}
+
+type mathInlineParser struct{}
+
+func (mathInlineParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (mathInlineParser) Parse(_ ast.Node, block text.Reader, _ parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+
+	delimiter := 1
+	if len(line) > 1 && line[1] == '$' {
+		delimiter = 2
+	}
+	body := line[delimiter:]
+
+	end := -1
+	for i := 0; i < len(body); i++ {
+		if body[i] == '\\' {
+			i++
+			continue
+		}
+		if body[i] == '$' && (delimiter == 1 || (i+1 < len(body) && body[i+1] == '$')) {
+			end = i
+			break
+		}
+	}
+	if end <= 0 {
+		return nil
+	}
+
+	// like pandoc, "$5 and $6" is not math
+	if delimiter == 1 && (isSpace(body[0]) || isSpace(body[end-1]) || (end+1 < len(body) && isDigitByte(body[end+1]))) {
+		return nil
+	}
+
+	block.Advance(delimiter + end + delimiter)
+	return &mathInline{tex: string(body[:end]), display: delimiter == 2}
+}
+
+var dollars = []byte("$$")
+
+type mathBlockParser struct{}
+
+func (mathBlockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (mathBlockParser) Open(_ ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], dollars) {
+		return nil, parser.NoChildren
+	}
+
+	node := &mathBlock{}
+	rest := bytes.TrimSpace(line[pos+len(dollars):])
+	if bytes.HasSuffix(rest, dollars) {
+		node.tex.Write(rest[:len(rest)-len(dollars)])
+		node.closed = true
+	} else {
+		node.tex.Write(rest)
+		node.tex.WriteByte('\n')
+	}
+
+	reader.Advance(segment.Len() - 1)
+	return node, parser.NoChildren
+}
+
+func (mathBlockParser) Continue(node ast.Node, reader text.Reader, _ parser.Context) parser.State {
+	n := node.(*mathBlock)
+	if n.closed {
+		return parser.Close
+	}
+
+	line, segment := reader.PeekLine()
+	if line == nil {
+		return parser.Close
+	}
+
+	trimmed := bytes.TrimSpace(line)
+	reader.Advance(segment.Len() - 1)
+	if bytes.HasSuffix(trimmed, dollars) {
+		n.tex.Write(trimmed[:len(trimmed)-len(dollars)])
+		n.closed = true
+		return parser.Continue | parser.NoChildren
+	}
+
+	n.tex.Write(trimmed)
+	n.tex.WriteByte('\n')
+	return parser.Continue | parser.NoChildren
+}
+
+func (mathBlockParser) Close(ast.Node, text.Reader, parser.Context) {}
+
+func (mathBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (mathBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+type mathRenderer struct{}
+
+func (mathRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMathInline, func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			node := n.(*mathInline)
+			_, _ = w.WriteString(LaTeXToMathML(node.tex, node.display))
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	reg.Register(kindMathBlock, func(w util.BufWriter, _ []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			_, _ = w.WriteString(LaTeXToMathML(n.(*mathBlock).tex.String(), true))
+			_ = w.WriteByte('\n')
+		}
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n'
+}
+
+func isDigitByte(b byte) bool {
+	return b >= '0' && b <= '9'
+}
@@ -0,0 +1,88 @@
+package statement
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type StatementTestSuite struct {
+	suite.Suite
+}
+
+func (s *StatementTestSuite) render(source string) string {
+	html, err := Render(source, "/problems/1/attachments/")
+	require.NoError(s.T(), err)
+	return string(html)
+}
+
+func (s *StatementTestSuite) TestMarkdown() {
+	html := s.render("Print **one** line.\n\n| n | answer |\n|---|---|\n| 1 | 2 |")
+
+	assert.Contains(s.T(), html, "<strong>one</strong>")
+	assert.Contains(s.T(), html, "<table>")
+}
+
+func (s *StatementTestSuite) TestSanitize() {
+	html := s.render(`Hi <script>alert(1)</script> <img src=x onerror=alert(1)> [x](javascript:alert(1))`)
+
+	assert.NotContains(s.T(), html, "<script")
+	assert.NotContains(s.T(), html, "onerror")
+	assert.NotContains(s.T(), html, "javascript:")
+}
+
+func (s *StatementTestSuite) TestAttachmentLinks() {
+	html := s.render("![tree](tree.png) [input](<./big test.txt>) ![abs](/static/x.png) [web](https://example.com/a.png)")
+
+	assert.Contains(s.T(), html, `src="/problems/1/attachments/tree.png"`)
+	assert.Contains(s.T(), html, `href="/problems/1/attachments/big%20test.txt"`)
+	assert.Contains(s.T(), html, `src="/static/x.png"`)
+	assert.Contains(s.T(), html, `href="https://example.com/a.png"`)
+}
+
+func (s *StatementTestSuite) TestInlineMath() {
+	html := s.render(`Given $1 \le n \le 10^5$ and costs of $5 and $6.`)
+
+	assert.Contains(s.T(), html, "<math><mrow><mn>1</mn><mo>≤</mo><mi>n</mi><mo>≤</mo><msup><mn>10</mn><mn>5</mn></msup></mrow></math>")
+	assert.Contains(s.T(), html, "costs of $5 and $6.")
+}
+
+func (s *StatementTestSuite) TestBlockMath() {
+	html := s.render("Answer:\n\n$$\n\\sum_{i=1}^{n} \\frac{a_i}{2}\n$$\n\nDone.")
+
+	assert.Contains(s.T(), html, `<math display="block">`)
+	assert.Contains(s.T(), html, "<munderover><mo>∑</mo>")
+	assert.Contains(s.T(), html, "<mfrac><msub><mi>a</mi><mi>i</mi></msub><mn>2</mn></mfrac>")
+	assert.Contains(s.T(), html, "<p>Done.</p>")
+}
+
+func (s *StatementTestSuite) TestLaTeXToMathML() {
+	testCases := []struct {
+		tex      string
+		expected string
+	}{
+		{`x^2`, `<math><msup><mi>x</mi><mn>2</mn></msup></math>`},
+		{`a_{i,j}`, `<math><msub><mi>a</mi><mrow><mi>i</mi><mo>,</mo><mi>j</mi></mrow></msub></math>`},
+		{`\sqrt{n}`, `<math><msqrt><mi>n</mi></msqrt></math>`},
+		{`\sqrt[3]{n}`, `<math><mroot><mi>n</mi><mn>3</mn></mroot></math>`},
+		{`\left\lfloor \frac{n}{2} \right\rfloor`,
+			`<math><mrow><mo stretchy="true">⌊</mo><mfrac><mi>n</mi><mn>2</mn></mfrac><mo stretchy="true">⌋</mo></mrow></math>`},
+		{`\text{if } x < 0`, `<math><mrow><mtext>if </mtext><mi>x</mi><mo>&lt;</mo><mn>0</mn></mrow></math>`},
+		{`\mathbb{Z}`, `<math><mi>ℤ</mi></math>`},
+		{`\Delta - 1`, `<math><mrow><mi mathvariant="normal">Δ</mi><mo>−</mo><mn>1</mn></mrow></math>`},
+		{`\unknown`, `<math><mtext>\unknown</mtext></math>`},
+		{`}{x^`, `<math><msup><mi>x</mi><mrow></mrow></msup></math>`},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.tex, func() {
+			assert.Equal(s.T(), tc.expected, LaTeXToMathML(tc.tex, false))
+		})
+	}
+}
+
+func TestStatementTestSuite(t *testing.T) {
+	suite.Run(t, new(StatementTestSuite))
+}
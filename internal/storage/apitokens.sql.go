@@ -0,0 +1,123 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: apitokens.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createPersonalAccessToken = `-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at
+`
+
+type CreatePersonalAccessTokenParams struct {
+	UserID    pgtype.UUID        `db:"user_id" json:"user_id"`
+	Name      string             `db:"name" json:"name"`
+	TokenHash []byte             `db:"token_hash" json:"token_hash"`
+	Scopes    []string           `db:"scopes" json:"scopes"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) CreatePersonalAccessToken(ctx context.Context, db DBTX, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error) {
+	row := db.QueryRow(ctx, createPersonalAccessToken,
+		arg.UserID,
+		arg.Name,
+		arg.TokenHash,
+		arg.Scopes,
+		arg.ExpiresAt,
+	)
+	var i PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.Scopes,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const deletePersonalAccessToken = `-- name: DeletePersonalAccessToken :execrows
+DELETE
+FROM personal_access_tokens
+WHERE user_id = $1
+  AND id = $2
+`
+
+func (q *Queries) DeletePersonalAccessToken(ctx context.Context, db DBTX, userID pgtype.UUID, tokenID pgtype.UUID) (int64, error) {
+	result, err := db.Exec(ctx, deletePersonalAccessToken, userID, tokenID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listUserPersonalAccessTokens = `-- name: ListUserPersonalAccessTokens :many
+SELECT id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at
+FROM personal_access_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListUserPersonalAccessTokens(ctx context.Context, db DBTX, userID pgtype.UUID) ([]PersonalAccessToken, error) {
+	rows, err := db.Query(ctx, listUserPersonalAccessTokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PersonalAccessToken
+	for rows.Next() {
+		var i PersonalAccessToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.TokenHash,
+			&i.Scopes,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const usePersonalAccessToken = `-- name: UsePersonalAccessToken :one
+UPDATE personal_access_tokens
+SET last_used_at = NOW()
+WHERE token_hash = $1
+  AND expires_at > NOW()
+RETURNING id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at
+`
+
+func (q *Queries) UsePersonalAccessToken(ctx context.Context, db DBTX, tokenHash []byte) (PersonalAccessToken, error) {
+	row := db.QueryRow(ctx, usePersonalAccessToken, tokenHash)
+	var i PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.Scopes,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
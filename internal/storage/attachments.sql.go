@@ -0,0 +1,135 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: attachments.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countProblemAttachments = `-- name: CountProblemAttachments :one
+SELECT COUNT(*)
+FROM problem_attachments
+WHERE problem_id = $1
+`
+
+func (q *Queries) CountProblemAttachments(ctx context.Context, db DBTX, problemID int32) (int64, error) {
+	row := db.QueryRow(ctx, countProblemAttachments, problemID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteProblemAttachment = `-- name: DeleteProblemAttachment :one
+DELETE FROM problem_attachments
+WHERE problem_id = $1 AND name = $2
+RETURNING name, content_type, OCTET_LENGTH(data) AS size
+`
+
+type DeleteProblemAttachmentRow struct {
+	Name        string `db:"name" json:"name"`
+	ContentType string `db:"content_type" json:"content_type"`
+	Size        int32  `db:"size" json:"size"`
+}
+
+func (q *Queries) DeleteProblemAttachment(ctx context.Context, db DBTX, problemID int32, name string) (DeleteProblemAttachmentRow, error) {
+	row := db.QueryRow(ctx, deleteProblemAttachment, problemID, name)
+	var i DeleteProblemAttachmentRow
+	err := row.Scan(&i.Name, &i.ContentType, &i.Size)
+	return i, err
+}
+
+const getProblemAttachment = `-- name: GetProblemAttachment :one
+SELECT id, problem_id, name, content_type, data, uploaded_by, created_at
+FROM problem_attachments
+WHERE problem_id = $1 AND name = $2
+`
+
+func (q *Queries) GetProblemAttachment(ctx context.Context, db DBTX, problemID int32, name string) (ProblemAttachment, error) {
+	row := db.QueryRow(ctx, getProblemAttachment, problemID, name)
+	var i ProblemAttachment
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Name,
+		&i.ContentType,
+		&i.Data,
+		&i.UploadedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProblemAttachments = `-- name: GetProblemAttachments :many
+SELECT id, name, content_type, octet_length(data)::BIGINT AS size, created_at
+FROM problem_attachments
+WHERE problem_id = $1
+ORDER BY name
+`
+
+type GetProblemAttachmentsRow struct {
+	ID          int32              `db:"id" json:"id"`
+	Name        string             `db:"name" json:"name"`
+	ContentType string             `db:"content_type" json:"content_type"`
+	Size        int64              `db:"size" json:"size"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) GetProblemAttachments(ctx context.Context, db DBTX, problemID int32) ([]GetProblemAttachmentsRow, error) {
+	rows, err := db.Query(ctx, getProblemAttachments, problemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetProblemAttachmentsRow
+	for rows.Next() {
+		var i GetProblemAttachmentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ContentType,
+			&i.Size,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertProblemAttachment = `-- name: UpsertProblemAttachment :exec
+INSERT INTO problem_attachments (problem_id, name, content_type, data, uploaded_by)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (problem_id, name) DO UPDATE
+SET content_type = excluded.content_type,
+    data = excluded.data,
+    uploaded_by = excluded.uploaded_by,
+    created_at = now()
+`
+
+type UpsertProblemAttachmentParams struct {
+	ProblemID   int32       `db:"problem_id" json:"problem_id"`
+	Name        string      `db:"name" json:"name"`
+	ContentType string      `db:"content_type" json:"content_type"`
+	Data        []byte      `db:"data" json:"data"`
+	UploadedBy  pgtype.UUID `db:"uploaded_by" json:"uploaded_by"`
+}
+
+func (q *Queries) UpsertProblemAttachment(ctx context.Context, db DBTX, arg UpsertProblemAttachmentParams) error {
+	_, err := db.Exec(ctx, upsertProblemAttachment,
+		arg.ProblemID,
+		arg.Name,
+		arg.ContentType,
+		arg.Data,
+		arg.UploadedBy,
+	)
+	return err
+}
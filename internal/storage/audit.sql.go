@@ -0,0 +1,107 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: audit.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createAuditEvent = `-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (actor_id, actor_name, action, target_type, target_id, before, after, request_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type CreateAuditEventParams struct {
+	ActorID    pgtype.UUID `db:"actor_id" json:"actor_id"`
+	ActorName  string      `db:"actor_name" json:"actor_name"`
+	Action     string      `db:"action" json:"action"`
+	TargetType string      `db:"target_type" json:"target_type"`
+	TargetID   string      `db:"target_id" json:"target_id"`
+	Before     []byte      `db:"before" json:"before"`
+	After      []byte      `db:"after" json:"after"`
+	RequestID  string      `db:"request_id" json:"request_id"`
+}
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, db DBTX, arg CreateAuditEventParams) error {
+	_, err := db.Exec(ctx, createAuditEvent,
+		arg.ActorID,
+		arg.ActorName,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Before,
+		arg.After,
+		arg.RequestID,
+	)
+	return err
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, created_at, actor_id, actor_name, action, target_type, target_id, before, after, request_id
+FROM audit_events
+WHERE ($1::TEXT IS NULL OR actor_name = $1)
+  AND ($2::TEXT IS NULL OR action = $2 OR starts_with(action, $2 || '.'))
+  AND ($3::TEXT IS NULL OR target_type = $3)
+  AND ($4::TEXT IS NULL OR target_id = $4)
+  AND ($5::TIMESTAMPTZ IS NULL OR created_at >= $5)
+  AND ($6::TIMESTAMPTZ IS NULL OR created_at < $6)
+  AND ($7::BIGINT IS NULL OR id < $7)
+ORDER BY id DESC
+LIMIT $8
+`
+
+type ListAuditEventsParams struct {
+	Actor      pgtype.Text        `db:"actor" json:"actor"`
+	Action     pgtype.Text        `db:"action" json:"action"`
+	TargetType pgtype.Text        `db:"target_type" json:"target_type"`
+	TargetID   pgtype.Text        `db:"target_id" json:"target_id"`
+	Since      pgtype.Timestamptz `db:"since" json:"since"`
+	Until      pgtype.Timestamptz `db:"until" json:"until"`
+	BeforeID   pgtype.Int8        `db:"before_id" json:"before_id"`
+	MaxEvents  int32              `db:"max_events" json:"max_events"`
+}
+
+func (q *Queries) ListAuditEvents(ctx context.Context, db DBTX, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := db.Query(ctx, listAuditEvents,
+		arg.Actor,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Since,
+		arg.Until,
+		arg.BeforeID,
+		arg.MaxEvents,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.ActorID,
+			&i.ActorName,
+			&i.Action,
+			&i.TargetType,
+			&i.TargetID,
+			&i.Before,
+			&i.After,
+			&i.RequestID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
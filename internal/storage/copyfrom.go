@@ -0,0 +1,49 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: copyfrom.go
+
+package storage
+
+import (
+	"context"
+)
+
+// iteratorForInsertProblemRevisionTests implements pgx.CopyFromSource.
+type iteratorForInsertProblemRevisionTests struct {
+	rows                 []InsertProblemRevisionTestsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForInsertProblemRevisionTests) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForInsertProblemRevisionTests) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].RevisionID,
+		r.rows[0].Position,
+		r.rows[0].Input,
+		r.rows[0].Output,
+		r.rows[0].GeneratorID,
+		r.rows[0].GeneratorArgs,
+		r.rows[0].IsSample,
+		r.rows[0].Explanation,
+	}, nil
+}
+
+func (r iteratorForInsertProblemRevisionTests) Err() error {
+	return nil
+}
+
+func (q *Queries) InsertProblemRevisionTests(ctx context.Context, db DBTX, arg []InsertProblemRevisionTestsParams) (int64, error) {
+	return db.CopyFrom(ctx, []string{"problem_revision_tests"}, []string{"revision_id", "position", "input", "output", "generator_id", "generator_args", "is_sample", "explanation"}, &iteratorForInsertProblemRevisionTests{rows: arg})
+}
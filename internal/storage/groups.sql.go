@@ -0,0 +1,536 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: groups.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const addAssignmentProblem = `-- name: AddAssignmentProblem :exec
+INSERT INTO assignment_problems (assignment_id, problem_id, position)
+VALUES ($1, $2, $3)
+`
+
+type AddAssignmentProblemParams struct {
+	AssignmentID int32 `db:"assignment_id" json:"assignment_id"`
+	ProblemID    int32 `db:"problem_id" json:"problem_id"`
+	Position     int32 `db:"position" json:"position"`
+}
+
+func (q *Queries) AddAssignmentProblem(ctx context.Context, db DBTX, arg AddAssignmentProblemParams) error {
+	_, err := db.Exec(ctx, addAssignmentProblem, arg.AssignmentID, arg.ProblemID, arg.Position)
+	return err
+}
+
+const addGroupMember = `-- name: AddGroupMember :exec
+INSERT INTO group_members (group_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING
+`
+
+type AddGroupMemberParams struct {
+	GroupID int32       `db:"group_id" json:"group_id"`
+	UserID  pgtype.UUID `db:"user_id" json:"user_id"`
+	Role    GroupRole   `db:"role" json:"role"`
+}
+
+func (q *Queries) AddGroupMember(ctx context.Context, db DBTX, arg AddGroupMemberParams) error {
+	_, err := db.Exec(ctx, addGroupMember, arg.GroupID, arg.UserID, arg.Role)
+	return err
+}
+
+const countGroupOwners = `-- name: CountGroupOwners :one
+SELECT COUNT(*)
+FROM group_members
+WHERE group_id = $1
+  AND role = 'OWNER'
+`
+
+func (q *Queries) CountGroupOwners(ctx context.Context, db DBTX, groupID int32) (int64, error) {
+	row := db.QueryRow(ctx, countGroupOwners, groupID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAssignment = `-- name: CreateAssignment :one
+INSERT INTO assignments (group_id, title, opens_at, closes_at, late_until, late_penalty_percent)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, group_id, title, opens_at, closes_at, late_until, late_penalty_percent, created_at
+`
+
+type CreateAssignmentParams struct {
+	GroupID            int32              `db:"group_id" json:"group_id"`
+	Title              string             `db:"title" json:"title"`
+	OpensAt            pgtype.Timestamptz `db:"opens_at" json:"opens_at"`
+	ClosesAt           pgtype.Timestamptz `db:"closes_at" json:"closes_at"`
+	LateUntil          pgtype.Timestamptz `db:"late_until" json:"late_until"`
+	LatePenaltyPercent int32              `db:"late_penalty_percent" json:"late_penalty_percent"`
+}
+
+func (q *Queries) CreateAssignment(ctx context.Context, db DBTX, arg CreateAssignmentParams) (Assignment, error) {
+	row := db.QueryRow(ctx, createAssignment,
+		arg.GroupID,
+		arg.Title,
+		arg.OpensAt,
+		arg.ClosesAt,
+		arg.LateUntil,
+		arg.LatePenaltyPercent,
+	)
+	var i Assignment
+	err := row.Scan(
+		&i.ID,
+		&i.GroupID,
+		&i.Title,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.LateUntil,
+		&i.LatePenaltyPercent,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createGroup = `-- name: CreateGroup :one
+INSERT INTO groups (name, description, invite_code, created_by)
+VALUES ($1, $2, $3, $4)
+RETURNING id, name, description, invite_code, created_by, created_at
+`
+
+type CreateGroupParams struct {
+	Name        string      `db:"name" json:"name"`
+	Description string      `db:"description" json:"description"`
+	InviteCode  string      `db:"invite_code" json:"invite_code"`
+	CreatedBy   pgtype.UUID `db:"created_by" json:"created_by"`
+}
+
+func (q *Queries) CreateGroup(ctx context.Context, db DBTX, arg CreateGroupParams) (Group, error) {
+	row := db.QueryRow(ctx, createGroup,
+		arg.Name,
+		arg.Description,
+		arg.InviteCode,
+		arg.CreatedBy,
+	)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.InviteCode,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteAssignment = `-- name: DeleteAssignment :execrows
+DELETE
+FROM assignments
+WHERE group_id = $1
+  AND id = $2
+`
+
+func (q *Queries) DeleteAssignment(ctx context.Context, db DBTX, groupID int32, iD int32) (int64, error) {
+	result, err := db.Exec(ctx, deleteAssignment, groupID, iD)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteAssignmentProblems = `-- name: DeleteAssignmentProblems :exec
+DELETE
+FROM assignment_problems
+WHERE assignment_id = $1
+`
+
+func (q *Queries) DeleteAssignmentProblems(ctx context.Context, db DBTX, assignmentID int32) error {
+	_, err := db.Exec(ctx, deleteAssignmentProblems, assignmentID)
+	return err
+}
+
+const getAssignment = `-- name: GetAssignment :one
+SELECT id, group_id, title, opens_at, closes_at, late_until, late_penalty_percent, created_at
+FROM assignments
+WHERE group_id = $1
+  AND id = $2
+`
+
+func (q *Queries) GetAssignment(ctx context.Context, db DBTX, groupID int32, iD int32) (Assignment, error) {
+	row := db.QueryRow(ctx, getAssignment, groupID, iD)
+	var i Assignment
+	err := row.Scan(
+		&i.ID,
+		&i.GroupID,
+		&i.Title,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.LateUntil,
+		&i.LatePenaltyPercent,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getGroup = `-- name: GetGroup :one
+SELECT id, name, description, invite_code, created_by, created_at
+FROM groups
+WHERE id = $1
+`
+
+func (q *Queries) GetGroup(ctx context.Context, db DBTX, id int32) (Group, error) {
+	row := db.QueryRow(ctx, getGroup, id)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.InviteCode,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getGroupAcceptedSubmissions = `-- name: GetGroupAcceptedSubmissions :many
+SELECT submissions.user_id, assignment_problems.assignment_id, submissions.problem_id, submissions.created_at
+FROM assignments
+         JOIN assignment_problems ON assignment_problems.assignment_id = assignments.id
+         JOIN group_members ON group_members.group_id = assignments.group_id
+         JOIN submissions ON submissions.problem_id = assignment_problems.problem_id
+    AND submissions.user_id = group_members.user_id
+WHERE assignments.group_id = $1
+  AND ($2::UUID IS NULL OR submissions.user_id = $2)
+  AND submissions.status = 'ACCEPTED'
+  AND submissions.created_at >= assignments.opens_at
+  AND submissions.created_at <= COALESCE(assignments.late_until, assignments.closes_at)
+`
+
+type GetGroupAcceptedSubmissionsRow struct {
+	UserID       pgtype.UUID        `db:"user_id" json:"user_id"`
+	AssignmentID int32              `db:"assignment_id" json:"assignment_id"`
+	ProblemID    int32              `db:"problem_id" json:"problem_id"`
+	CreatedAt    pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) GetGroupAcceptedSubmissions(ctx context.Context, db DBTX, groupID int32, userID pgtype.UUID) ([]GetGroupAcceptedSubmissionsRow, error) {
+	rows, err := db.Query(ctx, getGroupAcceptedSubmissions, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGroupAcceptedSubmissionsRow
+	for rows.Next() {
+		var i GetGroupAcceptedSubmissionsRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.AssignmentID,
+			&i.ProblemID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGroupByInviteCode = `-- name: GetGroupByInviteCode :one
+SELECT id, name, description, invite_code, created_by, created_at
+FROM groups
+WHERE invite_code = $1
+`
+
+func (q *Queries) GetGroupByInviteCode(ctx context.Context, db DBTX, inviteCode string) (Group, error) {
+	row := db.QueryRow(ctx, getGroupByInviteCode, inviteCode)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.InviteCode,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getGroupMemberRole = `-- name: GetGroupMemberRole :one
+SELECT role
+FROM group_members
+WHERE group_id = $1
+  AND user_id = $2
+`
+
+func (q *Queries) GetGroupMemberRole(ctx context.Context, db DBTX, groupID int32, userID pgtype.UUID) (GroupRole, error) {
+	row := db.QueryRow(ctx, getGroupMemberRole, groupID, userID)
+	var role GroupRole
+	err := row.Scan(&role)
+	return role, err
+}
+
+const listGroupAssignmentProblems = `-- name: ListGroupAssignmentProblems :many
+SELECT assignment_problems.assignment_id, assignment_problems.problem_id, problems.title
+FROM assignment_problems
+         JOIN assignments ON assignments.id = assignment_problems.assignment_id
+         JOIN problems ON problems.id = assignment_problems.problem_id
+WHERE assignments.group_id = $1
+ORDER BY assignments.opens_at, assignments.id, assignment_problems.position
+`
+
+type ListGroupAssignmentProblemsRow struct {
+	AssignmentID int32  `db:"assignment_id" json:"assignment_id"`
+	ProblemID    int32  `db:"problem_id" json:"problem_id"`
+	Title        string `db:"title" json:"title"`
+}
+
+func (q *Queries) ListGroupAssignmentProblems(ctx context.Context, db DBTX, groupID int32) ([]ListGroupAssignmentProblemsRow, error) {
+	rows, err := db.Query(ctx, listGroupAssignmentProblems, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListGroupAssignmentProblemsRow
+	for rows.Next() {
+		var i ListGroupAssignmentProblemsRow
+		if err := rows.Scan(&i.AssignmentID, &i.ProblemID, &i.Title); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGroupAssignments = `-- name: ListGroupAssignments :many
+SELECT id, group_id, title, opens_at, closes_at, late_until, late_penalty_percent, created_at
+FROM assignments
+WHERE group_id = $1
+ORDER BY opens_at, id
+`
+
+func (q *Queries) ListGroupAssignments(ctx context.Context, db DBTX, groupID int32) ([]Assignment, error) {
+	rows, err := db.Query(ctx, listGroupAssignments, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Assignment
+	for rows.Next() {
+		var i Assignment
+		if err := rows.Scan(
+			&i.ID,
+			&i.GroupID,
+			&i.Title,
+			&i.OpensAt,
+			&i.ClosesAt,
+			&i.LateUntil,
+			&i.LatePenaltyPercent,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGroupMembers = `-- name: ListGroupMembers :many
+SELECT users.id, users.username, group_members.role, group_members.joined_at
+FROM group_members
+         JOIN users ON users.id = group_members.user_id
+WHERE group_members.group_id = $1
+ORDER BY group_members.role, users.username
+`
+
+type ListGroupMembersRow struct {
+	ID       pgtype.UUID        `db:"id" json:"id"`
+	Username string             `db:"username" json:"username"`
+	Role     GroupRole          `db:"role" json:"role"`
+	JoinedAt pgtype.Timestamptz `db:"joined_at" json:"joined_at"`
+}
+
+func (q *Queries) ListGroupMembers(ctx context.Context, db DBTX, groupID int32) ([]ListGroupMembersRow, error) {
+	rows, err := db.Query(ctx, listGroupMembers, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListGroupMembersRow
+	for rows.Next() {
+		var i ListGroupMembersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Role,
+			&i.JoinedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserGroups = `-- name: ListUserGroups :many
+SELECT groups.id, groups.name, groups.description, groups.invite_code, groups.created_by, groups.created_at,
+       group_members.role,
+       (SELECT COUNT(*) FROM group_members AS members WHERE members.group_id = groups.id) AS member_count
+FROM groups
+         JOIN group_members ON group_members.group_id = groups.id
+WHERE group_members.user_id = $1
+ORDER BY groups.created_at DESC
+`
+
+type ListUserGroupsRow struct {
+	Group       Group     `db:"group" json:"group"`
+	Role        GroupRole `db:"role" json:"role"`
+	MemberCount int64     `db:"member_count" json:"member_count"`
+}
+
+func (q *Queries) ListUserGroups(ctx context.Context, db DBTX, userID pgtype.UUID) ([]ListUserGroupsRow, error) {
+	rows, err := db.Query(ctx, listUserGroups, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserGroupsRow
+	for rows.Next() {
+		var i ListUserGroupsRow
+		if err := rows.Scan(
+			&i.Group.ID,
+			&i.Group.Name,
+			&i.Group.Description,
+			&i.Group.InviteCode,
+			&i.Group.CreatedBy,
+			&i.Group.CreatedAt,
+			&i.Role,
+			&i.MemberCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockGroupMembers = `-- name: LockGroupMembers :exec
+SELECT pg_advisory_xact_lock(hashtextextended('group_members', $1::INT))
+`
+
+func (q *Queries) LockGroupMembers(ctx context.Context, db DBTX, groupID int32) error {
+	_, err := db.Exec(ctx, lockGroupMembers, groupID)
+	return err
+}
+
+const removeGroupMember = `-- name: RemoveGroupMember :execrows
+DELETE
+FROM group_members
+WHERE group_id = $1
+  AND user_id = $2
+`
+
+func (q *Queries) RemoveGroupMember(ctx context.Context, db DBTX, groupID int32, userID pgtype.UUID) (int64, error) {
+	result, err := db.Exec(ctx, removeGroupMember, groupID, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setGroupInviteCode = `-- name: SetGroupInviteCode :exec
+UPDATE groups
+SET invite_code = $2
+WHERE id = $1
+`
+
+func (q *Queries) SetGroupInviteCode(ctx context.Context, db DBTX, iD int32, inviteCode string) error {
+	_, err := db.Exec(ctx, setGroupInviteCode, iD, inviteCode)
+	return err
+}
+
+const setGroupMemberRole = `-- name: SetGroupMemberRole :execrows
+UPDATE group_members
+SET role = $3
+WHERE group_id = $1
+  AND user_id = $2
+`
+
+type SetGroupMemberRoleParams struct {
+	GroupID int32       `db:"group_id" json:"group_id"`
+	UserID  pgtype.UUID `db:"user_id" json:"user_id"`
+	Role    GroupRole   `db:"role" json:"role"`
+}
+
+func (q *Queries) SetGroupMemberRole(ctx context.Context, db DBTX, arg SetGroupMemberRoleParams) (int64, error) {
+	result, err := db.Exec(ctx, setGroupMemberRole, arg.GroupID, arg.UserID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateAssignment = `-- name: UpdateAssignment :one
+UPDATE assignments
+SET title                = $3,
+    opens_at             = $4,
+    closes_at            = $5,
+    late_until           = $6,
+    late_penalty_percent = $7
+WHERE group_id = $1
+  AND id = $2
+RETURNING id, group_id, title, opens_at, closes_at, late_until, late_penalty_percent, created_at
+`
+
+type UpdateAssignmentParams struct {
+	GroupID            int32              `db:"group_id" json:"group_id"`
+	ID                 int32              `db:"id" json:"id"`
+	Title              string             `db:"title" json:"title"`
+	OpensAt            pgtype.Timestamptz `db:"opens_at" json:"opens_at"`
+	ClosesAt           pgtype.Timestamptz `db:"closes_at" json:"closes_at"`
+	LateUntil          pgtype.Timestamptz `db:"late_until" json:"late_until"`
+	LatePenaltyPercent int32              `db:"late_penalty_percent" json:"late_penalty_percent"`
+}
+
+func (q *Queries) UpdateAssignment(ctx context.Context, db DBTX, arg UpdateAssignmentParams) (Assignment, error) {
+	row := db.QueryRow(ctx, updateAssignment,
+		arg.GroupID,
+		arg.ID,
+		arg.Title,
+		arg.OpensAt,
+		arg.ClosesAt,
+		arg.LateUntil,
+		arg.LatePenaltyPercent,
+	)
+	var i Assignment
+	err := row.Scan(
+		&i.ID,
+		&i.GroupID,
+		&i.Title,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.LateUntil,
+		&i.LatePenaltyPercent,
+		&i.CreatedAt,
+	)
+	return i, err
+}
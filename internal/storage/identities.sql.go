@@ -0,0 +1,125 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: identities.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createUserIdentity = `-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (provider, subject, user_id, email, last_login_at)
+VALUES ($1, $2, $3, $4, NOW())
+`
+
+type CreateUserIdentityParams struct {
+	Provider string      `db:"provider" json:"provider"`
+	Subject  string      `db:"subject" json:"subject"`
+	UserID   pgtype.UUID `db:"user_id" json:"user_id"`
+	Email    string      `db:"email" json:"email"`
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, db DBTX, arg CreateUserIdentityParams) error {
+	_, err := db.Exec(ctx, createUserIdentity,
+		arg.Provider,
+		arg.Subject,
+		arg.UserID,
+		arg.Email,
+	)
+	return err
+}
+
+const deleteUserIdentity = `-- name: DeleteUserIdentity :execrows
+DELETE
+FROM user_identities
+WHERE user_id = $1
+  AND provider = $2
+`
+
+func (q *Queries) DeleteUserIdentity(ctx context.Context, db DBTX, userID pgtype.UUID, provider string) (int64, error) {
+	result, err := db.Exec(ctx, deleteUserIdentity, userID, provider)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getUserByIdentity = `-- name: GetUserByIdentity :one
+SELECT users.id, users.username, users.password_hash, users.problems_attempted, users.problems_solved, users.submission_rate_per_minute, users.submission_rate_burst
+FROM users
+         JOIN user_identities ON user_identities.user_id = users.id
+WHERE user_identities.provider = $1
+  AND user_identities.subject = $2
+`
+
+func (q *Queries) GetUserByIdentity(ctx context.Context, db DBTX, provider string, subject string) (User, error) {
+	row := db.QueryRow(ctx, getUserByIdentity, provider, subject)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.ProblemsAttempted,
+		&i.ProblemsSolved,
+		&i.SubmissionRatePerMinute,
+		&i.SubmissionRateBurst,
+	)
+	return i, err
+}
+
+const listUserIdentities = `-- name: ListUserIdentities :many
+SELECT provider, subject, user_id, email, created_at, last_login_at
+FROM user_identities
+WHERE user_id = $1
+ORDER BY provider
+`
+
+func (q *Queries) ListUserIdentities(ctx context.Context, db DBTX, userID pgtype.UUID) ([]UserIdentity, error) {
+	rows, err := db.Query(ctx, listUserIdentities, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserIdentity
+	for rows.Next() {
+		var i UserIdentity
+		if err := rows.Scan(
+			&i.Provider,
+			&i.Subject,
+			&i.UserID,
+			&i.Email,
+			&i.CreatedAt,
+			&i.LastLoginAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordIdentityLogin = `-- name: RecordIdentityLogin :exec
+UPDATE user_identities
+SET email         = $3,
+    last_login_at = NOW()
+WHERE provider = $1
+  AND subject = $2
+`
+
+type RecordIdentityLoginParams struct {
+	Provider string `db:"provider" json:"provider"`
+	Subject  string `db:"subject" json:"subject"`
+	Email    string `db:"email" json:"email"`
+}
+
+func (q *Queries) RecordIdentityLogin(ctx context.Context, db DBTX, arg RecordIdentityLoginParams) error {
+	_, err := db.Exec(ctx, recordIdentityLogin, arg.Provider, arg.Subject, arg.Email)
+	return err
+}
@@ -0,0 +1,77 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: loginfailures.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const clearLoginFailures = `-- name: ClearLoginFailures :exec
+DELETE
+FROM login_failures
+WHERE key = $1
+`
+
+func (q *Queries) ClearLoginFailures(ctx context.Context, db DBTX, key string) error {
+	_, err := db.Exec(ctx, clearLoginFailures, key)
+	return err
+}
+
+const deleteStaleLoginFailures = `-- name: DeleteStaleLoginFailures :exec
+DELETE
+FROM login_failures
+WHERE last_failure_at < $1
+  AND (locked_until IS NULL OR locked_until < NOW())
+`
+
+func (q *Queries) DeleteStaleLoginFailures(ctx context.Context, db DBTX, lastFailureAt pgtype.Timestamptz) error {
+	_, err := db.Exec(ctx, deleteStaleLoginFailures, lastFailureAt)
+	return err
+}
+
+const getLoginLockedUntil = `-- name: GetLoginLockedUntil :one
+SELECT COALESCE(MAX(locked_until), 'epoch')::TIMESTAMPTZ
+FROM login_failures
+WHERE key = ANY ($1::TEXT[])
+`
+
+func (q *Queries) GetLoginLockedUntil(ctx context.Context, db DBTX, keys []string) (pgtype.Timestamptz, error) {
+	row := db.QueryRow(ctx, getLoginLockedUntil, keys)
+	var column_1 pgtype.Timestamptz
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const lockLogin = `-- name: LockLogin :exec
+UPDATE login_failures
+SET locked_until = $1
+WHERE key = $2
+`
+
+func (q *Queries) LockLogin(ctx context.Context, db DBTX, lockedUntil pgtype.Timestamptz, key string) error {
+	_, err := db.Exec(ctx, lockLogin, lockedUntil, key)
+	return err
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_failures (key, failures)
+VALUES ($1, 1)
+ON CONFLICT (key) DO UPDATE
+    SET failures        = CASE
+                              WHEN login_failures.last_failure_at < $2 THEN 1
+                              ELSE login_failures.failures + 1 END,
+        last_failure_at = NOW()
+RETURNING failures
+`
+
+func (q *Queries) RecordLoginFailure(ctx context.Context, db DBTX, key string, windowStart pgtype.Timestamptz) (int32, error) {
+	row := db.QueryRow(ctx, recordLoginFailure, key, windowStart)
+	var failures int32
+	err := row.Scan(&failures)
+	return failures, err
+}
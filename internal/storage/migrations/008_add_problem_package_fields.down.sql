@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS problem_tags;
+
+ALTER TABLE problems DROP COLUMN checker_source;
@@ -0,0 +1,9 @@
+ALTER TABLE problems ADD COLUMN checker_source TEXT;
+
+CREATE TABLE problem_tags (
+    problem_id INTEGER NOT NULL REFERENCES problems (id) ON DELETE CASCADE,
+    tag VARCHAR(64) NOT NULL,
+    PRIMARY KEY (problem_id, tag)
+);
+
+CREATE INDEX problem_tags_tag_idx ON problem_tags (tag);
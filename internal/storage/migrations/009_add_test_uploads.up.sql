@@ -0,0 +1,11 @@
+CREATE TABLE test_uploads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    problem_id INTEGER NOT NULL REFERENCES problems (id) ON DELETE CASCADE,
+    uploaded_by UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    archive BYTEA NOT NULL,
+    input_pattern VARCHAR(128) NOT NULL,
+    output_pattern VARCHAR(128) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX test_uploads_created_at_idx ON test_uploads (created_at);
@@ -0,0 +1,11 @@
+DROP TABLE test_generations;
+DROP TYPE GENERATION_STATUS;
+
+ALTER TABLE test_cases
+    DROP COLUMN generator_id,
+    DROP COLUMN generator_args,
+    DROP COLUMN input_key,
+    DROP COLUMN output_key;
+
+DROP TABLE problem_programs;
+DROP TYPE PROGRAM_KIND;
@@ -0,0 +1,36 @@
+CREATE TYPE PROGRAM_KIND AS ENUM ('GENERATOR', 'VALIDATOR', 'SOLUTION');
+
+CREATE TABLE problem_programs (
+    id SERIAL PRIMARY KEY,
+    problem_id INTEGER NOT NULL REFERENCES problems (id) ON DELETE CASCADE,
+    kind PROGRAM_KIND NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    source TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (problem_id, name)
+);
+
+-- a problem has at most one input validator and one reference solution
+CREATE UNIQUE INDEX problem_programs_validator_idx ON problem_programs (problem_id) WHERE kind = 'VALIDATOR';
+CREATE UNIQUE INDEX problem_programs_solution_idx ON problem_programs (problem_id) WHERE kind = 'SOLUTION';
+
+-- generated tests keep the generator and its arguments, input and output hold the cached result.
+-- input_key and output_key are hashes of what produced them and are NULL until the test is generated.
+ALTER TABLE test_cases
+    ADD COLUMN generator_id INTEGER REFERENCES problem_programs (id) ON DELETE CASCADE,
+    ADD COLUMN generator_args TEXT,
+    ADD COLUMN input_key VARCHAR(64),
+    ADD COLUMN output_key VARCHAR(64);
+
+CREATE TYPE GENERATION_STATUS AS ENUM ('RUNNING', 'SUCCEEDED', 'FAILED');
+
+CREATE TABLE test_generations (
+    id SERIAL PRIMARY KEY,
+    problem_id INTEGER NOT NULL REFERENCES problems (id) ON DELETE CASCADE,
+    status GENERATION_STATUS NOT NULL DEFAULT 'RUNNING',
+    report TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX test_generations_problem_id_idx ON test_generations (problem_id, started_at DESC);
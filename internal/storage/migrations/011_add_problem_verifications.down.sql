@@ -0,0 +1,15 @@
+DROP TABLE problem_verifications;
+
+ALTER TYPE JOB_STATUS RENAME TO GENERATION_STATUS;
+
+DELETE FROM problem_programs
+WHERE kind = 'SOLUTION' AND NOT main;
+
+DROP INDEX problem_programs_main_idx;
+CREATE UNIQUE INDEX problem_programs_solution_idx ON problem_programs (problem_id) WHERE kind = 'SOLUTION';
+
+ALTER TABLE problem_programs
+    DROP CONSTRAINT problem_programs_main_check,
+    DROP CONSTRAINT problem_programs_expected_status_check,
+    DROP COLUMN main,
+    DROP COLUMN expected_status;
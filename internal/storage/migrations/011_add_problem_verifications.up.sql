@@ -0,0 +1,33 @@
+-- solutions carry the verdict they are expected to get, the main one produces outputs of generated tests
+ALTER TABLE problem_programs
+    ADD COLUMN expected_status SUBMISSION_STATUS,
+    ADD COLUMN main BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE problem_programs
+SET expected_status = 'ACCEPTED', main = TRUE
+WHERE kind = 'SOLUTION';
+
+DROP INDEX problem_programs_solution_idx;
+CREATE UNIQUE INDEX problem_programs_main_idx ON problem_programs (problem_id) WHERE main;
+
+ALTER TABLE problem_programs
+    ADD CONSTRAINT problem_programs_expected_status_check
+        CHECK ((kind = 'SOLUTION') = (expected_status IS NOT NULL)),
+    ADD CONSTRAINT problem_programs_main_check
+        CHECK (NOT main OR (kind = 'SOLUTION' AND expected_status = 'ACCEPTED'));
+
+ALTER TYPE GENERATION_STATUS RENAME TO JOB_STATUS;
+
+CREATE TABLE problem_verifications (
+    id SERIAL PRIMARY KEY,
+    problem_id INTEGER NOT NULL REFERENCES problems (id) ON DELETE CASCADE,
+    status JOB_STATUS NOT NULL DEFAULT 'RUNNING',
+    -- hash of the limits, tests and solutions that were verified
+    fingerprint VARCHAR(64) NOT NULL,
+    report TEXT NOT NULL DEFAULT '',
+    suggested_time_limit_ms BIGINT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX problem_verifications_problem_id_idx ON problem_verifications (problem_id, started_at DESC);
@@ -0,0 +1,5 @@
+ALTER TABLE submissions DROP COLUMN revision_id;
+ALTER TABLE problems DROP COLUMN current_revision_id;
+
+DROP TABLE problem_revision_tests;
+DROP TABLE problem_revisions;
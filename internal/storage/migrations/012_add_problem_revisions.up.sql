@@ -0,0 +1,34 @@
+CREATE TABLE problem_revisions (
+    id SERIAL PRIMARY KEY,
+    problem_id INTEGER NOT NULL REFERENCES problems (id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    sample_input TEXT NOT NULL,
+    sample_output TEXT NOT NULL,
+    time_limit_ms BIGINT NOT NULL,
+    memory_limit_kb BIGINT NOT NULL,
+    checker_source TEXT,
+    -- hash of everything above and the tests, used to skip revisions that change nothing
+    content_hash VARCHAR(64) NOT NULL,
+    created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (problem_id, revision)
+);
+
+-- generator_id has no foreign key on purpose, revisions outlive the generators they were built with
+CREATE TABLE problem_revision_tests (
+    revision_id INTEGER NOT NULL REFERENCES problem_revisions (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    input TEXT NOT NULL,
+    output TEXT NOT NULL,
+    generator_id INTEGER,
+    generator_args TEXT,
+    PRIMARY KEY (revision_id, position)
+);
+
+ALTER TABLE problems
+    ADD COLUMN current_revision_id INTEGER REFERENCES problem_revisions (id) ON DELETE SET NULL;
+
+ALTER TABLE submissions
+    ADD COLUMN revision_id INTEGER REFERENCES problem_revisions (id) ON DELETE SET NULL;
@@ -0,0 +1,6 @@
+DROP INDEX submissions_user_id_idx;
+DROP INDEX submissions_problem_id_idx;
+DROP INDEX problems_difficulty_idx;
+DROP INDEX problems_search_idx;
+
+ALTER TABLE problems DROP COLUMN difficulty;
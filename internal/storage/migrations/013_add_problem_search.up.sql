@@ -0,0 +1,11 @@
+ALTER TABLE problems
+    ADD COLUMN difficulty INTEGER CHECK (difficulty BETWEEN 1 AND 10);
+
+-- the expression must match the one in SearchProblems for the index to be used
+CREATE INDEX problems_search_idx ON problems
+    USING GIN (to_tsvector('simple', title || ' ' || description));
+
+CREATE INDEX problems_difficulty_idx ON problems (difficulty);
+
+CREATE INDEX submissions_problem_id_idx ON submissions (problem_id, status);
+CREATE INDEX submissions_user_id_idx ON submissions (user_id, status);
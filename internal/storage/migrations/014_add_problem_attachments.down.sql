@@ -0,0 +1 @@
+DROP TABLE problem_attachments;
@@ -0,0 +1,10 @@
+CREATE TABLE problem_attachments (
+    id SERIAL PRIMARY KEY,
+    problem_id INTEGER NOT NULL REFERENCES problems (id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    content_type VARCHAR(128) NOT NULL,
+    data BYTEA NOT NULL,
+    uploaded_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (problem_id, name)
+);
@@ -0,0 +1,62 @@
+ALTER TABLE submissions
+    DROP COLUMN feedback;
+
+-- only the first sample of each problem and revision is kept
+ALTER TABLE problem_revisions
+    ADD COLUMN sample_input TEXT NOT NULL DEFAULT '',
+    ADD COLUMN sample_output TEXT NOT NULL DEFAULT '';
+
+UPDATE problem_revisions
+SET sample_input = first_sample.input, sample_output = first_sample.output
+FROM (
+    SELECT DISTINCT ON (revision_id) revision_id, input, output
+    FROM problem_revision_tests
+    WHERE is_sample
+    ORDER BY revision_id, position
+) AS first_sample
+WHERE first_sample.revision_id = problem_revisions.id;
+
+DELETE FROM problem_revision_tests WHERE is_sample;
+
+UPDATE problem_revision_tests
+SET position = -renumbered.position
+FROM (
+    SELECT revision_id, position AS old_position,
+        row_number() OVER (PARTITION BY revision_id ORDER BY position) AS position
+    FROM problem_revision_tests
+) AS renumbered
+WHERE renumbered.revision_id = problem_revision_tests.revision_id
+    AND renumbered.old_position = problem_revision_tests.position;
+UPDATE problem_revision_tests SET position = -position;
+
+ALTER TABLE problem_revision_tests
+    DROP COLUMN is_sample,
+    DROP COLUMN explanation;
+
+ALTER TABLE problem_revisions
+    ALTER COLUMN sample_input DROP DEFAULT,
+    ALTER COLUMN sample_output DROP DEFAULT;
+
+ALTER TABLE problems
+    ADD COLUMN sample_input TEXT NOT NULL DEFAULT '',
+    ADD COLUMN sample_output TEXT NOT NULL DEFAULT '';
+
+UPDATE problems
+SET sample_input = first_sample.input, sample_output = first_sample.output
+FROM (
+    SELECT DISTINCT ON (problem_id) problem_id, input, output
+    FROM test_cases
+    WHERE is_sample
+    ORDER BY problem_id, id
+) AS first_sample
+WHERE first_sample.problem_id = problems.id;
+
+DELETE FROM test_cases WHERE is_sample;
+
+ALTER TABLE problems
+    ALTER COLUMN sample_input DROP DEFAULT,
+    ALTER COLUMN sample_output DROP DEFAULT;
+
+ALTER TABLE test_cases
+    DROP COLUMN is_sample,
+    DROP COLUMN explanation;
@@ -0,0 +1,32 @@
+ALTER TABLE test_cases
+    ADD COLUMN is_sample BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN explanation TEXT NOT NULL DEFAULT '';
+
+INSERT INTO test_cases (problem_id, input, output, is_sample)
+SELECT id, sample_input, sample_output, TRUE
+FROM problems;
+
+ALTER TABLE problems
+    DROP COLUMN sample_input,
+    DROP COLUMN sample_output;
+
+ALTER TABLE problem_revision_tests
+    ADD COLUMN is_sample BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN explanation TEXT NOT NULL DEFAULT '';
+
+-- samples come first in a revision, shifting through negative positions keeps the primary key unique
+UPDATE problem_revision_tests SET position = -position;
+UPDATE problem_revision_tests SET position = 1 - position;
+
+INSERT INTO problem_revision_tests (revision_id, position, input, output, is_sample)
+SELECT id, 1, sample_input, sample_output, TRUE
+FROM problem_revisions;
+
+-- content hashes of older revisions no longer match, the next change of each problem records a new revision
+ALTER TABLE problem_revisions
+    DROP COLUMN sample_input,
+    DROP COLUMN sample_output;
+
+-- diff of the expected and actual output when a submission fails on a sample
+ALTER TABLE submissions
+    ADD COLUMN feedback TEXT;
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP CONSTRAINT users_submission_rate_check,
+DROP COLUMN submission_rate_per_minute,
+DROP COLUMN submission_rate_burst;
@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN submission_rate_per_minute DOUBLE PRECISION,
+ADD COLUMN submission_rate_burst INT,
+ADD CONSTRAINT users_submission_rate_check CHECK (
+    (submission_rate_per_minute IS NULL) = (submission_rate_burst IS NULL)
+    AND submission_rate_per_minute >= 0
+    AND submission_rate_burst > 0
+);
@@ -0,0 +1,4 @@
+DROP INDEX submissions_user_code_hash_idx;
+DROP INDEX submissions_code_hash_idx;
+
+ALTER TABLE submissions DROP COLUMN code_hash;
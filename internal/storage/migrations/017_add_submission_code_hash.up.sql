@@ -0,0 +1,8 @@
+ALTER TABLE submissions ADD COLUMN code_hash TEXT;
+
+UPDATE submissions SET code_hash = encode(sha256(convert_to(solution_code, 'UTF8')), 'hex');
+
+ALTER TABLE submissions ALTER COLUMN code_hash SET NOT NULL;
+
+CREATE INDEX submissions_code_hash_idx ON submissions (problem_id, code_hash);
+CREATE INDEX submissions_user_code_hash_idx ON submissions (user_id, problem_id, code_hash, created_at DESC);
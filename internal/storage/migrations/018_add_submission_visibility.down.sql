@@ -0,0 +1,3 @@
+ALTER TABLE problems DROP COLUMN submission_visibility;
+
+DROP TYPE SUBMISSION_VISIBILITY;
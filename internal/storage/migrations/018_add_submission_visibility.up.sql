@@ -0,0 +1,3 @@
+CREATE TYPE SUBMISSION_VISIBILITY AS ENUM ('PRIVATE', 'STAFF');
+
+ALTER TABLE problems ADD COLUMN submission_visibility SUBMISSION_VISIBILITY NOT NULL DEFAULT 'PRIVATE';
@@ -0,0 +1,3 @@
+ALTER TABLE submissions DROP COLUMN failed_test;
+
+ALTER TABLE problems DROP COLUMN reveal_failing_test;
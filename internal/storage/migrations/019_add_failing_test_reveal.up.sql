@@ -0,0 +1,4 @@
+ALTER TABLE problems ADD COLUMN reveal_failing_test BOOLEAN NOT NULL DEFAULT false;
+
+-- position of the first failed test in the revision the submission was judged on
+ALTER TABLE submissions ADD COLUMN failed_test INT;
@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN superuser BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE users
+SET superuser = TRUE
+WHERE id IN (SELECT user_id FROM user_roles WHERE role = 'admin');
+
+DROP TABLE user_roles;
+DROP TABLE role_permissions;
+DROP TABLE permissions;
+DROP TABLE roles;
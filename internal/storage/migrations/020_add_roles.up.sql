@@ -0,0 +1,69 @@
+CREATE TABLE roles (
+    name        TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE permissions (
+    name        TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+    role       TEXT NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions (name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE user_roles (
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role       TEXT        NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role)
+);
+
+CREATE INDEX user_roles_role_idx ON user_roles (role);
+
+INSERT INTO roles (name, description)
+VALUES ('admin', 'Manages the judge, holds every permission'),
+       ('problem_setter', 'Creates problems and manages their own'),
+       ('contest_manager', 'Organizes contests'),
+       ('teaching_assistant', 'Reviews the submissions of students'),
+       ('contestant', 'Solves problems, given to every new user');
+
+INSERT INTO permissions (name, description)
+VALUES ('submit', 'Submit solutions and run code against custom input'),
+       ('create_problems', 'Create problems'),
+       ('manage_problems', 'Edit and publish every problem'),
+       ('view_submissions', 'View the submissions of other users to problems that share them with staff'),
+       ('manage_contests', 'Create and run contests'),
+       ('manage_users', 'Grant roles, change permissions and set rate limits'),
+       ('unlimited_submissions', 'Submit without rate limits');
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name
+FROM permissions;
+
+INSERT INTO role_permissions (role, permission)
+VALUES ('problem_setter', 'submit'),
+       ('problem_setter', 'create_problems'),
+       ('contest_manager', 'submit'),
+       ('contest_manager', 'manage_contests'),
+       ('teaching_assistant', 'submit'),
+       ('teaching_assistant', 'view_submissions'),
+       ('contestant', 'submit');
+
+-- keep what everyone could do before roles existed
+INSERT INTO user_roles (user_id, role)
+SELECT id, 'contestant'
+FROM users;
+
+INSERT INTO user_roles (user_id, role)
+SELECT id, 'admin'
+FROM users
+WHERE superuser;
+
+INSERT INTO user_roles (user_id, role)
+SELECT DISTINCT created_by, 'problem_setter'
+FROM problems;
+
+ALTER TABLE users DROP COLUMN superuser;
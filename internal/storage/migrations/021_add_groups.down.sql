@@ -0,0 +1,9 @@
+DELETE
+FROM permissions
+WHERE name = 'create_groups';
+
+DROP TABLE assignment_problems;
+DROP TABLE assignments;
+DROP TABLE group_members;
+DROP TYPE GROUP_ROLE;
+DROP TABLE groups;
@@ -0,0 +1,52 @@
+CREATE TABLE groups (
+    id          SERIAL PRIMARY KEY,
+    name        TEXT        NOT NULL,
+    description TEXT        NOT NULL DEFAULT '',
+    invite_code TEXT        NOT NULL UNIQUE,
+    created_by  UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TYPE GROUP_ROLE AS ENUM ('OWNER', 'MEMBER');
+
+CREATE TABLE group_members (
+    group_id  INT         NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    user_id   UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role      GROUP_ROLE  NOT NULL DEFAULT 'MEMBER',
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX group_members_user_idx ON group_members (user_id);
+
+-- an accepted submission between opens_at and closes_at earns full score, after closes_at it loses
+-- late_penalty_percent for every started day until late_until, later submissions do not count
+CREATE TABLE assignments (
+    id                   SERIAL PRIMARY KEY,
+    group_id             INT         NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    title                TEXT        NOT NULL,
+    opens_at             TIMESTAMPTZ NOT NULL,
+    closes_at            TIMESTAMPTZ NOT NULL,
+    late_until           TIMESTAMPTZ,
+    late_penalty_percent INT         NOT NULL DEFAULT 0,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT assignments_window_check CHECK (closes_at > opens_at AND (late_until IS NULL OR late_until > closes_at)),
+    CONSTRAINT assignments_late_penalty_check CHECK (late_penalty_percent BETWEEN 0 AND 100)
+);
+
+CREATE INDEX assignments_group_idx ON assignments (group_id);
+
+CREATE TABLE assignment_problems (
+    assignment_id INT NOT NULL REFERENCES assignments (id) ON DELETE CASCADE,
+    problem_id    INT NOT NULL REFERENCES problems (id) ON DELETE CASCADE,
+    position      INT NOT NULL,
+    PRIMARY KEY (assignment_id, problem_id)
+);
+
+INSERT INTO permissions (name, description)
+VALUES ('create_groups', 'Create groups and assign problems to their members');
+
+INSERT INTO role_permissions (role, permission)
+VALUES ('admin', 'create_groups'),
+       ('problem_setter', 'create_groups'),
+       ('teaching_assistant', 'create_groups');
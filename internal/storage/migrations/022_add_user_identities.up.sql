@@ -0,0 +1,12 @@
+-- accounts of external login providers linked to users, subject is the id of the account at the provider
+CREATE TABLE user_identities (
+    provider      TEXT        NOT NULL,
+    subject       TEXT        NOT NULL,
+    user_id       UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    email         TEXT        NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMPTZ,
+    PRIMARY KEY (provider, subject),
+    -- a user links at most one account of each provider
+    UNIQUE (user_id, provider)
+);
@@ -0,0 +1,13 @@
+-- a session is a login, its id is the sid claim every token issued for that login carries
+CREATE TABLE sessions (
+    id           UUID PRIMARY KEY,
+    user_id      UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    user_agent   TEXT        NOT NULL DEFAULT '',
+    ip_address   TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMPTZ NOT NULL,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX sessions_user_idx ON sessions (user_id, expires_at);
@@ -0,0 +1,9 @@
+-- refresh tokens of a session are its token family, each is used once and replaced by the next
+CREATE TABLE refresh_tokens (
+    token_hash BYTEA PRIMARY KEY,
+    session_id UUID        NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX refresh_tokens_session_idx ON refresh_tokens (session_id);
@@ -0,0 +1,12 @@
+-- personal access tokens let scripts act as their user within the scopes of the token
+CREATE TABLE personal_access_tokens (
+    id           UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         TEXT        NOT NULL,
+    token_hash   BYTEA       NOT NULL UNIQUE,
+    scopes       TEXT[]      NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    UNIQUE (user_id, name)
+);
@@ -0,0 +1,10 @@
+-- failed logins by account ("account:<username>") and by client ip ("ip:<address>"), accounts are named by the
+-- username tried so unknown usernames are locked like existing ones
+CREATE TABLE login_failures (
+    key             TEXT PRIMARY KEY,
+    failures        INTEGER     NOT NULL,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until    TIMESTAMPTZ
+);
+
+CREATE INDEX login_failures_last_failure_idx ON login_failures (last_failure_at);
@@ -0,0 +1,11 @@
+-- one-time password reset links issued by admins, only the hash of the token in the link is stored
+CREATE TABLE password_resets (
+    token_hash BYTEA PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_by UUID        REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX password_resets_user_idx ON password_resets (user_id);
@@ -0,0 +1,5 @@
+ALTER TABLE roles DROP COLUMN require_two_factor;
+
+DROP TABLE two_factor_challenges;
+DROP TABLE recovery_codes;
+DROP TABLE user_totp;
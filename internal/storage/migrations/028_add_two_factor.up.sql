@@ -0,0 +1,27 @@
+-- the TOTP secret of a user, enabled_at is NULL while the user is still setting it up.
+-- last_used_step is the time step of the last accepted code, a code is accepted once.
+CREATE TABLE user_totp (
+    user_id        UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret         TEXT        NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    enabled_at     TIMESTAMPTZ,
+    last_used_step BIGINT      NOT NULL DEFAULT 0
+);
+
+-- one-time codes for users who lost their authenticator, only their hashes are stored
+CREATE TABLE recovery_codes (
+    user_id   UUID  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash BYTEA NOT NULL,
+    used_at   TIMESTAMPTZ,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+-- logins that passed the password and wait for the second factor
+CREATE TABLE two_factor_challenges (
+    token_hash BYTEA PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- the permissions of a role requiring two-factor authentication only apply to users who enabled it
+ALTER TABLE roles ADD COLUMN require_two_factor BOOLEAN NOT NULL DEFAULT FALSE;
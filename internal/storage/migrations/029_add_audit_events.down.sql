@@ -0,0 +1,6 @@
+DELETE
+FROM permissions
+WHERE name = 'view_audit_log';
+
+DROP TABLE audit_events;
+DROP FUNCTION reject_audit_event_change;
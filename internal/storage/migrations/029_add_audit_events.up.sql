@@ -0,0 +1,43 @@
+-- who did what to which object, rows are never changed or deleted.
+-- actor_id has no foreign key so deleting a user keeps their events, actor_name is their username at the time.
+CREATE TABLE audit_events (
+    id          BIGSERIAL PRIMARY KEY,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor_id    UUID,
+    actor_name  TEXT        NOT NULL,
+    action      TEXT        NOT NULL,
+    target_type TEXT        NOT NULL,
+    target_id   TEXT        NOT NULL,
+    before      JSONB,
+    after       JSONB,
+    request_id  TEXT        NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_events_actor_name_idx ON audit_events (actor_name, id);
+CREATE INDEX audit_events_target_idx ON audit_events (target_type, target_id, id);
+CREATE INDEX audit_events_action_idx ON audit_events (action, id);
+
+CREATE FUNCTION reject_audit_event_change() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'audit events are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE
+    ON audit_events
+    FOR EACH ROW
+EXECUTE FUNCTION reject_audit_event_change();
+
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE
+    ON audit_events
+    FOR EACH STATEMENT
+EXECUTE FUNCTION reject_audit_event_change();
+
+INSERT INTO permissions (name, description)
+VALUES ('view_audit_log', 'View and export the audit log');
+
+INSERT INTO role_permissions (role, permission)
+VALUES ('admin', 'view_audit_log');
@@ -0,0 +1,4 @@
+ALTER TABLE test_cases
+    DROP CONSTRAINT test_cases_generator_id_fkey,
+    ADD CONSTRAINT test_cases_generator_id_fkey
+        FOREIGN KEY (generator_id) REFERENCES problem_programs (id) ON DELETE CASCADE;
@@ -0,0 +1,6 @@
+-- deleting a generator used to silently delete the judged tests it produced,
+-- now its generated tests have to be deleted first
+ALTER TABLE test_cases
+    DROP CONSTRAINT test_cases_generator_id_fkey,
+    ADD CONSTRAINT test_cases_generator_id_fkey
+        FOREIGN KEY (generator_id) REFERENCES problem_programs (id) ON DELETE RESTRICT;
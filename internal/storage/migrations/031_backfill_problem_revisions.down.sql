@@ -0,0 +1,2 @@
+-- backfilled revisions cannot be told apart from recorded ones, they are kept like every other revision
+SELECT 1;
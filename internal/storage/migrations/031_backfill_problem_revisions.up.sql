@@ -0,0 +1,67 @@
+-- problems that were not changed since revisions were added have no revision yet, so their submissions record none.
+-- Their current content becomes their first revision and the submissions without a revision are tied to it.
+
+-- content_hash_field and content_hash_part build the same bytes as revisions.ContentHash, so snapshotting
+-- unchanged content later keeps the backfilled revision
+CREATE FUNCTION content_hash_field(field TEXT) RETURNS BYTEA AS $$
+    SELECT convert_to(field, 'UTF8') || '\x00'::BYTEA
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE FUNCTION content_hash_part(part TEXT) RETURNS BYTEA AS $$
+    SELECT content_hash_field(octet_length(part)::TEXT) || content_hash_field(part)
+$$ LANGUAGE SQL IMMUTABLE;
+
+WITH backfilled AS (
+    INSERT INTO problem_revisions (problem_id, revision, title, description, time_limit_ms, memory_limit_kb,
+        checker_source, content_hash, created_by, created_at)
+    SELECT problems.id,
+        (SELECT COALESCE(MAX(revision), 0) + 1 FROM problem_revisions WHERE problem_id = problems.id),
+        problems.title, problems.description, problems.time_limit_ms, problems.memory_limit_kb,
+        problems.checker_source,
+        encode(sha256(
+            content_hash_part(problems.title) ||
+            content_hash_part(problems.description) ||
+            content_hash_part(COALESCE(problems.checker_source, '')) ||
+            content_hash_field(problems.time_limit_ms::TEXT) ||
+            content_hash_field(problems.memory_limit_kb::TEXT) ||
+            content_hash_field((problems.checker_source IS NOT NULL)::TEXT) ||
+            COALESCE((
+                SELECT string_agg(
+                    content_hash_field('test') ||
+                    content_hash_part(test_cases.input) ||
+                    content_hash_part(test_cases.output) ||
+                    content_hash_field(COALESCE(test_cases.generator_id, 0)::TEXT) ||
+                    content_hash_field(COALESCE(test_cases.generator_args, '')) ||
+                    content_hash_field(test_cases.is_sample::TEXT) ||
+                    content_hash_part(test_cases.explanation),
+                    ''::BYTEA ORDER BY test_cases.is_sample DESC, test_cases.id)
+                FROM test_cases
+                WHERE test_cases.problem_id = problems.id
+            ), ''::BYTEA)
+        ), 'hex'),
+        problems.created_by, problems.created_at
+    FROM problems
+    WHERE problems.current_revision_id IS NULL
+    RETURNING id, problem_id
+), backfilled_tests AS (
+    INSERT INTO problem_revision_tests (revision_id, position, input, output, generator_id, generator_args, is_sample,
+        explanation)
+    SELECT backfilled.id,
+        row_number() OVER (PARTITION BY backfilled.id ORDER BY test_cases.is_sample DESC, test_cases.id),
+        test_cases.input, test_cases.output, test_cases.generator_id, test_cases.generator_args,
+        test_cases.is_sample, test_cases.explanation
+    FROM backfilled
+    JOIN test_cases ON test_cases.problem_id = backfilled.problem_id
+), tied_submissions AS (
+    UPDATE submissions
+    SET revision_id = backfilled.id
+    FROM backfilled
+    WHERE submissions.problem_id = backfilled.problem_id AND submissions.revision_id IS NULL
+)
+UPDATE problems
+SET current_revision_id = backfilled.id
+FROM backfilled
+WHERE problems.id = backfilled.problem_id;
+
+DROP FUNCTION content_hash_part(TEXT);
+DROP FUNCTION content_hash_field(TEXT);
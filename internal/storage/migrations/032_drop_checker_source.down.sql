@@ -0,0 +1,37 @@
+ALTER TABLE problems ADD COLUMN checker_source TEXT;
+ALTER TABLE problem_revisions ADD COLUMN checker_source TEXT;
+
+-- revisions are hashed again with the missing checker, the way revisions.ContentHash did before it was dropped
+CREATE FUNCTION content_hash_field(field TEXT) RETURNS BYTEA AS $$
+    SELECT convert_to(field, 'UTF8') || '\x00'::BYTEA
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE FUNCTION content_hash_part(part TEXT) RETURNS BYTEA AS $$
+    SELECT content_hash_field(octet_length(part)::TEXT) || content_hash_field(part)
+$$ LANGUAGE SQL IMMUTABLE;
+
+UPDATE problem_revisions
+SET content_hash = encode(sha256(
+    content_hash_part(problem_revisions.title) ||
+    content_hash_part(problem_revisions.description) ||
+    content_hash_part('') ||
+    content_hash_field(problem_revisions.time_limit_ms::TEXT) ||
+    content_hash_field(problem_revisions.memory_limit_kb::TEXT) ||
+    content_hash_field(FALSE::TEXT) ||
+    COALESCE((
+        SELECT string_agg(
+            content_hash_field('test') ||
+            content_hash_part(problem_revision_tests.input) ||
+            content_hash_part(problem_revision_tests.output) ||
+            content_hash_field(COALESCE(problem_revision_tests.generator_id, 0)::TEXT) ||
+            content_hash_field(COALESCE(problem_revision_tests.generator_args, '')) ||
+            content_hash_field(problem_revision_tests.is_sample::TEXT) ||
+            content_hash_part(problem_revision_tests.explanation),
+            ''::BYTEA ORDER BY problem_revision_tests.position)
+        FROM problem_revision_tests
+        WHERE problem_revision_tests.revision_id = problem_revisions.id
+    ), ''::BYTEA)
+), 'hex');
+
+DROP FUNCTION content_hash_part(TEXT);
+DROP FUNCTION content_hash_field(TEXT);
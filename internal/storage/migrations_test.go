@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratepgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/httpfs"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// testDatabaseURLEnv names a Postgres database the migration tests may create schemas in, they are skipped without it
+const testDatabaseURLEnv = "GO_JUDGE_TEST_DATABASE_URL"
+
+type MigrationsTestSuite struct {
+	suite.Suite
+	conn    *pgx.Conn
+	schema  string
+	migrate *migrate.Migrate
+}
+
+func (s *MigrationsTestSuite) SetupTest() {
+	databaseURL := os.Getenv(testDatabaseURLEnv)
+	if databaseURL == "" {
+		s.T().Skipf("%s is not set", testDatabaseURLEnv)
+	}
+	ctx := context.Background()
+
+	// every test migrates its own schema, so tests never see the tables of another
+	s.schema = fmt.Sprintf("migrations_test_%d", time.Now().UnixNano())
+	parsed, err := url.Parse(databaseURL)
+	require.NoError(s.T(), err)
+	query := parsed.Query()
+	query.Set("search_path", s.schema)
+	parsed.RawQuery = query.Encode()
+
+	s.conn, err = pgx.Connect(ctx, parsed.String())
+	require.NoError(s.T(), err)
+	_, err = s.conn.Exec(ctx, "CREATE SCHEMA "+s.schema)
+	require.NoError(s.T(), err)
+
+	srcDriver, err := httpfs.New(http.FS(Migrations), "migrations")
+	require.NoError(s.T(), err)
+	dbDriver, err := (&migratepgx.Postgres{}).Open(parsed.String())
+	require.NoError(s.T(), err)
+	s.migrate, err = migrate.NewWithInstance("httpfs", srcDriver, parsed.Path, dbDriver)
+	require.NoError(s.T(), err)
+}
+
+func (s *MigrationsTestSuite) TearDownTest() {
+	if s.migrate != nil {
+		s.migrate.Close()
+	}
+	if s.conn != nil {
+		_, err := s.conn.Exec(context.Background(), "DROP SCHEMA "+s.schema+" CASCADE")
+		assert.NoError(s.T(), err)
+		s.conn.Close(context.Background())
+	}
+}
+
+func (s *MigrationsTestSuite) userRoles(username string) []string {
+	rows, err := s.conn.Query(context.Background(), `SELECT user_roles.role
+FROM user_roles
+         JOIN users ON users.id = user_roles.user_id
+WHERE users.username = $1
+ORDER BY user_roles.role`, username)
+	require.NoError(s.T(), err)
+	roles, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	require.NoError(s.T(), err)
+	return roles
+}
+
+func (s *MigrationsTestSuite) TestRolesKeepWhatUsersCouldDo() {
+	ctx := context.Background()
+	require.NoError(s.T(), s.migrate.Migrate(19))
+
+	_, err := s.conn.Exec(ctx, `INSERT INTO users (username, password_hash, superuser)
+VALUES ('root', 'hash', TRUE),
+       ('setter', 'hash', FALSE),
+       ('alice', 'hash', FALSE)`)
+	require.NoError(s.T(), err)
+	_, err = s.conn.Exec(ctx, `INSERT INTO problems (title, description, time_limit_ms, memory_limit_kb, created_by)
+SELECT 'A', '', 1000, 65536, id
+FROM users
+WHERE username = 'setter'`)
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), s.migrate.Migrate(20))
+
+	assert.Equal(s.T(), []string{"admin", "contestant"}, s.userRoles("root"), "superusers become admins")
+	assert.Equal(s.T(), []string{"contestant", "problem_setter"}, s.userRoles("setter"))
+	assert.Equal(s.T(), []string{"contestant"}, s.userRoles("alice"))
+
+	var managers int64
+	err = s.conn.QueryRow(ctx, `SELECT COUNT(*)
+FROM user_roles
+         JOIN role_permissions ON role_permissions.role = user_roles.role
+WHERE role_permissions.permission = 'manage_users'`).Scan(&managers)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1), managers, "only the former superuser manages users")
+
+	require.NoError(s.T(), s.migrate.Up(), "later migrations apply to the mapped roles")
+}
+
+func TestMigrationsTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrationsTestSuite))
+}
@@ -11,6 +11,134 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type GroupRole string
+
+const (
+	GroupRoleOWNER  GroupRole = "OWNER"
+	GroupRoleMEMBER GroupRole = "MEMBER"
+)
+
+func (e *GroupRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = GroupRole(s)
+	case string:
+		*e = GroupRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for GroupRole: %T", src)
+	}
+	return nil
+}
+
+type NullGroupRole struct {
+	GroupRole GroupRole `json:"group_role"`
+	Valid     bool      `json:"valid"` // Valid is true if GroupRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullGroupRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.GroupRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.GroupRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullGroupRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.GroupRole), nil
+}
+
+type JobStatus string
+
+const (
+	JobStatusRUNNING   JobStatus = "RUNNING"
+	JobStatusSUCCEEDED JobStatus = "SUCCEEDED"
+	JobStatusFAILED    JobStatus = "FAILED"
+)
+
+func (e *JobStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = JobStatus(s)
+	case string:
+		*e = JobStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for JobStatus: %T", src)
+	}
+	return nil
+}
+
+type NullJobStatus struct {
+	JobStatus JobStatus `json:"job_status"`
+	Valid     bool      `json:"valid"` // Valid is true if JobStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullJobStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.JobStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.JobStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullJobStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.JobStatus), nil
+}
+
+type ProgramKind string
+
+const (
+	ProgramKindGENERATOR ProgramKind = "GENERATOR"
+	ProgramKindVALIDATOR ProgramKind = "VALIDATOR"
+	ProgramKindSOLUTION  ProgramKind = "SOLUTION"
+)
+
+func (e *ProgramKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ProgramKind(s)
+	case string:
+		*e = ProgramKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ProgramKind: %T", src)
+	}
+	return nil
+}
+
+type NullProgramKind struct {
+	ProgramKind ProgramKind `json:"program_kind"`
+	Valid       bool        `json:"valid"` // Valid is true if ProgramKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullProgramKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.ProgramKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ProgramKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullProgramKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ProgramKind), nil
+}
+
 type SubmissionStatus string
 
 const (
@@ -61,18 +189,236 @@ func (ns NullSubmissionStatus) Value() (driver.Value, error) {
 	return string(ns.SubmissionStatus), nil
 }
 
+type SubmissionVisibility string
+
+const (
+	SubmissionVisibilityPRIVATE SubmissionVisibility = "PRIVATE"
+	SubmissionVisibilitySTAFF   SubmissionVisibility = "STAFF"
+)
+
+func (e *SubmissionVisibility) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SubmissionVisibility(s)
+	case string:
+		*e = SubmissionVisibility(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SubmissionVisibility: %T", src)
+	}
+	return nil
+}
+
+type NullSubmissionVisibility struct {
+	SubmissionVisibility SubmissionVisibility `json:"submission_visibility"`
+	Valid                bool                 `json:"valid"` // Valid is true if SubmissionVisibility is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullSubmissionVisibility) Scan(value interface{}) error {
+	if value == nil {
+		ns.SubmissionVisibility, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.SubmissionVisibility.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullSubmissionVisibility) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.SubmissionVisibility), nil
+}
+
+type Assignment struct {
+	ID                 int32              `db:"id" json:"id"`
+	GroupID            int32              `db:"group_id" json:"group_id"`
+	Title              string             `db:"title" json:"title"`
+	OpensAt            pgtype.Timestamptz `db:"opens_at" json:"opens_at"`
+	ClosesAt           pgtype.Timestamptz `db:"closes_at" json:"closes_at"`
+	LateUntil          pgtype.Timestamptz `db:"late_until" json:"late_until"`
+	LatePenaltyPercent int32              `db:"late_penalty_percent" json:"late_penalty_percent"`
+	CreatedAt          pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type AssignmentProblem struct {
+	AssignmentID int32 `db:"assignment_id" json:"assignment_id"`
+	ProblemID    int32 `db:"problem_id" json:"problem_id"`
+	Position     int32 `db:"position" json:"position"`
+}
+
+type AuditEvent struct {
+	ID         int64              `db:"id" json:"id"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ActorID    pgtype.UUID        `db:"actor_id" json:"actor_id"`
+	ActorName  string             `db:"actor_name" json:"actor_name"`
+	Action     string             `db:"action" json:"action"`
+	TargetType string             `db:"target_type" json:"target_type"`
+	TargetID   string             `db:"target_id" json:"target_id"`
+	Before     []byte             `db:"before" json:"before"`
+	After      []byte             `db:"after" json:"after"`
+	RequestID  string             `db:"request_id" json:"request_id"`
+}
+
+type Group struct {
+	ID          int32              `db:"id" json:"id"`
+	Name        string             `db:"name" json:"name"`
+	Description string             `db:"description" json:"description"`
+	InviteCode  string             `db:"invite_code" json:"invite_code"`
+	CreatedBy   pgtype.UUID        `db:"created_by" json:"created_by"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type GroupMember struct {
+	GroupID  int32              `db:"group_id" json:"group_id"`
+	UserID   pgtype.UUID        `db:"user_id" json:"user_id"`
+	Role     GroupRole          `db:"role" json:"role"`
+	JoinedAt pgtype.Timestamptz `db:"joined_at" json:"joined_at"`
+}
+
+type LoginFailure struct {
+	Key           string             `db:"key" json:"key"`
+	Failures      int32              `db:"failures" json:"failures"`
+	LastFailureAt pgtype.Timestamptz `db:"last_failure_at" json:"last_failure_at"`
+	LockedUntil   pgtype.Timestamptz `db:"locked_until" json:"locked_until"`
+}
+
+type PasswordReset struct {
+	TokenHash []byte             `db:"token_hash" json:"token_hash"`
+	UserID    pgtype.UUID        `db:"user_id" json:"user_id"`
+	CreatedBy pgtype.UUID        `db:"created_by" json:"created_by"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `db:"used_at" json:"used_at"`
+}
+
+type Permission struct {
+	Name        string `db:"name" json:"name"`
+	Description string `db:"description" json:"description"`
+}
+
+type PersonalAccessToken struct {
+	ID         pgtype.UUID        `db:"id" json:"id"`
+	UserID     pgtype.UUID        `db:"user_id" json:"user_id"`
+	Name       string             `db:"name" json:"name"`
+	TokenHash  []byte             `db:"token_hash" json:"token_hash"`
+	Scopes     []string           `db:"scopes" json:"scopes"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ExpiresAt  pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `db:"last_used_at" json:"last_used_at"`
+}
+
 type Problem struct {
+	ID                   int32                `db:"id" json:"id"`
+	Title                string               `db:"title" json:"title"`
+	Description          string               `db:"description" json:"description"`
+	TimeLimitMs          int64                `db:"time_limit_ms" json:"time_limit_ms"`
+	MemoryLimitKb        int64                `db:"memory_limit_kb" json:"memory_limit_kb"`
+	CreatedAt            pgtype.Timestamptz   `db:"created_at" json:"created_at"`
+	CreatedBy            pgtype.UUID          `db:"created_by" json:"created_by"`
+	Draft                bool                 `db:"draft" json:"draft"`
+	PublishedAt          pgtype.Timestamptz   `db:"published_at" json:"published_at"`
+	CurrentRevisionID    pgtype.Int4          `db:"current_revision_id" json:"current_revision_id"`
+	Difficulty           pgtype.Int4          `db:"difficulty" json:"difficulty"`
+	SubmissionVisibility SubmissionVisibility `db:"submission_visibility" json:"submission_visibility"`
+	RevealFailingTest    bool                 `db:"reveal_failing_test" json:"reveal_failing_test"`
+}
+
+type ProblemAttachment struct {
+	ID          int32              `db:"id" json:"id"`
+	ProblemID   int32              `db:"problem_id" json:"problem_id"`
+	Name        string             `db:"name" json:"name"`
+	ContentType string             `db:"content_type" json:"content_type"`
+	Data        []byte             `db:"data" json:"data"`
+	UploadedBy  pgtype.UUID        `db:"uploaded_by" json:"uploaded_by"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type ProblemProgram struct {
+	ID             int32                `db:"id" json:"id"`
+	ProblemID      int32                `db:"problem_id" json:"problem_id"`
+	Kind           ProgramKind          `db:"kind" json:"kind"`
+	Name           string               `db:"name" json:"name"`
+	Source         string               `db:"source" json:"source"`
+	CreatedAt      pgtype.Timestamptz   `db:"created_at" json:"created_at"`
+	ExpectedStatus NullSubmissionStatus `db:"expected_status" json:"expected_status"`
+	Main           bool                 `db:"main" json:"main"`
+}
+
+type ProblemRevision struct {
 	ID            int32              `db:"id" json:"id"`
+	ProblemID     int32              `db:"problem_id" json:"problem_id"`
+	Revision      int32              `db:"revision" json:"revision"`
 	Title         string             `db:"title" json:"title"`
 	Description   string             `db:"description" json:"description"`
-	SampleInput   string             `db:"sample_input" json:"sample_input"`
-	SampleOutput  string             `db:"sample_output" json:"sample_output"`
 	TimeLimitMs   int64              `db:"time_limit_ms" json:"time_limit_ms"`
 	MemoryLimitKb int64              `db:"memory_limit_kb" json:"memory_limit_kb"`
-	CreatedAt     pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ContentHash   string             `db:"content_hash" json:"content_hash"`
 	CreatedBy     pgtype.UUID        `db:"created_by" json:"created_by"`
-	Draft         bool               `db:"draft" json:"draft"`
-	PublishedAt   pgtype.Timestamptz `db:"published_at" json:"published_at"`
+	CreatedAt     pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type ProblemRevisionTest struct {
+	RevisionID    int32       `db:"revision_id" json:"revision_id"`
+	Position      int32       `db:"position" json:"position"`
+	Input         string      `db:"input" json:"input"`
+	Output        string      `db:"output" json:"output"`
+	GeneratorID   pgtype.Int4 `db:"generator_id" json:"generator_id"`
+	GeneratorArgs pgtype.Text `db:"generator_args" json:"generator_args"`
+	IsSample      bool        `db:"is_sample" json:"is_sample"`
+	Explanation   string      `db:"explanation" json:"explanation"`
+}
+
+type ProblemTag struct {
+	ProblemID int32  `db:"problem_id" json:"problem_id"`
+	Tag       string `db:"tag" json:"tag"`
+}
+
+type ProblemVerification struct {
+	ID                   int32              `db:"id" json:"id"`
+	ProblemID            int32              `db:"problem_id" json:"problem_id"`
+	Status               JobStatus          `db:"status" json:"status"`
+	Fingerprint          string             `db:"fingerprint" json:"fingerprint"`
+	Report               string             `db:"report" json:"report"`
+	SuggestedTimeLimitMs pgtype.Int8        `db:"suggested_time_limit_ms" json:"suggested_time_limit_ms"`
+	StartedAt            pgtype.Timestamptz `db:"started_at" json:"started_at"`
+	FinishedAt           pgtype.Timestamptz `db:"finished_at" json:"finished_at"`
+}
+
+type RecoveryCode struct {
+	UserID   pgtype.UUID        `db:"user_id" json:"user_id"`
+	CodeHash []byte             `db:"code_hash" json:"code_hash"`
+	UsedAt   pgtype.Timestamptz `db:"used_at" json:"used_at"`
+}
+
+type RefreshToken struct {
+	TokenHash []byte             `db:"token_hash" json:"token_hash"`
+	SessionID pgtype.UUID        `db:"session_id" json:"session_id"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UsedAt    pgtype.Timestamptz `db:"used_at" json:"used_at"`
+}
+
+type Role struct {
+	Name             string `db:"name" json:"name"`
+	Description      string `db:"description" json:"description"`
+	RequireTwoFactor bool   `db:"require_two_factor" json:"require_two_factor"`
+}
+
+type RolePermission struct {
+	Role       string `db:"role" json:"role"`
+	Permission string `db:"permission" json:"permission"`
+}
+
+type Session struct {
+	ID         pgtype.UUID        `db:"id" json:"id"`
+	UserID     pgtype.UUID        `db:"user_id" json:"user_id"`
+	UserAgent  string             `db:"user_agent" json:"user_agent"`
+	IpAddress  string             `db:"ip_address" json:"ip_address"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	LastSeenAt pgtype.Timestamptz `db:"last_seen_at" json:"last_seen_at"`
+	ExpiresAt  pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	RevokedAt  pgtype.Timestamptz `db:"revoked_at" json:"revoked_at"`
 }
 
 type Submission struct {
@@ -85,20 +431,79 @@ type Submission struct {
 	LastModified pgtype.Timestamptz `db:"last_modified" json:"last_modified"`
 	Message      pgtype.Text        `db:"message" json:"message"`
 	Retries      int32              `db:"retries" json:"retries"`
+	RevisionID   pgtype.Int4        `db:"revision_id" json:"revision_id"`
+	Feedback     pgtype.Text        `db:"feedback" json:"feedback"`
+	CodeHash     string             `db:"code_hash" json:"code_hash"`
+	FailedTest   pgtype.Int4        `db:"failed_test" json:"failed_test"`
 }
 
 type TestCase struct {
-	ID        int32  `db:"id" json:"id"`
-	ProblemID int32  `db:"problem_id" json:"problem_id"`
-	Input     string `db:"input" json:"input"`
-	Output    string `db:"output" json:"output"`
+	ID            int32       `db:"id" json:"id"`
+	ProblemID     int32       `db:"problem_id" json:"problem_id"`
+	Input         string      `db:"input" json:"input"`
+	Output        string      `db:"output" json:"output"`
+	GeneratorID   pgtype.Int4 `db:"generator_id" json:"generator_id"`
+	GeneratorArgs pgtype.Text `db:"generator_args" json:"generator_args"`
+	InputKey      pgtype.Text `db:"input_key" json:"input_key"`
+	OutputKey     pgtype.Text `db:"output_key" json:"output_key"`
+	IsSample      bool        `db:"is_sample" json:"is_sample"`
+	Explanation   string      `db:"explanation" json:"explanation"`
+}
+
+type TestGeneration struct {
+	ID         int32              `db:"id" json:"id"`
+	ProblemID  int32              `db:"problem_id" json:"problem_id"`
+	Status     JobStatus          `db:"status" json:"status"`
+	Report     string             `db:"report" json:"report"`
+	StartedAt  pgtype.Timestamptz `db:"started_at" json:"started_at"`
+	FinishedAt pgtype.Timestamptz `db:"finished_at" json:"finished_at"`
+}
+
+type TestUpload struct {
+	ID            pgtype.UUID        `db:"id" json:"id"`
+	ProblemID     int32              `db:"problem_id" json:"problem_id"`
+	UploadedBy    pgtype.UUID        `db:"uploaded_by" json:"uploaded_by"`
+	Archive       []byte             `db:"archive" json:"archive"`
+	InputPattern  string             `db:"input_pattern" json:"input_pattern"`
+	OutputPattern string             `db:"output_pattern" json:"output_pattern"`
+	CreatedAt     pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type TwoFactorChallenge struct {
+	TokenHash []byte             `db:"token_hash" json:"token_hash"`
+	UserID    pgtype.UUID        `db:"user_id" json:"user_id"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
 }
 
 type User struct {
-	ID                pgtype.UUID `db:"id" json:"id"`
-	Username          string      `db:"username" json:"username"`
-	PasswordHash      string      `db:"password_hash" json:"password_hash"`
-	Superuser         bool        `db:"superuser" json:"superuser"`
-	ProblemsAttempted int32       `db:"problems_attempted" json:"problems_attempted"`
-	ProblemsSolved    int32       `db:"problems_solved" json:"problems_solved"`
+	ID                      pgtype.UUID   `db:"id" json:"id"`
+	Username                string        `db:"username" json:"username"`
+	PasswordHash            string        `db:"password_hash" json:"password_hash"`
+	ProblemsAttempted       int32         `db:"problems_attempted" json:"problems_attempted"`
+	ProblemsSolved          int32         `db:"problems_solved" json:"problems_solved"`
+	SubmissionRatePerMinute pgtype.Float8 `db:"submission_rate_per_minute" json:"submission_rate_per_minute"`
+	SubmissionRateBurst     pgtype.Int4   `db:"submission_rate_burst" json:"submission_rate_burst"`
+}
+
+type UserIdentity struct {
+	Provider    string             `db:"provider" json:"provider"`
+	Subject     string             `db:"subject" json:"subject"`
+	UserID      pgtype.UUID        `db:"user_id" json:"user_id"`
+	Email       string             `db:"email" json:"email"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	LastLoginAt pgtype.Timestamptz `db:"last_login_at" json:"last_login_at"`
+}
+
+type UserRole struct {
+	UserID    pgtype.UUID        `db:"user_id" json:"user_id"`
+	Role      string             `db:"role" json:"role"`
+	GrantedAt pgtype.Timestamptz `db:"granted_at" json:"granted_at"`
+}
+
+type UserTotp struct {
+	UserID       pgtype.UUID        `db:"user_id" json:"user_id"`
+	Secret       string             `db:"secret" json:"secret"`
+	CreatedAt    pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	EnabledAt    pgtype.Timestamptz `db:"enabled_at" json:"enabled_at"`
+	LastUsedStep int64              `db:"last_used_step" json:"last_used_step"`
 }
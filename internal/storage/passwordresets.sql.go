@@ -0,0 +1,90 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: passwordresets.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createPasswordReset = `-- name: CreatePasswordReset :exec
+INSERT INTO password_resets (token_hash, user_id, created_by, expires_at)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreatePasswordResetParams struct {
+	TokenHash []byte             `db:"token_hash" json:"token_hash"`
+	UserID    pgtype.UUID        `db:"user_id" json:"user_id"`
+	CreatedBy pgtype.UUID        `db:"created_by" json:"created_by"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) CreatePasswordReset(ctx context.Context, db DBTX, arg CreatePasswordResetParams) error {
+	_, err := db.Exec(ctx, createPasswordReset,
+		arg.TokenHash,
+		arg.UserID,
+		arg.CreatedBy,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const deleteUserPasswordResets = `-- name: DeleteUserPasswordResets :exec
+DELETE
+FROM password_resets
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserPasswordResets(ctx context.Context, db DBTX, userID pgtype.UUID) error {
+	_, err := db.Exec(ctx, deleteUserPasswordResets, userID)
+	return err
+}
+
+const getPasswordReset = `-- name: GetPasswordReset :one
+SELECT password_resets.token_hash, password_resets.user_id, password_resets.created_by, password_resets.created_at, password_resets.expires_at, password_resets.used_at, users.username
+FROM password_resets
+         JOIN users ON users.id = password_resets.user_id
+WHERE password_resets.token_hash = $1
+  AND password_resets.used_at IS NULL
+  AND password_resets.expires_at > NOW()
+`
+
+type GetPasswordResetRow struct {
+	PasswordReset PasswordReset `db:"password_reset" json:"password_reset"`
+	Username      string        `db:"username" json:"username"`
+}
+
+func (q *Queries) GetPasswordReset(ctx context.Context, db DBTX, tokenHash []byte) (GetPasswordResetRow, error) {
+	row := db.QueryRow(ctx, getPasswordReset, tokenHash)
+	var i GetPasswordResetRow
+	err := row.Scan(
+		&i.PasswordReset.TokenHash,
+		&i.PasswordReset.UserID,
+		&i.PasswordReset.CreatedBy,
+		&i.PasswordReset.CreatedAt,
+		&i.PasswordReset.ExpiresAt,
+		&i.PasswordReset.UsedAt,
+		&i.Username,
+	)
+	return i, err
+}
+
+const usePasswordReset = `-- name: UsePasswordReset :execrows
+UPDATE password_resets
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+`
+
+func (q *Queries) UsePasswordReset(ctx context.Context, db DBTX, tokenHash []byte) (int64, error) {
+	result, err := db.Exec(ctx, usePasswordReset, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
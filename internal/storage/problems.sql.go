@@ -23,7 +23,7 @@ func (q *Queries) DraftProblem(ctx context.Context, db DBTX, id int32) error {
 }
 
 const getAllProblemsSorted = `-- name: GetAllProblemsSorted :many
-SELECT problems.id, problems.title, problems.description, problems.sample_input, problems.sample_output, problems.time_limit_ms, problems.memory_limit_kb, problems.created_at, problems.created_by, problems.draft, problems.published_at, users.username as author_name
+SELECT problems.id, problems.title, problems.description, problems.time_limit_ms, problems.memory_limit_kb, problems.created_at, problems.created_by, problems.draft, problems.published_at, problems.current_revision_id, problems.difficulty, problems.submission_visibility, problems.reveal_failing_test, users.username as author_name
 FROM problems JOIN users  ON problems.created_by = users.id
 ORDER BY published_at DESC
 LIMIT $1
@@ -31,18 +31,20 @@ OFFSET $2
 `
 
 type GetAllProblemsSortedRow struct {
-	ID            int32              `db:"id" json:"id"`
-	Title         string             `db:"title" json:"title"`
-	Description   string             `db:"description" json:"description"`
-	SampleInput   string             `db:"sample_input" json:"sample_input"`
-	SampleOutput  string             `db:"sample_output" json:"sample_output"`
-	TimeLimitMs   int64              `db:"time_limit_ms" json:"time_limit_ms"`
-	MemoryLimitKb int64              `db:"memory_limit_kb" json:"memory_limit_kb"`
-	CreatedAt     pgtype.Timestamptz `db:"created_at" json:"created_at"`
-	CreatedBy     pgtype.UUID        `db:"created_by" json:"created_by"`
-	Draft         bool               `db:"draft" json:"draft"`
-	PublishedAt   pgtype.Timestamptz `db:"published_at" json:"published_at"`
-	AuthorName    string             `db:"author_name" json:"author_name"`
+	ID                   int32                `db:"id" json:"id"`
+	Title                string               `db:"title" json:"title"`
+	Description          string               `db:"description" json:"description"`
+	TimeLimitMs          int64                `db:"time_limit_ms" json:"time_limit_ms"`
+	MemoryLimitKb        int64                `db:"memory_limit_kb" json:"memory_limit_kb"`
+	CreatedAt            pgtype.Timestamptz   `db:"created_at" json:"created_at"`
+	CreatedBy            pgtype.UUID          `db:"created_by" json:"created_by"`
+	Draft                bool                 `db:"draft" json:"draft"`
+	PublishedAt          pgtype.Timestamptz   `db:"published_at" json:"published_at"`
+	CurrentRevisionID    pgtype.Int4          `db:"current_revision_id" json:"current_revision_id"`
+	Difficulty           pgtype.Int4          `db:"difficulty" json:"difficulty"`
+	SubmissionVisibility SubmissionVisibility `db:"submission_visibility" json:"submission_visibility"`
+	RevealFailingTest    bool                 `db:"reveal_failing_test" json:"reveal_failing_test"`
+	AuthorName           string               `db:"author_name" json:"author_name"`
 }
 
 func (q *Queries) GetAllProblemsSorted(ctx context.Context, db DBTX, limit int32, offset int32) ([]GetAllProblemsSortedRow, error) {
@@ -58,14 +60,16 @@ func (q *Queries) GetAllProblemsSorted(ctx context.Context, db DBTX, limit int32
 			&i.ID,
 			&i.Title,
 			&i.Description,
-			&i.SampleInput,
-			&i.SampleOutput,
 			&i.TimeLimitMs,
 			&i.MemoryLimitKb,
 			&i.CreatedAt,
 			&i.CreatedBy,
 			&i.Draft,
 			&i.PublishedAt,
+			&i.CurrentRevisionID,
+			&i.Difficulty,
+			&i.SubmissionVisibility,
+			&i.RevealFailingTest,
 			&i.AuthorName,
 		); err != nil {
 			return nil, err
@@ -78,40 +82,26 @@ func (q *Queries) GetAllProblemsSorted(ctx context.Context, db DBTX, limit int32
 	return items, nil
 }
 
-const getAllPublishedProblemsSorted = `-- name: GetAllPublishedProblemsSorted :many
-SELECT id, title, description, sample_input, sample_output, time_limit_ms, memory_limit_kb, created_at, created_by, draft, published_at
-FROM problems
-WHERE draft = false
-ORDER BY published_at DESC
-LIMIT $1
-OFFSET $2
+const getAllTags = `-- name: GetAllTags :many
+SELECT DISTINCT problem_tags.tag
+FROM problem_tags INNER JOIN problems ON problem_tags.problem_id = problems.id
+WHERE problems.draft = FALSE
+ORDER BY problem_tags.tag
 `
 
-func (q *Queries) GetAllPublishedProblemsSorted(ctx context.Context, db DBTX, limit int32, offset int32) ([]Problem, error) {
-	rows, err := db.Query(ctx, getAllPublishedProblemsSorted, limit, offset)
+func (q *Queries) GetAllTags(ctx context.Context, db DBTX) ([]string, error) {
+	rows, err := db.Query(ctx, getAllTags)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Problem
+	var items []string
 	for rows.Next() {
-		var i Problem
-		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Description,
-			&i.SampleInput,
-			&i.SampleOutput,
-			&i.TimeLimitMs,
-			&i.MemoryLimitKb,
-			&i.CreatedAt,
-			&i.CreatedBy,
-			&i.Draft,
-			&i.PublishedAt,
-		); err != nil {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, tag)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -120,7 +110,7 @@ func (q *Queries) GetAllPublishedProblemsSorted(ctx context.Context, db DBTX, li
 }
 
 const getProblemByID = `-- name: GetProblemByID :one
-SELECT id, title, description, sample_input, sample_output, time_limit_ms, memory_limit_kb, created_at, created_by, draft, published_at
+SELECT id, title, description, time_limit_ms, memory_limit_kb, created_at, created_by, draft, published_at, current_revision_id, difficulty, submission_visibility, reveal_failing_test
 FROM problems
 WHERE id = $1
 `
@@ -132,20 +122,22 @@ func (q *Queries) GetProblemByID(ctx context.Context, db DBTX, id int32) (Proble
 		&i.ID,
 		&i.Title,
 		&i.Description,
-		&i.SampleInput,
-		&i.SampleOutput,
 		&i.TimeLimitMs,
 		&i.MemoryLimitKb,
 		&i.CreatedAt,
 		&i.CreatedBy,
 		&i.Draft,
 		&i.PublishedAt,
+		&i.CurrentRevisionID,
+		&i.Difficulty,
+		&i.SubmissionVisibility,
+		&i.RevealFailingTest,
 	)
 	return i, err
 }
 
 const getProblemForUser = `-- name: GetProblemForUser :one
-SELECT id, title, description, sample_input, sample_output, time_limit_ms, memory_limit_kb, created_at, created_by, draft, published_at
+SELECT id, title, description, time_limit_ms, memory_limit_kb, created_at, created_by, draft, published_at, current_revision_id, difficulty, submission_visibility, reveal_failing_test
 FROM problems
 WHERE id = $1 and (draft = FALSE or created_by = $2 or $3::BOOLEAN)
 `
@@ -163,20 +155,22 @@ func (q *Queries) GetProblemForUser(ctx context.Context, db DBTX, arg GetProblem
 		&i.ID,
 		&i.Title,
 		&i.Description,
-		&i.SampleInput,
-		&i.SampleOutput,
 		&i.TimeLimitMs,
 		&i.MemoryLimitKb,
 		&i.CreatedAt,
 		&i.CreatedBy,
 		&i.Draft,
 		&i.PublishedAt,
+		&i.CurrentRevisionID,
+		&i.Difficulty,
+		&i.SubmissionVisibility,
+		&i.RevealFailingTest,
 	)
 	return i, err
 }
 
 const getUserProblemsSorted = `-- name: GetUserProblemsSorted :many
-SELECT id, title, description, sample_input, sample_output, time_limit_ms, memory_limit_kb, created_at, created_by, draft, published_at
+SELECT id, title, description, time_limit_ms, memory_limit_kb, created_at, created_by, draft, published_at, current_revision_id, difficulty, submission_visibility, reveal_failing_test
 FROM problems
 WHERE created_by = $3
 ORDER BY published_at DESC
@@ -203,14 +197,16 @@ func (q *Queries) GetUserProblemsSorted(ctx context.Context, db DBTX, arg GetUse
 			&i.ID,
 			&i.Title,
 			&i.Description,
-			&i.SampleInput,
-			&i.SampleOutput,
 			&i.TimeLimitMs,
 			&i.MemoryLimitKb,
 			&i.CreatedAt,
 			&i.CreatedBy,
 			&i.Draft,
 			&i.PublishedAt,
+			&i.CurrentRevisionID,
+			&i.Difficulty,
+			&i.SubmissionVisibility,
+			&i.RevealFailingTest,
 		); err != nil {
 			return nil, err
 		}
@@ -226,21 +222,17 @@ const insertProblem = `-- name: InsertProblem :one
 INSERT INTO problems (
     title,
     description,
-    sample_input,
-    sample_output,
     time_limit_ms,
     memory_limit_kb,
     created_by
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, title, description, sample_input, sample_output, time_limit_ms, memory_limit_kb, created_at, created_by, draft, published_at
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, title, description, time_limit_ms, memory_limit_kb, created_at, created_by, draft, published_at, current_revision_id, difficulty, submission_visibility, reveal_failing_test
 `
 
 type InsertProblemParams struct {
 	Title         string      `db:"title" json:"title"`
 	Description   string      `db:"description" json:"description"`
-	SampleInput   string      `db:"sample_input" json:"sample_input"`
-	SampleOutput  string      `db:"sample_output" json:"sample_output"`
 	TimeLimitMs   int64       `db:"time_limit_ms" json:"time_limit_ms"`
 	MemoryLimitKb int64       `db:"memory_limit_kb" json:"memory_limit_kb"`
 	CreatedBy     pgtype.UUID `db:"created_by" json:"created_by"`
@@ -250,8 +242,6 @@ func (q *Queries) InsertProblem(ctx context.Context, db DBTX, arg InsertProblemP
 	row := db.QueryRow(ctx, insertProblem,
 		arg.Title,
 		arg.Description,
-		arg.SampleInput,
-		arg.SampleOutput,
 		arg.TimeLimitMs,
 		arg.MemoryLimitKb,
 		arg.CreatedBy,
@@ -261,14 +251,16 @@ func (q *Queries) InsertProblem(ctx context.Context, db DBTX, arg InsertProblemP
 		&i.ID,
 		&i.Title,
 		&i.Description,
-		&i.SampleInput,
-		&i.SampleOutput,
 		&i.TimeLimitMs,
 		&i.MemoryLimitKb,
 		&i.CreatedAt,
 		&i.CreatedBy,
 		&i.Draft,
 		&i.PublishedAt,
+		&i.CurrentRevisionID,
+		&i.Difficulty,
+		&i.SubmissionVisibility,
+		&i.RevealFailingTest,
 	)
 	return i, err
 }
@@ -284,25 +276,152 @@ func (q *Queries) PublishProblem(ctx context.Context, db DBTX, id int32) error {
 	return err
 }
 
+const searchProblems = `-- name: SearchProblems :many
+SELECT
+    problems.id,
+    problems.title,
+    problems.time_limit_ms,
+    problems.memory_limit_kb,
+    problems.difficulty,
+    problems.published_at,
+    users.username AS author_name,
+    ARRAY(SELECT tag FROM problem_tags WHERE problem_tags.problem_id = problems.id ORDER BY tag)::TEXT[] AS tags,
+    stats.solved_count,
+    stats.submission_count,
+    stats.accepted_count,
+    COALESCE(stats.accepted_count::FLOAT8 / NULLIF(stats.submission_count, 0), 0)::FLOAT8 AS acceptance_rate,
+    ($1::UUID IS NOT NULL AND EXISTS (
+        SELECT 1 FROM submissions
+        WHERE submissions.problem_id = problems.id AND submissions.user_id = $1
+            AND submissions.status = 'ACCEPTED'
+    ))::BOOLEAN AS solved
+FROM problems
+    INNER JOIN users ON problems.created_by = users.id
+    CROSS JOIN LATERAL (
+        SELECT
+            COUNT(DISTINCT submissions.user_id) FILTER (WHERE submissions.status = 'ACCEPTED') AS solved_count,
+            COUNT(*) FILTER (WHERE submissions.status NOT IN ('IN_QUEUE', 'PENDING', 'RUNNING', 'INTERNAL_ERROR')) AS submission_count,
+            COUNT(*) FILTER (WHERE submissions.status = 'ACCEPTED') AS accepted_count
+        FROM submissions
+        WHERE submissions.problem_id = problems.id
+    ) AS stats
+WHERE problems.draft = FALSE
+    AND ($2::TEXT IS NULL
+        OR to_tsvector('simple', problems.title || ' ' || problems.description) @@ websearch_to_tsquery('simple', $2))
+    AND ($3::TEXT IS NULL
+        OR EXISTS (SELECT 1 FROM problem_tags WHERE problem_tags.problem_id = problems.id AND problem_tags.tag = $3))
+    AND ($4::INTEGER IS NULL OR problems.difficulty >= $4)
+    AND ($5::INTEGER IS NULL OR problems.difficulty <= $5)
+    AND ($6::TEXT IS NULL OR users.username = $6)
+    AND ($7::BOOLEAN IS NULL OR $7 = EXISTS (
+        SELECT 1 FROM submissions
+        WHERE submissions.problem_id = problems.id AND submissions.user_id = $1
+            AND submissions.status = 'ACCEPTED'
+    ))
+ORDER BY
+    CASE WHEN $8::TEXT = 'relevance' AND $2::TEXT IS NOT NULL
+        THEN ts_rank(to_tsvector('simple', problems.title || ' ' || problems.description), websearch_to_tsquery('simple', $2))
+    END DESC NULLS LAST,
+    CASE WHEN $8::TEXT = 'popularity' THEN stats.solved_count END DESC NULLS LAST,
+    CASE WHEN $8::TEXT = 'acceptance'
+        THEN stats.accepted_count::FLOAT8 / NULLIF(stats.submission_count, 0)
+    END DESC NULLS LAST,
+    CASE WHEN $8::TEXT = 'difficulty' THEN problems.difficulty END ASC NULLS LAST,
+    problems.published_at DESC,
+    problems.id DESC
+LIMIT $10
+OFFSET $9
+`
+
+type SearchProblemsParams struct {
+	ViewerID      pgtype.UUID `db:"viewer_id" json:"viewer_id"`
+	Query         pgtype.Text `db:"query" json:"query"`
+	Tag           pgtype.Text `db:"tag" json:"tag"`
+	MinDifficulty pgtype.Int4 `db:"min_difficulty" json:"min_difficulty"`
+	MaxDifficulty pgtype.Int4 `db:"max_difficulty" json:"max_difficulty"`
+	Author        pgtype.Text `db:"author" json:"author"`
+	Solved        pgtype.Bool `db:"solved" json:"solved"`
+	Sort          string      `db:"sort" json:"sort"`
+	PageOffset    int32       `db:"page_offset" json:"page_offset"`
+	PageLimit     int32       `db:"page_limit" json:"page_limit"`
+}
+
+type SearchProblemsRow struct {
+	ID              int32              `db:"id" json:"id"`
+	Title           string             `db:"title" json:"title"`
+	TimeLimitMs     int64              `db:"time_limit_ms" json:"time_limit_ms"`
+	MemoryLimitKb   int64              `db:"memory_limit_kb" json:"memory_limit_kb"`
+	Difficulty      pgtype.Int4        `db:"difficulty" json:"difficulty"`
+	PublishedAt     pgtype.Timestamptz `db:"published_at" json:"published_at"`
+	AuthorName      string             `db:"author_name" json:"author_name"`
+	Tags            []string           `db:"tags" json:"tags"`
+	SolvedCount     int64              `db:"solved_count" json:"solved_count"`
+	SubmissionCount int64              `db:"submission_count" json:"submission_count"`
+	AcceptedCount   int64              `db:"accepted_count" json:"accepted_count"`
+	AcceptanceRate  float64            `db:"acceptance_rate" json:"acceptance_rate"`
+	Solved          bool               `db:"solved" json:"solved"`
+}
+
+func (q *Queries) SearchProblems(ctx context.Context, db DBTX, arg SearchProblemsParams) ([]SearchProblemsRow, error) {
+	rows, err := db.Query(ctx, searchProblems,
+		arg.ViewerID,
+		arg.Query,
+		arg.Tag,
+		arg.MinDifficulty,
+		arg.MaxDifficulty,
+		arg.Author,
+		arg.Solved,
+		arg.Sort,
+		arg.PageOffset,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchProblemsRow
+	for rows.Next() {
+		var i SearchProblemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.TimeLimitMs,
+			&i.MemoryLimitKb,
+			&i.Difficulty,
+			&i.PublishedAt,
+			&i.AuthorName,
+			&i.Tags,
+			&i.SolvedCount,
+			&i.SubmissionCount,
+			&i.AcceptedCount,
+			&i.AcceptanceRate,
+			&i.Solved,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateProblem = `-- name: UpdateProblem :one
 UPDATE problems
 SET
     title = $2,
     description = $3,
-    sample_input = $4,
-    sample_output = $5,
-    time_limit_ms = $6,
-    memory_limit_kb = $7
+    time_limit_ms = $4,
+    memory_limit_kb = $5
 WHERE id = $1
-RETURNING id, title, description, sample_input, sample_output, time_limit_ms, memory_limit_kb, created_at, created_by, draft, published_at
+RETURNING id, title, description, time_limit_ms, memory_limit_kb, created_at, created_by, draft, published_at, current_revision_id, difficulty, submission_visibility, reveal_failing_test
 `
 
 type UpdateProblemParams struct {
 	ID            int32  `db:"id" json:"id"`
 	Title         string `db:"title" json:"title"`
 	Description   string `db:"description" json:"description"`
-	SampleInput   string `db:"sample_input" json:"sample_input"`
-	SampleOutput  string `db:"sample_output" json:"sample_output"`
 	TimeLimitMs   int64  `db:"time_limit_ms" json:"time_limit_ms"`
 	MemoryLimitKb int64  `db:"memory_limit_kb" json:"memory_limit_kb"`
 }
@@ -312,8 +431,6 @@ func (q *Queries) UpdateProblem(ctx context.Context, db DBTX, arg UpdateProblemP
 		arg.ID,
 		arg.Title,
 		arg.Description,
-		arg.SampleInput,
-		arg.SampleOutput,
 		arg.TimeLimitMs,
 		arg.MemoryLimitKb,
 	)
@@ -322,14 +439,44 @@ func (q *Queries) UpdateProblem(ctx context.Context, db DBTX, arg UpdateProblemP
 		&i.ID,
 		&i.Title,
 		&i.Description,
-		&i.SampleInput,
-		&i.SampleOutput,
 		&i.TimeLimitMs,
 		&i.MemoryLimitKb,
 		&i.CreatedAt,
 		&i.CreatedBy,
 		&i.Draft,
 		&i.PublishedAt,
+		&i.CurrentRevisionID,
+		&i.Difficulty,
+		&i.SubmissionVisibility,
+		&i.RevealFailingTest,
 	)
 	return i, err
 }
+
+const updateProblemDifficulty = `-- name: UpdateProblemDifficulty :exec
+UPDATE problems
+SET difficulty = $2
+WHERE id = $1
+`
+
+func (q *Queries) UpdateProblemDifficulty(ctx context.Context, db DBTX, iD int32, difficulty pgtype.Int4) error {
+	_, err := db.Exec(ctx, updateProblemDifficulty, iD, difficulty)
+	return err
+}
+
+const updateProblemSubmissionSettings = `-- name: UpdateProblemSubmissionSettings :exec
+UPDATE problems
+SET submission_visibility = $2, reveal_failing_test = $3
+WHERE id = $1
+`
+
+type UpdateProblemSubmissionSettingsParams struct {
+	ID                   int32                `db:"id" json:"id"`
+	SubmissionVisibility SubmissionVisibility `db:"submission_visibility" json:"submission_visibility"`
+	RevealFailingTest    bool                 `db:"reveal_failing_test" json:"reveal_failing_test"`
+}
+
+func (q *Queries) UpdateProblemSubmissionSettings(ctx context.Context, db DBTX, arg UpdateProblemSubmissionSettingsParams) error {
+	_, err := db.Exec(ctx, updateProblemSubmissionSettings, arg.ID, arg.SubmissionVisibility, arg.RevealFailingTest)
+	return err
+}
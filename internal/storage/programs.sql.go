@@ -0,0 +1,157 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: programs.sql
+
+package storage
+
+import (
+	"context"
+)
+
+const deleteProblemProgram = `-- name: DeleteProblemProgram :one
+DELETE FROM problem_programs
+WHERE id = $1 AND problem_id = $2
+RETURNING id, problem_id, kind, name, source, created_at, expected_status, main
+`
+
+func (q *Queries) DeleteProblemProgram(ctx context.Context, db DBTX, iD int32, problemID int32) (ProblemProgram, error) {
+	row := db.QueryRow(ctx, deleteProblemProgram, iD, problemID)
+	var i ProblemProgram
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Kind,
+		&i.Name,
+		&i.Source,
+		&i.CreatedAt,
+		&i.ExpectedStatus,
+		&i.Main,
+	)
+	return i, err
+}
+
+const getProblemProgram = `-- name: GetProblemProgram :one
+SELECT id, problem_id, kind, name, source, created_at, expected_status, main
+FROM problem_programs
+WHERE id = $1 AND problem_id = $2
+`
+
+func (q *Queries) GetProblemProgram(ctx context.Context, db DBTX, iD int32, problemID int32) (ProblemProgram, error) {
+	row := db.QueryRow(ctx, getProblemProgram, iD, problemID)
+	var i ProblemProgram
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Kind,
+		&i.Name,
+		&i.Source,
+		&i.CreatedAt,
+		&i.ExpectedStatus,
+		&i.Main,
+	)
+	return i, err
+}
+
+const getProblemPrograms = `-- name: GetProblemPrograms :many
+SELECT id, problem_id, kind, name, source, created_at, expected_status, main
+FROM problem_programs
+WHERE problem_id = $1
+ORDER BY kind, name
+`
+
+func (q *Queries) GetProblemPrograms(ctx context.Context, db DBTX, problemID int32) ([]ProblemProgram, error) {
+	rows, err := db.Query(ctx, getProblemPrograms, problemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProblemProgram
+	for rows.Next() {
+		var i ProblemProgram
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProblemID,
+			&i.Kind,
+			&i.Name,
+			&i.Source,
+			&i.CreatedAt,
+			&i.ExpectedStatus,
+			&i.Main,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertProblemProgram = `-- name: InsertProblemProgram :one
+INSERT INTO problem_programs (problem_id, kind, name, source, expected_status, main)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, problem_id, kind, name, source, created_at, expected_status, main
+`
+
+type InsertProblemProgramParams struct {
+	ProblemID      int32                `db:"problem_id" json:"problem_id"`
+	Kind           ProgramKind          `db:"kind" json:"kind"`
+	Name           string               `db:"name" json:"name"`
+	Source         string               `db:"source" json:"source"`
+	ExpectedStatus NullSubmissionStatus `db:"expected_status" json:"expected_status"`
+	Main           bool                 `db:"main" json:"main"`
+}
+
+func (q *Queries) InsertProblemProgram(ctx context.Context, db DBTX, arg InsertProblemProgramParams) (ProblemProgram, error) {
+	row := db.QueryRow(ctx, insertProblemProgram,
+		arg.ProblemID,
+		arg.Kind,
+		arg.Name,
+		arg.Source,
+		arg.ExpectedStatus,
+		arg.Main,
+	)
+	var i ProblemProgram
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Kind,
+		&i.Name,
+		&i.Source,
+		&i.CreatedAt,
+		&i.ExpectedStatus,
+		&i.Main,
+	)
+	return i, err
+}
+
+const updateProblemProgramSource = `-- name: UpdateProblemProgramSource :one
+UPDATE problem_programs
+SET source = $3
+WHERE id = $1 AND problem_id = $2
+RETURNING id, problem_id, kind, name, source, created_at, expected_status, main
+`
+
+type UpdateProblemProgramSourceParams struct {
+	ID        int32  `db:"id" json:"id"`
+	ProblemID int32  `db:"problem_id" json:"problem_id"`
+	Source    string `db:"source" json:"source"`
+}
+
+func (q *Queries) UpdateProblemProgramSource(ctx context.Context, db DBTX, arg UpdateProblemProgramSourceParams) (ProblemProgram, error) {
+	row := db.QueryRow(ctx, updateProblemProgramSource, arg.ID, arg.ProblemID, arg.Source)
+	var i ProblemProgram
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Kind,
+		&i.Name,
+		&i.Source,
+		&i.CreatedAt,
+		&i.ExpectedStatus,
+		&i.Main,
+	)
+	return i, err
+}
@@ -11,30 +11,166 @@ import (
 )
 
 type Querier interface {
-	CreateAdmin(ctx context.Context, db DBTX, username string, passwordHash string) (CreateAdminRow, error)
+	AddAssignmentProblem(ctx context.Context, db DBTX, arg AddAssignmentProblemParams) error
+	AddGroupMember(ctx context.Context, db DBTX, arg AddGroupMemberParams) error
+	ClearLoginFailures(ctx context.Context, db DBTX, key string) error
+	CountGroupOwners(ctx context.Context, db DBTX, groupID int32) (int64, error)
+	CountProblemAttachments(ctx context.Context, db DBTX, problemID int32) (int64, error)
+	CountUnusedRecoveryCodes(ctx context.Context, db DBTX, userID pgtype.UUID) (int64, error)
+	CountUsersWithPermission(ctx context.Context, db DBTX, permission string) (int64, error)
+	CreateAssignment(ctx context.Context, db DBTX, arg CreateAssignmentParams) (Assignment, error)
+	CreateAuditEvent(ctx context.Context, db DBTX, arg CreateAuditEventParams) error
+	CreateGroup(ctx context.Context, db DBTX, arg CreateGroupParams) (Group, error)
+	CreatePasswordReset(ctx context.Context, db DBTX, arg CreatePasswordResetParams) error
+	CreatePersonalAccessToken(ctx context.Context, db DBTX, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error)
+	CreateProblemVerification(ctx context.Context, db DBTX, problemID int32, fingerprint string) (ProblemVerification, error)
+	CreateRecoveryCode(ctx context.Context, db DBTX, userID pgtype.UUID, codeHash []byte) error
+	CreateRefreshToken(ctx context.Context, db DBTX, tokenHash []byte, sessionID pgtype.UUID) error
+	CreateRole(ctx context.Context, db DBTX, name string, description string) (Role, error)
+	CreateSession(ctx context.Context, db DBTX, arg CreateSessionParams) error
 	CreateSubmission(ctx context.Context, db DBTX, arg CreateSubmissionParams) (Submission, error)
-	CreateUser(ctx context.Context, db DBTX, username string, passwordHash string) (CreateUserRow, error)
+	CreateTestGeneration(ctx context.Context, db DBTX, problemID int32) (TestGeneration, error)
+	CreateTestUpload(ctx context.Context, db DBTX, arg CreateTestUploadParams) (TestUpload, error)
+	CreateTwoFactorChallenge(ctx context.Context, db DBTX, arg CreateTwoFactorChallengeParams) error
+	CreateUser(ctx context.Context, db DBTX, username string, passwordHash string) (User, error)
+	CreateUserIdentity(ctx context.Context, db DBTX, arg CreateUserIdentityParams) error
+	DeleteAllProblemTestCases(ctx context.Context, db DBTX, problemID int32) error
+	DeleteAssignment(ctx context.Context, db DBTX, groupID int32, iD int32) (int64, error)
+	DeleteAssignmentProblems(ctx context.Context, db DBTX, assignmentID int32) error
+	DeleteExpiredTwoFactorChallenges(ctx context.Context, db DBTX) error
+	DeleteExpiredUserSessions(ctx context.Context, db DBTX, userID pgtype.UUID) error
+	DeleteGeneratedTestCase(ctx context.Context, db DBTX, iD int32, problemID int32) (TestCase, error)
+	DeletePersonalAccessToken(ctx context.Context, db DBTX, userID pgtype.UUID, tokenID pgtype.UUID) (int64, error)
+	DeleteProblemAttachment(ctx context.Context, db DBTX, problemID int32, name string) (DeleteProblemAttachmentRow, error)
+	DeleteProblemProgram(ctx context.Context, db DBTX, iD int32, problemID int32) (ProblemProgram, error)
+	DeleteProblemSamples(ctx context.Context, db DBTX, problemID int32) error
+	DeleteProblemTags(ctx context.Context, db DBTX, problemID int32) error
 	DeleteProblemTestCases(ctx context.Context, db DBTX, problemID int32) error
+	DeleteRolePermissions(ctx context.Context, db DBTX, role string) error
+	DeleteStaleLoginFailures(ctx context.Context, db DBTX, lastFailureAt pgtype.Timestamptz) error
+	DeleteStaleTestUploads(ctx context.Context, db DBTX) error
+	DeleteTestUpload(ctx context.Context, db DBTX, id pgtype.UUID) error
+	DeleteTwoFactorChallenge(ctx context.Context, db DBTX, tokenHash []byte) error
+	DeleteUserIdentity(ctx context.Context, db DBTX, userID pgtype.UUID, provider string) (int64, error)
+	DeleteUserPasswordResets(ctx context.Context, db DBTX, userID pgtype.UUID) error
+	DeleteUserRecoveryCodes(ctx context.Context, db DBTX, userID pgtype.UUID) error
+	DeleteUserTOTP(ctx context.Context, db DBTX, userID pgtype.UUID) error
 	DraftProblem(ctx context.Context, db DBTX, id int32) error
+	EnableTOTP(ctx context.Context, db DBTX, step int64, userID pgtype.UUID) (int64, error)
+	FinishProblemVerification(ctx context.Context, db DBTX, arg FinishProblemVerificationParams) error
+	FinishTestGeneration(ctx context.Context, db DBTX, arg FinishTestGenerationParams) error
 	GetAllProblemsSorted(ctx context.Context, db DBTX, limit int32, offset int32) ([]GetAllProblemsSortedRow, error)
-	GetAllPublishedProblemsSorted(ctx context.Context, db DBTX, limit int32, offset int32) ([]Problem, error)
+	GetAllTags(ctx context.Context, db DBTX) ([]string, error)
+	GetAssignment(ctx context.Context, db DBTX, groupID int32, iD int32) (Assignment, error)
+	GetGroup(ctx context.Context, db DBTX, id int32) (Group, error)
+	GetGroupAcceptedSubmissions(ctx context.Context, db DBTX, groupID int32, userID pgtype.UUID) ([]GetGroupAcceptedSubmissionsRow, error)
+	GetGroupByInviteCode(ctx context.Context, db DBTX, inviteCode string) (Group, error)
+	GetGroupMemberRole(ctx context.Context, db DBTX, groupID int32, userID pgtype.UUID) (GroupRole, error)
+	GetLatestAcceptedSubmissions(ctx context.Context, db DBTX, problemID int32) ([]GetLatestAcceptedSubmissionsRow, error)
+	GetLatestProblemVerification(ctx context.Context, db DBTX, problemID int32) (ProblemVerification, error)
+	GetLatestTestGeneration(ctx context.Context, db DBTX, problemID int32) (TestGeneration, error)
+	GetLoginLockedUntil(ctx context.Context, db DBTX, keys []string) (pgtype.Timestamptz, error)
+	GetManualTestCasesByProblemID(ctx context.Context, db DBTX, problemID int32) ([]TestCase, error)
+	GetNextRevisionNumber(ctx context.Context, db DBTX, problemID int32) (int32, error)
+	GetPasswordReset(ctx context.Context, db DBTX, tokenHash []byte) (GetPasswordResetRow, error)
+	GetProblemAttachment(ctx context.Context, db DBTX, problemID int32, name string) (ProblemAttachment, error)
+	GetProblemAttachments(ctx context.Context, db DBTX, problemID int32) ([]GetProblemAttachmentsRow, error)
 	GetProblemByID(ctx context.Context, db DBTX, id int32) (Problem, error)
 	GetProblemForUser(ctx context.Context, db DBTX, arg GetProblemForUserParams) (Problem, error)
-	GetSubmissionForUser(ctx context.Context, db DBTX, userID pgtype.UUID, iD pgtype.UUID) (GetSubmissionForUserRow, error)
+	GetProblemProgram(ctx context.Context, db DBTX, iD int32, problemID int32) (ProblemProgram, error)
+	GetProblemPrograms(ctx context.Context, db DBTX, problemID int32) ([]ProblemProgram, error)
+	GetProblemRevision(ctx context.Context, db DBTX, id int32) (ProblemRevision, error)
+	GetProblemRevisionByNumber(ctx context.Context, db DBTX, problemID int32, revision int32) (ProblemRevision, error)
+	GetProblemRevisionTest(ctx context.Context, db DBTX, revisionID int32, position int32) (ProblemRevisionTest, error)
+	GetProblemRevisionTests(ctx context.Context, db DBTX, revisionID int32) ([]ProblemRevisionTest, error)
+	GetProblemRevisions(ctx context.Context, db DBTX, problemID int32) ([]GetProblemRevisionsRow, error)
+	GetProblemSamples(ctx context.Context, db DBTX, problemID int32) ([]TestCase, error)
+	GetProblemSolutionStats(ctx context.Context, db DBTX, problemID int32) (GetProblemSolutionStatsRow, error)
+	GetProblemSubmission(ctx context.Context, db DBTX, problemID int32, iD pgtype.UUID) (GetProblemSubmissionRow, error)
+	GetProblemTags(ctx context.Context, db DBTX, problemID int32) ([]string, error)
+	GetRecentDuplicateSubmission(ctx context.Context, db DBTX, arg GetRecentDuplicateSubmissionParams) (pgtype.UUID, error)
+	GetRefreshTokenForUpdate(ctx context.Context, db DBTX, tokenHash []byte) (GetRefreshTokenForUpdateRow, error)
+	GetRole(ctx context.Context, db DBTX, name string) (Role, error)
+	GetSubmission(ctx context.Context, db DBTX, id pgtype.UUID) (GetSubmissionRow, error)
+	// samples are judged first, in the order they are shown
 	GetTestCasesByProblemID(ctx context.Context, db DBTX, problemID int32) ([]TestCase, error)
+	GetTestUpload(ctx context.Context, db DBTX, iD pgtype.UUID, uploadedBy pgtype.UUID) (TestUpload, error)
+	GetTwoFactorChallenge(ctx context.Context, db DBTX, tokenHash []byte) (TwoFactorChallenge, error)
 	GetUser(ctx context.Context, db DBTX, id pgtype.UUID) (User, error)
+	GetUserByIdentity(ctx context.Context, db DBTX, provider string, subject string) (User, error)
 	GetUserByUsername(ctx context.Context, db DBTX, username string) (User, error)
+	GetUserPermissions(ctx context.Context, db DBTX, userID pgtype.UUID) ([]string, error)
+	GetUserProblemSubmissions(ctx context.Context, db DBTX, userID pgtype.UUID, problemID int32) ([]GetUserProblemSubmissionsRow, error)
 	GetUserProblemsSorted(ctx context.Context, db DBTX, arg GetUserProblemsSortedParams) ([]Problem, error)
+	GetUserRoles(ctx context.Context, db DBTX, userID pgtype.UUID) ([]string, error)
 	GetUserSubmissions(ctx context.Context, db DBTX, userID pgtype.UUID) ([]GetUserSubmissionsRow, error)
+	GetUserTOTP(ctx context.Context, db DBTX, userID pgtype.UUID) (UserTotp, error)
+	GrantRolePermission(ctx context.Context, db DBTX, role string, permission string) error
+	GrantUserRole(ctx context.Context, db DBTX, userID pgtype.UUID, role string) error
 	IncreaseUserAttempts(ctx context.Context, db DBTX, id pgtype.UUID) error
 	IncreaseUserSolves(ctx context.Context, db DBTX, id pgtype.UUID) error
+	InsertGeneratedTestCase(ctx context.Context, db DBTX, arg InsertGeneratedTestCaseParams) (TestCase, error)
 	InsertProblem(ctx context.Context, db DBTX, arg InsertProblemParams) (Problem, error)
+	InsertProblemProgram(ctx context.Context, db DBTX, arg InsertProblemProgramParams) (ProblemProgram, error)
+	InsertProblemRevision(ctx context.Context, db DBTX, arg InsertProblemRevisionParams) (ProblemRevision, error)
+	InsertProblemRevisionTests(ctx context.Context, db DBTX, arg []InsertProblemRevisionTestsParams) (int64, error)
+	InsertProblemTag(ctx context.Context, db DBTX, problemID int32, tag string) error
+	InsertSampleTestCase(ctx context.Context, db DBTX, arg InsertSampleTestCaseParams) (TestCase, error)
 	InsertTestCase(ctx context.Context, db DBTX, arg InsertTestCaseParams) (TestCase, error)
+	IsTwoFactorRequired(ctx context.Context, db DBTX, userID pgtype.UUID) (bool, error)
+	ListAuditEvents(ctx context.Context, db DBTX, arg ListAuditEventsParams) ([]AuditEvent, error)
+	ListGroupAssignmentProblems(ctx context.Context, db DBTX, groupID int32) ([]ListGroupAssignmentProblemsRow, error)
+	ListGroupAssignments(ctx context.Context, db DBTX, groupID int32) ([]Assignment, error)
+	ListGroupMembers(ctx context.Context, db DBTX, groupID int32) ([]ListGroupMembersRow, error)
+	ListPermissions(ctx context.Context, db DBTX) ([]Permission, error)
+	ListRolePermissions(ctx context.Context, db DBTX) ([]RolePermission, error)
+	ListRoles(ctx context.Context, db DBTX) ([]Role, error)
+	ListUserGroups(ctx context.Context, db DBTX, userID pgtype.UUID) ([]ListUserGroupsRow, error)
+	ListUserIdentities(ctx context.Context, db DBTX, userID pgtype.UUID) ([]UserIdentity, error)
+	ListUserPersonalAccessTokens(ctx context.Context, db DBTX, userID pgtype.UUID) ([]PersonalAccessToken, error)
+	ListUserSessions(ctx context.Context, db DBTX, userID pgtype.UUID) ([]Session, error)
+	LockGroupMembers(ctx context.Context, db DBTX, groupID int32) error
+	LockLogin(ctx context.Context, db DBTX, lockedUntil pgtype.Timestamptz, key string) error
+	LockProblem(ctx context.Context, db DBTX, id int32) (Problem, error)
+	LockRoles(ctx context.Context, db DBTX) error
+	LockUserSubmissions(ctx context.Context, db DBTX, userID pgtype.UUID) error
 	PublishProblem(ctx context.Context, db DBTX, id int32) error
+	RecordIdentityLogin(ctx context.Context, db DBTX, arg RecordIdentityLoginParams) error
+	RecordLoginFailure(ctx context.Context, db DBTX, key string, windowStart pgtype.Timestamptz) (int32, error)
+	RemoveGroupMember(ctx context.Context, db DBTX, groupID int32, userID pgtype.UUID) (int64, error)
+	RestoreTestCase(ctx context.Context, db DBTX, arg RestoreTestCaseParams) error
 	RetrySubmissionDueToInternalError(ctx context.Context, db DBTX, id pgtype.UUID) (Submission, error)
-	ToggleUserSuperLevel(ctx context.Context, db DBTX, id pgtype.UUID) (User, error)
+	RevokeOtherUserSessions(ctx context.Context, db DBTX, userID pgtype.UUID, keepSessionID pgtype.UUID) ([]pgtype.UUID, error)
+	RevokeRolePermission(ctx context.Context, db DBTX, role string, permission string) (int64, error)
+	RevokeSession(ctx context.Context, db DBTX, userID pgtype.UUID, sessionID pgtype.UUID) (int64, error)
+	RevokeUserRole(ctx context.Context, db DBTX, userID pgtype.UUID, role string) (int64, error)
+	RevokeUserSessions(ctx context.Context, db DBTX, userID pgtype.UUID) ([]pgtype.UUID, error)
+	SearchProblems(ctx context.Context, db DBTX, arg SearchProblemsParams) ([]SearchProblemsRow, error)
+	SetGroupInviteCode(ctx context.Context, db DBTX, iD int32, inviteCode string) error
+	SetGroupMemberRole(ctx context.Context, db DBTX, arg SetGroupMemberRoleParams) (int64, error)
+	SetProblemCurrentRevision(ctx context.Context, db DBTX, iD int32, currentRevisionID pgtype.Int4) error
+	SetRoleRequireTwoFactor(ctx context.Context, db DBTX, requireTwoFactor bool, name string) error
+	SetSubmissionFailedTest(ctx context.Context, db DBTX, iD pgtype.UUID, failedTest pgtype.Int4) error
+	SetSubmissionRevision(ctx context.Context, db DBTX, iD pgtype.UUID, revisionID pgtype.Int4) error
+	SetUserPassword(ctx context.Context, db DBTX, passwordHash string, userID pgtype.UUID) error
+	SetUserSubmissionRateLimit(ctx context.Context, db DBTX, arg SetUserSubmissionRateLimitParams) (User, error)
+	StartTOTPSetup(ctx context.Context, db DBTX, userID pgtype.UUID, secret string) error
+	TouchSession(ctx context.Context, db DBTX, id pgtype.UUID) (Session, error)
+	UpdateAssignment(ctx context.Context, db DBTX, arg UpdateAssignmentParams) (Assignment, error)
 	UpdateProblem(ctx context.Context, db DBTX, arg UpdateProblemParams) (Problem, error)
+	UpdateProblemDifficulty(ctx context.Context, db DBTX, iD int32, difficulty pgtype.Int4) error
+	UpdateProblemProgramSource(ctx context.Context, db DBTX, arg UpdateProblemProgramSourceParams) (ProblemProgram, error)
+	UpdateProblemSubmissionSettings(ctx context.Context, db DBTX, arg UpdateProblemSubmissionSettingsParams) error
 	UpdateSubmissionStatus(ctx context.Context, db DBTX, arg UpdateSubmissionStatusParams) (Submission, error)
+	UpdateSubmissionStatusWithFeedback(ctx context.Context, db DBTX, arg UpdateSubmissionStatusWithFeedbackParams) (Submission, error)
+	UpdateTestCaseInput(ctx context.Context, db DBTX, arg UpdateTestCaseInputParams) error
+	UpdateTestCaseOutput(ctx context.Context, db DBTX, arg UpdateTestCaseOutputParams) error
+	UpsertProblemAttachment(ctx context.Context, db DBTX, arg UpsertProblemAttachmentParams) error
+	UsePasswordReset(ctx context.Context, db DBTX, tokenHash []byte) (int64, error)
+	UsePersonalAccessToken(ctx context.Context, db DBTX, tokenHash []byte) (PersonalAccessToken, error)
+	UseRecoveryCode(ctx context.Context, db DBTX, userID pgtype.UUID, codeHash []byte) (int64, error)
+	UseRefreshToken(ctx context.Context, db DBTX, tokenHash []byte) error
+	UseTOTPStep(ctx context.Context, db DBTX, step int64, userID pgtype.UUID) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
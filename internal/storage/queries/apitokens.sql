@@ -0,0 +1,23 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListUserPersonalAccessTokens :many
+SELECT *
+FROM personal_access_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeletePersonalAccessToken :execrows
+DELETE
+FROM personal_access_tokens
+WHERE user_id = sqlc.arg(user_id)
+  AND id = sqlc.arg(token_id);
+
+-- name: UsePersonalAccessToken :one
+UPDATE personal_access_tokens
+SET last_used_at = NOW()
+WHERE token_hash = $1
+  AND expires_at > NOW()
+RETURNING *;
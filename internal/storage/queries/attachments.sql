@@ -0,0 +1,29 @@
+-- name: UpsertProblemAttachment :exec
+INSERT INTO problem_attachments (problem_id, name, content_type, data, uploaded_by)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (problem_id, name) DO UPDATE
+SET content_type = excluded.content_type,
+    data = excluded.data,
+    uploaded_by = excluded.uploaded_by,
+    created_at = now();
+
+-- name: GetProblemAttachment :one
+SELECT *
+FROM problem_attachments
+WHERE problem_id = $1 AND name = $2;
+
+-- name: GetProblemAttachments :many
+SELECT id, name, content_type, octet_length(data)::BIGINT AS size, created_at
+FROM problem_attachments
+WHERE problem_id = $1
+ORDER BY name;
+
+-- name: CountProblemAttachments :one
+SELECT COUNT(*)
+FROM problem_attachments
+WHERE problem_id = $1;
+
+-- name: DeleteProblemAttachment :one
+DELETE FROM problem_attachments
+WHERE problem_id = $1 AND name = $2
+RETURNING name, content_type, OCTET_LENGTH(data) AS size;
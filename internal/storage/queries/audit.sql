@@ -0,0 +1,16 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (actor_id, actor_name, action, target_type, target_id, before, after, request_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ListAuditEvents :many
+SELECT *
+FROM audit_events
+WHERE (sqlc.narg(actor)::TEXT IS NULL OR actor_name = sqlc.narg(actor))
+  AND (sqlc.narg(action)::TEXT IS NULL OR action = sqlc.narg(action) OR starts_with(action, sqlc.narg(action) || '.'))
+  AND (sqlc.narg(target_type)::TEXT IS NULL OR target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::TEXT IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(since)::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg(until))
+  AND (sqlc.narg(before_id)::BIGINT IS NULL OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(max_events);
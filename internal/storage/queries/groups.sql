@@ -0,0 +1,131 @@
+-- name: CreateGroup :one
+INSERT INTO groups (name, description, invite_code, created_by)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetGroup :one
+SELECT *
+FROM groups
+WHERE id = $1;
+
+-- name: GetGroupByInviteCode :one
+SELECT *
+FROM groups
+WHERE invite_code = $1;
+
+-- name: SetGroupInviteCode :exec
+UPDATE groups
+SET invite_code = $2
+WHERE id = $1;
+
+-- name: ListUserGroups :many
+SELECT sqlc.embed(groups),
+       group_members.role,
+       (SELECT COUNT(*) FROM group_members AS members WHERE members.group_id = groups.id) AS member_count
+FROM groups
+         JOIN group_members ON group_members.group_id = groups.id
+WHERE group_members.user_id = $1
+ORDER BY groups.created_at DESC;
+
+-- name: AddGroupMember :exec
+INSERT INTO group_members (group_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: GetGroupMemberRole :one
+SELECT role
+FROM group_members
+WHERE group_id = $1
+  AND user_id = $2;
+
+-- name: ListGroupMembers :many
+SELECT users.id, users.username, group_members.role, group_members.joined_at
+FROM group_members
+         JOIN users ON users.id = group_members.user_id
+WHERE group_members.group_id = $1
+ORDER BY group_members.role, users.username;
+
+-- name: SetGroupMemberRole :execrows
+UPDATE group_members
+SET role = $3
+WHERE group_id = $1
+  AND user_id = $2;
+
+-- name: RemoveGroupMember :execrows
+DELETE
+FROM group_members
+WHERE group_id = $1
+  AND user_id = $2;
+
+-- name: CountGroupOwners :one
+SELECT COUNT(*)
+FROM group_members
+WHERE group_id = $1
+  AND role = 'OWNER';
+
+-- name: LockGroupMembers :exec
+SELECT pg_advisory_xact_lock(hashtextextended('group_members', sqlc.arg(group_id)::INT));
+
+-- name: CreateAssignment :one
+INSERT INTO assignments (group_id, title, opens_at, closes_at, late_until, late_penalty_percent)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: UpdateAssignment :one
+UPDATE assignments
+SET title                = $3,
+    opens_at             = $4,
+    closes_at            = $5,
+    late_until           = $6,
+    late_penalty_percent = $7
+WHERE group_id = $1
+  AND id = $2
+RETURNING *;
+
+-- name: DeleteAssignment :execrows
+DELETE
+FROM assignments
+WHERE group_id = $1
+  AND id = $2;
+
+-- name: GetAssignment :one
+SELECT *
+FROM assignments
+WHERE group_id = $1
+  AND id = $2;
+
+-- name: ListGroupAssignments :many
+SELECT *
+FROM assignments
+WHERE group_id = $1
+ORDER BY opens_at, id;
+
+-- name: DeleteAssignmentProblems :exec
+DELETE
+FROM assignment_problems
+WHERE assignment_id = $1;
+
+-- name: AddAssignmentProblem :exec
+INSERT INTO assignment_problems (assignment_id, problem_id, position)
+VALUES ($1, $2, $3);
+
+-- name: ListGroupAssignmentProblems :many
+SELECT assignment_problems.assignment_id, assignment_problems.problem_id, problems.title
+FROM assignment_problems
+         JOIN assignments ON assignments.id = assignment_problems.assignment_id
+         JOIN problems ON problems.id = assignment_problems.problem_id
+WHERE assignments.group_id = $1
+ORDER BY assignments.opens_at, assignments.id, assignment_problems.position;
+
+-- name: GetGroupAcceptedSubmissions :many
+SELECT submissions.user_id, assignment_problems.assignment_id, submissions.problem_id, submissions.created_at
+FROM assignments
+         JOIN assignment_problems ON assignment_problems.assignment_id = assignments.id
+         JOIN group_members ON group_members.group_id = assignments.group_id
+         JOIN submissions ON submissions.problem_id = assignment_problems.problem_id
+    AND submissions.user_id = group_members.user_id
+WHERE assignments.group_id = sqlc.arg(group_id)
+  AND (sqlc.narg(user_id)::UUID IS NULL OR submissions.user_id = sqlc.narg(user_id))
+  AND submissions.status = 'ACCEPTED'
+  AND submissions.created_at >= assignments.opens_at
+  AND submissions.created_at <= COALESCE(assignments.late_until, assignments.closes_at);
@@ -0,0 +1,29 @@
+-- name: GetUserByIdentity :one
+SELECT users.*
+FROM users
+         JOIN user_identities ON user_identities.user_id = users.id
+WHERE user_identities.provider = $1
+  AND user_identities.subject = $2;
+
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (provider, subject, user_id, email, last_login_at)
+VALUES ($1, $2, $3, $4, NOW());
+
+-- name: RecordIdentityLogin :exec
+UPDATE user_identities
+SET email         = $3,
+    last_login_at = NOW()
+WHERE provider = $1
+  AND subject = $2;
+
+-- name: ListUserIdentities :many
+SELECT *
+FROM user_identities
+WHERE user_id = $1
+ORDER BY provider;
+
+-- name: DeleteUserIdentity :execrows
+DELETE
+FROM user_identities
+WHERE user_id = $1
+  AND provider = $2;
@@ -0,0 +1,30 @@
+-- name: GetLoginLockedUntil :one
+SELECT COALESCE(MAX(locked_until), 'epoch')::TIMESTAMPTZ
+FROM login_failures
+WHERE key = ANY (sqlc.arg(keys)::TEXT[]);
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_failures (key, failures)
+VALUES (sqlc.arg(key), 1)
+ON CONFLICT (key) DO UPDATE
+    SET failures        = CASE
+                              WHEN login_failures.last_failure_at < sqlc.arg(window_start) THEN 1
+                              ELSE login_failures.failures + 1 END,
+        last_failure_at = NOW()
+RETURNING failures;
+
+-- name: LockLogin :exec
+UPDATE login_failures
+SET locked_until = sqlc.arg(locked_until)
+WHERE key = sqlc.arg(key);
+
+-- name: ClearLoginFailures :exec
+DELETE
+FROM login_failures
+WHERE key = $1;
+
+-- name: DeleteStaleLoginFailures :exec
+DELETE
+FROM login_failures
+WHERE last_failure_at < $1
+  AND (locked_until IS NULL OR locked_until < NOW());
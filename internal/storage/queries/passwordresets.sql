@@ -0,0 +1,23 @@
+-- name: CreatePasswordReset :exec
+INSERT INTO password_resets (token_hash, user_id, created_by, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: DeleteUserPasswordResets :exec
+DELETE
+FROM password_resets
+WHERE user_id = $1;
+
+-- name: GetPasswordReset :one
+SELECT sqlc.embed(password_resets), users.username
+FROM password_resets
+         JOIN users ON users.id = password_resets.user_id
+WHERE password_resets.token_hash = $1
+  AND password_resets.used_at IS NULL
+  AND password_resets.expires_at > NOW();
+
+-- name: UsePasswordReset :execrows
+UPDATE password_resets
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW();
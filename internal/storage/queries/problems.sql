@@ -13,14 +13,6 @@ ORDER BY published_at DESC
 LIMIT $1
 OFFSET $2;
 
--- name: GetAllPublishedProblemsSorted :many
-SELECT *
-FROM problems
-WHERE draft = false
-ORDER BY published_at DESC
-LIMIT $1
-OFFSET $2;
-
 -- name: GetProblemByID :one
 SELECT *
 FROM problems
@@ -45,13 +37,11 @@ WHERE id = $1;
 INSERT INTO problems (
     title,
     description,
-    sample_input,
-    sample_output,
     time_limit_ms,
     memory_limit_kb,
     created_by
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: UpdateProblem :one
@@ -59,9 +49,79 @@ UPDATE problems
 SET
     title = $2,
     description = $3,
-    sample_input = $4,
-    sample_output = $5,
-    time_limit_ms = $6,
-    memory_limit_kb = $7
+    time_limit_ms = $4,
+    memory_limit_kb = $5
 WHERE id = $1
 RETURNING *;
+
+-- name: UpdateProblemDifficulty :exec
+UPDATE problems
+SET difficulty = $2
+WHERE id = $1;
+
+-- name: UpdateProblemSubmissionSettings :exec
+UPDATE problems
+SET submission_visibility = $2, reveal_failing_test = $3
+WHERE id = $1;
+
+-- name: SearchProblems :many
+SELECT
+    problems.id,
+    problems.title,
+    problems.time_limit_ms,
+    problems.memory_limit_kb,
+    problems.difficulty,
+    problems.published_at,
+    users.username AS author_name,
+    ARRAY(SELECT tag FROM problem_tags WHERE problem_tags.problem_id = problems.id ORDER BY tag)::TEXT[] AS tags,
+    stats.solved_count,
+    stats.submission_count,
+    stats.accepted_count,
+    COALESCE(stats.accepted_count::FLOAT8 / NULLIF(stats.submission_count, 0), 0)::FLOAT8 AS acceptance_rate,
+    (sqlc.narg(viewer_id)::UUID IS NOT NULL AND EXISTS (
+        SELECT 1 FROM submissions
+        WHERE submissions.problem_id = problems.id AND submissions.user_id = sqlc.narg(viewer_id)
+            AND submissions.status = 'ACCEPTED'
+    ))::BOOLEAN AS solved
+FROM problems
+    INNER JOIN users ON problems.created_by = users.id
+    CROSS JOIN LATERAL (
+        SELECT
+            COUNT(DISTINCT submissions.user_id) FILTER (WHERE submissions.status = 'ACCEPTED') AS solved_count,
+            COUNT(*) FILTER (WHERE submissions.status NOT IN ('IN_QUEUE', 'PENDING', 'RUNNING', 'INTERNAL_ERROR')) AS submission_count,
+            COUNT(*) FILTER (WHERE submissions.status = 'ACCEPTED') AS accepted_count
+        FROM submissions
+        WHERE submissions.problem_id = problems.id
+    ) AS stats
+WHERE problems.draft = FALSE
+    AND (sqlc.narg(query)::TEXT IS NULL
+        OR to_tsvector('simple', problems.title || ' ' || problems.description) @@ websearch_to_tsquery('simple', sqlc.narg(query)))
+    AND (sqlc.narg(tag)::TEXT IS NULL
+        OR EXISTS (SELECT 1 FROM problem_tags WHERE problem_tags.problem_id = problems.id AND problem_tags.tag = sqlc.narg(tag)))
+    AND (sqlc.narg(min_difficulty)::INTEGER IS NULL OR problems.difficulty >= sqlc.narg(min_difficulty))
+    AND (sqlc.narg(max_difficulty)::INTEGER IS NULL OR problems.difficulty <= sqlc.narg(max_difficulty))
+    AND (sqlc.narg(author)::TEXT IS NULL OR users.username = sqlc.narg(author))
+    AND (sqlc.narg(solved)::BOOLEAN IS NULL OR sqlc.narg(solved) = EXISTS (
+        SELECT 1 FROM submissions
+        WHERE submissions.problem_id = problems.id AND submissions.user_id = sqlc.narg(viewer_id)
+            AND submissions.status = 'ACCEPTED'
+    ))
+ORDER BY
+    CASE WHEN sqlc.arg(sort)::TEXT = 'relevance' AND sqlc.narg(query)::TEXT IS NOT NULL
+        THEN ts_rank(to_tsvector('simple', problems.title || ' ' || problems.description), websearch_to_tsquery('simple', sqlc.narg(query)))
+    END DESC NULLS LAST,
+    CASE WHEN sqlc.arg(sort)::TEXT = 'popularity' THEN stats.solved_count END DESC NULLS LAST,
+    CASE WHEN sqlc.arg(sort)::TEXT = 'acceptance'
+        THEN stats.accepted_count::FLOAT8 / NULLIF(stats.submission_count, 0)
+    END DESC NULLS LAST,
+    CASE WHEN sqlc.arg(sort)::TEXT = 'difficulty' THEN problems.difficulty END ASC NULLS LAST,
+    problems.published_at DESC,
+    problems.id DESC
+LIMIT sqlc.arg(page_limit)
+OFFSET sqlc.arg(page_offset);
+
+-- name: GetAllTags :many
+SELECT DISTINCT problem_tags.tag
+FROM problem_tags INNER JOIN problems ON problem_tags.problem_id = problems.id
+WHERE problems.draft = FALSE
+ORDER BY problem_tags.tag;
@@ -0,0 +1,26 @@
+-- name: InsertProblemProgram :one
+INSERT INTO problem_programs (problem_id, kind, name, source, expected_status, main)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: UpdateProblemProgramSource :one
+UPDATE problem_programs
+SET source = $3
+WHERE id = $1 AND problem_id = $2
+RETURNING *;
+
+-- name: DeleteProblemProgram :one
+DELETE FROM problem_programs
+WHERE id = $1 AND problem_id = $2
+RETURNING *;
+
+-- name: GetProblemProgram :one
+SELECT *
+FROM problem_programs
+WHERE id = $1 AND problem_id = $2;
+
+-- name: GetProblemPrograms :many
+SELECT *
+FROM problem_programs
+WHERE problem_id = $1
+ORDER BY kind, name;
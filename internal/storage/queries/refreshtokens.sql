@@ -0,0 +1,15 @@
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (token_hash, session_id)
+VALUES ($1, $2);
+
+-- name: GetRefreshTokenForUpdate :one
+SELECT sqlc.embed(refresh_tokens), sqlc.embed(sessions)
+FROM refresh_tokens
+         JOIN sessions ON sessions.id = refresh_tokens.session_id
+WHERE refresh_tokens.token_hash = $1
+    FOR UPDATE OF refresh_tokens;
+
+-- name: UseRefreshToken :exec
+UPDATE refresh_tokens
+SET used_at = NOW()
+WHERE token_hash = $1;
@@ -0,0 +1,72 @@
+-- name: LockProblem :one
+SELECT *
+FROM problems
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetNextRevisionNumber :one
+SELECT (COALESCE(MAX(revision), 0) + 1)::INTEGER
+FROM problem_revisions
+WHERE problem_id = $1;
+
+-- name: InsertProblemRevision :one
+INSERT INTO problem_revisions (
+    problem_id,
+    revision,
+    title,
+    description,
+    time_limit_ms,
+    memory_limit_kb,
+    content_hash,
+    created_by
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: InsertProblemRevisionTests :copyfrom
+INSERT INTO problem_revision_tests (revision_id, position, input, output, generator_id, generator_args, is_sample,
+    explanation)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: SetProblemCurrentRevision :exec
+UPDATE problems
+SET current_revision_id = $2
+WHERE id = $1;
+
+-- name: GetProblemRevision :one
+SELECT *
+FROM problem_revisions
+WHERE id = $1;
+
+-- name: GetProblemRevisionByNumber :one
+SELECT *
+FROM problem_revisions
+WHERE problem_id = $1 AND revision = $2;
+
+-- name: GetProblemRevisions :many
+SELECT
+    sqlc.embed(problem_revisions),
+    users.username AS author_name,
+    (SELECT COUNT(*) FROM problem_revision_tests WHERE problem_revision_tests.revision_id = problem_revisions.id) AS test_count
+FROM problem_revisions LEFT JOIN users ON problem_revisions.created_by = users.id
+WHERE problem_revisions.problem_id = $1
+ORDER BY problem_revisions.revision DESC;
+
+-- name: GetProblemRevisionTest :one
+SELECT *
+FROM problem_revision_tests
+WHERE revision_id = $1 AND position = $2;
+
+-- name: GetProblemRevisionTests :many
+SELECT *
+FROM problem_revision_tests
+WHERE revision_id = $1
+ORDER BY position;
+
+-- name: DeleteAllProblemTestCases :exec
+DELETE FROM test_cases
+WHERE problem_id = $1;
+
+-- name: RestoreTestCase :exec
+INSERT INTO test_cases (problem_id, input, output, generator_id, generator_args, is_sample, explanation)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
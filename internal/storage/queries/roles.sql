@@ -0,0 +1,88 @@
+-- name: ListRoles :many
+SELECT *
+FROM roles
+ORDER BY name;
+
+-- name: ListPermissions :many
+SELECT *
+FROM permissions
+ORDER BY name;
+
+-- name: ListRolePermissions :many
+SELECT *
+FROM role_permissions
+ORDER BY role, permission;
+
+-- name: GetUserRoles :many
+SELECT role
+FROM user_roles
+WHERE user_id = $1
+ORDER BY role;
+
+-- name: GetUserPermissions :many
+SELECT DISTINCT role_permissions.permission
+FROM user_roles
+         JOIN roles ON roles.name = user_roles.role
+         JOIN role_permissions ON role_permissions.role = user_roles.role
+WHERE user_roles.user_id = $1
+  AND (NOT roles.require_two_factor OR EXISTS (SELECT 1
+                                               FROM user_totp
+                                               WHERE user_totp.user_id = user_roles.user_id
+                                                 AND user_totp.enabled_at IS NOT NULL))
+ORDER BY role_permissions.permission;
+
+-- name: GrantUserRole :exec
+INSERT INTO user_roles (user_id, role)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RevokeUserRole :execrows
+DELETE
+FROM user_roles
+WHERE user_id = $1
+  AND role = $2;
+
+-- name: GrantRolePermission :exec
+INSERT INTO role_permissions (role, permission)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RevokeRolePermission :execrows
+DELETE
+FROM role_permissions
+WHERE role = $1
+  AND permission = $2;
+
+-- name: CountUsersWithPermission :one
+SELECT COUNT(DISTINCT user_roles.user_id)
+FROM user_roles
+         JOIN roles ON roles.name = user_roles.role
+         JOIN role_permissions ON role_permissions.role = user_roles.role
+WHERE role_permissions.permission = $1
+  AND (NOT roles.require_two_factor OR EXISTS (SELECT 1
+                                               FROM user_totp
+                                               WHERE user_totp.user_id = user_roles.user_id
+                                                 AND user_totp.enabled_at IS NOT NULL));
+
+-- name: LockRoles :exec
+LOCK TABLE roles, user_roles, role_permissions IN SHARE ROW EXCLUSIVE MODE;
+
+-- name: GetRole :one
+SELECT *
+FROM roles
+WHERE name = $1;
+
+-- name: CreateRole :one
+INSERT INTO roles (name, description)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: DeleteRolePermissions :exec
+DELETE
+FROM role_permissions
+WHERE role = $1;
+
+-- name: SetRoleRequireTwoFactor :exec
+UPDATE roles
+SET require_two_factor = sqlc.arg(require_two_factor)
+WHERE name = sqlc.arg(name);
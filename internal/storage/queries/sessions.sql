@@ -0,0 +1,47 @@
+-- name: CreateSession :exec
+INSERT INTO sessions (id, user_id, user_agent, ip_address, expires_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: TouchSession :one
+UPDATE sessions
+SET last_seen_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListUserSessions :many
+SELECT *
+FROM sessions
+WHERE user_id = $1
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+ORDER BY last_seen_at DESC;
+
+-- name: RevokeSession :execrows
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE user_id = sqlc.arg(user_id)
+  AND id = sqlc.arg(session_id)
+  AND revoked_at IS NULL;
+
+-- name: RevokeUserSessions :many
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE user_id = $1
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+RETURNING id;
+
+-- name: DeleteExpiredUserSessions :exec
+DELETE
+FROM sessions
+WHERE user_id = $1
+  AND expires_at < NOW();
+
+-- name: RevokeOtherUserSessions :many
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE user_id = sqlc.arg(user_id)
+  AND id <> sqlc.arg(keep_session_id)
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+RETURNING id;
@@ -1,14 +1,52 @@
 -- name: CreateSubmission :one
-INSERT INTO submissions (problem_id, user_id, solution_code)
-VALUES ($1, $2, $3)
+INSERT INTO submissions (problem_id, user_id, solution_code, code_hash)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
+-- name: LockUserSubmissions :exec
+SELECT pg_advisory_xact_lock(hashtextextended(sqlc.arg(user_id)::UUID::TEXT, 0));
+
+-- name: GetRecentDuplicateSubmission :one
+SELECT id
+FROM submissions
+WHERE user_id = $1
+  AND problem_id = $2
+  AND code_hash = $3
+  AND created_at > sqlc.arg(since)
+  AND status != 'INTERNAL_ERROR'
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: GetProblemSolutionStats :one
+SELECT
+    count(*) AS submissions,
+    count(DISTINCT code_hash) AS distinct_solutions,
+    count(DISTINCT code_hash) FILTER (WHERE status = 'ACCEPTED') AS distinct_accepted
+FROM submissions
+WHERE problem_id = $1;
+
+-- name: SetSubmissionRevision :exec
+UPDATE submissions
+SET revision_id = $2
+WHERE id = $1;
+
 -- name: UpdateSubmissionStatus :one
 UPDATE submissions
 SET status = $2, message = $3
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateSubmissionStatusWithFeedback :one
+UPDATE submissions
+SET status = $2, message = $3, feedback = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: SetSubmissionFailedTest :exec
+UPDATE submissions
+SET failed_test = $2
+WHERE id = $1;
+
 -- name: RetrySubmissionDueToInternalError :one
 UPDATE submissions
 SET retries = retries + 1
@@ -23,9 +61,38 @@ FROM submissions INNER JOIN problems ON submissions.problem_id = problems.id
 WHERE submissions.user_id = $1
 ORDER BY submissions.created_at DESC;
 
--- name: GetSubmissionForUser :one
+-- name: GetSubmission :one
 SELECT
     problems.title AS problem_name,
+    problem_revisions.revision AS problem_revision,
+    problems.created_by AS problem_author,
+    problems.submission_visibility,
+    problems.reveal_failing_test,
+    users.username,
     sqlc.embed(submissions)
-FROM submissions INNER JOIN problems ON submissions.problem_id = problems.id
-WHERE submissions.user_id = $1 AND submissions.id = $2;
+FROM submissions
+    INNER JOIN problems ON submissions.problem_id = problems.id
+    INNER JOIN users ON submissions.user_id = users.id
+    LEFT JOIN problem_revisions ON submissions.revision_id = problem_revisions.id
+WHERE submissions.id = $1;
+
+-- name: GetUserProblemSubmissions :many
+SELECT id, status, created_at
+FROM submissions
+WHERE user_id = $1 AND problem_id = $2
+ORDER BY created_at DESC;
+
+-- name: GetLatestAcceptedSubmissions :many
+SELECT DISTINCT ON (submissions.user_id)
+    users.username,
+    sqlc.embed(submissions)
+FROM submissions INNER JOIN users ON submissions.user_id = users.id
+WHERE submissions.problem_id = $1 AND submissions.status = 'ACCEPTED'
+ORDER BY submissions.user_id, submissions.created_at DESC;
+
+-- name: GetProblemSubmission :one
+SELECT
+    users.username,
+    sqlc.embed(submissions)
+FROM submissions INNER JOIN users ON submissions.user_id = users.id
+WHERE submissions.problem_id = $1 AND submissions.id = $2;
@@ -0,0 +1,14 @@
+-- name: InsertProblemTag :exec
+INSERT INTO problem_tags (problem_id, tag)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteProblemTags :exec
+DELETE FROM problem_tags
+WHERE problem_id = $1;
+
+-- name: GetProblemTags :many
+SELECT tag
+FROM problem_tags
+WHERE problem_id = $1
+ORDER BY tag;
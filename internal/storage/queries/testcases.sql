@@ -3,12 +3,54 @@ INSERT INTO test_cases (problem_id, input, output)
 VALUES ($1, $2, $3)
 RETURNING *;
 
+-- name: InsertSampleTestCase :one
+INSERT INTO test_cases (problem_id, input, output, explanation, is_sample)
+VALUES ($1, $2, $3, $4, TRUE)
+RETURNING *;
+
 -- name: DeleteProblemTestCases :exec
 DELETE FROM test_cases
-WHERE problem_id = $1;
+WHERE problem_id = $1 AND generator_id IS NULL AND NOT is_sample;
+
+-- name: DeleteProblemSamples :exec
+DELETE FROM test_cases
+WHERE problem_id = $1 AND is_sample;
 
 -- name: GetTestCasesByProblemID :many
+-- samples are judged first, in the order they are shown
+SELECT *
+FROM test_cases
+WHERE problem_id = $1
+ORDER BY is_sample DESC, id;
+
+-- name: GetManualTestCasesByProblemID :many
+SELECT *
+FROM test_cases
+WHERE problem_id = $1 AND generator_id IS NULL AND NOT is_sample
+ORDER BY id;
+
+-- name: GetProblemSamples :many
 SELECT *
 FROM test_cases
-WHERE problem_id = $1;
+WHERE problem_id = $1 AND is_sample
+ORDER BY id;
+
+-- name: InsertGeneratedTestCase :one
+INSERT INTO test_cases (problem_id, input, output, generator_id, generator_args)
+VALUES ($1, '', '', $2, $3)
+RETURNING *;
+
+-- name: DeleteGeneratedTestCase :one
+DELETE FROM test_cases
+WHERE id = $1 AND problem_id = $2 AND generator_id IS NOT NULL
+RETURNING *;
+
+-- name: UpdateTestCaseInput :exec
+UPDATE test_cases
+SET input = $2, input_key = $3, output = '', output_key = NULL
+WHERE id = $1;
 
+-- name: UpdateTestCaseOutput :exec
+UPDATE test_cases
+SET output = $2, output_key = $3
+WHERE id = $1;
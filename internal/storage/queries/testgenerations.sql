@@ -0,0 +1,16 @@
+-- name: CreateTestGeneration :one
+INSERT INTO test_generations (problem_id)
+VALUES ($1)
+RETURNING *;
+
+-- name: FinishTestGeneration :exec
+UPDATE test_generations
+SET status = $2, report = $3, finished_at = now()
+WHERE id = $1;
+
+-- name: GetLatestTestGeneration :one
+SELECT *
+FROM test_generations
+WHERE problem_id = $1
+ORDER BY started_at DESC
+LIMIT 1;
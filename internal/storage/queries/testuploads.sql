@@ -0,0 +1,17 @@
+-- name: CreateTestUpload :one
+INSERT INTO test_uploads (problem_id, uploaded_by, archive, input_pattern, output_pattern)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetTestUpload :one
+SELECT *
+FROM test_uploads
+WHERE id = $1 AND uploaded_by = $2;
+
+-- name: DeleteTestUpload :exec
+DELETE FROM test_uploads
+WHERE id = $1;
+
+-- name: DeleteStaleTestUploads :exec
+DELETE FROM test_uploads
+WHERE created_at < now() - INTERVAL '1 day';
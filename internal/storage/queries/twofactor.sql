@@ -0,0 +1,80 @@
+-- name: GetUserTOTP :one
+SELECT *
+FROM user_totp
+WHERE user_id = $1;
+
+-- name: StartTOTPSetup :exec
+INSERT INTO user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+    SET secret     = EXCLUDED.secret,
+        created_at = NOW()
+WHERE user_totp.enabled_at IS NULL;
+
+-- name: EnableTOTP :execrows
+UPDATE user_totp
+SET enabled_at     = NOW(),
+    last_used_step = sqlc.arg(step)
+WHERE user_id = sqlc.arg(user_id)
+  AND enabled_at IS NULL;
+
+-- name: UseTOTPStep :execrows
+UPDATE user_totp
+SET last_used_step = sqlc.arg(step)
+WHERE user_id = sqlc.arg(user_id)
+  AND enabled_at IS NOT NULL
+  AND last_used_step < sqlc.arg(step);
+
+-- name: DeleteUserTOTP :exec
+DELETE
+FROM user_totp
+WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: DeleteUserRecoveryCodes :exec
+DELETE
+FROM recovery_codes
+WHERE user_id = $1;
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = sqlc.arg(user_id)
+  AND code_hash = sqlc.arg(code_hash)
+  AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*)
+FROM recovery_codes
+WHERE user_id = $1
+  AND used_at IS NULL;
+
+-- name: CreateTwoFactorChallenge :exec
+INSERT INTO two_factor_challenges (token_hash, user_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: GetTwoFactorChallenge :one
+SELECT *
+FROM two_factor_challenges
+WHERE token_hash = $1
+  AND expires_at > NOW();
+
+-- name: DeleteTwoFactorChallenge :exec
+DELETE
+FROM two_factor_challenges
+WHERE token_hash = $1;
+
+-- name: DeleteExpiredTwoFactorChallenges :exec
+DELETE
+FROM two_factor_challenges
+WHERE expires_at < NOW();
+
+-- name: IsTwoFactorRequired :one
+SELECT EXISTS (SELECT 1
+               FROM user_roles
+                        JOIN roles ON roles.name = user_roles.role
+               WHERE user_roles.user_id = $1
+                 AND roles.require_two_factor);
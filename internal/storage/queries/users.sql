@@ -9,19 +9,8 @@ FROM users
 WHERE username = $1;
 
 -- name: CreateUser :one
-INSERT INTO users (username, password_hash, superuser)
-VALUES ($1, $2, false)
-RETURNING id, username, password_hash, superuser;
-
--- name: CreateAdmin :one
-INSERT INTO users (username, password_hash, superuser)
-VALUES ($1, $2, true)
-RETURNING id, username, password_hash, superuser;
-
--- name: ToggleUserSuperLevel :one
-UPDATE users
-SET superuser = NOT superuser
-WHERE id = $1
+INSERT INTO users (username, password_hash)
+VALUES ($1, $2)
 RETURNING *;
 
 -- name: IncreaseUserAttempts :exec
@@ -33,3 +22,15 @@ WHERE id = $1;
 UPDATE users
 SET problems_solved = problems_solved + 1
 WHERE id = $1;
+
+-- name: SetUserSubmissionRateLimit :one
+UPDATE users
+SET submission_rate_per_minute = sqlc.narg(per_minute),
+    submission_rate_burst      = sqlc.narg(burst)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: SetUserPassword :exec
+UPDATE users
+SET password_hash = sqlc.arg(password_hash)
+WHERE id = sqlc.arg(user_id);
@@ -0,0 +1,16 @@
+-- name: CreateProblemVerification :one
+INSERT INTO problem_verifications (problem_id, fingerprint)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: FinishProblemVerification :exec
+UPDATE problem_verifications
+SET status = $2, report = $3, suggested_time_limit_ms = $4, finished_at = now()
+WHERE id = $1;
+
+-- name: GetLatestProblemVerification :one
+SELECT *
+FROM problem_verifications
+WHERE problem_id = $1
+ORDER BY started_at DESC
+LIMIT 1;
@@ -0,0 +1,66 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: refreshtokens.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createRefreshToken = `-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (token_hash, session_id)
+VALUES ($1, $2)
+`
+
+func (q *Queries) CreateRefreshToken(ctx context.Context, db DBTX, tokenHash []byte, sessionID pgtype.UUID) error {
+	_, err := db.Exec(ctx, createRefreshToken, tokenHash, sessionID)
+	return err
+}
+
+const getRefreshTokenForUpdate = `-- name: GetRefreshTokenForUpdate :one
+SELECT refresh_tokens.token_hash, refresh_tokens.session_id, refresh_tokens.created_at, refresh_tokens.used_at, sessions.id, sessions.user_id, sessions.user_agent, sessions.ip_address, sessions.created_at, sessions.last_seen_at, sessions.expires_at, sessions.revoked_at
+FROM refresh_tokens
+         JOIN sessions ON sessions.id = refresh_tokens.session_id
+WHERE refresh_tokens.token_hash = $1
+    FOR UPDATE OF refresh_tokens
+`
+
+type GetRefreshTokenForUpdateRow struct {
+	RefreshToken RefreshToken `db:"refresh_token" json:"refresh_token"`
+	Session      Session      `db:"session" json:"session"`
+}
+
+func (q *Queries) GetRefreshTokenForUpdate(ctx context.Context, db DBTX, tokenHash []byte) (GetRefreshTokenForUpdateRow, error) {
+	row := db.QueryRow(ctx, getRefreshTokenForUpdate, tokenHash)
+	var i GetRefreshTokenForUpdateRow
+	err := row.Scan(
+		&i.RefreshToken.TokenHash,
+		&i.RefreshToken.SessionID,
+		&i.RefreshToken.CreatedAt,
+		&i.RefreshToken.UsedAt,
+		&i.Session.ID,
+		&i.Session.UserID,
+		&i.Session.UserAgent,
+		&i.Session.IpAddress,
+		&i.Session.CreatedAt,
+		&i.Session.LastSeenAt,
+		&i.Session.ExpiresAt,
+		&i.Session.RevokedAt,
+	)
+	return i, err
+}
+
+const useRefreshToken = `-- name: UseRefreshToken :exec
+UPDATE refresh_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+`
+
+func (q *Queries) UseRefreshToken(ctx context.Context, db DBTX, tokenHash []byte) error {
+	_, err := db.Exec(ctx, useRefreshToken, tokenHash)
+	return err
+}
@@ -0,0 +1,321 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: revisions.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteAllProblemTestCases = `-- name: DeleteAllProblemTestCases :exec
+DELETE FROM test_cases
+WHERE problem_id = $1
+`
+
+func (q *Queries) DeleteAllProblemTestCases(ctx context.Context, db DBTX, problemID int32) error {
+	_, err := db.Exec(ctx, deleteAllProblemTestCases, problemID)
+	return err
+}
+
+const getNextRevisionNumber = `-- name: GetNextRevisionNumber :one
+SELECT (COALESCE(MAX(revision), 0) + 1)::INTEGER
+FROM problem_revisions
+WHERE problem_id = $1
+`
+
+func (q *Queries) GetNextRevisionNumber(ctx context.Context, db DBTX, problemID int32) (int32, error) {
+	row := db.QueryRow(ctx, getNextRevisionNumber, problemID)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const getProblemRevision = `-- name: GetProblemRevision :one
+SELECT id, problem_id, revision, title, description, time_limit_ms, memory_limit_kb, content_hash, created_by, created_at
+FROM problem_revisions
+WHERE id = $1
+`
+
+func (q *Queries) GetProblemRevision(ctx context.Context, db DBTX, id int32) (ProblemRevision, error) {
+	row := db.QueryRow(ctx, getProblemRevision, id)
+	var i ProblemRevision
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Revision,
+		&i.Title,
+		&i.Description,
+		&i.TimeLimitMs,
+		&i.MemoryLimitKb,
+		&i.ContentHash,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProblemRevisionByNumber = `-- name: GetProblemRevisionByNumber :one
+SELECT id, problem_id, revision, title, description, time_limit_ms, memory_limit_kb, content_hash, created_by, created_at
+FROM problem_revisions
+WHERE problem_id = $1 AND revision = $2
+`
+
+func (q *Queries) GetProblemRevisionByNumber(ctx context.Context, db DBTX, problemID int32, revision int32) (ProblemRevision, error) {
+	row := db.QueryRow(ctx, getProblemRevisionByNumber, problemID, revision)
+	var i ProblemRevision
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Revision,
+		&i.Title,
+		&i.Description,
+		&i.TimeLimitMs,
+		&i.MemoryLimitKb,
+		&i.ContentHash,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProblemRevisionTest = `-- name: GetProblemRevisionTest :one
+SELECT revision_id, position, input, output, generator_id, generator_args, is_sample, explanation
+FROM problem_revision_tests
+WHERE revision_id = $1 AND position = $2
+`
+
+func (q *Queries) GetProblemRevisionTest(ctx context.Context, db DBTX, revisionID int32, position int32) (ProblemRevisionTest, error) {
+	row := db.QueryRow(ctx, getProblemRevisionTest, revisionID, position)
+	var i ProblemRevisionTest
+	err := row.Scan(
+		&i.RevisionID,
+		&i.Position,
+		&i.Input,
+		&i.Output,
+		&i.GeneratorID,
+		&i.GeneratorArgs,
+		&i.IsSample,
+		&i.Explanation,
+	)
+	return i, err
+}
+
+const getProblemRevisionTests = `-- name: GetProblemRevisionTests :many
+SELECT revision_id, position, input, output, generator_id, generator_args, is_sample, explanation
+FROM problem_revision_tests
+WHERE revision_id = $1
+ORDER BY position
+`
+
+func (q *Queries) GetProblemRevisionTests(ctx context.Context, db DBTX, revisionID int32) ([]ProblemRevisionTest, error) {
+	rows, err := db.Query(ctx, getProblemRevisionTests, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProblemRevisionTest
+	for rows.Next() {
+		var i ProblemRevisionTest
+		if err := rows.Scan(
+			&i.RevisionID,
+			&i.Position,
+			&i.Input,
+			&i.Output,
+			&i.GeneratorID,
+			&i.GeneratorArgs,
+			&i.IsSample,
+			&i.Explanation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProblemRevisions = `-- name: GetProblemRevisions :many
+SELECT
+    problem_revisions.id, problem_revisions.problem_id, problem_revisions.revision, problem_revisions.title, problem_revisions.description, problem_revisions.time_limit_ms, problem_revisions.memory_limit_kb, problem_revisions.content_hash, problem_revisions.created_by, problem_revisions.created_at,
+    users.username AS author_name,
+    (SELECT COUNT(*) FROM problem_revision_tests WHERE problem_revision_tests.revision_id = problem_revisions.id) AS test_count
+FROM problem_revisions LEFT JOIN users ON problem_revisions.created_by = users.id
+WHERE problem_revisions.problem_id = $1
+ORDER BY problem_revisions.revision DESC
+`
+
+type GetProblemRevisionsRow struct {
+	ProblemRevision ProblemRevision `db:"problem_revision" json:"problem_revision"`
+	AuthorName      pgtype.Text     `db:"author_name" json:"author_name"`
+	TestCount       int64           `db:"test_count" json:"test_count"`
+}
+
+func (q *Queries) GetProblemRevisions(ctx context.Context, db DBTX, problemID int32) ([]GetProblemRevisionsRow, error) {
+	rows, err := db.Query(ctx, getProblemRevisions, problemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetProblemRevisionsRow
+	for rows.Next() {
+		var i GetProblemRevisionsRow
+		if err := rows.Scan(
+			&i.ProblemRevision.ID,
+			&i.ProblemRevision.ProblemID,
+			&i.ProblemRevision.Revision,
+			&i.ProblemRevision.Title,
+			&i.ProblemRevision.Description,
+			&i.ProblemRevision.TimeLimitMs,
+			&i.ProblemRevision.MemoryLimitKb,
+			&i.ProblemRevision.ContentHash,
+			&i.ProblemRevision.CreatedBy,
+			&i.ProblemRevision.CreatedAt,
+			&i.AuthorName,
+			&i.TestCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertProblemRevision = `-- name: InsertProblemRevision :one
+INSERT INTO problem_revisions (
+    problem_id,
+    revision,
+    title,
+    description,
+    time_limit_ms,
+    memory_limit_kb,
+    content_hash,
+    created_by
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, problem_id, revision, title, description, time_limit_ms, memory_limit_kb, content_hash, created_by, created_at
+`
+
+type InsertProblemRevisionParams struct {
+	ProblemID     int32       `db:"problem_id" json:"problem_id"`
+	Revision      int32       `db:"revision" json:"revision"`
+	Title         string      `db:"title" json:"title"`
+	Description   string      `db:"description" json:"description"`
+	TimeLimitMs   int64       `db:"time_limit_ms" json:"time_limit_ms"`
+	MemoryLimitKb int64       `db:"memory_limit_kb" json:"memory_limit_kb"`
+	ContentHash   string      `db:"content_hash" json:"content_hash"`
+	CreatedBy     pgtype.UUID `db:"created_by" json:"created_by"`
+}
+
+func (q *Queries) InsertProblemRevision(ctx context.Context, db DBTX, arg InsertProblemRevisionParams) (ProblemRevision, error) {
+	row := db.QueryRow(ctx, insertProblemRevision,
+		arg.ProblemID,
+		arg.Revision,
+		arg.Title,
+		arg.Description,
+		arg.TimeLimitMs,
+		arg.MemoryLimitKb,
+		arg.ContentHash,
+		arg.CreatedBy,
+	)
+	var i ProblemRevision
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Revision,
+		&i.Title,
+		&i.Description,
+		&i.TimeLimitMs,
+		&i.MemoryLimitKb,
+		&i.ContentHash,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+type InsertProblemRevisionTestsParams struct {
+	RevisionID    int32       `db:"revision_id" json:"revision_id"`
+	Position      int32       `db:"position" json:"position"`
+	Input         string      `db:"input" json:"input"`
+	Output        string      `db:"output" json:"output"`
+	GeneratorID   pgtype.Int4 `db:"generator_id" json:"generator_id"`
+	GeneratorArgs pgtype.Text `db:"generator_args" json:"generator_args"`
+	IsSample      bool        `db:"is_sample" json:"is_sample"`
+	Explanation   string      `db:"explanation" json:"explanation"`
+}
+
+const lockProblem = `-- name: LockProblem :one
+SELECT id, title, description, time_limit_ms, memory_limit_kb, created_at, created_by, draft, published_at, current_revision_id, difficulty, submission_visibility, reveal_failing_test
+FROM problems
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockProblem(ctx context.Context, db DBTX, id int32) (Problem, error) {
+	row := db.QueryRow(ctx, lockProblem, id)
+	var i Problem
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.TimeLimitMs,
+		&i.MemoryLimitKb,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.Draft,
+		&i.PublishedAt,
+		&i.CurrentRevisionID,
+		&i.Difficulty,
+		&i.SubmissionVisibility,
+		&i.RevealFailingTest,
+	)
+	return i, err
+}
+
+const restoreTestCase = `-- name: RestoreTestCase :exec
+INSERT INTO test_cases (problem_id, input, output, generator_id, generator_args, is_sample, explanation)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+type RestoreTestCaseParams struct {
+	ProblemID     int32       `db:"problem_id" json:"problem_id"`
+	Input         string      `db:"input" json:"input"`
+	Output        string      `db:"output" json:"output"`
+	GeneratorID   pgtype.Int4 `db:"generator_id" json:"generator_id"`
+	GeneratorArgs pgtype.Text `db:"generator_args" json:"generator_args"`
+	IsSample      bool        `db:"is_sample" json:"is_sample"`
+	Explanation   string      `db:"explanation" json:"explanation"`
+}
+
+func (q *Queries) RestoreTestCase(ctx context.Context, db DBTX, arg RestoreTestCaseParams) error {
+	_, err := db.Exec(ctx, restoreTestCase,
+		arg.ProblemID,
+		arg.Input,
+		arg.Output,
+		arg.GeneratorID,
+		arg.GeneratorArgs,
+		arg.IsSample,
+		arg.Explanation,
+	)
+	return err
+}
+
+const setProblemCurrentRevision = `-- name: SetProblemCurrentRevision :exec
+UPDATE problems
+SET current_revision_id = $2
+WHERE id = $1
+`
+
+func (q *Queries) SetProblemCurrentRevision(ctx context.Context, db DBTX, iD int32, currentRevisionID pgtype.Int4) error {
+	_, err := db.Exec(ctx, setProblemCurrentRevision, iD, currentRevisionID)
+	return err
+}
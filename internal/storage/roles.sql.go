@@ -0,0 +1,278 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: roles.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countUsersWithPermission = `-- name: CountUsersWithPermission :one
+SELECT COUNT(DISTINCT user_roles.user_id)
+FROM user_roles
+         JOIN roles ON roles.name = user_roles.role
+         JOIN role_permissions ON role_permissions.role = user_roles.role
+WHERE role_permissions.permission = $1
+  AND (NOT roles.require_two_factor OR EXISTS (SELECT 1
+                                               FROM user_totp
+                                               WHERE user_totp.user_id = user_roles.user_id
+                                                 AND user_totp.enabled_at IS NOT NULL))
+`
+
+func (q *Queries) CountUsersWithPermission(ctx context.Context, db DBTX, permission string) (int64, error) {
+	row := db.QueryRow(ctx, countUsersWithPermission, permission)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createRole = `-- name: CreateRole :one
+INSERT INTO roles (name, description)
+VALUES ($1, $2)
+RETURNING name, description, require_two_factor
+`
+
+func (q *Queries) CreateRole(ctx context.Context, db DBTX, name string, description string) (Role, error) {
+	row := db.QueryRow(ctx, createRole, name, description)
+	var i Role
+	err := row.Scan(&i.Name, &i.Description, &i.RequireTwoFactor)
+	return i, err
+}
+
+const deleteRolePermissions = `-- name: DeleteRolePermissions :exec
+DELETE
+FROM role_permissions
+WHERE role = $1
+`
+
+func (q *Queries) DeleteRolePermissions(ctx context.Context, db DBTX, role string) error {
+	_, err := db.Exec(ctx, deleteRolePermissions, role)
+	return err
+}
+
+const getRole = `-- name: GetRole :one
+SELECT name, description, require_two_factor
+FROM roles
+WHERE name = $1
+`
+
+func (q *Queries) GetRole(ctx context.Context, db DBTX, name string) (Role, error) {
+	row := db.QueryRow(ctx, getRole, name)
+	var i Role
+	err := row.Scan(&i.Name, &i.Description, &i.RequireTwoFactor)
+	return i, err
+}
+
+const getUserPermissions = `-- name: GetUserPermissions :many
+SELECT DISTINCT role_permissions.permission
+FROM user_roles
+         JOIN roles ON roles.name = user_roles.role
+         JOIN role_permissions ON role_permissions.role = user_roles.role
+WHERE user_roles.user_id = $1
+  AND (NOT roles.require_two_factor OR EXISTS (SELECT 1
+                                               FROM user_totp
+                                               WHERE user_totp.user_id = user_roles.user_id
+                                                 AND user_totp.enabled_at IS NOT NULL))
+ORDER BY role_permissions.permission
+`
+
+func (q *Queries) GetUserPermissions(ctx context.Context, db DBTX, userID pgtype.UUID) ([]string, error) {
+	rows, err := db.Query(ctx, getUserPermissions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		items = append(items, permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserRoles = `-- name: GetUserRoles :many
+SELECT role
+FROM user_roles
+WHERE user_id = $1
+ORDER BY role
+`
+
+func (q *Queries) GetUserRoles(ctx context.Context, db DBTX, userID pgtype.UUID) ([]string, error) {
+	rows, err := db.Query(ctx, getUserRoles, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		items = append(items, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const grantRolePermission = `-- name: GrantRolePermission :exec
+INSERT INTO role_permissions (role, permission)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) GrantRolePermission(ctx context.Context, db DBTX, role string, permission string) error {
+	_, err := db.Exec(ctx, grantRolePermission, role, permission)
+	return err
+}
+
+const grantUserRole = `-- name: GrantUserRole :exec
+INSERT INTO user_roles (user_id, role)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) GrantUserRole(ctx context.Context, db DBTX, userID pgtype.UUID, role string) error {
+	_, err := db.Exec(ctx, grantUserRole, userID, role)
+	return err
+}
+
+const listPermissions = `-- name: ListPermissions :many
+SELECT name, description
+FROM permissions
+ORDER BY name
+`
+
+func (q *Queries) ListPermissions(ctx context.Context, db DBTX) ([]Permission, error) {
+	rows, err := db.Query(ctx, listPermissions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Permission
+	for rows.Next() {
+		var i Permission
+		if err := rows.Scan(&i.Name, &i.Description); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRolePermissions = `-- name: ListRolePermissions :many
+SELECT role, permission
+FROM role_permissions
+ORDER BY role, permission
+`
+
+func (q *Queries) ListRolePermissions(ctx context.Context, db DBTX) ([]RolePermission, error) {
+	rows, err := db.Query(ctx, listRolePermissions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RolePermission
+	for rows.Next() {
+		var i RolePermission
+		if err := rows.Scan(&i.Role, &i.Permission); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRoles = `-- name: ListRoles :many
+SELECT name, description, require_two_factor
+FROM roles
+ORDER BY name
+`
+
+func (q *Queries) ListRoles(ctx context.Context, db DBTX) ([]Role, error) {
+	rows, err := db.Query(ctx, listRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Role
+	for rows.Next() {
+		var i Role
+		if err := rows.Scan(&i.Name, &i.Description, &i.RequireTwoFactor); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockRoles = `-- name: LockRoles :exec
+LOCK TABLE roles, user_roles, role_permissions IN SHARE ROW EXCLUSIVE MODE
+`
+
+func (q *Queries) LockRoles(ctx context.Context, db DBTX) error {
+	_, err := db.Exec(ctx, lockRoles)
+	return err
+}
+
+const revokeRolePermission = `-- name: RevokeRolePermission :execrows
+DELETE
+FROM role_permissions
+WHERE role = $1
+  AND permission = $2
+`
+
+func (q *Queries) RevokeRolePermission(ctx context.Context, db DBTX, role string, permission string) (int64, error) {
+	result, err := db.Exec(ctx, revokeRolePermission, role, permission)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeUserRole = `-- name: RevokeUserRole :execrows
+DELETE
+FROM user_roles
+WHERE user_id = $1
+  AND role = $2
+`
+
+func (q *Queries) RevokeUserRole(ctx context.Context, db DBTX, userID pgtype.UUID, role string) (int64, error) {
+	result, err := db.Exec(ctx, revokeUserRole, userID, role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setRoleRequireTwoFactor = `-- name: SetRoleRequireTwoFactor :exec
+UPDATE roles
+SET require_two_factor = $1
+WHERE name = $2
+`
+
+func (q *Queries) SetRoleRequireTwoFactor(ctx context.Context, db DBTX, requireTwoFactor bool, name string) error {
+	_, err := db.Exec(ctx, setRoleRequireTwoFactor, requireTwoFactor, name)
+	return err
+}
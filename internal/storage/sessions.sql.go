@@ -0,0 +1,184 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: sessions.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createSession = `-- name: CreateSession :exec
+INSERT INTO sessions (id, user_id, user_agent, ip_address, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateSessionParams struct {
+	ID        pgtype.UUID        `db:"id" json:"id"`
+	UserID    pgtype.UUID        `db:"user_id" json:"user_id"`
+	UserAgent string             `db:"user_agent" json:"user_agent"`
+	IpAddress string             `db:"ip_address" json:"ip_address"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) CreateSession(ctx context.Context, db DBTX, arg CreateSessionParams) error {
+	_, err := db.Exec(ctx, createSession,
+		arg.ID,
+		arg.UserID,
+		arg.UserAgent,
+		arg.IpAddress,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const deleteExpiredUserSessions = `-- name: DeleteExpiredUserSessions :exec
+DELETE
+FROM sessions
+WHERE user_id = $1
+  AND expires_at < NOW()
+`
+
+func (q *Queries) DeleteExpiredUserSessions(ctx context.Context, db DBTX, userID pgtype.UUID) error {
+	_, err := db.Exec(ctx, deleteExpiredUserSessions, userID)
+	return err
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at
+FROM sessions
+WHERE user_id = $1
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+ORDER BY last_seen_at DESC
+`
+
+func (q *Queries) ListUserSessions(ctx context.Context, db DBTX, userID pgtype.UUID) ([]Session, error) {
+	rows, err := db.Query(ctx, listUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeOtherUserSessions = `-- name: RevokeOtherUserSessions :many
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE user_id = $1
+  AND id <> $2
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+RETURNING id
+`
+
+func (q *Queries) RevokeOtherUserSessions(ctx context.Context, db DBTX, userID pgtype.UUID, keepSessionID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := db.Query(ctx, revokeOtherUserSessions, userID, keepSessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeSession = `-- name: RevokeSession :execrows
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE user_id = $1
+  AND id = $2
+  AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeSession(ctx context.Context, db DBTX, userID pgtype.UUID, sessionID pgtype.UUID) (int64, error) {
+	result, err := db.Exec(ctx, revokeSession, userID, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeUserSessions = `-- name: RevokeUserSessions :many
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE user_id = $1
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+RETURNING id
+`
+
+func (q *Queries) RevokeUserSessions(ctx context.Context, db DBTX, userID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := db.Query(ctx, revokeUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchSession = `-- name: TouchSession :one
+UPDATE sessions
+SET last_seen_at = NOW()
+WHERE id = $1
+RETURNING id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at
+`
+
+func (q *Queries) TouchSession(ctx context.Context, db DBTX, id pgtype.UUID) (Session, error) {
+	row := db.QueryRow(ctx, touchSession, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.CreatedAt,
+		&i.LastSeenAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
@@ -12,19 +12,25 @@ import (
 )
 
 const createSubmission = `-- name: CreateSubmission :one
-INSERT INTO submissions (problem_id, user_id, solution_code)
-VALUES ($1, $2, $3)
-RETURNING id, problem_id, user_id, solution_code, status, created_at, last_modified, message, retries
+INSERT INTO submissions (problem_id, user_id, solution_code, code_hash)
+VALUES ($1, $2, $3, $4)
+RETURNING id, problem_id, user_id, solution_code, status, created_at, last_modified, message, retries, revision_id, feedback, code_hash, failed_test
 `
 
 type CreateSubmissionParams struct {
 	ProblemID    int32       `db:"problem_id" json:"problem_id"`
 	UserID       pgtype.UUID `db:"user_id" json:"user_id"`
 	SolutionCode string      `db:"solution_code" json:"solution_code"`
+	CodeHash     string      `db:"code_hash" json:"code_hash"`
 }
 
 func (q *Queries) CreateSubmission(ctx context.Context, db DBTX, arg CreateSubmissionParams) (Submission, error) {
-	row := db.QueryRow(ctx, createSubmission, arg.ProblemID, arg.UserID, arg.SolutionCode)
+	row := db.QueryRow(ctx, createSubmission,
+		arg.ProblemID,
+		arg.UserID,
+		arg.SolutionCode,
+		arg.CodeHash,
+	)
 	var i Submission
 	err := row.Scan(
 		&i.ID,
@@ -36,28 +42,187 @@ func (q *Queries) CreateSubmission(ctx context.Context, db DBTX, arg CreateSubmi
 		&i.LastModified,
 		&i.Message,
 		&i.Retries,
+		&i.RevisionID,
+		&i.Feedback,
+		&i.CodeHash,
+		&i.FailedTest,
 	)
 	return i, err
 }
 
-const getSubmissionForUser = `-- name: GetSubmissionForUser :one
+const getLatestAcceptedSubmissions = `-- name: GetLatestAcceptedSubmissions :many
+SELECT DISTINCT ON (submissions.user_id)
+    users.username,
+    submissions.id, submissions.problem_id, submissions.user_id, submissions.solution_code, submissions.status, submissions.created_at, submissions.last_modified, submissions.message, submissions.retries, submissions.revision_id, submissions.feedback, submissions.code_hash, submissions.failed_test
+FROM submissions INNER JOIN users ON submissions.user_id = users.id
+WHERE submissions.problem_id = $1 AND submissions.status = 'ACCEPTED'
+ORDER BY submissions.user_id, submissions.created_at DESC
+`
+
+type GetLatestAcceptedSubmissionsRow struct {
+	Username   string     `db:"username" json:"username"`
+	Submission Submission `db:"submission" json:"submission"`
+}
+
+func (q *Queries) GetLatestAcceptedSubmissions(ctx context.Context, db DBTX, problemID int32) ([]GetLatestAcceptedSubmissionsRow, error) {
+	rows, err := db.Query(ctx, getLatestAcceptedSubmissions, problemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLatestAcceptedSubmissionsRow
+	for rows.Next() {
+		var i GetLatestAcceptedSubmissionsRow
+		if err := rows.Scan(
+			&i.Username,
+			&i.Submission.ID,
+			&i.Submission.ProblemID,
+			&i.Submission.UserID,
+			&i.Submission.SolutionCode,
+			&i.Submission.Status,
+			&i.Submission.CreatedAt,
+			&i.Submission.LastModified,
+			&i.Submission.Message,
+			&i.Submission.Retries,
+			&i.Submission.RevisionID,
+			&i.Submission.Feedback,
+			&i.Submission.CodeHash,
+			&i.Submission.FailedTest,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProblemSolutionStats = `-- name: GetProblemSolutionStats :one
+SELECT
+    count(*) AS submissions,
+    count(DISTINCT code_hash) AS distinct_solutions,
+    count(DISTINCT code_hash) FILTER (WHERE status = 'ACCEPTED') AS distinct_accepted
+FROM submissions
+WHERE problem_id = $1
+`
+
+type GetProblemSolutionStatsRow struct {
+	Submissions       int64 `db:"submissions" json:"submissions"`
+	DistinctSolutions int64 `db:"distinct_solutions" json:"distinct_solutions"`
+	DistinctAccepted  int64 `db:"distinct_accepted" json:"distinct_accepted"`
+}
+
+func (q *Queries) GetProblemSolutionStats(ctx context.Context, db DBTX, problemID int32) (GetProblemSolutionStatsRow, error) {
+	row := db.QueryRow(ctx, getProblemSolutionStats, problemID)
+	var i GetProblemSolutionStatsRow
+	err := row.Scan(&i.Submissions, &i.DistinctSolutions, &i.DistinctAccepted)
+	return i, err
+}
+
+const getProblemSubmission = `-- name: GetProblemSubmission :one
+SELECT
+    users.username,
+    submissions.id, submissions.problem_id, submissions.user_id, submissions.solution_code, submissions.status, submissions.created_at, submissions.last_modified, submissions.message, submissions.retries, submissions.revision_id, submissions.feedback, submissions.code_hash, submissions.failed_test
+FROM submissions INNER JOIN users ON submissions.user_id = users.id
+WHERE submissions.problem_id = $1 AND submissions.id = $2
+`
+
+type GetProblemSubmissionRow struct {
+	Username   string     `db:"username" json:"username"`
+	Submission Submission `db:"submission" json:"submission"`
+}
+
+func (q *Queries) GetProblemSubmission(ctx context.Context, db DBTX, problemID int32, iD pgtype.UUID) (GetProblemSubmissionRow, error) {
+	row := db.QueryRow(ctx, getProblemSubmission, problemID, iD)
+	var i GetProblemSubmissionRow
+	err := row.Scan(
+		&i.Username,
+		&i.Submission.ID,
+		&i.Submission.ProblemID,
+		&i.Submission.UserID,
+		&i.Submission.SolutionCode,
+		&i.Submission.Status,
+		&i.Submission.CreatedAt,
+		&i.Submission.LastModified,
+		&i.Submission.Message,
+		&i.Submission.Retries,
+		&i.Submission.RevisionID,
+		&i.Submission.Feedback,
+		&i.Submission.CodeHash,
+		&i.Submission.FailedTest,
+	)
+	return i, err
+}
+
+const getRecentDuplicateSubmission = `-- name: GetRecentDuplicateSubmission :one
+SELECT id
+FROM submissions
+WHERE user_id = $1
+  AND problem_id = $2
+  AND code_hash = $3
+  AND created_at > $4
+  AND status != 'INTERNAL_ERROR'
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type GetRecentDuplicateSubmissionParams struct {
+	UserID    pgtype.UUID        `db:"user_id" json:"user_id"`
+	ProblemID int32              `db:"problem_id" json:"problem_id"`
+	CodeHash  string             `db:"code_hash" json:"code_hash"`
+	Since     pgtype.Timestamptz `db:"since" json:"since"`
+}
+
+func (q *Queries) GetRecentDuplicateSubmission(ctx context.Context, db DBTX, arg GetRecentDuplicateSubmissionParams) (pgtype.UUID, error) {
+	row := db.QueryRow(ctx, getRecentDuplicateSubmission,
+		arg.UserID,
+		arg.ProblemID,
+		arg.CodeHash,
+		arg.Since,
+	)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getSubmission = `-- name: GetSubmission :one
 SELECT
     problems.title AS problem_name,
-    submissions.id, submissions.problem_id, submissions.user_id, submissions.solution_code, submissions.status, submissions.created_at, submissions.last_modified, submissions.message, submissions.retries
-FROM submissions INNER JOIN problems ON submissions.problem_id = problems.id
-WHERE submissions.user_id = $1 AND submissions.id = $2
+    problem_revisions.revision AS problem_revision,
+    problems.created_by AS problem_author,
+    problems.submission_visibility,
+    problems.reveal_failing_test,
+    users.username,
+    submissions.id, submissions.problem_id, submissions.user_id, submissions.solution_code, submissions.status, submissions.created_at, submissions.last_modified, submissions.message, submissions.retries, submissions.revision_id, submissions.feedback, submissions.code_hash, submissions.failed_test
+FROM submissions
+    INNER JOIN problems ON submissions.problem_id = problems.id
+    INNER JOIN users ON submissions.user_id = users.id
+    LEFT JOIN problem_revisions ON submissions.revision_id = problem_revisions.id
+WHERE submissions.id = $1
 `
 
-type GetSubmissionForUserRow struct {
-	ProblemName string     `db:"problem_name" json:"problem_name"`
-	Submission  Submission `db:"submission" json:"submission"`
+type GetSubmissionRow struct {
+	ProblemName          string               `db:"problem_name" json:"problem_name"`
+	ProblemRevision      pgtype.Int4          `db:"problem_revision" json:"problem_revision"`
+	ProblemAuthor        pgtype.UUID          `db:"problem_author" json:"problem_author"`
+	SubmissionVisibility SubmissionVisibility `db:"submission_visibility" json:"submission_visibility"`
+	RevealFailingTest    bool                 `db:"reveal_failing_test" json:"reveal_failing_test"`
+	Username             string               `db:"username" json:"username"`
+	Submission           Submission           `db:"submission" json:"submission"`
 }
 
-func (q *Queries) GetSubmissionForUser(ctx context.Context, db DBTX, userID pgtype.UUID, iD pgtype.UUID) (GetSubmissionForUserRow, error) {
-	row := db.QueryRow(ctx, getSubmissionForUser, userID, iD)
-	var i GetSubmissionForUserRow
+func (q *Queries) GetSubmission(ctx context.Context, db DBTX, id pgtype.UUID) (GetSubmissionRow, error) {
+	row := db.QueryRow(ctx, getSubmission, id)
+	var i GetSubmissionRow
 	err := row.Scan(
 		&i.ProblemName,
+		&i.ProblemRevision,
+		&i.ProblemAuthor,
+		&i.SubmissionVisibility,
+		&i.RevealFailingTest,
+		&i.Username,
 		&i.Submission.ID,
 		&i.Submission.ProblemID,
 		&i.Submission.UserID,
@@ -67,14 +232,51 @@ func (q *Queries) GetSubmissionForUser(ctx context.Context, db DBTX, userID pgty
 		&i.Submission.LastModified,
 		&i.Submission.Message,
 		&i.Submission.Retries,
+		&i.Submission.RevisionID,
+		&i.Submission.Feedback,
+		&i.Submission.CodeHash,
+		&i.Submission.FailedTest,
 	)
 	return i, err
 }
 
+const getUserProblemSubmissions = `-- name: GetUserProblemSubmissions :many
+SELECT id, status, created_at
+FROM submissions
+WHERE user_id = $1 AND problem_id = $2
+ORDER BY created_at DESC
+`
+
+type GetUserProblemSubmissionsRow struct {
+	ID        pgtype.UUID        `db:"id" json:"id"`
+	Status    SubmissionStatus   `db:"status" json:"status"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) GetUserProblemSubmissions(ctx context.Context, db DBTX, userID pgtype.UUID, problemID int32) ([]GetUserProblemSubmissionsRow, error) {
+	rows, err := db.Query(ctx, getUserProblemSubmissions, userID, problemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserProblemSubmissionsRow
+	for rows.Next() {
+		var i GetUserProblemSubmissionsRow
+		if err := rows.Scan(&i.ID, &i.Status, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserSubmissions = `-- name: GetUserSubmissions :many
 SELECT
     problems.title AS problem_name,
-    submissions.id, submissions.problem_id, submissions.user_id, submissions.solution_code, submissions.status, submissions.created_at, submissions.last_modified, submissions.message, submissions.retries
+    submissions.id, submissions.problem_id, submissions.user_id, submissions.solution_code, submissions.status, submissions.created_at, submissions.last_modified, submissions.message, submissions.retries, submissions.revision_id, submissions.feedback, submissions.code_hash, submissions.failed_test
 FROM submissions INNER JOIN problems ON submissions.problem_id = problems.id
 WHERE submissions.user_id = $1
 ORDER BY submissions.created_at DESC
@@ -105,6 +307,10 @@ func (q *Queries) GetUserSubmissions(ctx context.Context, db DBTX, userID pgtype
 			&i.Submission.LastModified,
 			&i.Submission.Message,
 			&i.Submission.Retries,
+			&i.Submission.RevisionID,
+			&i.Submission.Feedback,
+			&i.Submission.CodeHash,
+			&i.Submission.FailedTest,
 		); err != nil {
 			return nil, err
 		}
@@ -116,11 +322,20 @@ func (q *Queries) GetUserSubmissions(ctx context.Context, db DBTX, userID pgtype
 	return items, nil
 }
 
+const lockUserSubmissions = `-- name: LockUserSubmissions :exec
+SELECT pg_advisory_xact_lock(hashtextextended($1::UUID::TEXT, 0))
+`
+
+func (q *Queries) LockUserSubmissions(ctx context.Context, db DBTX, userID pgtype.UUID) error {
+	_, err := db.Exec(ctx, lockUserSubmissions, userID)
+	return err
+}
+
 const retrySubmissionDueToInternalError = `-- name: RetrySubmissionDueToInternalError :one
 UPDATE submissions
 SET retries = retries + 1
 WHERE id = $1
-RETURNING id, problem_id, user_id, solution_code, status, created_at, last_modified, message, retries
+RETURNING id, problem_id, user_id, solution_code, status, created_at, last_modified, message, retries, revision_id, feedback, code_hash, failed_test
 `
 
 func (q *Queries) RetrySubmissionDueToInternalError(ctx context.Context, db DBTX, id pgtype.UUID) (Submission, error) {
@@ -136,15 +351,41 @@ func (q *Queries) RetrySubmissionDueToInternalError(ctx context.Context, db DBTX
 		&i.LastModified,
 		&i.Message,
 		&i.Retries,
+		&i.RevisionID,
+		&i.Feedback,
+		&i.CodeHash,
+		&i.FailedTest,
 	)
 	return i, err
 }
 
+const setSubmissionFailedTest = `-- name: SetSubmissionFailedTest :exec
+UPDATE submissions
+SET failed_test = $2
+WHERE id = $1
+`
+
+func (q *Queries) SetSubmissionFailedTest(ctx context.Context, db DBTX, iD pgtype.UUID, failedTest pgtype.Int4) error {
+	_, err := db.Exec(ctx, setSubmissionFailedTest, iD, failedTest)
+	return err
+}
+
+const setSubmissionRevision = `-- name: SetSubmissionRevision :exec
+UPDATE submissions
+SET revision_id = $2
+WHERE id = $1
+`
+
+func (q *Queries) SetSubmissionRevision(ctx context.Context, db DBTX, iD pgtype.UUID, revisionID pgtype.Int4) error {
+	_, err := db.Exec(ctx, setSubmissionRevision, iD, revisionID)
+	return err
+}
+
 const updateSubmissionStatus = `-- name: UpdateSubmissionStatus :one
 UPDATE submissions
 SET status = $2, message = $3
 WHERE id = $1
-RETURNING id, problem_id, user_id, solution_code, status, created_at, last_modified, message, retries
+RETURNING id, problem_id, user_id, solution_code, status, created_at, last_modified, message, retries, revision_id, feedback, code_hash, failed_test
 `
 
 type UpdateSubmissionStatusParams struct {
@@ -166,6 +407,50 @@ func (q *Queries) UpdateSubmissionStatus(ctx context.Context, db DBTX, arg Updat
 		&i.LastModified,
 		&i.Message,
 		&i.Retries,
+		&i.RevisionID,
+		&i.Feedback,
+		&i.CodeHash,
+		&i.FailedTest,
+	)
+	return i, err
+}
+
+const updateSubmissionStatusWithFeedback = `-- name: UpdateSubmissionStatusWithFeedback :one
+UPDATE submissions
+SET status = $2, message = $3, feedback = $4
+WHERE id = $1
+RETURNING id, problem_id, user_id, solution_code, status, created_at, last_modified, message, retries, revision_id, feedback, code_hash, failed_test
+`
+
+type UpdateSubmissionStatusWithFeedbackParams struct {
+	ID       pgtype.UUID      `db:"id" json:"id"`
+	Status   SubmissionStatus `db:"status" json:"status"`
+	Message  pgtype.Text      `db:"message" json:"message"`
+	Feedback pgtype.Text      `db:"feedback" json:"feedback"`
+}
+
+func (q *Queries) UpdateSubmissionStatusWithFeedback(ctx context.Context, db DBTX, arg UpdateSubmissionStatusWithFeedbackParams) (Submission, error) {
+	row := db.QueryRow(ctx, updateSubmissionStatusWithFeedback,
+		arg.ID,
+		arg.Status,
+		arg.Message,
+		arg.Feedback,
+	)
+	var i Submission
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.UserID,
+		&i.SolutionCode,
+		&i.Status,
+		&i.CreatedAt,
+		&i.LastModified,
+		&i.Message,
+		&i.Retries,
+		&i.RevisionID,
+		&i.Feedback,
+		&i.CodeHash,
+		&i.FailedTest,
 	)
 	return i, err
 }
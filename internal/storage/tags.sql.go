@@ -0,0 +1,58 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: tags.sql
+
+package storage
+
+import (
+	"context"
+)
+
+const deleteProblemTags = `-- name: DeleteProblemTags :exec
+DELETE FROM problem_tags
+WHERE problem_id = $1
+`
+
+func (q *Queries) DeleteProblemTags(ctx context.Context, db DBTX, problemID int32) error {
+	_, err := db.Exec(ctx, deleteProblemTags, problemID)
+	return err
+}
+
+const getProblemTags = `-- name: GetProblemTags :many
+SELECT tag
+FROM problem_tags
+WHERE problem_id = $1
+ORDER BY tag
+`
+
+func (q *Queries) GetProblemTags(ctx context.Context, db DBTX, problemID int32) ([]string, error) {
+	rows, err := db.Query(ctx, getProblemTags, problemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		items = append(items, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertProblemTag = `-- name: InsertProblemTag :exec
+INSERT INTO problem_tags (problem_id, tag)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) InsertProblemTag(ctx context.Context, db DBTX, problemID int32, tag string) error {
+	_, err := db.Exec(ctx, insertProblemTag, problemID, tag)
+	return err
+}
@@ -7,11 +7,47 @@ package storage
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const deleteGeneratedTestCase = `-- name: DeleteGeneratedTestCase :one
+DELETE FROM test_cases
+WHERE id = $1 AND problem_id = $2 AND generator_id IS NOT NULL
+RETURNING id, problem_id, input, output, generator_id, generator_args, input_key, output_key, is_sample, explanation
+`
+
+func (q *Queries) DeleteGeneratedTestCase(ctx context.Context, db DBTX, iD int32, problemID int32) (TestCase, error) {
+	row := db.QueryRow(ctx, deleteGeneratedTestCase, iD, problemID)
+	var i TestCase
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Input,
+		&i.Output,
+		&i.GeneratorID,
+		&i.GeneratorArgs,
+		&i.InputKey,
+		&i.OutputKey,
+		&i.IsSample,
+		&i.Explanation,
+	)
+	return i, err
+}
+
+const deleteProblemSamples = `-- name: DeleteProblemSamples :exec
+DELETE FROM test_cases
+WHERE problem_id = $1 AND is_sample
+`
+
+func (q *Queries) DeleteProblemSamples(ctx context.Context, db DBTX, problemID int32) error {
+	_, err := db.Exec(ctx, deleteProblemSamples, problemID)
+	return err
+}
+
 const deleteProblemTestCases = `-- name: DeleteProblemTestCases :exec
 DELETE FROM test_cases
-WHERE problem_id = $1
+WHERE problem_id = $1 AND generator_id IS NULL AND NOT is_sample
 `
 
 func (q *Queries) DeleteProblemTestCases(ctx context.Context, db DBTX, problemID int32) error {
@@ -19,12 +55,90 @@ func (q *Queries) DeleteProblemTestCases(ctx context.Context, db DBTX, problemID
 	return err
 }
 
+const getManualTestCasesByProblemID = `-- name: GetManualTestCasesByProblemID :many
+SELECT id, problem_id, input, output, generator_id, generator_args, input_key, output_key, is_sample, explanation
+FROM test_cases
+WHERE problem_id = $1 AND generator_id IS NULL AND NOT is_sample
+ORDER BY id
+`
+
+func (q *Queries) GetManualTestCasesByProblemID(ctx context.Context, db DBTX, problemID int32) ([]TestCase, error) {
+	rows, err := db.Query(ctx, getManualTestCasesByProblemID, problemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestCase
+	for rows.Next() {
+		var i TestCase
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProblemID,
+			&i.Input,
+			&i.Output,
+			&i.GeneratorID,
+			&i.GeneratorArgs,
+			&i.InputKey,
+			&i.OutputKey,
+			&i.IsSample,
+			&i.Explanation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProblemSamples = `-- name: GetProblemSamples :many
+SELECT id, problem_id, input, output, generator_id, generator_args, input_key, output_key, is_sample, explanation
+FROM test_cases
+WHERE problem_id = $1 AND is_sample
+ORDER BY id
+`
+
+func (q *Queries) GetProblemSamples(ctx context.Context, db DBTX, problemID int32) ([]TestCase, error) {
+	rows, err := db.Query(ctx, getProblemSamples, problemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestCase
+	for rows.Next() {
+		var i TestCase
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProblemID,
+			&i.Input,
+			&i.Output,
+			&i.GeneratorID,
+			&i.GeneratorArgs,
+			&i.InputKey,
+			&i.OutputKey,
+			&i.IsSample,
+			&i.Explanation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTestCasesByProblemID = `-- name: GetTestCasesByProblemID :many
-SELECT id, problem_id, input, output
+SELECT id, problem_id, input, output, generator_id, generator_args, input_key, output_key, is_sample, explanation
 FROM test_cases
 WHERE problem_id = $1
+ORDER BY is_sample DESC, id
 `
 
+// samples are judged first, in the order they are shown
 func (q *Queries) GetTestCasesByProblemID(ctx context.Context, db DBTX, problemID int32) ([]TestCase, error) {
 	rows, err := db.Query(ctx, getTestCasesByProblemID, problemID)
 	if err != nil {
@@ -39,6 +153,12 @@ func (q *Queries) GetTestCasesByProblemID(ctx context.Context, db DBTX, problemI
 			&i.ProblemID,
 			&i.Input,
 			&i.Output,
+			&i.GeneratorID,
+			&i.GeneratorArgs,
+			&i.InputKey,
+			&i.OutputKey,
+			&i.IsSample,
+			&i.Explanation,
 		); err != nil {
 			return nil, err
 		}
@@ -50,10 +170,76 @@ func (q *Queries) GetTestCasesByProblemID(ctx context.Context, db DBTX, problemI
 	return items, nil
 }
 
+const insertGeneratedTestCase = `-- name: InsertGeneratedTestCase :one
+INSERT INTO test_cases (problem_id, input, output, generator_id, generator_args)
+VALUES ($1, '', '', $2, $3)
+RETURNING id, problem_id, input, output, generator_id, generator_args, input_key, output_key, is_sample, explanation
+`
+
+type InsertGeneratedTestCaseParams struct {
+	ProblemID     int32       `db:"problem_id" json:"problem_id"`
+	GeneratorID   pgtype.Int4 `db:"generator_id" json:"generator_id"`
+	GeneratorArgs pgtype.Text `db:"generator_args" json:"generator_args"`
+}
+
+func (q *Queries) InsertGeneratedTestCase(ctx context.Context, db DBTX, arg InsertGeneratedTestCaseParams) (TestCase, error) {
+	row := db.QueryRow(ctx, insertGeneratedTestCase, arg.ProblemID, arg.GeneratorID, arg.GeneratorArgs)
+	var i TestCase
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Input,
+		&i.Output,
+		&i.GeneratorID,
+		&i.GeneratorArgs,
+		&i.InputKey,
+		&i.OutputKey,
+		&i.IsSample,
+		&i.Explanation,
+	)
+	return i, err
+}
+
+const insertSampleTestCase = `-- name: InsertSampleTestCase :one
+INSERT INTO test_cases (problem_id, input, output, explanation, is_sample)
+VALUES ($1, $2, $3, $4, TRUE)
+RETURNING id, problem_id, input, output, generator_id, generator_args, input_key, output_key, is_sample, explanation
+`
+
+type InsertSampleTestCaseParams struct {
+	ProblemID   int32  `db:"problem_id" json:"problem_id"`
+	Input       string `db:"input" json:"input"`
+	Output      string `db:"output" json:"output"`
+	Explanation string `db:"explanation" json:"explanation"`
+}
+
+func (q *Queries) InsertSampleTestCase(ctx context.Context, db DBTX, arg InsertSampleTestCaseParams) (TestCase, error) {
+	row := db.QueryRow(ctx, insertSampleTestCase,
+		arg.ProblemID,
+		arg.Input,
+		arg.Output,
+		arg.Explanation,
+	)
+	var i TestCase
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Input,
+		&i.Output,
+		&i.GeneratorID,
+		&i.GeneratorArgs,
+		&i.InputKey,
+		&i.OutputKey,
+		&i.IsSample,
+		&i.Explanation,
+	)
+	return i, err
+}
+
 const insertTestCase = `-- name: InsertTestCase :one
 INSERT INTO test_cases (problem_id, input, output)
 VALUES ($1, $2, $3)
-RETURNING id, problem_id, input, output
+RETURNING id, problem_id, input, output, generator_id, generator_args, input_key, output_key, is_sample, explanation
 `
 
 type InsertTestCaseParams struct {
@@ -70,6 +256,46 @@ func (q *Queries) InsertTestCase(ctx context.Context, db DBTX, arg InsertTestCas
 		&i.ProblemID,
 		&i.Input,
 		&i.Output,
+		&i.GeneratorID,
+		&i.GeneratorArgs,
+		&i.InputKey,
+		&i.OutputKey,
+		&i.IsSample,
+		&i.Explanation,
 	)
 	return i, err
 }
+
+const updateTestCaseInput = `-- name: UpdateTestCaseInput :exec
+UPDATE test_cases
+SET input = $2, input_key = $3, output = '', output_key = NULL
+WHERE id = $1
+`
+
+type UpdateTestCaseInputParams struct {
+	ID       int32       `db:"id" json:"id"`
+	Input    string      `db:"input" json:"input"`
+	InputKey pgtype.Text `db:"input_key" json:"input_key"`
+}
+
+func (q *Queries) UpdateTestCaseInput(ctx context.Context, db DBTX, arg UpdateTestCaseInputParams) error {
+	_, err := db.Exec(ctx, updateTestCaseInput, arg.ID, arg.Input, arg.InputKey)
+	return err
+}
+
+const updateTestCaseOutput = `-- name: UpdateTestCaseOutput :exec
+UPDATE test_cases
+SET output = $2, output_key = $3
+WHERE id = $1
+`
+
+type UpdateTestCaseOutputParams struct {
+	ID        int32       `db:"id" json:"id"`
+	Output    string      `db:"output" json:"output"`
+	OutputKey pgtype.Text `db:"output_key" json:"output_key"`
+}
+
+func (q *Queries) UpdateTestCaseOutput(ctx context.Context, db DBTX, arg UpdateTestCaseOutputParams) error {
+	_, err := db.Exec(ctx, updateTestCaseOutput, arg.ID, arg.Output, arg.OutputKey)
+	return err
+}
@@ -0,0 +1,69 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: testgenerations.sql
+
+package storage
+
+import (
+	"context"
+)
+
+const createTestGeneration = `-- name: CreateTestGeneration :one
+INSERT INTO test_generations (problem_id)
+VALUES ($1)
+RETURNING id, problem_id, status, report, started_at, finished_at
+`
+
+func (q *Queries) CreateTestGeneration(ctx context.Context, db DBTX, problemID int32) (TestGeneration, error) {
+	row := db.QueryRow(ctx, createTestGeneration, problemID)
+	var i TestGeneration
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Status,
+		&i.Report,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const finishTestGeneration = `-- name: FinishTestGeneration :exec
+UPDATE test_generations
+SET status = $2, report = $3, finished_at = now()
+WHERE id = $1
+`
+
+type FinishTestGenerationParams struct {
+	ID     int32     `db:"id" json:"id"`
+	Status JobStatus `db:"status" json:"status"`
+	Report string    `db:"report" json:"report"`
+}
+
+func (q *Queries) FinishTestGeneration(ctx context.Context, db DBTX, arg FinishTestGenerationParams) error {
+	_, err := db.Exec(ctx, finishTestGeneration, arg.ID, arg.Status, arg.Report)
+	return err
+}
+
+const getLatestTestGeneration = `-- name: GetLatestTestGeneration :one
+SELECT id, problem_id, status, report, started_at, finished_at
+FROM test_generations
+WHERE problem_id = $1
+ORDER BY started_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestTestGeneration(ctx context.Context, db DBTX, problemID int32) (TestGeneration, error) {
+	row := db.QueryRow(ctx, getLatestTestGeneration, problemID)
+	var i TestGeneration
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Status,
+		&i.Report,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
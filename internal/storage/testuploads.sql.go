@@ -0,0 +1,88 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: testuploads.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createTestUpload = `-- name: CreateTestUpload :one
+INSERT INTO test_uploads (problem_id, uploaded_by, archive, input_pattern, output_pattern)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, problem_id, uploaded_by, archive, input_pattern, output_pattern, created_at
+`
+
+type CreateTestUploadParams struct {
+	ProblemID     int32       `db:"problem_id" json:"problem_id"`
+	UploadedBy    pgtype.UUID `db:"uploaded_by" json:"uploaded_by"`
+	Archive       []byte      `db:"archive" json:"archive"`
+	InputPattern  string      `db:"input_pattern" json:"input_pattern"`
+	OutputPattern string      `db:"output_pattern" json:"output_pattern"`
+}
+
+func (q *Queries) CreateTestUpload(ctx context.Context, db DBTX, arg CreateTestUploadParams) (TestUpload, error) {
+	row := db.QueryRow(ctx, createTestUpload,
+		arg.ProblemID,
+		arg.UploadedBy,
+		arg.Archive,
+		arg.InputPattern,
+		arg.OutputPattern,
+	)
+	var i TestUpload
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.UploadedBy,
+		&i.Archive,
+		&i.InputPattern,
+		&i.OutputPattern,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteStaleTestUploads = `-- name: DeleteStaleTestUploads :exec
+DELETE FROM test_uploads
+WHERE created_at < now() - INTERVAL '1 day'
+`
+
+func (q *Queries) DeleteStaleTestUploads(ctx context.Context, db DBTX) error {
+	_, err := db.Exec(ctx, deleteStaleTestUploads)
+	return err
+}
+
+const deleteTestUpload = `-- name: DeleteTestUpload :exec
+DELETE FROM test_uploads
+WHERE id = $1
+`
+
+func (q *Queries) DeleteTestUpload(ctx context.Context, db DBTX, id pgtype.UUID) error {
+	_, err := db.Exec(ctx, deleteTestUpload, id)
+	return err
+}
+
+const getTestUpload = `-- name: GetTestUpload :one
+SELECT id, problem_id, uploaded_by, archive, input_pattern, output_pattern, created_at
+FROM test_uploads
+WHERE id = $1 AND uploaded_by = $2
+`
+
+func (q *Queries) GetTestUpload(ctx context.Context, db DBTX, iD pgtype.UUID, uploadedBy pgtype.UUID) (TestUpload, error) {
+	row := db.QueryRow(ctx, getTestUpload, iD, uploadedBy)
+	var i TestUpload
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.UploadedBy,
+		&i.Archive,
+		&i.InputPattern,
+		&i.OutputPattern,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -6,5 +6,6 @@ func (tc *TestCase) ToProto() *runnerPb.SubmissionRequest_TestCase {
 	return &runnerPb.SubmissionRequest_TestCase{
 		Input:  tc.Input,
 		Output: tc.Output,
+		Sample: tc.IsSample,
 	}
 }
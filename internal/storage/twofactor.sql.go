@@ -0,0 +1,206 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: twofactor.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countUnusedRecoveryCodes = `-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*)
+FROM recovery_codes
+WHERE user_id = $1
+  AND used_at IS NULL
+`
+
+func (q *Queries) CountUnusedRecoveryCodes(ctx context.Context, db DBTX, userID pgtype.UUID) (int64, error) {
+	row := db.QueryRow(ctx, countUnusedRecoveryCodes, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, code_hash)
+VALUES ($1, $2)
+`
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, db DBTX, userID pgtype.UUID, codeHash []byte) error {
+	_, err := db.Exec(ctx, createRecoveryCode, userID, codeHash)
+	return err
+}
+
+const createTwoFactorChallenge = `-- name: CreateTwoFactorChallenge :exec
+INSERT INTO two_factor_challenges (token_hash, user_id, expires_at)
+VALUES ($1, $2, $3)
+`
+
+type CreateTwoFactorChallengeParams struct {
+	TokenHash []byte             `db:"token_hash" json:"token_hash"`
+	UserID    pgtype.UUID        `db:"user_id" json:"user_id"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) CreateTwoFactorChallenge(ctx context.Context, db DBTX, arg CreateTwoFactorChallengeParams) error {
+	_, err := db.Exec(ctx, createTwoFactorChallenge, arg.TokenHash, arg.UserID, arg.ExpiresAt)
+	return err
+}
+
+const deleteExpiredTwoFactorChallenges = `-- name: DeleteExpiredTwoFactorChallenges :exec
+DELETE
+FROM two_factor_challenges
+WHERE expires_at < NOW()
+`
+
+func (q *Queries) DeleteExpiredTwoFactorChallenges(ctx context.Context, db DBTX) error {
+	_, err := db.Exec(ctx, deleteExpiredTwoFactorChallenges)
+	return err
+}
+
+const deleteTwoFactorChallenge = `-- name: DeleteTwoFactorChallenge :exec
+DELETE
+FROM two_factor_challenges
+WHERE token_hash = $1
+`
+
+func (q *Queries) DeleteTwoFactorChallenge(ctx context.Context, db DBTX, tokenHash []byte) error {
+	_, err := db.Exec(ctx, deleteTwoFactorChallenge, tokenHash)
+	return err
+}
+
+const deleteUserRecoveryCodes = `-- name: DeleteUserRecoveryCodes :exec
+DELETE
+FROM recovery_codes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserRecoveryCodes(ctx context.Context, db DBTX, userID pgtype.UUID) error {
+	_, err := db.Exec(ctx, deleteUserRecoveryCodes, userID)
+	return err
+}
+
+const deleteUserTOTP = `-- name: DeleteUserTOTP :exec
+DELETE
+FROM user_totp
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserTOTP(ctx context.Context, db DBTX, userID pgtype.UUID) error {
+	_, err := db.Exec(ctx, deleteUserTOTP, userID)
+	return err
+}
+
+const enableTOTP = `-- name: EnableTOTP :execrows
+UPDATE user_totp
+SET enabled_at     = NOW(),
+    last_used_step = $1
+WHERE user_id = $2
+  AND enabled_at IS NULL
+`
+
+func (q *Queries) EnableTOTP(ctx context.Context, db DBTX, step int64, userID pgtype.UUID) (int64, error) {
+	result, err := db.Exec(ctx, enableTOTP, step, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getTwoFactorChallenge = `-- name: GetTwoFactorChallenge :one
+SELECT token_hash, user_id, expires_at
+FROM two_factor_challenges
+WHERE token_hash = $1
+  AND expires_at > NOW()
+`
+
+func (q *Queries) GetTwoFactorChallenge(ctx context.Context, db DBTX, tokenHash []byte) (TwoFactorChallenge, error) {
+	row := db.QueryRow(ctx, getTwoFactorChallenge, tokenHash)
+	var i TwoFactorChallenge
+	err := row.Scan(&i.TokenHash, &i.UserID, &i.ExpiresAt)
+	return i, err
+}
+
+const getUserTOTP = `-- name: GetUserTOTP :one
+SELECT user_id, secret, created_at, enabled_at, last_used_step
+FROM user_totp
+WHERE user_id = $1
+`
+
+func (q *Queries) GetUserTOTP(ctx context.Context, db DBTX, userID pgtype.UUID) (UserTotp, error) {
+	row := db.QueryRow(ctx, getUserTOTP, userID)
+	var i UserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.CreatedAt,
+		&i.EnabledAt,
+		&i.LastUsedStep,
+	)
+	return i, err
+}
+
+const isTwoFactorRequired = `-- name: IsTwoFactorRequired :one
+SELECT EXISTS (SELECT 1
+               FROM user_roles
+                        JOIN roles ON roles.name = user_roles.role
+               WHERE user_roles.user_id = $1
+                 AND roles.require_two_factor)
+`
+
+func (q *Queries) IsTwoFactorRequired(ctx context.Context, db DBTX, userID pgtype.UUID) (bool, error) {
+	row := db.QueryRow(ctx, isTwoFactorRequired, userID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const startTOTPSetup = `-- name: StartTOTPSetup :exec
+INSERT INTO user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+    SET secret     = EXCLUDED.secret,
+        created_at = NOW()
+WHERE user_totp.enabled_at IS NULL
+`
+
+func (q *Queries) StartTOTPSetup(ctx context.Context, db DBTX, userID pgtype.UUID, secret string) error {
+	_, err := db.Exec(ctx, startTOTPSetup, userID, secret)
+	return err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1
+  AND code_hash = $2
+  AND used_at IS NULL
+`
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, db DBTX, userID pgtype.UUID, codeHash []byte) (int64, error) {
+	result, err := db.Exec(ctx, useRecoveryCode, userID, codeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE user_totp
+SET last_used_step = $1
+WHERE user_id = $2
+  AND enabled_at IS NOT NULL
+  AND last_used_step < $1
+`
+
+func (q *Queries) UseTOTPStep(ctx context.Context, db DBTX, step int64, userID pgtype.UUID) (int64, error) {
+	result, err := db.Exec(ctx, useTOTPStep, step, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -11,58 +11,29 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const createAdmin = `-- name: CreateAdmin :one
-INSERT INTO users (username, password_hash, superuser)
-VALUES ($1, $2, true)
-RETURNING id, username, password_hash, superuser
-`
-
-type CreateAdminRow struct {
-	ID           pgtype.UUID `db:"id" json:"id"`
-	Username     string      `db:"username" json:"username"`
-	PasswordHash string      `db:"password_hash" json:"password_hash"`
-	Superuser    bool        `db:"superuser" json:"superuser"`
-}
-
-func (q *Queries) CreateAdmin(ctx context.Context, db DBTX, username string, passwordHash string) (CreateAdminRow, error) {
-	row := db.QueryRow(ctx, createAdmin, username, passwordHash)
-	var i CreateAdminRow
-	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.PasswordHash,
-		&i.Superuser,
-	)
-	return i, err
-}
-
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (username, password_hash, superuser)
-VALUES ($1, $2, false)
-RETURNING id, username, password_hash, superuser
+INSERT INTO users (username, password_hash)
+VALUES ($1, $2)
+RETURNING id, username, password_hash, problems_attempted, problems_solved, submission_rate_per_minute, submission_rate_burst
 `
 
-type CreateUserRow struct {
-	ID           pgtype.UUID `db:"id" json:"id"`
-	Username     string      `db:"username" json:"username"`
-	PasswordHash string      `db:"password_hash" json:"password_hash"`
-	Superuser    bool        `db:"superuser" json:"superuser"`
-}
-
-func (q *Queries) CreateUser(ctx context.Context, db DBTX, username string, passwordHash string) (CreateUserRow, error) {
+func (q *Queries) CreateUser(ctx context.Context, db DBTX, username string, passwordHash string) (User, error) {
 	row := db.QueryRow(ctx, createUser, username, passwordHash)
-	var i CreateUserRow
+	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.Username,
 		&i.PasswordHash,
-		&i.Superuser,
+		&i.ProblemsAttempted,
+		&i.ProblemsSolved,
+		&i.SubmissionRatePerMinute,
+		&i.SubmissionRateBurst,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, username, password_hash, superuser, problems_attempted, problems_solved
+SELECT id, username, password_hash, problems_attempted, problems_solved, submission_rate_per_minute, submission_rate_burst
 FROM users
 WHERE id = $1
 `
@@ -74,15 +45,16 @@ func (q *Queries) GetUser(ctx context.Context, db DBTX, id pgtype.UUID) (User, e
 		&i.ID,
 		&i.Username,
 		&i.PasswordHash,
-		&i.Superuser,
 		&i.ProblemsAttempted,
 		&i.ProblemsSolved,
+		&i.SubmissionRatePerMinute,
+		&i.SubmissionRateBurst,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, password_hash, superuser, problems_attempted, problems_solved
+SELECT id, username, password_hash, problems_attempted, problems_solved, submission_rate_per_minute, submission_rate_burst
 FROM users
 WHERE username = $1
 `
@@ -94,9 +66,10 @@ func (q *Queries) GetUserByUsername(ctx context.Context, db DBTX, username strin
 		&i.ID,
 		&i.Username,
 		&i.PasswordHash,
-		&i.Superuser,
 		&i.ProblemsAttempted,
 		&i.ProblemsSolved,
+		&i.SubmissionRatePerMinute,
+		&i.SubmissionRateBurst,
 	)
 	return i, err
 }
@@ -123,23 +96,42 @@ func (q *Queries) IncreaseUserSolves(ctx context.Context, db DBTX, id pgtype.UUI
 	return err
 }
 
-const toggleUserSuperLevel = `-- name: ToggleUserSuperLevel :one
+const setUserPassword = `-- name: SetUserPassword :exec
 UPDATE users
-SET superuser = NOT superuser
-WHERE id = $1
-RETURNING id, username, password_hash, superuser, problems_attempted, problems_solved
+SET password_hash = $1
+WHERE id = $2
 `
 
-func (q *Queries) ToggleUserSuperLevel(ctx context.Context, db DBTX, id pgtype.UUID) (User, error) {
-	row := db.QueryRow(ctx, toggleUserSuperLevel, id)
+func (q *Queries) SetUserPassword(ctx context.Context, db DBTX, passwordHash string, userID pgtype.UUID) error {
+	_, err := db.Exec(ctx, setUserPassword, passwordHash, userID)
+	return err
+}
+
+const setUserSubmissionRateLimit = `-- name: SetUserSubmissionRateLimit :one
+UPDATE users
+SET submission_rate_per_minute = $1,
+    submission_rate_burst      = $2
+WHERE id = $3
+RETURNING id, username, password_hash, problems_attempted, problems_solved, submission_rate_per_minute, submission_rate_burst
+`
+
+type SetUserSubmissionRateLimitParams struct {
+	PerMinute pgtype.Float8 `db:"per_minute" json:"per_minute"`
+	Burst     pgtype.Int4   `db:"burst" json:"burst"`
+	ID        pgtype.UUID   `db:"id" json:"id"`
+}
+
+func (q *Queries) SetUserSubmissionRateLimit(ctx context.Context, db DBTX, arg SetUserSubmissionRateLimitParams) (User, error) {
+	row := db.QueryRow(ctx, setUserSubmissionRateLimit, arg.PerMinute, arg.Burst, arg.ID)
 	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.Username,
 		&i.PasswordHash,
-		&i.Superuser,
 		&i.ProblemsAttempted,
 		&i.ProblemsSolved,
+		&i.SubmissionRatePerMinute,
+		&i.SubmissionRateBurst,
 	)
 	return i, err
 }
@@ -0,0 +1,81 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: verifications.sql
+
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createProblemVerification = `-- name: CreateProblemVerification :one
+INSERT INTO problem_verifications (problem_id, fingerprint)
+VALUES ($1, $2)
+RETURNING id, problem_id, status, fingerprint, report, suggested_time_limit_ms, started_at, finished_at
+`
+
+func (q *Queries) CreateProblemVerification(ctx context.Context, db DBTX, problemID int32, fingerprint string) (ProblemVerification, error) {
+	row := db.QueryRow(ctx, createProblemVerification, problemID, fingerprint)
+	var i ProblemVerification
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Status,
+		&i.Fingerprint,
+		&i.Report,
+		&i.SuggestedTimeLimitMs,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const finishProblemVerification = `-- name: FinishProblemVerification :exec
+UPDATE problem_verifications
+SET status = $2, report = $3, suggested_time_limit_ms = $4, finished_at = now()
+WHERE id = $1
+`
+
+type FinishProblemVerificationParams struct {
+	ID                   int32       `db:"id" json:"id"`
+	Status               JobStatus   `db:"status" json:"status"`
+	Report               string      `db:"report" json:"report"`
+	SuggestedTimeLimitMs pgtype.Int8 `db:"suggested_time_limit_ms" json:"suggested_time_limit_ms"`
+}
+
+func (q *Queries) FinishProblemVerification(ctx context.Context, db DBTX, arg FinishProblemVerificationParams) error {
+	_, err := db.Exec(ctx, finishProblemVerification,
+		arg.ID,
+		arg.Status,
+		arg.Report,
+		arg.SuggestedTimeLimitMs,
+	)
+	return err
+}
+
+const getLatestProblemVerification = `-- name: GetLatestProblemVerification :one
+SELECT id, problem_id, status, fingerprint, report, suggested_time_limit_ms, started_at, finished_at
+FROM problem_verifications
+WHERE problem_id = $1
+ORDER BY started_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestProblemVerification(ctx context.Context, db DBTX, problemID int32) (ProblemVerification, error) {
+	row := db.QueryRow(ctx, getLatestProblemVerification, problemID)
+	var i ProblemVerification
+	err := row.Scan(
+		&i.ID,
+		&i.ProblemID,
+		&i.Status,
+		&i.Fingerprint,
+		&i.Report,
+		&i.SuggestedTimeLimitMs,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
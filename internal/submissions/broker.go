@@ -7,21 +7,29 @@ import (
 	"io"
 	"iter"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/samber/lo"
 	"google.golang.org/grpc"
 
 	runnerPb "github.com/computer-technology-team/go-judge/api/gen/runner"
 	"github.com/computer-technology-team/go-judge/config"
+	"github.com/computer-technology-team/go-judge/internal/sourceview"
 	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/internal/testgen"
 )
 
-const jobsChannelBufferSize = 100
+const (
+	jobsChannelBufferSize = 100
+	// maxSampleFeedback caps the diff of a failing sample kept with the submission
+	maxSampleFeedback = 128 << 10
+)
 
 var errInternalErrorInEvaluation = errors.New("internal error happened while processing the job")
 
@@ -71,17 +79,9 @@ func NewBroker(brokerConfig config.BrokerConfig, runnerClient runnerPb.RunnerCli
 func (b *broker) AddSubmissionEvaluation(submission storage.Submission) {
 	ctx := context.Background()
 
-	// Get the problem details
-	problem, err := b.querier.GetProblemByID(ctx, b.pool, submission.ProblemID)
-	if err != nil {
-		slog.Error("could not get problem for submission", "submission_id", submission.ID, "problem_id", submission.ProblemID, "error", err)
-		return
-	}
-
-	// Get the test cases for the problem
-	testCases, err := b.querier.GetTestCasesByProblemID(ctx, b.pool, submission.ProblemID)
+	problem, testCases, err := b.loadProblem(ctx, submission.ProblemID)
 	if err != nil {
-		slog.Error("could not get test cases for problem", "submission_id", submission.ID, "problem_id", submission.ProblemID, "error", err)
+		slog.Error("could not load problem for submission", "submission_id", submission.ID, "problem_id", submission.ProblemID, "error", err)
 		return
 	}
 
@@ -101,6 +101,32 @@ func (b *broker) AddSubmissionEvaluation(submission storage.Submission) {
 		return
 	}
 
+	if !testgen.Ready(testCases) {
+		slog.Error("problem has tests that are not generated yet", "submission_id", submission.ID, "problem_id", submission.ProblemID)
+		_, err := b.querier.UpdateSubmissionStatus(ctx, b.pool, storage.UpdateSubmissionStatusParams{
+			ID:     submission.ID,
+			Status: storage.SubmissionStatusINTERNALERROR,
+			Message: pgtype.Text{
+				Valid:  true,
+				String: "Some tests of this problem have not been generated yet",
+			},
+		})
+		if err != nil {
+			slog.Error("could not update submission status", "error", err)
+		}
+		return
+	}
+
+	// Record the revision the submission is judged on
+	if problem.CurrentRevisionID.Valid {
+		err := b.querier.SetSubmissionRevision(ctx, b.pool, submission.ID, problem.CurrentRevisionID)
+		if err != nil {
+			slog.Error("could not set submission revision", "submission_id", submission.ID, "error", err)
+			return
+		}
+		submission.RevisionID = problem.CurrentRevisionID
+	}
+
 	// Create the job and send it to the channel
 	job := submissionEvaluation{
 		submission: submission,
@@ -111,6 +137,33 @@ func (b *broker) AddSubmissionEvaluation(submission storage.Submission) {
 	b.addJob(ctx, job)
 }
 
+// loadProblem reads the problem and its test cases from one snapshot, so they match its current revision
+func (b *broker) loadProblem(ctx context.Context, problemID int32) (storage.Problem, []storage.TestCase, error) {
+	tx, err := b.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return storage.Problem{}, nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func(ctx context.Context, tx pgx.Tx) {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.Error("could not rollback transaction", "error", err)
+		}
+	}(ctx, tx)
+
+	problem, err := b.querier.GetProblemByID(ctx, tx, problemID)
+	if err != nil {
+		return storage.Problem{}, nil, fmt.Errorf("could not get problem: %w", err)
+	}
+
+	testCases, err := b.querier.GetTestCasesByProblemID(ctx, tx, problemID)
+	if err != nil {
+		return storage.Problem{}, nil, fmt.Errorf("could not get test cases: %w", err)
+	}
+
+	return problem, testCases, nil
+}
+
 func (b *broker) addJob(ctx context.Context, job submissionEvaluation) {
 	select {
 	case b.jobsChan <- job:
@@ -259,6 +312,12 @@ func getErrorForStatus(status runnerPb.SubmissionStatusUpdate_Status) error {
 
 // handleStatusUpdate processes a status update and returns the updated submission
 func (b *broker) handleStatusUpdate(ctx context.Context, job submissionEvaluation, updateEvent *runnerPb.SubmissionStatusUpdate) (storage.Submission, error) {
+	switch updateEvent.GetStatus() {
+	case runnerPb.SubmissionStatusUpdate_WRONG_ANSWER, runnerPb.SubmissionStatusUpdate_TIME_LIMIT_EXCEEDED,
+		runnerPb.SubmissionStatusUpdate_RUNTIME_ERROR, runnerPb.SubmissionStatusUpdate_MEMORY_LIMIT_EXCEEDED:
+		b.recordFailedTest(ctx, job, updateEvent)
+	}
+
 	switch updateEvent.GetStatus() {
 	case runnerPb.SubmissionStatusUpdate_RUNNING:
 		return b.updateSubmissionStatus(ctx, b.pool, job.submission, storage.SubmissionStatusRUNNING,
@@ -292,8 +351,7 @@ func (b *broker) handleStatusUpdate(ctx context.Context, job submissionEvaluatio
 			fmt.Sprintf("Time limit exceeded (%d ms) on test case %d", job.problem.TimeLimitMs, updateEvent.TestsCompleted+1), "time limit exceeded")
 
 	case runnerPb.SubmissionStatusUpdate_WRONG_ANSWER:
-		return b.updateSubmissionStatus(ctx, b.pool, job.submission, storage.SubmissionStatusWRONGANSWER,
-			fmt.Sprintf("Wrong answer on test case %d", updateEvent.TestsCompleted+1), "wrong answer")
+		return b.wrongAnswer(ctx, job, updateEvent)
 
 	default:
 		slog.Error("unexpected update event", "status", updateEvent.GetStatus())
@@ -322,6 +380,65 @@ func (b *broker) updateSubmissionStatus(ctx context.Context, db storage.DBTX, su
 	return updatedSubmission, nil
 }
 
+// recordFailedTest stores which test of the judged revision failed, problems in practice mode show it to the submitter
+func (b *broker) recordFailedTest(ctx context.Context, job submissionEvaluation,
+	updateEvent *runnerPb.SubmissionStatusUpdate) {
+
+	if !job.submission.RevisionID.Valid {
+		return
+	}
+
+	err := b.querier.SetSubmissionFailedTest(ctx, b.pool, job.submission.ID,
+		pgtype.Int4{Int32: int32(updateEvent.TestsCompleted) + 1, Valid: true})
+	if err != nil {
+		slog.Error("could not record failed test", "submission_id", job.submission.ID, "error", err)
+	}
+}
+
+// wrongAnswer fails the submission, a failed sample is public so the user also gets the diff of the outputs
+func (b *broker) wrongAnswer(ctx context.Context, job submissionEvaluation,
+	updateEvent *runnerPb.SubmissionStatusUpdate) (storage.Submission, error) {
+
+	index := int(updateEvent.TestsCompleted)
+	if index >= len(job.testCases) || !job.testCases[index].IsSample {
+		return b.updateSubmissionStatus(ctx, b.pool, job.submission, storage.SubmissionStatusWRONGANSWER,
+			fmt.Sprintf("Wrong answer on test case %d", index+1), "wrong answer")
+	}
+
+	feedback, err := sampleDiff(job.testCases[index].Output, updateEvent.GetOutput())
+	if err != nil {
+		slog.Error("could not diff sample output", "error", err)
+	}
+	if cut, truncated := sourceview.Truncate(feedback, maxSampleFeedback); truncated {
+		feedback = cut + "\n[diff truncated]"
+	}
+
+	updatedSubmission, err := b.querier.UpdateSubmissionStatusWithFeedback(ctx, b.pool,
+		storage.UpdateSubmissionStatusWithFeedbackParams{
+			ID:       job.submission.ID,
+			Status:   storage.SubmissionStatusWRONGANSWER,
+			Message:  pgtype.Text{Valid: true, String: fmt.Sprintf("Wrong answer on sample %d", index+1)},
+			Feedback: pgtype.Text{Valid: feedback != "", String: feedback},
+		})
+	if err != nil {
+		slog.Error("could not update submission status", "status", "wrong answer", "error", err)
+		return job.submission, fmt.Errorf("could not update submission status: %w", err)
+	}
+
+	return updatedSubmission, nil
+}
+
+// sampleDiff compares outputs the way the runner does, ignoring surrounding whitespace
+func sampleDiff(expected, actual string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(strings.TrimSpace(expected) + "\n"),
+		B:        difflib.SplitLines(strings.TrimSpace(actual) + "\n"),
+		FromFile: "expected",
+		ToFile:   "your output",
+		Context:  3,
+	})
+}
+
 func (b *broker) acceptSubmission(ctx context.Context, submission storage.Submission,
 	status storage.SubmissionStatus, message string, logStatus string) (storage.Submission, error) {
 
@@ -2,17 +2,21 @@ package submissions
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
 	"github.com/computer-technology-team/go-judge/internal/storage"
 	"github.com/computer-technology-team/go-judge/web/templates"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const maxFileSize = 10_000_000 // 10MB
@@ -88,10 +92,40 @@ func (s *ServicerImpl) CreateSubmission(w http.ResponseWriter, r *http.Request)
 		}
 	}(ctx, tx)
 
+	hash := codeHash(code)
+
+	if s.duplicateWindow > 0 {
+		// serializes submissions of the user so that both requests of a double click can not miss each other
+		err = s.querier.LockUserSubmissions(ctx, tx, user.ID)
+		if err != nil {
+			logger.ErrorContext(ctx, "could not lock user submissions", "error", err)
+			templates.RenderError(ctx, w, "could not process submission", http.StatusInternalServerError, s.templates)
+			return
+		}
+
+		duplicateID, err := s.querier.GetRecentDuplicateSubmission(ctx, tx, storage.GetRecentDuplicateSubmissionParams{
+			UserID:    user.ID,
+			ProblemID: int32(problemID),
+			CodeHash:  hash,
+			Since:     pgtype.Timestamptz{Time: time.Now().Add(-s.duplicateWindow), Valid: true},
+		})
+		if err == nil {
+			logger.InfoContext(ctx, "redirecting duplicate submission", "submission_id", duplicateID)
+			http.Redirect(w, r, fmt.Sprintf("/submissions/%s", duplicateID), http.StatusSeeOther)
+			return
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logger.ErrorContext(ctx, "could not check for duplicate submissions", "error", err)
+			templates.RenderError(ctx, w, "could not process submission", http.StatusInternalServerError, s.templates)
+			return
+		}
+	}
+
 	submissionParams := storage.CreateSubmissionParams{
 		ProblemID:    int32(problemID),
 		UserID:       user.ID,
 		SolutionCode: code,
+		CodeHash:     hash,
 	}
 
 	submission, err := s.querier.CreateSubmission(ctx, tx, submissionParams)
@@ -119,3 +153,9 @@ func (s *ServicerImpl) CreateSubmission(w http.ResponseWriter, r *http.Request)
 
 	go s.broker.AddSubmissionEvaluation(submission)
 }
+
+// codeHash identifies identical solutions, it matches the hash computed for existing submissions in the migrations
+func codeHash(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
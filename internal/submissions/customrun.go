@@ -0,0 +1,150 @@
+package submissions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/samber/lo"
+
+	runnerPb "github.com/computer-technology-team/go-judge/api/gen/runner"
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+	"github.com/computer-technology-team/go-judge/internal/sourceview"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+const (
+	maxCustomRunInput  = 1 << 20 // 1MB
+	maxCustomRunOutput = 64 << 10
+	// customRunTimeout covers building the code and running it
+	customRunTimeout = 50 * time.Second
+)
+
+type customRunResult struct {
+	Status   string `json:"status"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	TimeMs   int64  `json:"time_ms"`
+	MemoryKb int64  `json:"memory_kb"`
+}
+
+// CustomRun runs the posted code on the posted input with the limits of the problem and returns its output.
+// Nothing is stored, it does not count as a submission or an attempt.
+func (s *ServicerImpl) CustomRun(w http.ResponseWriter, r *http.Request) {
+	logger := slog.With("function", "CustomRun", "package", "submissions")
+	ctx := r.Context()
+
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	if allowed, retryAfter := s.customRunLimiter.Allow(user.ID.String()); !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeJSONError(w, "too many custom runs, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	problemID, err := strconv.Atoi(chi.URLParam(r, "problem_id"))
+	if err != nil {
+		writeJSONError(w, "problem id is invalid", http.StatusBadRequest)
+		return
+	}
+
+	problem, err := s.querier.GetProblemForUser(ctx, s.pool, storage.GetProblemForUserParams{
+		ID:        int32(problemID),
+		CreatedBy: user.ID,
+		IsAdmin:   internalcontext.HasPermission(ctx, rbac.ManageProblems),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSONError(w, "problem not found", http.StatusNotFound)
+			return
+		}
+		logger.ErrorContext(ctx, "could not retrieve problem", "error", err)
+		writeJSONError(w, "could not retrieve problem", http.StatusInternalServerError)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxFileSize+maxCustomRunInput)
+	if err := r.ParseMultipartForm(maxFileSize); err != nil {
+		writeJSONError(w, "could not parse form", http.StatusBadRequest)
+		return
+	}
+
+	code := r.PostFormValue("code")
+	input := r.PostFormValue("input")
+	if code == "" {
+		writeJSONError(w, "solution code is required", http.StatusBadRequest)
+		return
+	}
+	if len(input) > maxCustomRunInput {
+		writeJSONError(w, fmt.Sprintf("input is too large (max size: %d bytes)", maxCustomRunInput), http.StatusBadRequest)
+		return
+	}
+
+	// building the code can take longer than the server write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(customRunTimeout + 5*time.Second)); err != nil {
+		logger.WarnContext(ctx, "could not extend write deadline", "error", err)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, customRunTimeout)
+	defer cancel()
+
+	stream, err := s.runnerClient.RunProgram(runCtx, &runnerPb.ProgramRequest{
+		ProgramId:     "custom-" + user.ID.String(),
+		Code:          code,
+		TimeLimitMs:   problem.TimeLimitMs,
+		MemoryLimitKb: problem.MemoryLimitKb,
+		Runs:          []*runnerPb.ProgramRequest_Run{{Input: input}},
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "could not start custom run", "error", err)
+		writeJSONError(w, "could not start the run", http.StatusBadGateway)
+		return
+	}
+
+	res, err := stream.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			writeJSONError(w, "the run took too long", http.StatusGatewayTimeout)
+			return
+		}
+		logger.ErrorContext(ctx, "could not receive custom run result", "error", err)
+		writeJSONError(w, "could not run the code", http.StatusBadGateway)
+		return
+	}
+
+	result := customRunResult{
+		Status:   lo.Ternary(res.GetStatus() == runnerPb.SubmissionStatusUpdate_ACCEPTED, "OK", res.GetStatus().String()),
+		Stdout:   truncateOutput(res.GetStdout()),
+		Stderr:   truncateOutput(res.GetStderr()),
+		TimeMs:   res.GetTimeSpentMs(),
+		MemoryKb: res.GetMemoryKb(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logger.ErrorContext(ctx, "could not encode custom run result", "error", err)
+	}
+}
+
+func truncateOutput(s string) string {
+	if s, truncated := sourceview.Truncate(s, maxCustomRunOutput); truncated {
+		return s + "\n[output truncated]"
+	}
+	return s
+}
+
+func writeJSONError(w http.ResponseWriter, message string, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
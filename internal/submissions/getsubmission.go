@@ -1,49 +1,201 @@
 package submissions
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"html/template"
+	"io"
 	"log/slog"
 	"net/http"
 
-	"github.com/computer-technology-team/go-judge/internal/context"
-	"github.com/computer-technology-team/go-judge/web/templates"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/lo"
+
+	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
+	"github.com/computer-technology-team/go-judge/internal/sourceview"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/web/templates"
 )
 
+// maxRevealedTestSize caps the input and the output of a failed test shown in practice mode
+const maxRevealedTestSize = 4 << 10
+
+type submissionPageData struct {
+	storage.GetSubmissionRow
+	Code template.HTML
+	Own  bool
+	// Others are the other submissions of the same user to the problem, to diff against
+	Others []storage.GetUserProblemSubmissionsRow
+	// FailedTest is set when the problem reveals the first failed test
+	FailedTest *revealedTest
+}
+
+type revealedTest struct {
+	Number          int32
+	Input           string
+	Output          string
+	InputTruncated  bool
+	OutputTruncated bool
+}
+
+type submissionDiffPageData struct {
+	From  storage.GetSubmissionRow
+	To    storage.GetSubmissionRow
+	Lines []sourceview.DiffLine
+}
+
 // GetSubmission returns a specific submission
 func (s *ServicerImpl) GetSubmission(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	id := chi.URLParam(r, "id")
+	user, _ := internalcontext.GetUserFromContext(ctx)
 
-	idUUID, err := uuid.Parse(id)
-	if err != nil {
-		templates.RenderError(ctx, w, "invalid id, id must be uuid", http.StatusBadRequest, s.templates)
+	submission, ok := s.getVisibleSubmission(ctx, w, user, chi.URLParam(r, "id"))
+	if !ok {
 		return
 	}
 
-	user, _ := context.GetUserFromContext(ctx)
+	code, err := sourceview.Highlight(submission.Submission.SolutionCode)
+	if err != nil {
+		slog.Error("could not highlight submission code", "error", err)
+		templates.RenderError(ctx, w, "could not render submission", http.StatusInternalServerError, s.templates)
+		return
+	}
 
-	submission, err := s.querier.GetSubmissionForUser(ctx, s.pool, user.ID, pgtype.UUID{
-		Bytes: idUUID,
-		Valid: true,
-	})
+	others, err := s.querier.GetUserProblemSubmissions(ctx, s.pool, submission.Submission.UserID,
+		submission.Submission.ProblemID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			templates.RenderError(ctx, w, "submission not found", http.StatusNotFound, s.templates)
-			return
-		}
-		slog.Error("could not get submission from database", "error", err)
+		slog.Error("could not get other submissions", "error", err)
 		templates.RenderError(ctx, w, "could not retrieve submission", http.StatusInternalServerError, s.templates)
 		return
 	}
 
-	err = s.templates.Render(ctx, "submission", w, submission)
+	data := submissionPageData{
+		GetSubmissionRow: submission,
+		Code:             code,
+		Own:              submission.Submission.UserID == user.ID,
+		Others: lo.Filter(others, func(other storage.GetUserProblemSubmissionsRow, _ int) bool {
+			return other.ID != submission.Submission.ID
+		}),
+	}
+
+	if submission.RevealFailingTest && submission.Submission.FailedTest.Valid && submission.Submission.RevisionID.Valid {
+		test, err := s.querier.GetProblemRevisionTest(ctx, s.pool, submission.Submission.RevisionID.Int32,
+			submission.Submission.FailedTest.Int32)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			slog.Error("could not get failed test", "error", err)
+			templates.RenderError(ctx, w, "could not retrieve submission", http.StatusInternalServerError, s.templates)
+			return
+		}
+		if err == nil {
+			data.FailedTest = &revealedTest{Number: test.Position}
+			data.FailedTest.Input, data.FailedTest.InputTruncated = sourceview.Truncate(test.Input, maxRevealedTestSize)
+			data.FailedTest.Output, data.FailedTest.OutputTruncated = sourceview.Truncate(test.Output, maxRevealedTestSize)
+		}
+	}
+
+	err = s.templates.Render(ctx, "submission", w, data)
 	if err != nil {
 		slog.Error("could not render submssion template", "error", err)
 		templates.RenderError(ctx, w, "could not render template", http.StatusInternalServerError, s.templates)
 		return
 	}
 }
+
+// DownloadSource downloads the code of a submission as a Go file
+func (s *ServicerImpl) DownloadSource(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	submission, ok := s.getVisibleSubmission(ctx, w, user, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/x-go; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.go"`,
+		submission.Username, submission.Submission.ID))
+	_, _ = io.WriteString(w, submission.Submission.SolutionCode)
+}
+
+// DiffSubmissions shows the changes between two submissions of a user to the same problem
+func (s *ServicerImpl) DiffSubmissions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := internalcontext.GetUserFromContext(ctx)
+
+	from, ok := s.getVisibleSubmission(ctx, w, user, r.URL.Query().Get("with"))
+	if !ok {
+		return
+	}
+	to, ok := s.getVisibleSubmission(ctx, w, user, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	if from.Submission.UserID != to.Submission.UserID || from.Submission.ProblemID != to.Submission.ProblemID {
+		templates.RenderError(ctx, w, "only submissions of the same user to the same problem can be compared",
+			http.StatusBadRequest, s.templates)
+		return
+	}
+
+	// the older submission is always the base of the diff
+	if from.Submission.CreatedAt.Time.After(to.Submission.CreatedAt.Time) {
+		from, to = to, from
+	}
+
+	lines, err := sourceview.Diff(from.Submission.SolutionCode, to.Submission.SolutionCode,
+		from.Submission.CreatedAt.Time.Format("Jan 02 15:04:05"), to.Submission.CreatedAt.Time.Format("Jan 02 15:04:05"))
+	if err != nil {
+		slog.Error("could not diff submissions", "error", err)
+		templates.RenderError(ctx, w, "could not diff submissions", http.StatusInternalServerError, s.templates)
+		return
+	}
+
+	err = s.templates.Render(ctx, "submissiondiff", w, submissionDiffPageData{From: from, To: to, Lines: lines})
+	if err != nil {
+		slog.Error("could not render submission diff template", "error", err)
+		templates.RenderError(ctx, w, "could not render template", http.StatusInternalServerError, s.templates)
+		return
+	}
+}
+
+// getVisibleSubmission loads a submission the user may see, rendering an error otherwise.
+// Users see their own submissions, the author of the problem and staff who can view submissions see everyone's when the problem allows it.
+func (s *ServicerImpl) getVisibleSubmission(ctx context.Context, w http.ResponseWriter, user *storage.User,
+	id string) (storage.GetSubmissionRow, bool) {
+
+	idUUID, err := uuid.Parse(id)
+	if err != nil {
+		templates.RenderError(ctx, w, "invalid id, id must be uuid", http.StatusBadRequest, s.templates)
+		return storage.GetSubmissionRow{}, false
+	}
+
+	submission, err := s.querier.GetSubmission(ctx, s.pool, pgtype.UUID{Bytes: idUUID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			templates.RenderError(ctx, w, "submission not found", http.StatusNotFound, s.templates)
+			return storage.GetSubmissionRow{}, false
+		}
+		slog.Error("could not get submission from database", "error", err)
+		templates.RenderError(ctx, w, "could not retrieve submission", http.StatusInternalServerError, s.templates)
+		return storage.GetSubmissionRow{}, false
+	}
+
+	// personal access tokens only read the submissions of their user
+	_, viaAPIToken := internalcontext.GetAPITokenFromContext(ctx)
+	visible := submission.Submission.UserID == user.ID ||
+		(!viaAPIToken && submission.SubmissionVisibility == storage.SubmissionVisibilitySTAFF &&
+			(internalcontext.HasPermission(ctx, rbac.ViewSubmissions) || submission.ProblemAuthor == user.ID))
+	if !visible {
+		// hidden submissions look like missing ones
+		templates.RenderError(ctx, w, "submission not found", http.StatusNotFound, s.templates)
+		return storage.GetSubmissionRow{}, false
+	}
+
+	return submission, true
+}
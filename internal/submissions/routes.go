@@ -2,11 +2,17 @@ package submissions
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	runnerPb "github.com/computer-technology-team/go-judge/api/gen/runner"
+	"github.com/computer-technology-team/go-judge/config"
+	"github.com/computer-technology-team/go-judge/internal/middleware"
+	"github.com/computer-technology-team/go-judge/internal/ratelimit"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
 	"github.com/computer-technology-team/go-judge/internal/storage"
 	"github.com/computer-technology-team/go-judge/web/templates"
 )
@@ -17,33 +23,50 @@ type Servicer interface {
 	SubmissionForm(w http.ResponseWriter, r *http.Request)
 	CreateSubmission(w http.ResponseWriter, r *http.Request)
 	GetSubmission(w http.ResponseWriter, r *http.Request)
+	DiffSubmissions(w http.ResponseWriter, r *http.Request)
+	DownloadSource(w http.ResponseWriter, r *http.Request)
+	CustomRun(w http.ResponseWriter, r *http.Request)
 }
 
 // NewRoutes returns a function that registers routes with the given handler
-// This allows for dependency injection when setting up routes
-func NewRoutes(s Servicer) func(r chi.Router) {
+// This allows for dependency injection when setting up routes, submissionLimit guards creating submissions
+func NewRoutes(s Servicer, sharedTemplates *templates.Templates,
+	submissionLimit func(http.Handler) http.Handler) func(r chi.Router) {
 	return func(r chi.Router) {
 		r.Get("/", s.ListSubmissions)
-		r.Get("/problem/{problem_id}/new", s.SubmissionForm)
-		r.Post("/", s.CreateSubmission)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.NewRequirePermissionMiddleware(sharedTemplates, rbac.Submit))
+			r.Get("/problem/{problem_id}/new", s.SubmissionForm)
+			r.Post("/problem/{problem_id}/run", s.CustomRun)
+			r.With(submissionLimit).Post("/", s.CreateSubmission)
+		})
 		r.Get("/{id}", s.GetSubmission)
+		r.Get("/{id}/diff", s.DiffSubmissions)
+		r.Get("/{id}/source", s.DownloadSource)
 	}
 }
 
 // ServicerImpl is the default implementation of the Handler interface
 type ServicerImpl struct {
-	broker    Broker
-	querier   storage.Querier
-	pool      *pgxpool.Pool
-	templates *templates.Templates
+	broker           Broker
+	querier          storage.Querier
+	pool             *pgxpool.Pool
+	templates        *templates.Templates
+	runnerClient     runnerPb.RunnerClient
+	customRunLimiter *ratelimit.Limiter
+	duplicateWindow  time.Duration
 }
 
 // NewServicer creates a new instance of the default submission handler
-func NewServicer(broker Broker, templates *templates.Templates, querier storage.Querier, pool *pgxpool.Pool) Servicer {
+func NewServicer(broker Broker, templates *templates.Templates, querier storage.Querier, pool *pgxpool.Pool,
+	runnerClient runnerPb.RunnerClient, cfg config.SubmissionsConfig, rateLimits config.RateLimitsConfig) Servicer {
 	return &ServicerImpl{
-		broker:    broker,
-		querier:   querier,
-		pool:      pool,
-		templates: templates,
+		broker:           broker,
+		querier:          querier,
+		pool:             pool,
+		templates:        templates,
+		runnerClient:     runnerClient,
+		customRunLimiter: ratelimit.New(rateLimits.CustomRun),
+		duplicateWindow:  cfg.DuplicateWindow,
 	}
 }
@@ -0,0 +1,265 @@
+package testarchive
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	DefaultInputPattern  = "*.in"
+	DefaultOutputPattern = "*.out"
+
+	// MaxArchiveSize is the largest archive accepted for upload
+	MaxArchiveSize = 64 << 20 // 64MB
+	// MaxFileSize caps a single uncompressed test file
+	MaxFileSize = 16 << 20 // 16MB
+	// MaxTotalSize caps the sum of all uncompressed test files
+	MaxTotalSize = 128 << 20 // 128MB
+	// MaxTests caps the number of test pairs in a single archive
+	MaxTests = 500
+)
+
+var ErrInvalidArchive = errors.New("invalid test archive")
+
+// Options configure how archive entries are paired into tests.
+// Each pattern must contain exactly one '*' which stands for the test name, e.g. "*.in" and "*.out".
+// Patterns without a '/' are matched against file base names, so pairs must live in the same directory.
+type Options struct {
+	InputPattern  string
+	OutputPattern string
+}
+
+type Test struct {
+	Name   string
+	Input  string
+	Output string
+}
+
+// Result holds the parsed tests in natural name order and the files that matched neither pattern
+type Result struct {
+	Tests   []Test
+	Ignored []string
+}
+
+func (o Options) Validate() error {
+	var errs error
+	for _, pattern := range []string{o.InputPattern, o.OutputPattern} {
+		if strings.Count(pattern, "*") != 1 {
+			errs = errors.Join(errs, fmt.Errorf("pattern %q must contain exactly one '*'", pattern))
+		}
+	}
+	if o.InputPattern == o.OutputPattern {
+		errs = errors.Join(errs, errors.New("input and output patterns must differ"))
+	}
+	if errs != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidArchive, errs)
+	}
+	return nil
+}
+
+// Parse reads a zip archive and pairs its files into tests according to opts
+func Parse(r io.ReaderAt, size int64, opts Options) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	if size > MaxArchiveSize {
+		return nil, fmt.Errorf("%w: archive is larger than %d bytes", ErrInvalidArchive, MaxArchiveSize)
+	}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not open zip: %w", ErrInvalidArchive, err)
+	}
+
+	inputs := make(map[string]*zip.File)
+	outputs := make(map[string]*zip.File)
+	result := &Result{}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		name := path.Clean(f.Name)
+
+		inputName, isInput := match(opts.InputPattern, name)
+		outputName, isOutput := match(opts.OutputPattern, name)
+
+		// when both patterns match, the one with more literal characters is the more specific one
+		if isInput && isOutput {
+			if len(opts.OutputPattern) >= len(opts.InputPattern) {
+				isInput = false
+			} else {
+				isOutput = false
+			}
+		}
+
+		switch {
+		case isInput:
+			inputs[inputName] = f
+		case isOutput:
+			outputs[outputName] = f
+		default:
+			result.Ignored = append(result.Ignored, name)
+		}
+	}
+
+	var errs error
+	for name := range inputs {
+		if _, ok := outputs[name]; !ok {
+			errs = errors.Join(errs, fmt.Errorf("test %q has no output file", name))
+		}
+	}
+	for name := range outputs {
+		if _, ok := inputs[name]; !ok {
+			errs = errors.Join(errs, fmt.Errorf("test %q has no input file", name))
+		}
+	}
+	if errs != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, errs)
+	}
+
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: no files match %q and %q", ErrInvalidArchive, opts.InputPattern, opts.OutputPattern)
+	}
+	if len(inputs) > MaxTests {
+		return nil, fmt.Errorf("%w: archive has %d tests, at most %d are allowed", ErrInvalidArchive, len(inputs), MaxTests)
+	}
+
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, naturalCompare)
+
+	var totalSize uint64
+	for _, name := range names {
+		totalSize += inputs[name].UncompressedSize64 + outputs[name].UncompressedSize64
+		if totalSize > MaxTotalSize {
+			return nil, fmt.Errorf("%w: tests are larger than %d bytes in total", ErrInvalidArchive, MaxTotalSize)
+		}
+
+		input, err := readEntry(inputs[name])
+		if err != nil {
+			return nil, err
+		}
+		output, err := readEntry(outputs[name])
+		if err != nil {
+			return nil, err
+		}
+
+		result.Tests = append(result.Tests, Test{Name: name, Input: input, Output: output})
+	}
+
+	slices.Sort(result.Ignored)
+
+	return result, nil
+}
+
+// match reports whether name matches pattern and returns the part captured by '*'
+func match(pattern, name string) (string, bool) {
+	dir := ""
+	if !strings.Contains(pattern, "/") {
+		dir, name = path.Split(name)
+	}
+
+	prefix, suffix, _ := strings.Cut(pattern, "*")
+	if len(name) <= len(prefix)+len(suffix) ||
+		!strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return "", false
+	}
+
+	captured := name[len(prefix) : len(name)-len(suffix)]
+	if strings.Contains(captured, "/") {
+		return "", false
+	}
+
+	return dir + captured, true
+}
+
+func readEntry(f *zip.File) (string, error) {
+	if f.UncompressedSize64 > MaxFileSize {
+		return "", fmt.Errorf("%w: file %q is larger than %d bytes", ErrInvalidArchive, f.Name, MaxFileSize)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return "", fmt.Errorf("could not open %q: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(io.LimitReader(rc, MaxFileSize+1))
+	if err != nil {
+		return "", fmt.Errorf("could not read %q: %w", f.Name, err)
+	}
+	if len(content) > MaxFileSize {
+		return "", fmt.Errorf("%w: file %q is larger than %d bytes", ErrInvalidArchive, f.Name, MaxFileSize)
+	}
+
+	return string(content), nil
+}
+
+// naturalCompare orders names so that embedded numbers compare by value, e.g. "2" < "10"
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		aDigits, bDigits := leadingDigits(a), leadingDigits(b)
+		if aDigits != "" && bDigits != "" {
+			aNum, bNum := strings.TrimLeft(aDigits, "0"), strings.TrimLeft(bDigits, "0")
+			if len(aNum) != len(bNum) {
+				return len(aNum) - len(bNum)
+			}
+			if c := strings.Compare(aNum, bNum); c != 0 {
+				return c
+			}
+			a, b = a[len(aDigits):], b[len(bDigits):]
+			continue
+		}
+
+		if a[0] != b[0] {
+			return int(a[0]) - int(b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+
+	return len(a) - len(b)
+}
+
+func leadingDigits(s string) string {
+	end := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if end == -1 {
+		return s
+	}
+	return s[:end]
+}
+
+// Write serializes tests as a zip archive with the default patterns, so Parse reads it back
+func Write(w io.Writer, tests []Test) error {
+	zw := zip.NewWriter(w)
+
+	for _, test := range tests {
+		entries := []struct{ pattern, content string }{
+			{DefaultInputPattern, test.Input},
+			{DefaultOutputPattern, test.Output},
+		}
+		for _, entry := range entries {
+			fw, err := zw.Create(strings.Replace(entry.pattern, "*", test.Name, 1))
+			if err != nil {
+				return fmt.Errorf("could not create archive entry: %w", err)
+			}
+			if _, err := io.WriteString(fw, entry.content); err != nil {
+				return fmt.Errorf("could not write archive entry: %w", err)
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("could not finish archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package testarchive
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type TestArchiveTestSuite struct {
+	suite.Suite
+}
+
+func (s *TestArchiveTestSuite) TestParse() {
+	archive := s.createArchive(map[string]string{
+		"10.in":     "10",
+		"10.out":    "100",
+		"2.in":      "2",
+		"2.out":     "4",
+		"README.md": "readme",
+	})
+
+	result, err := Parse(bytes.NewReader(archive), int64(len(archive)), Options{
+		InputPattern:  DefaultInputPattern,
+		OutputPattern: DefaultOutputPattern,
+	})
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), []Test{
+		{Name: "2", Input: "2", Output: "4"},
+		{Name: "10", Input: "10", Output: "100"},
+	}, result.Tests)
+	assert.Equal(s.T(), []string{"README.md"}, result.Ignored)
+}
+
+func (s *TestArchiveTestSuite) TestParseCustomPatterns() {
+	archive := s.createArchive(map[string]string{
+		"tests/input1.txt":  "1",
+		"tests/output1.txt": "2",
+	})
+
+	result, err := Parse(bytes.NewReader(archive), int64(len(archive)), Options{
+		InputPattern:  "input*.txt",
+		OutputPattern: "output*.txt",
+	})
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), []Test{{Name: "tests/1", Input: "1", Output: "2"}}, result.Tests)
+}
+
+func (s *TestArchiveTestSuite) TestParseErrors() {
+	testCases := []struct {
+		name  string
+		files map[string]string
+		opts  Options
+	}{
+		{
+			name:  "missing output",
+			files: map[string]string{"1.in": "1", "1.out": "1", "2.in": "2"},
+			opts:  Options{InputPattern: DefaultInputPattern, OutputPattern: DefaultOutputPattern},
+		},
+		{
+			name:  "missing input",
+			files: map[string]string{"1.out": "1"},
+			opts:  Options{InputPattern: DefaultInputPattern, OutputPattern: DefaultOutputPattern},
+		},
+		{
+			name:  "no matching files",
+			files: map[string]string{"a.txt": "1"},
+			opts:  Options{InputPattern: DefaultInputPattern, OutputPattern: DefaultOutputPattern},
+		},
+		{
+			name:  "invalid pattern",
+			files: map[string]string{"1.in": "1", "1.out": "1"},
+			opts:  Options{InputPattern: "in", OutputPattern: DefaultOutputPattern},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			archive := s.createArchive(tc.files)
+
+			result, err := Parse(bytes.NewReader(archive), int64(len(archive)), tc.opts)
+			assert.ErrorIs(s.T(), err, ErrInvalidArchive)
+			assert.Nil(s.T(), result)
+		})
+	}
+}
+
+func (s *TestArchiveTestSuite) TestWriteRoundTrip() {
+	tests := []Test{
+		{Name: "01", Input: "1 2", Output: "3"},
+		{Name: "02", Input: "", Output: "0"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(s.T(), Write(&buf, tests))
+
+	result, err := Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()), Options{
+		InputPattern:  DefaultInputPattern,
+		OutputPattern: DefaultOutputPattern,
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), tests, result.Tests)
+	assert.Empty(s.T(), result.Ignored)
+}
+
+func (s *TestArchiveTestSuite) createArchive(files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		fw, err := zw.Create(name)
+		require.NoError(s.T(), err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(s.T(), err)
+	}
+	require.NoError(s.T(), zw.Close())
+
+	return buf.Bytes()
+}
+
+func TestTestArchiveSuite(t *testing.T) {
+	suite.Run(t, new(TestArchiveTestSuite))
+}
@@ -0,0 +1,403 @@
+package testgen
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/samber/lo"
+
+	runnerPb "github.com/computer-technology-team/go-judge/api/gen/runner"
+	"github.com/computer-technology-team/go-judge/internal/revisions"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+const (
+	// generators and validators are trusted author code, they get more room than submissions
+	programTimeLimitMs   = 10_000
+	programMemoryLimitKb = 1_048_576
+
+	// GenerationTimeout bounds a whole generation, a run older than this is considered dead
+	GenerationTimeout = 30 * time.Minute
+)
+
+var (
+	ErrGenerationRunning = errors.New("a test generation is already running for this problem")
+	ErrProgramFailed     = errors.New("program failed")
+)
+
+// Report describes what a generation did and why it failed, if it did
+type Report struct {
+	Generated int
+	Solved    int
+	Validated int
+	Errors    []string
+}
+
+func (r *Report) Failed() bool {
+	return len(r.Errors) > 0
+}
+
+func (r *Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "generated %d inputs, produced %d outputs, validated %d tests\n", r.Generated, r.Solved, r.Validated)
+	for _, e := range r.Errors {
+		sb.WriteString(e)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func (r *Report) addError(format string, args ...any) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+// database is the part of the pool the generator uses
+type database interface {
+	storage.DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Generator fills in generated tests by running the problem programs in the runner.
+// Inputs are cached by the hash of their generator source and arguments, and outputs by the hash
+// of the reference solution and the input, so only what changed is run again.
+type Generator struct {
+	pool         database
+	querier      storage.Querier
+	runnerClient runnerPb.RunnerClient
+}
+
+func NewGenerator(pool *pgxpool.Pool, querier storage.Querier, runnerClient runnerPb.RunnerClient) *Generator {
+	return &Generator{pool: pool, querier: querier, runnerClient: runnerClient}
+}
+
+// Start records a new generation for the problem and runs it in the background
+func (g *Generator) Start(ctx context.Context, problemID int32) (storage.TestGeneration, error) {
+	latest, err := g.querier.GetLatestTestGeneration(ctx, g.pool, problemID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return storage.TestGeneration{}, fmt.Errorf("could not get latest generation: %w", err)
+	}
+	if err == nil && latest.Status == storage.JobStatusRUNNING &&
+		time.Since(latest.StartedAt.Time) < GenerationTimeout {
+		return storage.TestGeneration{}, ErrGenerationRunning
+	}
+
+	generation, err := g.querier.CreateTestGeneration(ctx, g.pool, problemID)
+	if err != nil {
+		return storage.TestGeneration{}, fmt.Errorf("could not create generation: %w", err)
+	}
+
+	go func(ctx context.Context) {
+		runCtx, cancel := context.WithTimeout(ctx, GenerationTimeout)
+		defer cancel()
+
+		logger := slog.With("problem_id", problemID, "generation_id", generation.ID)
+
+		status := storage.JobStatusSUCCEEDED
+		report, err := g.Run(runCtx, problemID)
+		if err != nil {
+			logger.Error("test generation failed", "error", err)
+			status = storage.JobStatusFAILED
+			report.addError("generation stopped: %v", err)
+		} else if report.Failed() {
+			status = storage.JobStatusFAILED
+		}
+
+		err = g.querier.FinishTestGeneration(ctx, g.pool, storage.FinishTestGenerationParams{
+			ID:     generation.ID,
+			Status: status,
+			Report: report.String(),
+		})
+		if err != nil {
+			logger.Error("could not store generation result", "error", err)
+		}
+	}(context.WithoutCancel(ctx))
+
+	return generation, nil
+}
+
+// Run generates missing or outdated inputs, validates every test input and produces the outputs
+// of generated tests with the reference solution. Nothing is stored when any step reports an error.
+// The returned report is never nil.
+func (g *Generator) Run(ctx context.Context, problemID int32) (*Report, error) {
+	report := &Report{}
+
+	problem, err := g.querier.GetProblemByID(ctx, g.pool, problemID)
+	if err != nil {
+		return report, fmt.Errorf("could not get problem: %w", err)
+	}
+
+	programs, err := g.querier.GetProblemPrograms(ctx, g.pool, problemID)
+	if err != nil {
+		return report, fmt.Errorf("could not get programs: %w", err)
+	}
+
+	testCases, err := g.querier.GetTestCasesByProblemID(ctx, g.pool, problemID)
+	if err != nil {
+		return report, fmt.Errorf("could not get test cases: %w", err)
+	}
+
+	generators := lo.SliceToMap(lo.Filter(programs, func(p storage.ProblemProgram, _ int) bool {
+		return p.Kind == storage.ProgramKindGENERATOR
+	}), func(p storage.ProblemProgram) (int32, storage.ProblemProgram) {
+		return p.ID, p
+	})
+	validator, hasValidator := lo.Find(programs, func(p storage.ProblemProgram) bool {
+		return p.Kind == storage.ProgramKindVALIDATOR
+	})
+	solution, hasSolution := lo.Find(programs, func(p storage.ProblemProgram) bool {
+		return p.Main
+	})
+
+	// inputs, first by regenerating outdated generated tests grouped by generator
+	byGenerator := lo.GroupBy(lo.Filter(testCases, func(tc storage.TestCase, _ int) bool {
+		return tc.GeneratorID.Valid
+	}), func(tc storage.TestCase) int32 {
+		return tc.GeneratorID.Int32
+	})
+
+	inputs := make(map[int32]string, len(testCases))
+	inputKeys := make(map[int32]string, len(testCases))
+	changedInputs := make(map[int32]bool)
+	for _, tc := range testCases {
+		inputs[tc.ID] = tc.Input
+		inputKeys[tc.ID] = tc.InputKey.String
+	}
+
+	for generatorID, tests := range byGenerator {
+		generator := generators[generatorID]
+
+		outdated := lo.Filter(tests, func(tc storage.TestCase, _ int) bool {
+			return tc.InputKey.String != hashKey(generator.Source, tc.GeneratorArgs.String)
+		})
+		if len(outdated) == 0 {
+			continue
+		}
+
+		results, err := g.runProgram(ctx, generator, lo.Map(outdated, func(tc storage.TestCase, _ int) *runnerPb.ProgramRequest_Run {
+			return &runnerPb.ProgramRequest_Run{Args: strings.Fields(tc.GeneratorArgs.String)}
+		}), programTimeLimitMs, programMemoryLimitKb)
+		if err != nil {
+			if errors.Is(err, ErrProgramFailed) {
+				report.addError("generator %q: %v", generator.Name, err)
+				continue
+			}
+			return report, err
+		}
+
+		for i, res := range results {
+			tc := outdated[i]
+			if res.GetStatus() != runnerPb.SubmissionStatusUpdate_ACCEPTED {
+				report.addError("generator %q with arguments %q: %s %s", generator.Name, tc.GeneratorArgs.String,
+					res.GetStatus(), res.GetStderr())
+				continue
+			}
+
+			inputs[tc.ID] = res.GetStdout()
+			inputKeys[tc.ID] = hashKey(generator.Source, tc.GeneratorArgs.String)
+			changedInputs[tc.ID] = true
+			report.Generated++
+		}
+	}
+
+	if report.Failed() {
+		return report, nil
+	}
+
+	// every input, generated or not, has to pass the validator
+	if hasValidator && len(testCases) > 0 {
+		results, err := g.runProgram(ctx, validator, lo.Map(testCases, func(tc storage.TestCase, _ int) *runnerPb.ProgramRequest_Run {
+			return &runnerPb.ProgramRequest_Run{Input: inputs[tc.ID]}
+		}), programTimeLimitMs, programMemoryLimitKb)
+		if err != nil {
+			if errors.Is(err, ErrProgramFailed) {
+				report.addError("validator %q: %v", validator.Name, err)
+				return report, nil
+			}
+			return report, err
+		}
+
+		for i, res := range results {
+			if res.GetStatus() != runnerPb.SubmissionStatusUpdate_ACCEPTED {
+				report.addError("test %d is rejected by the validator: %s", i+1, res.GetStderr())
+				continue
+			}
+			report.Validated++
+		}
+
+		if report.Failed() {
+			return report, nil
+		}
+	}
+
+	// outputs of generated tests come from the reference solution
+	type pendingOutput struct {
+		testCase storage.TestCase
+		key      string
+	}
+	var pending []pendingOutput
+	for _, tc := range testCases {
+		if !tc.GeneratorID.Valid {
+			continue
+		}
+		if !hasSolution {
+			report.addError("generated tests need a main solution to produce their outputs")
+			return report, nil
+		}
+
+		key := hashKey(solution.Source, inputKeys[tc.ID])
+		if changedInputs[tc.ID] || tc.OutputKey.String != key {
+			pending = append(pending, pendingOutput{testCase: tc, key: key})
+		}
+	}
+
+	outputs := make(map[int32]string, len(pending))
+	if len(pending) > 0 {
+		results, err := g.runProgram(ctx, solution, lo.Map(pending, func(p pendingOutput, _ int) *runnerPb.ProgramRequest_Run {
+			return &runnerPb.ProgramRequest_Run{Input: inputs[p.testCase.ID]}
+		}), problem.TimeLimitMs, problem.MemoryLimitKb)
+		if err != nil {
+			if errors.Is(err, ErrProgramFailed) {
+				report.addError("main solution %q: %v", solution.Name, err)
+				return report, nil
+			}
+			return report, err
+		}
+
+		for i, res := range results {
+			if res.GetStatus() != runnerPb.SubmissionStatusUpdate_ACCEPTED {
+				report.addError("main solution %q on generated test with arguments %q: %s %s", solution.Name,
+					pending[i].testCase.GeneratorArgs.String, res.GetStatus(), res.GetStderr())
+				continue
+			}
+			outputs[pending[i].testCase.ID] = res.GetStdout()
+			report.Solved++
+		}
+
+		if report.Failed() {
+			return report, nil
+		}
+	}
+
+	tx, err := g.pool.Begin(ctx)
+	if err != nil {
+		return report, fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func(ctx context.Context, tx pgx.Tx) {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "could not rollback transaction", "error", err)
+		}
+	}(ctx, tx)
+
+	for id := range changedInputs {
+		err := g.querier.UpdateTestCaseInput(ctx, tx, storage.UpdateTestCaseInputParams{
+			ID:       id,
+			Input:    inputs[id],
+			InputKey: pgtype.Text{String: inputKeys[id], Valid: true},
+		})
+		if err != nil {
+			return report, fmt.Errorf("could not store generated input: %w", err)
+		}
+	}
+
+	for _, p := range pending {
+		err := g.querier.UpdateTestCaseOutput(ctx, tx, storage.UpdateTestCaseOutputParams{
+			ID:        p.testCase.ID,
+			Output:    outputs[p.testCase.ID],
+			OutputKey: pgtype.Text{String: p.key, Valid: true},
+		})
+		if err != nil {
+			return report, fmt.Errorf("could not store generated output: %w", err)
+		}
+	}
+
+	if _, err := revisions.Snapshot(ctx, tx, g.querier, problemID, pgtype.UUID{}); err != nil {
+		return report, fmt.Errorf("could not record revision: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return report, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return report, nil
+}
+
+// runProgram builds the program once and runs it for every run, results are in the order of runs
+func (g *Generator) runProgram(ctx context.Context, program storage.ProblemProgram, runs []*runnerPb.ProgramRequest_Run,
+	timeLimitMs, memoryLimitKb int64) ([]*runnerPb.ProgramRunResult, error) {
+
+	stream, err := g.runnerClient.RunProgram(ctx, &runnerPb.ProgramRequest{
+		ProgramId:     fmt.Sprintf("%d", program.ID),
+		Code:          program.Source,
+		TimeLimitMs:   timeLimitMs,
+		MemoryLimitKb: memoryLimitKb,
+		Runs:          runs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not start run program stream: %w", err)
+	}
+
+	results := make([]*runnerPb.ProgramRunResult, 0, len(runs))
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("could not receive program result: %w", err)
+		}
+
+		switch res.GetStatus() {
+		case runnerPb.SubmissionStatusUpdate_COMPILATION_ERROR:
+			return nil, fmt.Errorf("%w: compilation error\n%s", ErrProgramFailed, res.GetStderr())
+		case runnerPb.SubmissionStatusUpdate_INTERNAL_ERROR:
+			return nil, errors.New("runner reported an internal error")
+		}
+
+		results = append(results, res)
+	}
+
+	if len(results) != len(runs) {
+		return nil, fmt.Errorf("runner returned %d results for %d runs", len(results), len(runs))
+	}
+
+	return results, nil
+}
+
+// Ready reports whether every generated test of the problem has been generated at least once
+func Ready(testCases []storage.TestCase) bool {
+	return lo.EveryBy(testCases, func(tc storage.TestCase) bool {
+		return !tc.GeneratorID.Valid || (tc.InputKey.Valid && tc.OutputKey.Valid)
+	})
+}
+
+// Outdated reports whether a generated test no longer matches its generator or the reference solution
+func Outdated(tc storage.TestCase, generator, solution *storage.ProblemProgram) bool {
+	if !tc.GeneratorID.Valid {
+		return false
+	}
+	if generator == nil || tc.InputKey.String != hashKey(generator.Source, tc.GeneratorArgs.String) {
+		return true
+	}
+	return solution == nil || tc.OutputKey.String != hashKey(solution.Source, tc.InputKey.String)
+}
+
+func hashKey(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
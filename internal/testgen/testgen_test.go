@@ -0,0 +1,290 @@
+package testgen
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+
+	runnerPb "github.com/computer-technology-team/go-judge/api/gen/runner"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+// fakeDatabase hands out transactions that only count commits
+type fakeDatabase struct {
+	storage.DBTX
+	begun, committed int
+}
+
+func (d *fakeDatabase) Begin(context.Context) (pgx.Tx, error) {
+	d.begun++
+	return &fakeTx{db: d}, nil
+}
+
+type fakeTx struct {
+	pgx.Tx
+	db     *fakeDatabase
+	closed bool
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	tx.closed = true
+	tx.db.committed++
+	return nil
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.closed = true
+	return nil
+}
+
+// fakeQuerier keeps one problem with its programs and tests in memory
+type fakeQuerier struct {
+	storage.Querier
+	problem   storage.Problem
+	programs  []storage.ProblemProgram
+	testCases []storage.TestCase
+	inputs    []storage.UpdateTestCaseInputParams
+	outputs   []storage.UpdateTestCaseOutputParams
+	revisions int
+}
+
+func (q *fakeQuerier) GetProblemByID(context.Context, storage.DBTX, int32) (storage.Problem, error) {
+	return q.problem, nil
+}
+
+func (q *fakeQuerier) LockProblem(context.Context, storage.DBTX, int32) (storage.Problem, error) {
+	return q.problem, nil
+}
+
+func (q *fakeQuerier) GetProblemPrograms(context.Context, storage.DBTX, int32) ([]storage.ProblemProgram, error) {
+	return q.programs, nil
+}
+
+func (q *fakeQuerier) GetTestCasesByProblemID(context.Context, storage.DBTX, int32) ([]storage.TestCase, error) {
+	return q.testCases, nil
+}
+
+func (q *fakeQuerier) UpdateTestCaseInput(_ context.Context, _ storage.DBTX, arg storage.UpdateTestCaseInputParams) error {
+	q.inputs = append(q.inputs, arg)
+	return nil
+}
+
+func (q *fakeQuerier) UpdateTestCaseOutput(_ context.Context, _ storage.DBTX, arg storage.UpdateTestCaseOutputParams) error {
+	q.outputs = append(q.outputs, arg)
+	return nil
+}
+
+func (q *fakeQuerier) GetNextRevisionNumber(context.Context, storage.DBTX, int32) (int32, error) {
+	return int32(q.revisions + 1), nil
+}
+
+func (q *fakeQuerier) InsertProblemRevision(_ context.Context, _ storage.DBTX,
+	arg storage.InsertProblemRevisionParams) (storage.ProblemRevision, error) {
+	q.revisions++
+	return storage.ProblemRevision{ID: int32(q.revisions), ProblemID: arg.ProblemID, Revision: arg.Revision}, nil
+}
+
+func (q *fakeQuerier) InsertProblemRevisionTests(_ context.Context, _ storage.DBTX,
+	arg []storage.InsertProblemRevisionTestsParams) (int64, error) {
+	return int64(len(arg)), nil
+}
+
+func (q *fakeQuerier) SetProblemCurrentRevision(_ context.Context, _ storage.DBTX, _ int32,
+	currentRevisionID pgtype.Int4) error {
+	q.problem.CurrentRevisionID = currentRevisionID
+	return nil
+}
+
+// fakeRunner answers every run of a program with run, keyed by the program name
+type fakeRunner struct {
+	runnerPb.RunnerClient
+	programs map[string]storage.ProblemProgram
+	run      func(program string, run *runnerPb.ProgramRequest_Run) *runnerPb.ProgramRunResult
+	runs     map[string]int
+}
+
+func (r *fakeRunner) RunProgram(_ context.Context, in *runnerPb.ProgramRequest,
+	_ ...grpc.CallOption) (grpc.ServerStreamingClient[runnerPb.ProgramRunResult], error) {
+	var name string
+	for _, program := range r.programs {
+		if program.Source == in.GetCode() {
+			name = program.Name
+		}
+	}
+
+	stream := &fakeStream{}
+	for i, run := range in.GetRuns() {
+		r.runs[name]++
+		res := r.run(name, run)
+		res.Index = int32(i)
+		stream.results = append(stream.results, res)
+	}
+	return stream, nil
+}
+
+type fakeStream struct {
+	grpc.ClientStream
+	results []*runnerPb.ProgramRunResult
+}
+
+func (s *fakeStream) Recv() (*runnerPb.ProgramRunResult, error) {
+	if len(s.results) == 0 {
+		return nil, io.EOF
+	}
+	res := s.results[0]
+	s.results = s.results[1:]
+	return res, nil
+}
+
+func accepted(stdout string) *runnerPb.ProgramRunResult {
+	return &runnerPb.ProgramRunResult{Status: runnerPb.SubmissionStatusUpdate_ACCEPTED, Stdout: stdout}
+}
+
+type TestgenTestSuite struct {
+	suite.Suite
+	generator storage.ProblemProgram
+	validator storage.ProblemProgram
+	solution  storage.ProblemProgram
+	querier   *fakeQuerier
+	db        *fakeDatabase
+	runner    *fakeRunner
+	testgen   *Generator
+}
+
+func (s *TestgenTestSuite) SetupTest() {
+	s.generator = storage.ProblemProgram{ID: 1, Kind: storage.ProgramKindGENERATOR, Name: "gen", Source: "gen v1"}
+	s.validator = storage.ProblemProgram{ID: 2, Kind: storage.ProgramKindVALIDATOR, Name: "val", Source: "val v1"}
+	s.solution = storage.ProblemProgram{ID: 3, Kind: storage.ProgramKindSOLUTION, Name: "sol", Source: "sol v1",
+		Main: true}
+
+	upToDateKey := hashKey(s.generator.Source, "1")
+	s.querier = &fakeQuerier{
+		problem:  storage.Problem{ID: 7, TimeLimitMs: 1000, MemoryLimitKb: 65536},
+		programs: []storage.ProblemProgram{s.generator, s.validator, s.solution},
+		testCases: []storage.TestCase{
+			{ID: 10, Input: "manual", Output: "manual out"},
+			s.generated(11, "1", "gen 1", upToDateKey, hashKey(s.solution.Source, upToDateKey)),
+			s.generated(12, "2", "", "", ""),
+		},
+	}
+	s.db = &fakeDatabase{}
+	s.runner = &fakeRunner{
+		programs: map[string]storage.ProblemProgram{"gen": s.generator, "val": s.validator, "sol": s.solution},
+		runs:     map[string]int{},
+		run: func(program string, run *runnerPb.ProgramRequest_Run) *runnerPb.ProgramRunResult {
+			switch program {
+			case "gen":
+				return accepted("gen " + strings.Join(run.GetArgs(), " "))
+			case "sol":
+				return accepted("answer to " + run.GetInput())
+			}
+			return accepted("")
+		},
+	}
+	s.testgen = &Generator{pool: s.db, querier: s.querier, runnerClient: s.runner}
+}
+
+func (s *TestgenTestSuite) generated(id int32, args, input, inputKey, outputKey string) storage.TestCase {
+	return storage.TestCase{
+		ID:            id,
+		Input:         input,
+		GeneratorID:   pgtype.Int4{Int32: s.generator.ID, Valid: true},
+		GeneratorArgs: pgtype.Text{String: args, Valid: true},
+		InputKey:      pgtype.Text{String: inputKey, Valid: inputKey != ""},
+		OutputKey:     pgtype.Text{String: outputKey, Valid: outputKey != ""},
+	}
+}
+
+func (s *TestgenTestSuite) TestHashKey() {
+	assert.Equal(s.T(), hashKey("gen", "1 2"), hashKey("gen", "1 2"))
+	assert.NotEqual(s.T(), hashKey("gen", "1 2"), hashKey("gen", "1 3"))
+	assert.NotEqual(s.T(), hashKey("ge", "n1 2"), hashKey("gen", "1 2"), "parts are separated")
+	assert.Len(s.T(), hashKey(), 64)
+}
+
+func (s *TestgenTestSuite) TestReady() {
+	assert.True(s.T(), Ready(s.querier.testCases[:2]))
+	assert.False(s.T(), Ready(s.querier.testCases), "a generated test without keys was never generated")
+	assert.True(s.T(), Ready(nil))
+}
+
+func (s *TestgenTestSuite) TestOutdated() {
+	manual, upToDate, missing := s.querier.testCases[0], s.querier.testCases[1], s.querier.testCases[2]
+
+	assert.False(s.T(), Outdated(manual, nil, nil), "manual tests are never outdated")
+	assert.False(s.T(), Outdated(upToDate, &s.generator, &s.solution))
+	assert.True(s.T(), Outdated(missing, &s.generator, &s.solution))
+	assert.True(s.T(), Outdated(upToDate, nil, &s.solution), "the generator is missing")
+	assert.True(s.T(), Outdated(upToDate, &s.generator, nil), "there is no main solution")
+
+	changed := s.generator
+	changed.Source = "gen v2"
+	assert.True(s.T(), Outdated(upToDate, &changed, &s.solution))
+
+	changed = s.solution
+	changed.Source = "sol v2"
+	assert.True(s.T(), Outdated(upToDate, &s.generator, &changed))
+}
+
+func (s *TestgenTestSuite) TestRunOnlyGeneratesWhatChanged() {
+	report, err := s.testgen.Run(context.Background(), s.querier.problem.ID)
+	require.NoError(s.T(), err)
+	require.False(s.T(), report.Failed(), report.String())
+
+	assert.Equal(s.T(), map[string]int{"gen": 1, "val": 3, "sol": 1}, s.runner.runs)
+	assert.Equal(s.T(), Report{Generated: 1, Solved: 1, Validated: 3}, *report)
+
+	require.Len(s.T(), s.querier.inputs, 1)
+	assert.Equal(s.T(), int32(12), s.querier.inputs[0].ID)
+	assert.Equal(s.T(), "gen 2", s.querier.inputs[0].Input)
+	assert.Equal(s.T(), hashKey(s.generator.Source, "2"), s.querier.inputs[0].InputKey.String)
+
+	require.Len(s.T(), s.querier.outputs, 1)
+	assert.Equal(s.T(), "answer to gen 2", s.querier.outputs[0].Output)
+	assert.Equal(s.T(), hashKey(s.solution.Source, s.querier.inputs[0].InputKey.String),
+		s.querier.outputs[0].OutputKey.String)
+
+	assert.Equal(s.T(), 1, s.db.committed)
+	assert.Equal(s.T(), 1, s.querier.revisions)
+}
+
+func (s *TestgenTestSuite) TestRunStoresNothingOnFailure() {
+	s.runner.run = func(program string, run *runnerPb.ProgramRequest_Run) *runnerPb.ProgramRunResult {
+		if program == "val" && run.GetInput() == "manual" {
+			return &runnerPb.ProgramRunResult{Status: runnerPb.SubmissionStatusUpdate_RUNTIME_ERROR, Stderr: "bad"}
+		}
+		return accepted("gen")
+	}
+
+	report, err := s.testgen.Run(context.Background(), s.querier.problem.ID)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), report.Failed())
+	assert.Contains(s.T(), report.String(), "test 1 is rejected by the validator: bad")
+
+	assert.Zero(s.T(), s.runner.runs["sol"], "outputs are not produced for invalid inputs")
+	assert.Zero(s.T(), s.db.begun)
+}
+
+func (s *TestgenTestSuite) TestRunNeedsMainSolution() {
+	s.querier.programs = s.querier.programs[:2]
+
+	report, err := s.testgen.Run(context.Background(), s.querier.problem.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"generated tests need a main solution to produce their outputs"}, report.Errors)
+	assert.Zero(s.T(), s.db.begun)
+}
+
+func TestTestgenTestSuite(t *testing.T) {
+	suite.Run(t, new(TestgenTestSuite))
+}
@@ -0,0 +1,348 @@
+package verification
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/samber/lo"
+
+	runnerPb "github.com/computer-technology-team/go-judge/api/gen/runner"
+	"github.com/computer-technology-team/go-judge/internal/storage"
+	"github.com/computer-technology-team/go-judge/internal/testgen"
+)
+
+const (
+	// VerificationTimeout bounds a whole verification, a run older than this is considered dead
+	VerificationTimeout = 30 * time.Minute
+
+	// the suggested time limit leaves the main solution this much headroom, rounded up to timeLimitStep
+	timeLimitFactor = 2
+	timeLimitStep   = 100
+	minTimeLimitMs  = 100
+	maxTimeLimitMs  = 20_000
+)
+
+var (
+	ErrVerificationRunning = errors.New("a verification is already running for this problem")
+	ErrNotVerified         = errors.New("problem solutions have not been verified against the current tests")
+	ErrNoMainSolution      = errors.New("problem has no main solution expected to be accepted")
+)
+
+var runnerStatusToStorage = map[runnerPb.SubmissionStatusUpdate_Status]storage.SubmissionStatus{
+	runnerPb.SubmissionStatusUpdate_ACCEPTED:              storage.SubmissionStatusACCEPTED,
+	runnerPb.SubmissionStatusUpdate_WRONG_ANSWER:          storage.SubmissionStatusWRONGANSWER,
+	runnerPb.SubmissionStatusUpdate_TIME_LIMIT_EXCEEDED:   storage.SubmissionStatusTIMELIMITEXCEEDED,
+	runnerPb.SubmissionStatusUpdate_MEMORY_LIMIT_EXCEEDED: storage.SubmissionStatusMEMORYLIMITEXCEEDED,
+	runnerPb.SubmissionStatusUpdate_RUNTIME_ERROR:         storage.SubmissionStatusRUNTIMEERROR,
+	runnerPb.SubmissionStatusUpdate_COMPILATION_ERROR:     storage.SubmissionStatusCOMPILATIONERROR,
+	runnerPb.SubmissionStatusUpdate_INTERNAL_ERROR:        storage.SubmissionStatusINTERNALERROR,
+}
+
+// SolutionResult is the outcome of judging one solution on the current tests
+type SolutionResult struct {
+	Name           string
+	Main           bool
+	Expected       storage.SubmissionStatus
+	Got            storage.SubmissionStatus
+	Message        string
+	MaxTimeSpentMs int64
+}
+
+func (r SolutionResult) OK() bool {
+	return r.Expected == r.Got
+}
+
+// Report lists every solution result and the time limit suggested from the main solution
+type Report struct {
+	Solutions          []SolutionResult
+	SuggestedTimeLimit int64
+	Errors             []string
+}
+
+func (r *Report) Failed() bool {
+	return len(r.Errors) > 0 || lo.SomeBy(r.Solutions, func(s SolutionResult) bool { return !s.OK() })
+}
+
+func (r *Report) String() string {
+	var sb strings.Builder
+	for _, s := range r.Solutions {
+		mark := "ok"
+		if !s.OK() {
+			mark = "FAILED"
+		}
+		fmt.Fprintf(&sb, "%s: %s expected %s, got %s in at most %d ms", mark, s.Name, s.Expected, s.Got, s.MaxTimeSpentMs)
+		if s.Main {
+			sb.WriteString(" (main)")
+		}
+		if s.Message != "" && !s.OK() {
+			fmt.Fprintf(&sb, ": %s", s.Message)
+		}
+		sb.WriteString("\n")
+	}
+	if r.SuggestedTimeLimit > 0 {
+		fmt.Fprintf(&sb, "suggested time limit: %d ms\n", r.SuggestedTimeLimit)
+	}
+	for _, e := range r.Errors {
+		sb.WriteString(e)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Verifier judges the solutions of a problem on its current tests through the runner, the same way submissions are
+type Verifier struct {
+	pool         *pgxpool.Pool
+	querier      storage.Querier
+	runnerClient runnerPb.RunnerClient
+}
+
+func NewVerifier(pool *pgxpool.Pool, querier storage.Querier, runnerClient runnerPb.RunnerClient) *Verifier {
+	return &Verifier{pool: pool, querier: querier, runnerClient: runnerClient}
+}
+
+// Start records a new verification for the problem and runs it in the background
+func (v *Verifier) Start(ctx context.Context, problemID int32) (storage.ProblemVerification, error) {
+	job, err := v.begin(ctx, problemID)
+	if err != nil {
+		return storage.ProblemVerification{}, err
+	}
+
+	go func(ctx context.Context) {
+		runCtx, cancel := context.WithTimeout(ctx, VerificationTimeout)
+		defer cancel()
+
+		v.finish(ctx, job, v.run(runCtx, job.problem, job.testCases, job.solutions))
+	}(context.WithoutCancel(ctx))
+
+	return job.verification, nil
+}
+
+// Run records a new verification for the problem and runs it before returning its report
+func (v *Verifier) Run(ctx context.Context, problemID int32) (*Report, error) {
+	job, err := v.begin(ctx, problemID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := v.run(ctx, job.problem, job.testCases, job.solutions)
+	v.finish(context.WithoutCancel(ctx), job, report)
+
+	return report, nil
+}
+
+type verificationJob struct {
+	verification storage.ProblemVerification
+	problem      storage.Problem
+	testCases    []storage.TestCase
+	solutions    []storage.ProblemProgram
+}
+
+func (v *Verifier) begin(ctx context.Context, problemID int32) (*verificationJob, error) {
+	latest, err := v.querier.GetLatestProblemVerification(ctx, v.pool, problemID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("could not get latest verification: %w", err)
+	}
+	if err == nil && latest.Status == storage.JobStatusRUNNING &&
+		time.Since(latest.StartedAt.Time) < VerificationTimeout {
+		return nil, ErrVerificationRunning
+	}
+
+	problem, testCases, solutions, err := v.load(ctx, problemID)
+	if err != nil {
+		return nil, err
+	}
+
+	verification, err := v.querier.CreateProblemVerification(ctx, v.pool, problemID, Fingerprint(problem, testCases, solutions))
+	if err != nil {
+		return nil, fmt.Errorf("could not create verification: %w", err)
+	}
+
+	return &verificationJob{
+		verification: verification,
+		problem:      problem,
+		testCases:    testCases,
+		solutions:    solutions,
+	}, nil
+}
+
+func (v *Verifier) finish(ctx context.Context, job *verificationJob, report *Report) {
+	status := storage.JobStatusSUCCEEDED
+	if report.Failed() {
+		status = storage.JobStatusFAILED
+	}
+
+	err := v.querier.FinishProblemVerification(ctx, v.pool, storage.FinishProblemVerificationParams{
+		ID:                   job.verification.ID,
+		Status:               status,
+		Report:               report.String(),
+		SuggestedTimeLimitMs: pgtype.Int8{Int64: report.SuggestedTimeLimit, Valid: report.SuggestedTimeLimit > 0},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not store verification result", "error", err,
+			"problem_id", job.problem.ID, "verification_id", job.verification.ID)
+	}
+}
+
+// CheckPublishable returns ErrNoMainSolution unless the problem has a main solution expected to be accepted,
+// and ErrNotVerified unless the latest verification succeeded on exactly the current limits, tests and solutions.
+func (v *Verifier) CheckPublishable(ctx context.Context, problemID int32) error {
+	problem, testCases, solutions, err := v.load(ctx, problemID)
+	if err != nil {
+		return err
+	}
+
+	if !lo.SomeBy(solutions, func(s storage.ProblemProgram) bool {
+		return s.Main && s.ExpectedStatus.SubmissionStatus == storage.SubmissionStatusACCEPTED
+	}) {
+		return ErrNoMainSolution
+	}
+
+	latest, err := v.querier.GetLatestProblemVerification(ctx, v.pool, problemID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotVerified
+		}
+		return fmt.Errorf("could not get latest verification: %w", err)
+	}
+
+	if latest.Status != storage.JobStatusSUCCEEDED || latest.Fingerprint != Fingerprint(problem, testCases, solutions) {
+		return ErrNotVerified
+	}
+
+	return nil
+}
+
+func (v *Verifier) load(ctx context.Context, problemID int32) (storage.Problem, []storage.TestCase, []storage.ProblemProgram, error) {
+	problem, err := v.querier.GetProblemByID(ctx, v.pool, problemID)
+	if err != nil {
+		return storage.Problem{}, nil, nil, fmt.Errorf("could not get problem: %w", err)
+	}
+
+	testCases, err := v.querier.GetTestCasesByProblemID(ctx, v.pool, problemID)
+	if err != nil {
+		return storage.Problem{}, nil, nil, fmt.Errorf("could not get test cases: %w", err)
+	}
+
+	programs, err := v.querier.GetProblemPrograms(ctx, v.pool, problemID)
+	if err != nil {
+		return storage.Problem{}, nil, nil, fmt.Errorf("could not get programs: %w", err)
+	}
+
+	solutions := lo.Filter(programs, func(p storage.ProblemProgram, _ int) bool {
+		return p.Kind == storage.ProgramKindSOLUTION
+	})
+
+	return problem, testCases, solutions, nil
+}
+
+func (v *Verifier) run(ctx context.Context, problem storage.Problem, testCases []storage.TestCase, solutions []storage.ProblemProgram) *Report {
+	report := &Report{}
+
+	if len(testCases) == 0 {
+		report.Errors = append(report.Errors, "problem has no tests")
+		return report
+	}
+	if !testgen.Ready(testCases) {
+		report.Errors = append(report.Errors, "some generated tests have not been generated yet")
+		return report
+	}
+	if !lo.SomeBy(solutions, func(s storage.ProblemProgram) bool { return s.Main }) {
+		report.Errors = append(report.Errors, "problem has no main solution")
+	}
+
+	for _, solution := range solutions {
+		result, err := v.judge(ctx, problem, testCases, solution)
+		if err != nil {
+			slog.ErrorContext(ctx, "could not judge solution", "error", err, "program_id", solution.ID)
+			report.Errors = append(report.Errors, fmt.Sprintf("could not judge %s: %v", solution.Name, err))
+			continue
+		}
+
+		report.Solutions = append(report.Solutions, result)
+		if result.Main && result.OK() {
+			report.SuggestedTimeLimit = SuggestTimeLimit(result.MaxTimeSpentMs)
+		}
+	}
+
+	return report
+}
+
+// judge sends the solution through the submission pipeline of the runner and waits for its verdict
+func (v *Verifier) judge(ctx context.Context, problem storage.Problem, testCases []storage.TestCase,
+	solution storage.ProblemProgram) (SolutionResult, error) {
+
+	result := SolutionResult{
+		Name:     solution.Name,
+		Main:     solution.Main,
+		Expected: solution.ExpectedStatus.SubmissionStatus,
+	}
+
+	stream, err := v.runnerClient.ExecuteSubmission(ctx, &runnerPb.SubmissionRequest{
+		SubmissionId:  fmt.Sprintf("verification-%d-%s", solution.ID, uuid.NewString()),
+		Code:          solution.Source,
+		TimeLimitMs:   problem.TimeLimitMs,
+		MemoryLimitKb: problem.MemoryLimitKb,
+		TestCases: lo.Map(testCases, func(tc storage.TestCase, _ int) *runnerPb.SubmissionRequest_TestCase {
+			return tc.ToProto()
+		}),
+	})
+	if err != nil {
+		return result, fmt.Errorf("could not start grpc stream: %w", err)
+	}
+
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return result, errors.New("runner closed the stream without a verdict")
+			}
+			return result, fmt.Errorf("could not receive update: %w", err)
+		}
+
+		status, terminal := runnerStatusToStorage[update.GetStatus()]
+		if !terminal {
+			continue
+		}
+		if status == storage.SubmissionStatusINTERNALERROR {
+			return result, errors.New("runner reported an internal error")
+		}
+
+		result.Got = status
+		result.MaxTimeSpentMs = update.GetMaxTimeSpentMs()
+		if status != storage.SubmissionStatusACCEPTED {
+			result.Message = fmt.Sprintf("on test %d", update.GetTestsCompleted()+1)
+		}
+		return result, nil
+	}
+}
+
+// SuggestTimeLimit gives the main solution twice its slowest test time, within the limits the problem form accepts
+func SuggestTimeLimit(maxTimeSpentMs int64) int64 {
+	limit := maxTimeSpentMs * timeLimitFactor
+	limit = (limit + timeLimitStep - 1) / timeLimitStep * timeLimitStep
+	return min(max(limit, minTimeLimitMs), maxTimeLimitMs)
+}
+
+// Fingerprint identifies the limits, tests and solutions a verification ran with
+func Fingerprint(problem storage.Problem, testCases []storage.TestCase, solutions []storage.ProblemProgram) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%d\x00", problem.TimeLimitMs, problem.MemoryLimitKb)
+	for _, tc := range testCases {
+		fmt.Fprintf(h, "test\x00%d\x00%d\x00%s\x00%d\x00%s\x00", tc.ID, len(tc.Input), tc.Input, len(tc.Output), tc.Output)
+	}
+	for _, s := range solutions {
+		fmt.Fprintf(h, "solution\x00%d\x00%s\x00%t\x00%d\x00%s\x00", s.ID, s.ExpectedStatus.SubmissionStatus, s.Main, len(s.Source), s.Source)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,178 @@
+package verification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/computer-technology-team/go-judge/internal/storage"
+)
+
+// fakeQuerier keeps one problem with its tests, programs and latest verification in memory
+type fakeQuerier struct {
+	storage.Querier
+	problem      storage.Problem
+	testCases    []storage.TestCase
+	programs     []storage.ProblemProgram
+	verification *storage.ProblemVerification
+}
+
+func (q *fakeQuerier) GetProblemByID(context.Context, storage.DBTX, int32) (storage.Problem, error) {
+	return q.problem, nil
+}
+
+func (q *fakeQuerier) GetTestCasesByProblemID(context.Context, storage.DBTX, int32) ([]storage.TestCase, error) {
+	return q.testCases, nil
+}
+
+func (q *fakeQuerier) GetProblemPrograms(context.Context, storage.DBTX, int32) ([]storage.ProblemProgram, error) {
+	return q.programs, nil
+}
+
+func (q *fakeQuerier) GetLatestProblemVerification(context.Context, storage.DBTX, int32) (storage.ProblemVerification, error) {
+	if q.verification == nil {
+		return storage.ProblemVerification{}, pgx.ErrNoRows
+	}
+	return *q.verification, nil
+}
+
+type VerificationTestSuite struct {
+	suite.Suite
+	problem   storage.Problem
+	testCases []storage.TestCase
+	solutions []storage.ProblemProgram
+}
+
+func (s *VerificationTestSuite) SetupTest() {
+	s.problem = storage.Problem{ID: 7, TimeLimitMs: 1000, MemoryLimitKb: 65536}
+	s.testCases = []storage.TestCase{
+		{ID: 1, Input: "1 2", Output: "3"},
+		{ID: 2, Input: "2 2", Output: "4"},
+	}
+	s.solutions = []storage.ProblemProgram{
+		s.solution(1, "main", storage.SubmissionStatusACCEPTED, true),
+		s.solution(2, "slow", storage.SubmissionStatusTIMELIMITEXCEEDED, false),
+	}
+}
+
+func (s *VerificationTestSuite) solution(id int32, name string, expected storage.SubmissionStatus,
+	main bool) storage.ProblemProgram {
+	return storage.ProblemProgram{
+		ID:             id,
+		Kind:           storage.ProgramKindSOLUTION,
+		Name:           name,
+		Source:         "package main // " + name,
+		ExpectedStatus: storage.NullSubmissionStatus{SubmissionStatus: expected, Valid: true},
+		Main:           main,
+	}
+}
+
+func (s *VerificationTestSuite) TestSuggestTimeLimit() {
+	testCases := []struct {
+		maxTimeSpentMs int64
+		expected       int64
+	}{
+		{0, 100},
+		{10, 100},
+		{50, 100},
+		{51, 200},
+		{120, 300},
+		{500, 1000},
+		{9_999, 20_000},
+		{60_000, 20_000},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(s.T(), tc.expected, SuggestTimeLimit(tc.maxTimeSpentMs), "slowest test took %d ms", tc.maxTimeSpentMs)
+	}
+}
+
+func (s *VerificationTestSuite) TestFingerprint() {
+	fingerprint := Fingerprint(s.problem, s.testCases, s.solutions)
+	assert.Equal(s.T(), fingerprint, Fingerprint(s.problem, s.testCases, s.solutions))
+
+	testCases := []struct {
+		name   string
+		change func(problem *storage.Problem, testCases []storage.TestCase, solutions []storage.ProblemProgram)
+	}{
+		{"time limit", func(p *storage.Problem, _ []storage.TestCase, _ []storage.ProblemProgram) { p.TimeLimitMs++ }},
+		{"memory limit", func(p *storage.Problem, _ []storage.TestCase, _ []storage.ProblemProgram) { p.MemoryLimitKb++ }},
+		{"test input", func(_ *storage.Problem, t []storage.TestCase, _ []storage.ProblemProgram) { t[0].Input = "1 3" }},
+		{"test output", func(_ *storage.Problem, t []storage.TestCase, _ []storage.ProblemProgram) { t[1].Output = "5" }},
+		{"test boundary", func(_ *storage.Problem, t []storage.TestCase, _ []storage.ProblemProgram) {
+			t[0].Input, t[0].Output = "1 23", ""
+		}},
+		{"solution source", func(_ *storage.Problem, _ []storage.TestCase, p []storage.ProblemProgram) {
+			p[1].Source += "\n"
+		}},
+		{"expected verdict", func(_ *storage.Problem, _ []storage.TestCase, p []storage.ProblemProgram) {
+			p[1].ExpectedStatus.SubmissionStatus = storage.SubmissionStatusWRONGANSWER
+		}},
+		{"main solution", func(_ *storage.Problem, _ []storage.TestCase, p []storage.ProblemProgram) {
+			p[0].Main = false
+		}},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			problem := s.problem
+			testCases := append([]storage.TestCase(nil), s.testCases...)
+			solutions := append([]storage.ProblemProgram(nil), s.solutions...)
+			tc.change(&problem, testCases, solutions)
+
+			assert.NotEqual(s.T(), fingerprint, Fingerprint(problem, testCases, solutions))
+		})
+	}
+
+	assert.NotEqual(s.T(), fingerprint, Fingerprint(s.problem, s.testCases[:1], s.solutions), "a test was removed")
+	assert.NotEqual(s.T(), fingerprint, Fingerprint(s.problem, s.testCases, s.solutions[:1]), "a solution was removed")
+}
+
+func (s *VerificationTestSuite) TestCheckPublishable() {
+	fingerprint := Fingerprint(s.problem, s.testCases, s.solutions)
+	succeeded := &storage.ProblemVerification{Status: storage.JobStatusSUCCEEDED, Fingerprint: fingerprint}
+
+	testCases := []struct {
+		name         string
+		solutions    []storage.ProblemProgram
+		verification *storage.ProblemVerification
+		expected     error
+	}{
+		{"verified", s.solutions, succeeded, nil},
+		{"no solutions", nil, succeeded, ErrNoMainSolution},
+		{"no main solution", s.solutions[1:], succeeded, ErrNoMainSolution},
+		{"never verified", s.solutions, nil, ErrNotVerified},
+		{"failed", s.solutions, &storage.ProblemVerification{Status: storage.JobStatusFAILED, Fingerprint: fingerprint},
+			ErrNotVerified},
+		{"running", s.solutions, &storage.ProblemVerification{Status: storage.JobStatusRUNNING, Fingerprint: fingerprint},
+			ErrNotVerified},
+		{"outdated", s.solutions, &storage.ProblemVerification{Status: storage.JobStatusSUCCEEDED, Fingerprint: "old"},
+			ErrNotVerified},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			querier := &fakeQuerier{
+				problem:      s.problem,
+				testCases:    s.testCases,
+				programs:     tc.solutions,
+				verification: tc.verification,
+			}
+			verifier := NewVerifier(nil, querier, nil)
+
+			err := verifier.CheckPublishable(context.Background(), s.problem.ID)
+			if tc.expected == nil {
+				assert.NoError(s.T(), err)
+			} else {
+				assert.ErrorIs(s.T(), err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestVerificationTestSuite(t *testing.T) {
+	suite.Run(t, new(VerificationTestSuite))
+}
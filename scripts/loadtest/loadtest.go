@@ -3,12 +3,14 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -30,6 +32,29 @@ type User struct {
 	Token    string
 }
 
+// csrfTokenPattern finds the CSRF token in the forms of a page
+var csrfTokenPattern = regexp.MustCompile(`name="csrf_token" value="([^"]+)"`)
+
+// csrfToken loads the login page with client, which stores the CSRF cookie in its jar, and returns the token of its form
+func csrfToken(client *http.Client) (string, error) {
+	resp, err := client.Get(baseURL + "/auth/login")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	page, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	match := csrfTokenPattern.FindSubmatch(page)
+	if match == nil {
+		return "", errors.New("csrf token was not found")
+	}
+	return string(match[1]), nil
+}
+
 // randomString generates a random string of length n
 func randomString(n int) string {
 	letters := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
@@ -60,6 +85,12 @@ func signupUsers(count int) []User {
 		form.Add("password", password)
 
 		// Create the request
+		csrf, err := csrfToken(client)
+		if err != nil {
+			log.Println("Error getting csrf token:", err)
+			continue
+		}
+
 		req, err := http.NewRequest("POST", baseURL+"/auth/signup", strings.NewReader(form.Encode()))
 		if err != nil {
 			log.Println("Error creating request:", err)
@@ -73,6 +104,7 @@ func signupUsers(count int) []User {
 		req.Header.Set("Connection", "keep-alive")
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 		req.Header.Set("Origin", baseURL)
+		req.Header.Set("X-CSRF-Token", csrf)
 		req.Header.Set("Referer", baseURL+"/auth/signup")
 		req.Header.Set("Sec-Fetch-Dest", "document")
 		req.Header.Set("Sec-Fetch-Mode", "navigate")
@@ -137,6 +169,11 @@ func signupUser() (*User, error) {
 	form.Add("password", password)
 
 	// Create the request
+	csrf, err := csrfToken(client)
+	if err != nil {
+		return nil, fmt.Errorf("error getting csrf token: %w", err)
+	}
+
 	req, err := http.NewRequest("POST", baseURL+"/auth/signup", strings.NewReader(form.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
@@ -149,6 +186,7 @@ func signupUser() (*User, error) {
 	req.Header.Set("Connection", "keep-alive")
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Origin", baseURL)
+	req.Header.Set("X-CSRF-Token", csrf)
 	req.Header.Set("Referer", baseURL+"/auth/signup")
 	req.Header.Set("Sec-Fetch-Dest", "document")
 	req.Header.Set("Sec-Fetch-Mode", "navigate")
@@ -268,6 +306,12 @@ func login(username, password string) (string, error) {
 	form.Add("password", password)
 
 	// Create the request - use login endpoint instead of signup
+	csrf, err := csrfToken(client)
+	if err != nil {
+		log.Println("Error getting csrf token:", err)
+		return "", err
+	}
+
 	req, err := http.NewRequest("POST", baseURL+"/auth/login", strings.NewReader(form.Encode()))
 	if err != nil {
 		log.Println("Error creating request:", err)
@@ -281,6 +325,7 @@ func login(username, password string) (string, error) {
 	req.Header.Set("Connection", "keep-alive")
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Origin", baseURL)
+	req.Header.Set("X-CSRF-Token", csrf)
 	req.Header.Set("Referer", baseURL+"/auth/signup")
 	req.Header.Set("Sec-Fetch-Dest", "document")
 	req.Header.Set("Sec-Fetch-Mode", "navigate")
@@ -334,23 +379,29 @@ func createProblem(token string) (int, error) {
 	// Generate random problem data
 	title := "Problem_" + randomString(8)
 	description := "Description for " + title
+	// outputs echo their inputs so echoSolution, the submitted code, is the main solution
 	sampleInput := "sample input " + randomString(5)
-	sampleOutput := "sample output " + randomString(5)
+	sampleOutput := sampleInput
 	testInput := "test input " + randomString(20)
-	testOutput := "test output " + randomString(20)
+	testOutput := testInput
 
 	// Prepare form data
 	form := url.Values{}
 	form.Add("title", title)
 	form.Add("description", description)
-	form.Add("sample_input", sampleInput)
-	form.Add("sample_output", sampleOutput)
+	form.Add("sample_input_1", sampleInput)
+	form.Add("sample_output_1", sampleOutput)
 	form.Add("time_limit", "1000")
 	form.Add("memory_limit", "64000")
 	form.Add("test_input_1", testInput)
 	form.Add("test_output_1", testOutput)
 
 	// Create the request
+	csrf, err := csrfToken(client)
+	if err != nil {
+		return 0, fmt.Errorf("error getting csrf token: %w", err)
+	}
+
 	req, err := http.NewRequest("POST", baseURL+"/problems", strings.NewReader(form.Encode()))
 	if err != nil {
 		return 0, fmt.Errorf("error creating request: %w", err)
@@ -363,6 +414,7 @@ func createProblem(token string) (int, error) {
 	req.Header.Set("Connection", "keep-alive")
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Origin", baseURL)
+	req.Header.Set("X-CSRF-Token", csrf)
 	req.Header.Set("Pragma", "no-cache")
 	req.Header.Set("Referer", baseURL+"/problems/form/new")
 	req.Header.Set("Sec-Fetch-Dest", "document")
@@ -415,6 +467,86 @@ func createProblem(token string) (int, error) {
 	return id, nil
 }
 
+// echoSolution prints the first line of its input, it is submitted and is the main solution of the problem
+const echoSolution = `package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+func main() {
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		line := scanner.Text()
+		fmt.Println(line)
+	}
+}
+`
+
+// postForm posts form to path as the user of token
+func postForm(token, path string, form url.Values) error {
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{
+		Jar: jar,
+	}
+
+	u, _ := url.Parse(baseURL)
+	client.Jar.SetCookies(u, []*http.Cookie{
+		{
+			Name:  "token",
+			Value: token,
+		},
+	})
+
+	csrf, err := csrfToken(client)
+	if err != nil {
+		return fmt.Errorf("error getting csrf token: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Origin", baseURL)
+	req.Header.Set("X-CSRF-Token", csrf)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusFound {
+		return fmt.Errorf("%s failed with status %d", path, resp.StatusCode)
+	}
+	return nil
+}
+
+// verifyProblem adds echoSolution as the main solution and starts verifying it, problems are only published
+// after a successful verification
+func verifyProblem(token string, problemID int) error {
+	form := url.Values{}
+	form.Add("kind", "SOLUTION")
+	form.Add("name", "echo")
+	form.Add("source", echoSolution)
+	form.Add("expected_status", "ACCEPTED")
+	form.Add("main", "on")
+
+	if err := postForm(token, fmt.Sprintf("/problems/%d/programs", problemID), form); err != nil {
+		return fmt.Errorf("could not add main solution: %w", err)
+	}
+
+	if err := postForm(token, fmt.Sprintf("/problems/%d/verify", problemID), url.Values{}); err != nil {
+		return fmt.Errorf("could not start verification: %w", err)
+	}
+
+	log.Printf("Started verification of problem %d\n", problemID)
+	return nil
+}
+
 func toggleProblemStatus(token string, problemID int) error {
 	jar, _ := cookiejar.New(nil)
 	client := &http.Client{
@@ -436,6 +568,11 @@ func toggleProblemStatus(token string, problemID int) error {
 
 	// Create the request
 	toggleURL := fmt.Sprintf("%s/problems/%d/toggle-status", baseURL, problemID)
+	csrf, err := csrfToken(client)
+	if err != nil {
+		return fmt.Errorf("error getting csrf token: %w", err)
+	}
+
 	req, err := http.NewRequest("POST", toggleURL, strings.NewReader(form.Encode()))
 	if err != nil {
 		return fmt.Errorf("error creating request: %w", err)
@@ -448,6 +585,7 @@ func toggleProblemStatus(token string, problemID int) error {
 	req.Header.Set("Connection", "keep-alive")
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Origin", baseURL)
+	req.Header.Set("X-CSRF-Token", csrf)
 	req.Header.Set("Pragma", "no-cache")
 	req.Header.Set("Referer", baseURL+"/problems/my")
 	req.Header.Set("Sec-Fetch-Dest", "document")
@@ -506,22 +644,7 @@ func submitAnswer(token string, problemID int) error {
 	// Add code field with the Go solution
 	body.WriteString(fmt.Sprintf("--%s\r\n", boundary))
 	body.WriteString("Content-Disposition: form-data; name=\"code\"\r\n\r\n")
-	body.WriteString(`package main
-
-import (
-	"bufio"
-	"fmt"
-	"os"
-)
-
-func main() {
-	scanner := bufio.NewScanner(os.Stdin)
-	if scanner.Scan() {
-		line := scanner.Text()
-		fmt.Println(line)
-	}
-}
-`)
+	body.WriteString(echoSolution)
 	body.WriteString("\r\n")
 
 	// Add empty file field
@@ -534,6 +657,11 @@ func main() {
 
 	// Create the request
 	submissionURL := fmt.Sprintf("%s/submissions", baseURL)
+	csrf, err := csrfToken(client)
+	if err != nil {
+		return fmt.Errorf("error getting csrf token: %w", err)
+	}
+
 	req, err := http.NewRequest("POST", submissionURL, strings.NewReader(body.String()))
 	if err != nil {
 		return fmt.Errorf("error creating request: %w", err)
@@ -546,6 +674,7 @@ func main() {
 	req.Header.Set("Connection", "keep-alive")
 	req.Header.Set("Content-Type", fmt.Sprintf("multipart/form-data; boundary=%s", boundary))
 	req.Header.Set("Origin", baseURL)
+	req.Header.Set("X-CSRF-Token", csrf)
 	req.Header.Set("Pragma", "no-cache")
 	req.Header.Set("Referer", fmt.Sprintf("%s/submissions/problem/%d/new", baseURL, problemID))
 	req.Header.Set("Sec-Fetch-Dest", "document")
@@ -693,13 +822,24 @@ func main() {
 	}
 	fmt.Printf("Created problem with ID %d\n", problemID)
 
-	// Step 3: Make the problem public
+	// Step 3: Verify the problem and make it public once the verification succeeds
+	fmt.Println("Verifying problem...")
+	if err := verifyProblem(adminToken, problemID); err != nil {
+		log.Fatalf("Failed to verify problem: %v", err)
+	}
+
 	fmt.Println("Making problem public...")
-	err = toggleProblemStatus(adminToken, problemID)
-	if err != nil {
-		log.Printf("Failed to toggle problem status: %v", err)
-	} else {
-		fmt.Println("Problem is now public")
+	for attempt := 1; ; attempt++ {
+		err = toggleProblemStatus(adminToken, problemID)
+		if err == nil {
+			fmt.Println("Problem is now public")
+			break
+		}
+		if attempt == 30 {
+			log.Printf("Failed to toggle problem status: %v", err)
+			break
+		}
+		time.Sleep(2 * time.Second)
 	}
 
 	// Step 4: Create multiple users for testing
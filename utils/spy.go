@@ -8,9 +8,13 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 )
 
+// memoryReportPrefix starts the last line of stderr in raw mode, it holds the peak memory of the binary in KB
+const memoryReportPrefix = "SPY MAX RSS KB: "
+
 func main() {
 	// Define command-line flags
 	var (
@@ -21,30 +25,37 @@ func main() {
 		inputFile    = flag.String("input", "test_input", "Name of the input file")
 		outputFile   = flag.String("output", "test_output", "Name of the expected output file")
 		userOutFile  = flag.String("user-output", "user_output", "Name of the file to write user output to")
+		raw          = flag.Bool("raw", false, "Pass the binary output through instead of comparing it, remaining arguments are given to the binary")
 	)
 
 	flag.Parse()
 
+	// in raw mode stdout belongs to the binary, so our own messages go to stderr
+	report := os.Stdout
+	if *raw {
+		report = os.Stderr
+	}
+
 	binaryPath := filepath.Join(*binaryFolder, *binaryName)
 	inputPath := filepath.Join(*appDir, *inputFile)
 	expectedPath := filepath.Join(*appDir, *outputFile)
 	userOutputPath := filepath.Join(*appDir, *userOutFile)
 
 	if _, err := os.Stat(binaryPath); os.IsNotExist(err) {
-		fmt.Printf("Error: Binary not found at %s\n", binaryPath)
+		fmt.Fprintf(report, "Error: Binary not found at %s\n", binaryPath)
 		os.Exit(127) // Standard exit code for "command not found"
 	}
 
 	// Read input file
 	input, err := os.ReadFile(inputPath)
 	if err != nil {
-		fmt.Printf("Error reading input file %s: %v\n", inputPath, err)
+		fmt.Fprintf(report, "Error reading input file %s: %v\n", inputPath, err)
 		os.Exit(3) // Exit code 3 for internal errors (file system issues)
 	}
 
 	userOutputFile, err := os.Create(userOutputPath)
 	if err != nil {
-		fmt.Printf("Error creating user output file %s: %v\n", *userOutFile, err)
+		fmt.Fprintf(report, "Error creating user output file %s: %v\n", *userOutFile, err)
 		os.Exit(3)
 	}
 	// We'll close this manually later, so don't use defer here
@@ -54,12 +65,12 @@ func main() {
 	defer cancel()
 
 	// Run the binary with input redirection using pipes to ensure proper EOF handling
-	cmd := exec.CommandContext(ctx, binaryPath)
+	cmd := exec.CommandContext(ctx, binaryPath, flag.Args()...)
 
 	// Set up pipes for stdin and combined output
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
-		fmt.Printf("Error creating stdin pipe: %v\n", err)
+		fmt.Fprintf(report, "Error creating stdin pipe: %v\n", err)
 		os.Exit(3) // Exit code 3 for internal errors
 	}
 
@@ -67,17 +78,20 @@ func main() {
 	var errorBuffer strings.Builder
 	cmd.Stdout = userOutputFile
 	cmd.Stderr = &errorBuffer
+	if *raw {
+		cmd.Stdout = os.Stdout
+	}
 
 	// Start the command
 	if err := cmd.Start(); err != nil {
-		fmt.Printf("Error starting command: %v\n", err)
+		fmt.Fprintf(report, "Error starting command: %v\n", err)
 		os.Exit(3) // Exit code 3 for internal errors
 	}
 
 	// Write input to stdin
 	_, err = stdin.Write(input)
 	if err != nil {
-		fmt.Printf("Error writing to stdin: %v\n", err)
+		fmt.Fprintf(report, "Error writing to stdin: %v\n", err)
 		os.Exit(3) // Exit code 3 for internal errors
 	}
 
@@ -86,9 +100,25 @@ func main() {
 
 	// Wait for command to complete
 	err = cmd.Wait()
+
+	// in raw mode every exit from here on reports the peak memory of the binary
+	exit := os.Exit
+	if *raw {
+		var maxRSSKb int64
+		if cmd.ProcessState != nil {
+			if usage, ok := cmd.ProcessState.SysUsage().(*syscall.Rusage); ok {
+				maxRSSKb = usage.Maxrss
+			}
+		}
+		exit = func(code int) {
+			fmt.Fprintf(report, "\n%s%d\n", memoryReportPrefix, maxRSSKb)
+			os.Exit(code)
+		}
+	}
+
 	if ctx.Err() == context.DeadlineExceeded {
-		fmt.Printf("Error: Process timed out after %d seconds\n", *timeLimit)
-		os.Exit(124) // Standard exit code for "timed out"
+		fmt.Fprintf(report, "Error: Process timed out after %d seconds\n", *timeLimit)
+		exit(124) // Standard exit code for "timed out"
 	}
 
 	// Check for other errors
@@ -97,39 +127,44 @@ func main() {
 			exitCode := exitErr.ExitCode()
 			// Handle OOM kill (137) and other memory-related errors
 			if exitCode == 137 || exitCode == -1 {
-				fmt.Println("Error: Process terminated due to memory limit violation")
-				os.Exit(137) // Standardize on 137 for OOM kill
+				fmt.Fprintln(report, "Error: Process terminated due to memory limit violation")
+				exit(137) // Standardize on 137 for OOM kill
 			}
-			fmt.Printf("RUNTIME ERROR\n exit code %d:\n%s", exitCode, errorBuffer.String())
-			os.Exit(exitCode)
+			fmt.Fprintf(report, "RUNTIME ERROR\n exit code %d:\n%s", exitCode, errorBuffer.String())
+			exit(exitCode)
 		} else {
-			fmt.Printf("Error executing binary: %v\n", err)
-			os.Exit(3) // Exit code 3 for internal errors (execution issues)
+			fmt.Fprintf(report, "Error executing binary: %v\n", err)
+			exit(3) // Exit code 3 for internal errors (execution issues)
 		}
 	}
 
+	if *raw {
+		fmt.Fprint(report, errorBuffer.String())
+		exit(0)
+	}
+
 	expected, err := os.ReadFile(expectedPath)
 	if err != nil {
-		fmt.Printf("Error reading expected output file %s: %v\n", expectedPath, err)
+		fmt.Fprintf(report, "Error reading expected output file %s: %v\n", expectedPath, err)
 		os.Exit(3) // Exit code 3 for internal errors (file system issues)
 	}
 
 	// Flush the file to ensure all data is written to disk
 	if err := userOutputFile.Sync(); err != nil {
-		fmt.Printf("Error flushing user output file: %v\n", err)
+		fmt.Fprintf(report, "Error flushing user output file: %v\n", err)
 		os.Exit(3)
 	}
 
 	// Close the file before reading it
 	if err := userOutputFile.Close(); err != nil {
-		fmt.Printf("Error closing user output file: %v\n", err)
+		fmt.Fprintf(report, "Error closing user output file: %v\n", err)
 		os.Exit(3)
 	}
 
 	// Read the output from the file
 	output, err := os.ReadFile(userOutputPath)
 	if err != nil {
-		fmt.Printf("Error reading user output file: %v\n", err)
+		fmt.Fprintf(report, "Error reading user output file: %v\n", err)
 		os.Exit(3) // Exit code 3 for internal errors (file system issues)
 	}
 
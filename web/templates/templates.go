@@ -14,6 +14,7 @@ import (
 	"github.com/Masterminds/sprig/v3"
 
 	internalcontext "github.com/computer-technology-team/go-judge/internal/context"
+	"github.com/computer-technology-team/go-judge/internal/rbac"
 	"github.com/computer-technology-team/go-judge/internal/storage"
 )
 
@@ -25,9 +26,12 @@ const (
 	Problems       PackageName = "problems"
 	Authentication PackageName = "authentication"
 	Submissions    PackageName = "submissions"
+	Roles          PackageName = "roles"
+	Groups         PackageName = "groups"
+	Audit          PackageName = "audit"
 )
 
-//go:embed shared/*.gohtml shared/layouts/*.gohtml shared/partials/*.gohtml home/*.gohtml profiles/*.gohtml authentication/*.gohtml problems/*.gohtml submissions/*.gohtml
+//go:embed shared/*.gohtml shared/layouts/*.gohtml shared/partials/*.gohtml home/*.gohtml profiles/*.gohtml authentication/*.gohtml problems/*.gohtml submissions/*.gohtml roles/*.gohtml groups/*.gohtml audit/*.gohtml
 var templateFS embed.FS
 
 // Templates holds all parsed templates
@@ -36,8 +40,16 @@ type Templates struct {
 }
 
 type TemplateData struct {
-	Data any
-	User *storage.User
+	Data        any
+	User        *storage.User
+	Permissions rbac.Permissions
+	// CSRFToken is sent by every form that changes state, templates add it with {{ template "csrf" $ }}
+	CSRFToken string
+}
+
+// Can reports whether the user holds permission, templates use it as {{ if $.Can "manage_problems" }}
+func (d TemplateData) Can(permission rbac.Permission) bool {
+	return d.Permissions.Has(permission)
 }
 
 func GetSharedTemplates() (*Templates, error) {
@@ -129,10 +141,11 @@ func (t *Templates) Render(ctx context.Context, name string, rw http.ResponseWri
 		return errors.New("could not read template")
 	}
 
-	templateData := TemplateData{Data: data}
+	templateData := TemplateData{Data: data, CSRFToken: internalcontext.GetCSRFTokenFromContext(ctx)}
 
 	if user, ok := internalcontext.GetUserFromContext(ctx); ok {
 		templateData.User = user
+		templateData.Permissions = internalcontext.GetPermissionsFromContext(ctx)
 	}
 
 	rw.Header().Set("Content-Type", "text/html; charset=utf-8")